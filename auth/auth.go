@@ -0,0 +1,334 @@
+// Package auth issues and validates signed tokens for service-to-service
+// authentication so that internal calls don't rely on shared static API keys.
+//
+// Tokens use the compact JWT serialization and are signed either with a
+// shared HMAC secret ([HMAC]) or with an Ed25519 key pair ([Ed25519Signer],
+// [Ed25519Verifier]). The calling service uses an [Issuer] as gRPC per-RPC
+// credentials or as an HTTP client round tripper, while the called service
+// checks incoming tokens with a [Validator]:
+//
+//	key, err := auth.HMAC(secret)
+//
+//	// calling service
+//	issuer := auth.NewIssuer("crawler", key, 5*time.Minute)
+//	conn, err := grpc.NewClient(addr, grpc.WithPerRPCCredentials(issuer.PerRPCCredentials("api", true)))
+//
+//	// called service
+//	validator, err := auth.NewValidator("api", key, 30*time.Second)
+//	server := grpc.NewServer(grpc.ChainUnaryInterceptor(validator.UnaryServerInterceptor()))
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken is returned by [Validator.Validate] for tokens that are
+// malformed, carry an invalid signature, or are expired.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the claims carried by a service token.
+type Claims struct {
+	// Issuer is the name of the service that issued the token.
+	Issuer string `json:"iss"`
+	// Audience is the name of the service the token is intended for.
+	Audience string `json:"aud,omitempty"`
+	// IssuedAt is the unix timestamp in seconds at which the token was issued.
+	IssuedAt int64 `json:"iat"`
+	// Expiry is the unix timestamp in seconds after which the token is no
+	// longer valid.
+	Expiry int64 `json:"exp"`
+}
+
+// Signer signs tokens.
+type Signer interface {
+	// Alg returns the JWT algorithm name of the signature.
+	Alg() string
+	// Sign returns the signature of the given message.
+	Sign(msg []byte) ([]byte, error)
+}
+
+// Verifier verifies token signatures.
+type Verifier interface {
+	// Alg returns the JWT algorithm name of the signature.
+	Alg() string
+	// Verify returns an error if sig is not a valid signature of msg.
+	Verify(msg, sig []byte) error
+}
+
+// SymmetricKey both signs and verifies tokens.
+type SymmetricKey interface {
+	Signer
+	Verifier
+}
+
+// MinHMACSecretSize is the minimum size in bytes of the secret passed to
+// [HMAC], which matches the output size of SHA-256.
+const MinHMACSecretSize = 32
+
+// hmacKey signs and verifies tokens with HMAC-SHA256.
+type hmacKey []byte
+
+var (
+	_ Signer   = hmacKey(nil)
+	_ Verifier = hmacKey(nil)
+)
+
+// HMAC returns a [Signer] and [Verifier] that uses HMAC-SHA256 with the given
+// shared secret. Secrets shorter than [MinHMACSecretSize] are rejected, so
+// that a missing or empty secret doesn't let anyone forge tokens.
+func HMAC(secret []byte) (SymmetricKey, error) {
+	if len(secret) < MinHMACSecretSize {
+		return nil, fmt.Errorf("hmac secret must be at least %d bytes, got %d", MinHMACSecretSize, len(secret))
+	}
+
+	return hmacKey(secret), nil
+}
+
+func (k hmacKey) Alg() string { return "HS256" }
+
+func (k hmacKey) Sign(msg []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, k)
+	mac.Write(msg)
+	return mac.Sum(nil), nil
+}
+
+func (k hmacKey) Verify(msg, sig []byte) error {
+	expected, _ := k.Sign(msg)
+	if !hmac.Equal(expected, sig) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+type ed25519Signer ed25519.PrivateKey
+
+// Ed25519Signer returns a [Signer] that signs tokens with the given Ed25519
+// private key.
+func Ed25519Signer(key ed25519.PrivateKey) Signer {
+	return ed25519Signer(key)
+}
+
+func (k ed25519Signer) Alg() string { return "EdDSA" }
+
+func (k ed25519Signer) Sign(msg []byte) ([]byte, error) {
+	return ed25519.Sign(ed25519.PrivateKey(k), msg), nil
+}
+
+type ed25519Verifier ed25519.PublicKey
+
+// Ed25519Verifier returns a [Verifier] that checks token signatures with the
+// given Ed25519 public key.
+func Ed25519Verifier(key ed25519.PublicKey) Verifier {
+	return ed25519Verifier(key)
+}
+
+func (k ed25519Verifier) Alg() string { return "EdDSA" }
+
+func (k ed25519Verifier) Verify(msg, sig []byte) error {
+	if !ed25519.Verify(ed25519.PublicKey(k), msg, sig) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// header is the JWT header.
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// Issuer issues tokens on behalf of a service. Tokens are cached per audience
+// and reused until they are about to expire.
+type Issuer struct {
+	service string
+	signer  Signer
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedToken // audience -> token
+}
+
+type cachedToken struct {
+	token  string
+	expiry time.Time
+}
+
+// NewIssuer creates an [Issuer] for the given service whose tokens are valid
+// for ttl.
+func NewIssuer(service string, signer Signer, ttl time.Duration) *Issuer {
+	return &Issuer{
+		service: service,
+		signer:  signer,
+		ttl:     ttl,
+		now:     time.Now,
+		cache:   map[string]cachedToken{},
+	}
+}
+
+// Token returns a token for the given audience.
+func (i *Issuer) Token(audience string) (string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := i.now()
+
+	// reuse cached tokens until less than a quarter of their lifetime is left
+	if cached, found := i.cache[audience]; found && now.Add(i.ttl/4).Before(cached.expiry) {
+		return cached.token, nil
+	}
+
+	claims := Claims{
+		Issuer:   i.service,
+		Audience: audience,
+		IssuedAt: now.Unix(),
+		Expiry:   now.Add(i.ttl).Unix(),
+	}
+
+	token, err := Sign(i.signer, claims)
+	if err != nil {
+		return "", err
+	}
+
+	i.cache[audience] = cachedToken{token: token, expiry: now.Add(i.ttl)}
+
+	return token, nil
+}
+
+// Sign serializes and signs the given claims.
+func Sign(signer Signer, claims Claims) (string, error) {
+	hdr, err := json.Marshal(header{Alg: signer.Alg(), Typ: "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("marshal header: %w", err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshal claims: %w", err)
+	}
+
+	msg := b64.EncodeToString(hdr) + "." + b64.EncodeToString(payload)
+	sig, err := signer.Sign([]byte(msg))
+	if err != nil {
+		return "", fmt.Errorf("sign token: %w", err)
+	}
+
+	return msg + "." + b64.EncodeToString(sig), nil
+}
+
+// Validator validates tokens issued for a specific audience.
+type Validator struct {
+	audience string
+	verifier Verifier
+	skew     time.Duration
+	now      func() time.Time
+}
+
+// NewValidator creates a [Validator] that accepts tokens for the given
+// audience and tolerates the given clock skew between services. The audience
+// must not be empty, so that tokens issued for one service can't be used to
+// call another.
+func NewValidator(audience string, verifier Verifier, skew time.Duration) (*Validator, error) {
+	if audience == "" {
+		return nil, fmt.Errorf("audience must not be empty")
+	}
+
+	if verifier == nil {
+		return nil, fmt.Errorf("verifier must not be nil")
+	}
+
+	if skew < 0 {
+		return nil, fmt.Errorf("clock skew must not be negative")
+	}
+
+	return &Validator{
+		audience: audience,
+		verifier: verifier,
+		skew:     skew,
+		now:      time.Now,
+	}, nil
+}
+
+// Validate checks the token's signature, audience, and validity period and
+// returns its claims. All validation failures wrap [ErrInvalidToken].
+func (v *Validator) Validate(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	hdrData, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode header: %w", ErrInvalidToken, err)
+	}
+
+	var hdr header
+	if err := json.Unmarshal(hdrData, &hdr); err != nil {
+		return nil, fmt.Errorf("%w: unmarshal header: %w", ErrInvalidToken, err)
+	}
+
+	if hdr.Alg != v.verifier.Alg() {
+		return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidToken, hdr.Alg)
+	}
+
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode signature: %w", ErrInvalidToken, err)
+	}
+
+	if err := v.verifier.Verify([]byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	payload, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode claims: %w", ErrInvalidToken, err)
+	}
+
+	claims := &Claims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, fmt.Errorf("%w: unmarshal claims: %w", ErrInvalidToken, err)
+	}
+
+	if claims.Audience != v.audience {
+		return nil, fmt.Errorf("%w: unexpected audience %q", ErrInvalidToken, claims.Audience)
+	}
+
+	now := v.now()
+	if now.Add(v.skew).Before(time.Unix(claims.IssuedAt, 0)) {
+		return nil, fmt.Errorf("%w: issued in the future", ErrInvalidToken)
+	}
+
+	if now.Add(-v.skew).After(time.Unix(claims.Expiry, 0)) {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+// claimsCtxKey is the context key under which validated claims are stored.
+type claimsCtxKey struct{}
+
+// WithClaims returns a copy of ctx that carries the given claims.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsCtxKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated caller that were
+// stored by the server-side middlewares.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsCtxKey{}).(*Claims)
+	return claims, ok
+}
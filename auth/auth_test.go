@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSecret is a valid HMAC secret.
+var testSecret = []byte(strings.Repeat("s", MinHMACSecretSize))
+
+func newHMAC(t *testing.T, secret []byte) SymmetricKey {
+	t.Helper()
+
+	key, err := HMAC(secret)
+	require.NoError(t, err)
+	return key
+}
+
+func newValidator(t *testing.T, audience string, verifier Verifier, skew time.Duration) *Validator {
+	t.Helper()
+
+	v, err := NewValidator(audience, verifier, skew)
+	require.NoError(t, err)
+	return v
+}
+
+func TestHMAC(t *testing.T) {
+	for _, secret := range [][]byte{nil, {}, testSecret[:MinHMACSecretSize-1]} {
+		_, err := HMAC(secret)
+		assert.Error(t, err)
+	}
+
+	_, err := HMAC(testSecret)
+	assert.NoError(t, err)
+}
+
+func TestNewValidator(t *testing.T) {
+	key := newHMAC(t, testSecret)
+
+	tests := []struct {
+		name     string
+		audience string
+		verifier Verifier
+		skew     time.Duration
+		wantErr  bool
+	}{
+		{"valid", "api", key, time.Second, false},
+		{"empty audience", "", key, time.Second, true},
+		{"nil verifier", "api", nil, time.Second, true},
+		{"negative skew", "api", key, -time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewValidator(tt.audience, tt.verifier, tt.skew)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIssuer_Validator(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	key := newHMAC(t, testSecret)
+
+	tests := []struct {
+		name     string
+		signer   Signer
+		verifier Verifier
+	}{
+		{name: "hmac", signer: key, verifier: key},
+		{name: "ed25519", signer: Ed25519Signer(priv), verifier: Ed25519Verifier(pub)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := NewIssuer("crawler", tt.signer, time.Minute)
+			validator := newValidator(t, "api", tt.verifier, 5*time.Second)
+
+			token, err := issuer.Token("api")
+			require.NoError(t, err)
+
+			claims, err := validator.Validate(token)
+			require.NoError(t, err)
+			assert.Equal(t, "crawler", claims.Issuer)
+			assert.Equal(t, "api", claims.Audience)
+
+			// tokens are cached
+			token2, err := issuer.Token("api")
+			require.NoError(t, err)
+			assert.Equal(t, token, token2)
+		})
+	}
+}
+
+func TestValidator_Validate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	key := newHMAC(t, testSecret)
+
+	sign := func(claims Claims) string {
+		token, err := Sign(key, claims)
+		require.NoError(t, err)
+		return token
+	}
+
+	validClaims := Claims{Issuer: "crawler", Audience: "api", IssuedAt: now.Unix(), Expiry: now.Add(time.Minute).Unix()}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid", token: sign(validClaims)},
+		{name: "malformed", token: "abc", wantErr: true},
+		{name: "wrong audience", token: sign(Claims{Issuer: "crawler", Audience: "other", IssuedAt: now.Unix(), Expiry: now.Add(time.Minute).Unix()}), wantErr: true},
+		{name: "expired within skew", token: sign(Claims{Issuer: "crawler", Audience: "api", IssuedAt: now.Add(-time.Minute).Unix(), Expiry: now.Add(-5 * time.Second).Unix()})},
+		{name: "expired", token: sign(Claims{Issuer: "crawler", Audience: "api", IssuedAt: now.Add(-time.Minute).Unix(), Expiry: now.Add(-time.Minute).Unix()}), wantErr: true},
+		{name: "issued in future within skew", token: sign(Claims{Issuer: "crawler", Audience: "api", IssuedAt: now.Add(5 * time.Second).Unix(), Expiry: now.Add(time.Minute).Unix()})},
+		{name: "issued in future", token: sign(Claims{Issuer: "crawler", Audience: "api", IssuedAt: now.Add(time.Minute).Unix(), Expiry: now.Add(2 * time.Minute).Unix()}), wantErr: true},
+		{name: "wrong key", token: func() string {
+			token, err := Sign(newHMAC(t, []byte(strings.Repeat("o", MinHMACSecretSize))), validClaims)
+			require.NoError(t, err)
+			return token
+		}(), wantErr: true},
+		{name: "tampered", token: func() string {
+			parts := strings.Split(sign(validClaims), ".")
+			other := strings.Split(sign(Claims{Issuer: "evil", Audience: "api", IssuedAt: now.Unix(), Expiry: now.Add(time.Hour).Unix()}), ".")
+			return parts[0] + "." + other[1] + "." + parts[2]
+		}(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newValidator(t, "api", key, 10*time.Second)
+			v.now = func() time.Time { return now }
+
+			_, err := v.Validate(tt.token)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidToken)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRoundTripper_Middleware(t *testing.T) {
+	key := newHMAC(t, testSecret)
+	validator := newValidator(t, "api", key, time.Second)
+
+	var gotIssuer string
+	srv := httptest.NewServer(validator.Middleware()(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		claims, ok := ClaimsFromContext(req.Context())
+		require.True(t, ok)
+		gotIssuer = claims.Issuer
+	})))
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	client := &http.Client{Transport: NewIssuer("crawler", key, time.Minute).RoundTripper("api", nil)}
+	resp, err = client.Get(srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "crawler", gotIssuer)
+}
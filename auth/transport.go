@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	phttp "github.com/probe-lab/go-commons/http"
+)
+
+// authorizationKey is the header and gRPC metadata key carrying the token.
+const authorizationKey = "authorization"
+
+const bearerPrefix = "Bearer "
+
+// perRPCCredentials attaches service tokens to outgoing gRPC calls.
+type perRPCCredentials struct {
+	issuer     *Issuer
+	audience   string
+	requireTLS bool
+}
+
+var _ credentials.PerRPCCredentials = (*perRPCCredentials)(nil)
+
+// PerRPCCredentials returns gRPC credentials that attach a token for the
+// given audience to every call. Use requireTLS to prevent sending tokens over
+// insecure connections.
+func (i *Issuer) PerRPCCredentials(audience string, requireTLS bool) credentials.PerRPCCredentials {
+	return &perRPCCredentials{issuer: i, audience: audience, requireTLS: requireTLS}
+}
+
+func (c *perRPCCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.issuer.Token(c.audience)
+	if err != nil {
+		return nil, fmt.Errorf("issue token: %w", err)
+	}
+
+	return map[string]string{authorizationKey: bearerPrefix + token}, nil
+}
+
+func (c *perRPCCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
+
+// roundTripper attaches service tokens to outgoing HTTP requests.
+type roundTripper struct {
+	issuer   *Issuer
+	audience string
+	next     http.RoundTripper
+}
+
+// RoundTripper returns an [http.RoundTripper] that attaches a token for the
+// given audience to every request before passing it to next. If next is nil,
+// [http.DefaultTransport] is used.
+func (i *Issuer) RoundTripper(audience string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &roundTripper{issuer: i, audience: audience, next: next}
+}
+
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.issuer.Token(t.audience)
+	if err != nil {
+		return nil, fmt.Errorf("issue token: %w", err)
+	}
+
+	// RoundTrippers must not modify the original request
+	req = req.Clone(req.Context())
+	req.Header.Set(authorizationKey, bearerPrefix+token)
+
+	return t.next.RoundTrip(req)
+}
+
+// Middleware returns an HTTP middleware that rejects requests without a valid
+// bearer token with 401 Unauthorized and stores the caller's claims in the
+// request context.
+func (v *Validator) Middleware() phttp.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			token, found := strings.CutPrefix(req.Header.Get(authorizationKey), bearerPrefix)
+			if !found {
+				phttp.EncodeErr(rw, http.StatusUnauthorized, "missing bearer token")
+				return
+			}
+
+			claims, err := v.Validate(token)
+			if err != nil {
+				phttp.EncodeErr(rw, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			next.ServeHTTP(rw, req.WithContext(WithClaims(req.Context(), claims)))
+		})
+	}
+}
+
+// authenticate validates the bearer token in the incoming gRPC metadata.
+func (v *Validator) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationKey)
+	if len(values) == 0 {
+		return ctx, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	token, found := strings.CutPrefix(values[0], bearerPrefix)
+	if !found {
+		return ctx, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	claims, err := v.Validate(token)
+	if err != nil {
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return WithClaims(ctx, claims), nil
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor that rejects calls without
+// a valid bearer token with UNAUTHENTICATED and stores the caller's claims
+// in the context.
+func (v *Validator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := v.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// [Validator.UnaryServerInterceptor].
+func (v *Validator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := v.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticatedStream overrides the context of a server stream.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
+)
+
+// AWSConfig holds the settings for the clients of the AWS SDK. Empty fields
+// fall back to the SDK's default resolution, i.e., the standard AWS_*
+// environment variables, the shared config files, and the instance or task
+// metadata.
+type AWSConfig struct {
+	// Region is the AWS region that this service runs in.
+	Region string
+
+	// Profile is the name of the shared config profile to use.
+	Profile string
+
+	// Endpoint overrides the base endpoint of all AWS clients, e.g., to use
+	// LocalStack during local development.
+	Endpoint string
+}
+
+// DefaultAWSConfig returns an [AWSConfig] that defers everything to the SDK's
+// default resolution.
+func DefaultAWSConfig() *AWSConfig {
+	return &AWSConfig{
+		Region:   "",
+		Profile:  "",
+		Endpoint: "",
+	}
+}
+
+// Validate validates the AWS configuration.
+func (cfg *AWSConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if cfg.Endpoint != "" {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("endpoint must be an absolute URL, got %q", cfg.Endpoint)
+		}
+	}
+
+	return nil
+}
+
+// LogValue implements [slog.LogValuer].
+func (cfg *AWSConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("region", cfg.Region),
+		slog.String("profile", cfg.Profile),
+		slog.String("endpoint", cfg.Endpoint),
+	)
+}
+
+// Load builds an [aws.Config] for the clients of the AWS SDK v2. All clients
+// that are created from it emit traces of their API calls via the global
+// tracer provider:
+//
+//	awsCfg, err := cfg.AWS.Load(ctx)
+//	if err != nil { ... }
+//	client := secretsmanager.NewFromConfig(awsCfg)
+func (cfg *AWSConfig) Load(ctx context.Context) (aws.Config, error) {
+	if err := cfg.Validate(); err != nil {
+		return aws.Config{}, err
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, config.WithRegion(cfg.Region))
+	}
+
+	if cfg.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(cfg.Profile))
+	}
+
+	if cfg.Endpoint != "" {
+		opts = append(opts, config.WithBaseEndpoint(cfg.Endpoint))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("load aws config: %w", err)
+	}
+
+	otelaws.AppendMiddlewares(&awsCfg.APIOptions)
+
+	return awsCfg, nil
+}
+
+// AWSFlags generates a slice of [cli.Flag] for the region, shared config
+// profile, and endpoint override of the AWS SDK. Each flag is read from the
+// prefixed environment variable and, if that is not set, from the standard
+// variable of the SDK, e.g., <PREFIX>_AWS_REGION and then AWS_REGION. The
+// root command created by [NewRootCommand] includes these flags for
+// [RootCommandConfig.AWS].
+func AWSFlags(envPrefix string, cfg *AWSConfig) []cli.Flag {
+	envPrefix = buildEnvPrefix(envPrefix)
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "aws.region",
+			Usage:       "The AWS region that this service runs in.",
+			Sources:     cli.EnvVars(envPrefix+"AWS_REGION", "AWS_REGION"),
+			Value:       cfg.Region,
+			Destination: &cfg.Region,
+			Category:    flagCategoryAWS,
+		},
+		&cli.StringFlag{
+			Name:        "aws.profile",
+			Usage:       "The shared config profile to load AWS credentials and settings from.",
+			Sources:     cli.EnvVars(envPrefix+"AWS_PROFILE", "AWS_PROFILE"),
+			Value:       cfg.Profile,
+			Destination: &cfg.Profile,
+			Category:    flagCategoryAWS,
+		},
+		&cli.StringFlag{
+			Name:        "aws.endpoint",
+			Usage:       "Overrides the endpoint of all AWS clients, e.g., http://localhost:4566 for LocalStack.",
+			Sources:     cli.EnvVars(envPrefix+"AWS_ENDPOINT_URL", "AWS_ENDPOINT_URL"),
+			Value:       cfg.Endpoint,
+			Destination: &cfg.Endpoint,
+			Category:    flagCategoryAWS,
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func TestAWSFlags(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWSTEST_AWS_REGION", "eu-central-1")
+	t.Setenv("AWS_PROFILE", "dev")
+
+	cfg := DefaultAWSConfig()
+	cmd := &cli.Command{
+		Name:   "awstest",
+		Flags:  AWSFlags("AWSTEST", cfg),
+		Action: func(context.Context, *cli.Command) error { return nil },
+	}
+
+	require.NoError(t, cmd.Run(context.Background(), []string{"awstest", "--aws.endpoint", "http://localhost:4566"}))
+
+	assert.Equal(t, "eu-central-1", cfg.Region, "prefixed env var must take precedence")
+	assert.Equal(t, "dev", cfg.Profile)
+	assert.Equal(t, "http://localhost:4566", cfg.Endpoint)
+}
+
+func TestAWSConfig_Validate(t *testing.T) {
+	var nilCfg *AWSConfig
+	assert.ErrorContains(t, nilCfg.Validate(), "config is nil")
+
+	cfg := DefaultAWSConfig()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Endpoint = "localhost:4566"
+	assert.ErrorContains(t, cfg.Validate(), "endpoint must be an absolute URL")
+}
+
+func TestAWSConfig_Load(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+
+	cfg := DefaultAWSConfig()
+	cfg.Region = "eu-west-1"
+	cfg.Endpoint = "http://localhost:4566"
+
+	awsCfg, err := cfg.Load(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "eu-west-1", awsCfg.Region)
+	assert.Equal(t, aws.String("http://localhost:4566"), awsCfg.BaseEndpoint)
+	assert.NotEmpty(t, awsCfg.APIOptions, "otel middlewares must be installed")
+}
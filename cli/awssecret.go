@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/urfave/cli/v3"
+)
+
+// Prefixes of flag values that reference secrets in AWS. The root command
+// created by [NewRootCommand] replaces string flag values with these
+// prefixes by the secret values in its Before hook, so that, e.g., database
+// passwords don't have to be plain environment variables:
+//
+//	CLICKHOUSE_PASSWORD=awssm://prod/clickhouse#password
+//
+// The AWS clients use [RootCommandConfig.AWS] and are only created if a
+// flag references a secret.
+const (
+	// AWSSecretsManagerPrefix references a secret in AWS Secrets Manager by
+	// its name or ARN, e.g., awssm://prod/clickhouse. A #key suffix selects
+	// a key of a JSON secret, e.g., awssm://prod/rds#password.
+	AWSSecretsManagerPrefix = "awssm://"
+
+	// AWSParameterStorePrefix references a parameter in the AWS Systems
+	// Manager Parameter Store by its name, e.g., ssm:///prod/clickhouse/password.
+	// SecureString parameters are decrypted.
+	AWSParameterStorePrefix = "ssm://"
+)
+
+// secretsManagerAPI is the subset of the Secrets Manager client that is
+// used to resolve secrets.
+type secretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// parameterStoreAPI is the subset of the SSM client that is used to resolve
+// parameters.
+type parameterStoreAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// awsSecretResolver resolves secret references with lazily created clients
+// so that services without references don't need AWS credentials.
+type awsSecretResolver struct {
+	load func(ctx context.Context) (aws.Config, error)
+
+	sm  secretsManagerAPI
+	ssm parameterStoreAPI
+}
+
+// isAWSSecretRef reports whether value references a secret in AWS.
+func isAWSSecretRef(value string) bool {
+	return strings.HasPrefix(value, AWSSecretsManagerPrefix) || strings.HasPrefix(value, AWSParameterStorePrefix)
+}
+
+// resolve returns the secret value that ref references.
+func (r *awsSecretResolver) resolve(ctx context.Context, ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, AWSSecretsManagerPrefix):
+		id, key, _ := strings.Cut(strings.TrimPrefix(ref, AWSSecretsManagerPrefix), "#")
+		if id == "" {
+			return "", fmt.Errorf("secret id must not be empty")
+		}
+
+		if r.sm == nil {
+			awsCfg, err := r.load(ctx)
+			if err != nil {
+				return "", err
+			}
+			r.sm = secretsmanager.NewFromConfig(awsCfg)
+		}
+
+		out, err := r.sm.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+		if err != nil {
+			return "", fmt.Errorf("get secret %s: %w", id, err)
+		}
+
+		var value string
+		if out.SecretString != nil {
+			value = *out.SecretString
+		} else {
+			value = string(out.SecretBinary)
+		}
+
+		if key == "" {
+			return value, nil
+		}
+
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(value), &fields); err != nil {
+			return "", fmt.Errorf("secret %s must be a JSON object to select key %q: %w", id, key, err)
+		}
+
+		field, found := fields[key]
+		if !found {
+			return "", fmt.Errorf("secret %s has no key %q", id, key)
+		}
+
+		if s, ok := field.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(field), nil
+
+	case strings.HasPrefix(ref, AWSParameterStorePrefix):
+		name := strings.TrimPrefix(ref, AWSParameterStorePrefix)
+		if name == "" {
+			return "", fmt.Errorf("parameter name must not be empty")
+		}
+
+		if r.ssm == nil {
+			awsCfg, err := r.load(ctx)
+			if err != nil {
+				return "", err
+			}
+			r.ssm = ssm.NewFromConfig(awsCfg)
+		}
+
+		out, err := r.ssm.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name), WithDecryption: aws.Bool(true)})
+		if err != nil {
+			return "", fmt.Errorf("get parameter %s: %w", name, err)
+		}
+
+		if out.Parameter == nil || out.Parameter.Value == nil {
+			return "", fmt.Errorf("parameter %s has no value", name)
+		}
+
+		return *out.Parameter.Value, nil
+
+	default:
+		return "", fmt.Errorf("unsupported secret reference %q", ref)
+	}
+}
+
+// resolveAWSSecrets replaces the values of all string flags of the invoked
+// commands that reference a secret in AWS with the secret value. Each
+// reference is only fetched once.
+func resolveAWSSecrets(ctx context.Context, cmd *cli.Command, r *awsSecretResolver) error {
+	cache := map[string]string{}
+	for _, c := range invokedCommands(cmd) {
+		for _, f := range c.Flags {
+			ref, ok := f.Get().(string)
+			if !ok || !isAWSSecretRef(ref) {
+				continue
+			}
+
+			name := f.Names()[0]
+
+			value, found := cache[ref]
+			if !found {
+				var err error
+				value, err = r.resolve(ctx, ref)
+				if err != nil {
+					return fmt.Errorf("resolve flag %s: %w", name, err)
+				}
+				cache[ref] = value
+			}
+
+			if err := f.Set(name, value); err != nil {
+				return fmt.Errorf("set flag %s: %w", name, err)
+			}
+
+			slog.Debug("Resolved secret reference", "flag", name, "ref", ref)
+		}
+	}
+
+	return nil
+}
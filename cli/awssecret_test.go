@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/errs"
+	"github.com/probe-lab/go-commons/tele"
+)
+
+type fakeSecretsManager struct {
+	secrets map[string]string
+}
+
+func (f *fakeSecretsManager) GetSecretValue(_ context.Context, in *secretsmanager.GetSecretValueInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	secret, found := f.secrets[*in.SecretId]
+	if !found {
+		return nil, errors.New("ResourceNotFoundException")
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(secret)}, nil
+}
+
+type fakeParameterStore struct {
+	params map[string]string
+}
+
+func (f *fakeParameterStore) GetParameter(_ context.Context, in *ssm.GetParameterInput, _ ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	if !*in.WithDecryption {
+		return nil, errors.New("parameters must be decrypted")
+	}
+
+	param, found := f.params[*in.Name]
+	if !found {
+		return nil, errors.New("ParameterNotFound")
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssmtypes.Parameter{Value: aws.String(param)}}, nil
+}
+
+func newFakeSecretResolver() *awsSecretResolver {
+	return &awsSecretResolver{
+		load: func(context.Context) (aws.Config, error) { return aws.Config{}, errors.New("no aws in tests") },
+		sm: &fakeSecretsManager{secrets: map[string]string{
+			"prod/clickhouse": "ch-secret",
+			"prod/rds":        `{"username":"admin","password":"pg-secret","port":5432}`,
+		}},
+		ssm: &fakeParameterStore{params: map[string]string{
+			"/prod/admin/key": "admin-secret",
+		}},
+	}
+}
+
+func TestAWSSecretResolver_resolve(t *testing.T) {
+	ctx := context.Background()
+	r := newFakeSecretResolver()
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{ref: "awssm://prod/clickhouse", want: "ch-secret"},
+		{ref: "awssm://prod/rds#password", want: "pg-secret"},
+		{ref: "awssm://prod/rds#port", want: "5432"},
+		{ref: "ssm:///prod/admin/key", want: "admin-secret"},
+		{ref: "awssm://prod/rds#missing", wantErr: `has no key "missing"`},
+		{ref: "awssm://prod/clickhouse#password", wantErr: "must be a JSON object"},
+		{ref: "awssm://unknown", wantErr: "get secret unknown"},
+		{ref: "awssm://", wantErr: "secret id must not be empty"},
+		{ref: "ssm:///unknown", wantErr: "get parameter /unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := r.resolve(ctx, tt.ref)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAWSSecretResolver_lazyClients(t *testing.T) {
+	r := &awsSecretResolver{
+		load: func(context.Context) (aws.Config, error) { return aws.Config{}, errors.New("no credentials") },
+	}
+
+	_, err := r.resolve(context.Background(), "awssm://prod/clickhouse")
+	assert.ErrorContains(t, err, "no credentials")
+}
+
+func TestRootCommand_resolvesAWSSecrets(t *testing.T) {
+	tele.DisableForTest(t)
+
+	var password, plain string
+	root, cfg := NewRootCommand(&cli.Command{
+		Name: "test",
+		Commands: []*cli.Command{{
+			Name: "sub",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "password", Destination: &password},
+				&cli.StringFlag{Name: "plain", Destination: &plain},
+			},
+			Action: func(context.Context, *cli.Command) error { return nil },
+		}},
+	})
+
+	cfg.secrets = newFakeSecretResolver()
+
+	err := root.RunWithContextAndArgs(context.Background(), []string{"test", "sub", "--password", "awssm://prod/rds#password", "--plain", "value"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "pg-secret", password)
+	assert.Equal(t, "value", plain)
+
+	// unresolvable references fail the command
+	root, cfg = NewRootCommand(&cli.Command{
+		Name:   "test",
+		Flags:  []cli.Flag{&cli.StringFlag{Name: "password"}},
+		Action: func(context.Context, *cli.Command) error { return nil },
+	})
+	cfg.secrets = newFakeSecretResolver()
+
+	err = root.RunWithContextAndArgs(context.Background(), []string{"test", "--password", "awssm://unknown"})
+	assert.ErrorIs(t, err, errs.Unavailable)
+	assert.ErrorContains(t, err, "resolve flag password")
+}
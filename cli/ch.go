@@ -1,6 +1,16 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/golang-migrate/migrate/v4"
 	"github.com/probe-lab/go-commons/db"
 	"github.com/urfave/cli/v3"
 )
@@ -28,6 +38,22 @@ func ClickHouseFlags(envPrefix string, cfg *db.ClickHouseConfig) []cli.Flag {
 			Destination: &cfg.Database,
 			Category:    flagCategoryDatabase,
 		},
+		&cli.BoolFlag{
+			Name:        "clickhouse.async.insert",
+			Usage:       "Whether to let the ClickHouse server batch inserts (async_insert)",
+			Sources:     cli.EnvVars(envPrefix + "CLICKHOUSE_ASYNC_INSERT"),
+			Value:       cfg.AsyncInsert,
+			Destination: &cfg.AsyncInsert,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.BoolFlag{
+			Name:        "clickhouse.async.insert.wait",
+			Usage:       "Whether async inserts wait until the data was flushed to the table (wait_for_async_insert)",
+			Sources:     cli.EnvVars(envPrefix + "CLICKHOUSE_ASYNC_INSERT_WAIT"),
+			Value:       cfg.WaitForAsyncInsert,
+			Destination: &cfg.WaitForAsyncInsert,
+			Category:    flagCategoryDatabase,
+		},
 	)
 }
 
@@ -49,6 +75,21 @@ func ClickHouseMultiFlags(envPrefix string, cfg *db.ClickHouseMultiConfig) []cli
 	)
 }
 
+// ClickHouseReplicaFlags extends [ClickHouseFlags] for the primary with a
+// flag for the addresses of read replicas, see [db.ClickHouseReplicaConfig].
+func ClickHouseReplicaFlags(envPrefix string, cfg *db.ClickHouseReplicaConfig) []cli.Flag {
+	return append(ClickHouseFlags(envPrefix, cfg.Primary),
+		&cli.StringSliceFlag{
+			Name:        "clickhouse.replicas",
+			Usage:       "A list of host:port addresses of ClickHouse read replicas. Separate multiple addresses with commas. Reads use the primary if empty.",
+			Sources:     cli.EnvVars(buildEnvPrefix(envPrefix) + "CLICKHOUSE_REPLICAS"),
+			Value:       cfg.Replicas,
+			Destination: &cfg.Replicas,
+			Category:    flagCategoryDatabase,
+		},
+	)
+}
+
 // ClickHouseBaseFlags generates a slice of cli.Flag for configuring the basic
 // connection settings to a ClickHouse server through a command-line interface.
 // These flags allow users to specify the host, port, user, password, and SSL
@@ -72,7 +113,7 @@ func ClickHouseBaseFlags(envPrefix string, cfg *db.ClickHouseBaseConfig) []cli.F
 		},
 		&cli.IntFlag{
 			Name:        "clickhouse.port",
-			Usage:       "Port at which the ClickHouse database is accessible",
+			Usage:       "Port at which the ClickHouse database is accessible (0 uses 9000, or 9440 with SSL)",
 			Sources:     cli.EnvVars(envPrefix + "CLICKHOUSE_PORT"),
 			Value:       cfg.Port,
 			Destination: &cfg.Port,
@@ -89,7 +130,7 @@ func ClickHouseBaseFlags(envPrefix string, cfg *db.ClickHouseBaseConfig) []cli.F
 		&cli.StringFlag{
 			Name:        "clickhouse.password",
 			Usage:       "The password for the ClickHouse user",
-			Sources:     cli.EnvVars(envPrefix + "CLICKHOUSE_PASSWORD"),
+			Sources:     SecretEnvVars(envPrefix + "CLICKHOUSE_PASSWORD"),
 			Value:       cfg.Pass,
 			Destination: &cfg.Pass,
 			Category:    flagCategoryDatabase,
@@ -102,6 +143,46 @@ func ClickHouseBaseFlags(envPrefix string, cfg *db.ClickHouseBaseConfig) []cli.F
 			Destination: &cfg.SSL,
 			Category:    flagCategoryDatabase,
 		},
+		&cli.StringFlag{
+			Name:        "clickhouse.ssl.ca",
+			Usage:       "Path to a PEM encoded CA bundle to verify the ClickHouse server certificate",
+			Sources:     cli.EnvVars(envPrefix + "CLICKHOUSE_SSL_CA"),
+			Value:       cfg.SSLCAFile,
+			Destination: &cfg.SSLCAFile,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.StringFlag{
+			Name:        "clickhouse.ssl.cert",
+			Usage:       "Path to a PEM encoded client certificate for mutual TLS with ClickHouse",
+			Sources:     cli.EnvVars(envPrefix + "CLICKHOUSE_SSL_CERT"),
+			Value:       cfg.SSLCertFile,
+			Destination: &cfg.SSLCertFile,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.StringFlag{
+			Name:        "clickhouse.ssl.key",
+			Usage:       "Path to the PEM encoded private key of the ClickHouse client certificate",
+			Sources:     cli.EnvVars(envPrefix + "CLICKHOUSE_SSL_KEY"),
+			Value:       cfg.SSLKeyFile,
+			Destination: &cfg.SSLKeyFile,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.StringFlag{
+			Name:        "clickhouse.ssl.servername",
+			Usage:       "Overrides the server name used to verify the ClickHouse server certificate",
+			Sources:     cli.EnvVars(envPrefix + "CLICKHOUSE_SSL_SERVERNAME"),
+			Value:       cfg.SSLServerName,
+			Destination: &cfg.SSLServerName,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.BoolFlag{
+			Name:        "clickhouse.ssl.insecure",
+			Usage:       "Whether to skip the verification of the ClickHouse server certificate (testing only)",
+			Sources:     cli.EnvVars(envPrefix + "CLICKHOUSE_SSL_INSECURE"),
+			Value:       cfg.SSLInsecureSkipVerify,
+			Destination: &cfg.SSLInsecureSkipVerify,
+			Category:    flagCategoryDatabase,
+		},
 	}
 }
 
@@ -156,5 +237,152 @@ func ClickHouseMigrationsFlags(envPrefix string, cfg *db.ClickHouseMigrationsCon
 			Destination: &cfg.ReplicatedTableEngines,
 			Category:    flagCategoryDatabase,
 		},
+		&cli.StringFlag{
+			Name:        "clickhouse.migrations.onCluster",
+			Usage:       "Whether to inject or validate ON CLUSTER clauses in migrations if a cluster is set (inject, validate). Empty disables the check.",
+			Sources:     cli.EnvVars(envPrefix + "CLICKHOUSE_MIGRATIONS_ON_CLUSTER"),
+			Value:       cfg.OnCluster,
+			Destination: &cfg.OnCluster,
+			Category:    flagCategoryDatabase,
+		},
+	}
+}
+
+// NewClickHouseMigrateCommand returns a "migrate" command with subcommands to
+// apply, roll back, force, and inspect the ClickHouse migrations in the given
+// filesystems, which are merged by version with [db.MergeMigrations]. The
+// database and migrations configurations are usually populated by the flags
+// from [ClickHouseFlags] and [ClickHouseMigrationsFlags] on the root command.
+func NewClickHouseMigrateCommand(chCfg *db.ClickHouseConfig, cfg *db.ClickHouseMigrationsConfig, migrations ...fs.ReadDirFS) *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Manages the ClickHouse database migrations",
+		Commands: []*cli.Command{
+			{
+				Name:  "up",
+				Usage: "Applies all pending migrations",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "create-database",
+						Usage: "Creates the database (on the configured cluster) before applying migrations if it does not exist",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if c.Bool("create-database") {
+						if err := chCfg.EnsureDatabase(ctx, cfg.ClusterName); err != nil {
+							return err
+						}
+					}
+					return cfg.Apply(chCfg.Options(), migrations...)
+				},
+			},
+			{
+				Name:  "down",
+				Usage: "Rolls back all applied migrations",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "confirm",
+						Usage: "Confirms that all migrations should be rolled back",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if !c.Bool("confirm") {
+						return fmt.Errorf("rolling back all migrations requires --confirm, use 'steps -- -1' to roll back the last migration")
+					}
+					return cfg.Down(ctx, chCfg.Options(), migrations...)
+				},
+			},
+			{
+				Name:      "steps",
+				Usage:     "Applies the next n migrations, or rolls back the last n migrations if n is negative",
+				ArgsUsage: "<n>",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					n, err := strconv.Atoi(c.Args().First())
+					if err != nil || n == 0 {
+						return fmt.Errorf("steps requires a non-zero integer argument")
+					}
+					return cfg.Steps(ctx, chCfg.Options(), n, migrations...)
+				},
+			},
+			{
+				Name:      "force",
+				Usage:     "Sets the migration version without running migrations and clears the dirty flag",
+				ArgsUsage: "<version>",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					version, err := strconv.Atoi(c.Args().First())
+					if err != nil {
+						return fmt.Errorf("force requires an integer version argument")
+					}
+					return cfg.Force(ctx, chCfg.Options(), version, migrations...)
+				},
+			},
+			{
+				Name:  "status",
+				Usage: "Prints the current migration version and the applied and pending migrations",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					status, err := cfg.Status(ctx, chCfg.Options(), migrations...)
+					if err != nil {
+						return err
+					}
+					return writeMigrationStatus(c.Root().Writer, status)
+				},
+			},
+			{
+				Name:  "version",
+				Usage: "Prints the current migration version",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					version, dirty, err := cfg.Version(ctx, chCfg.Options(), migrations...)
+					if errors.Is(err, migrate.ErrNilVersion) {
+						fmt.Fprintln(c.Root().Writer, "none")
+						return nil
+					} else if err != nil {
+						return err
+					}
+
+					if dirty {
+						fmt.Fprintf(c.Root().Writer, "%d (dirty)\n", version)
+					} else {
+						fmt.Fprintln(c.Root().Writer, version)
+					}
+
+					return nil
+				},
+			},
+		},
 	}
 }
+
+// writeMigrationStatus prints the migration version followed by a table of
+// all migrations and whether they are applied.
+func writeMigrationStatus(w io.Writer, status *db.MigrationStatus) error {
+	var sb strings.Builder
+
+	switch {
+	case status.Version == 0 && status.Pending() == len(status.Migrations):
+		sb.WriteString("Version: none\n")
+	case status.Dirty:
+		fmt.Fprintf(&sb, "Version: %d (dirty)\n", status.Version)
+	default:
+		fmt.Fprintf(&sb, "Version: %d\n", status.Version)
+	}
+	fmt.Fprintf(&sb, "Pending: %d\n\n", status.Pending())
+
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Applied {
+			state = "applied"
+		}
+		if m.Applied && status.Dirty && m.Version == status.Version {
+			state = "dirty"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", m.Version, m.Identifier, state)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
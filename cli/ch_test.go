@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -62,13 +63,13 @@ func TestClickHouseBaseConfig_Validate(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "no port",
+			name: "default port",
 			cfgFn: func() *db.ClickHouseBaseConfig {
 				cfg := validBaseCfgFn()
 				cfg.Port = 0
 				return cfg
 			},
-			wantErr: true,
+			wantErr: false,
 		},
 		{
 			name: "negative port",
@@ -240,3 +241,37 @@ func TestClickHouseMultiFlags(t *testing.T) {
 	flags := ClickHouseMultiFlags(envPrefix, validMultiCfgFn())
 	assert.NotEmpty(t, flags)
 }
+
+func TestClickHouseReplicaFlags(t *testing.T) {
+	cfg := &db.ClickHouseReplicaConfig{Primary: validCfgFn()}
+	flags := ClickHouseReplicaFlags("TEST_", cfg)
+	assert.Len(t, flags, len(ClickHouseFlags("TEST_", validCfgFn()))+1)
+}
+
+func TestWriteMigrationStatus(t *testing.T) {
+	status := &db.MigrationStatus{
+		Version: 2,
+		Dirty:   true,
+		Migrations: []db.MigrationInfo{
+			{Version: 1, Identifier: "create_peers", Applied: true},
+			{Version: 2, Identifier: "add_agent", Applied: true},
+			{Version: 100, Identifier: "create_visits"},
+		},
+	}
+
+	var sb strings.Builder
+	require.NoError(t, writeMigrationStatus(&sb, status))
+	assert.Equal(t, `Version: 2 (dirty)
+Pending: 1
+
+VERSION  NAME           STATUS
+1        create_peers   applied
+2        add_agent      dirty
+100      create_visits  pending
+`, sb.String())
+
+	sb.Reset()
+	status = &db.MigrationStatus{Migrations: []db.MigrationInfo{{Version: 1, Identifier: "create_peers"}}}
+	require.NoError(t, writeMigrationStatus(&sb, status))
+	assert.Contains(t, sb.String(), "Version: none\nPending: 1\n")
+}
@@ -0,0 +1,159 @@
+// Package clitest runs root commands created by [cli.NewRootCommand]
+// in-process, so that applications can test their commands end to end
+// without building and executing the binary:
+//
+//	func TestServe(t *testing.T) {
+//		root, _ := newRootCommand()
+//
+//		res := clitest.Run(t, root, []string{"migrate", "--dry-run"},
+//			clitest.WithEnv("APP_CLICKHOUSE_HOST", "localhost"),
+//		)
+//
+//		assert.Equal(t, cli.ExitOK, res.ExitCode)
+//		assert.Contains(t, res.Logs, "Applied migrations")
+//	}
+//
+// Run disables telemetry and signal handling and captures the output and
+// logs of the command. It modifies the environment and the global logger,
+// so tests that use it must not run in parallel.
+package clitest
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/probe-lab/go-commons/cli"
+	"github.com/probe-lab/go-commons/tele"
+)
+
+// DefaultTimeout bounds the duration of a command run with [Run].
+const DefaultTimeout = time.Minute
+
+// Result holds the outcome of a command run with [Run].
+type Result struct {
+	// Err is the error that the command returned.
+	Err error
+
+	// ExitCode is the process exit code for Err, see [cli.ExitCode].
+	ExitCode int
+
+	// Stdout and Stderr hold what the command wrote to its Writer and
+	// ErrWriter, e.g., the output of the version command or the help text.
+	Stdout string
+	Stderr string
+
+	// Logs holds the log output of the command.
+	Logs string
+}
+
+// Option configures [Run].
+type Option func(*runConfig)
+
+type runConfig struct {
+	ctx     context.Context
+	timeout time.Duration
+	env     map[string]string
+}
+
+// WithEnv sets the environment variable key to value for the duration of the
+// test.
+func WithEnv(key, value string) Option {
+	return func(cfg *runConfig) {
+		cfg.env[key] = value
+	}
+}
+
+// WithContext runs the command with the given context, e.g., to cancel it
+// and test the graceful shutdown of long-running commands.
+func WithContext(ctx context.Context) Option {
+	return func(cfg *runConfig) {
+		cfg.ctx = ctx
+	}
+}
+
+// WithTimeout bounds the duration of the command instead of the
+// [DefaultTimeout]. Zero disables the timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *runConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+// Run runs root with the given arguments, which exclude the name of the
+// binary, and returns the result. Failing commands don't fail the test, so
+// assert on [Result.Err] or [Result.ExitCode].
+//
+// A root command must only be run once.
+func Run(t testing.TB, root *cli.RootCommand, args []string, opts ...Option) *Result {
+	t.Helper()
+
+	rc := &runConfig{
+		ctx:     context.Background(),
+		timeout: DefaultTimeout,
+		env:     map[string]string{},
+	}
+	for _, opt := range opts {
+		opt(rc)
+	}
+
+	for key, value := range rc.env {
+		t.Setenv(key, value)
+	}
+
+	tele.DisableForTest(t)
+
+	// the root command replaces the global logger in its Before hook
+	prevLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prevLogger) })
+
+	var stdout, stderr, logs buffer
+
+	cmd := root.Command()
+	cmd.Writer = &stdout
+	cmd.ErrWriter = &stderr
+
+	cfg := root.Config()
+	cfg.Log.Writer = &logs
+	cfg.ShutdownSignals = nil
+	cfg.ReloadSignals = nil
+
+	ctx := rc.ctx
+	if rc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rc.timeout)
+		defer cancel()
+	}
+
+	err := root.RunWithContextAndArgs(ctx, append([]string{cmd.Name}, args...))
+
+	return &Result{
+		Err:      err,
+		ExitCode: cli.ExitCode(err),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Logs:     logs.String(),
+	}
+}
+
+// buffer is a [bytes.Buffer] that is safe for concurrent use, as services
+// may still log from background goroutines.
+type buffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *buffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,76 @@
+package clitest
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ucli "github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/cli"
+	"github.com/probe-lab/go-commons/errs"
+)
+
+func newRootCommand(action ucli.ActionFunc) *cli.RootCommand {
+	root, _ := cli.NewRootCommand(&ucli.Command{
+		Name: "app",
+		Commands: []*ucli.Command{{
+			Name:   "serve",
+			Flags:  []ucli.Flag{&ucli.StringFlag{Name: "name", Sources: ucli.EnvVars("APP_NAME")}},
+			Action: action,
+		}},
+	})
+	return root
+}
+
+func TestRun(t *testing.T) {
+	root := newRootCommand(func(ctx context.Context, c *ucli.Command) error {
+		slog.Info("Serving", "name", c.String("name"))
+		_, err := c.Root().Writer.Write([]byte("hello " + c.String("name")))
+		return err
+	})
+
+	res := Run(t, root, []string{"serve"}, WithEnv("APP_NAME", "world"))
+	require.NoError(t, res.Err)
+	assert.Equal(t, cli.ExitOK, res.ExitCode)
+	assert.Equal(t, "hello world", res.Stdout)
+	assert.Contains(t, res.Logs, "msg=Serving name=world")
+	assert.Contains(t, res.Logs, "msg=\"Started app\"")
+}
+
+func TestRun_environmentIsRestored(t *testing.T) {
+	t.Run("run", func(t *testing.T) {
+		Run(t, newRootCommand(nil), []string{"version"}, WithEnv("APP_NAME", "world"))
+	})
+
+	_, found := os.LookupEnv("APP_NAME")
+	assert.False(t, found)
+}
+
+func TestRun_exitCode(t *testing.T) {
+	root := newRootCommand(func(ctx context.Context, c *ucli.Command) error {
+		return errs.Newf(errs.Unavailable, "database is down")
+	})
+
+	res := Run(t, root, []string{"serve"})
+	assert.ErrorIs(t, res.Err, errs.Unavailable)
+	assert.Equal(t, cli.ExitUnavailable, res.ExitCode)
+
+	res = Run(t, newRootCommand(nil), []string{"--unknown", "serve"})
+	assert.Equal(t, cli.ExitInvalid, res.ExitCode)
+}
+
+func TestRun_timeout(t *testing.T) {
+	root := newRootCommand(func(ctx context.Context, c *ucli.Command) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	res := Run(t, root, []string{"serve"}, WithTimeout(10*time.Millisecond))
+	assert.ErrorIs(t, res.Err, context.DeadlineExceeded)
+	assert.Equal(t, cli.ExitFailure, res.ExitCode)
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/errs"
+)
+
+// NewDebugCommand returns a hidden "debug" command that fetches runtime
+// dumps from the /debug/pprof endpoints that the metrics server of a
+// running instance serves:
+//
+//	app debug goroutines > goroutines.txt
+//	app debug heap --gc --output heap.pprof
+//	app debug gc --addr 10.0.1.12:6060
+//
+// The root command created by [NewRootCommand] includes it.
+func NewDebugCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "debug",
+		Usage:  "Dumps goroutines, heap profiles, or GC stats of a running instance",
+		Hidden: true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "addr",
+				Usage: "The address of the metrics server of the running instance",
+				Value: "localhost:6060",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "The maximum time to wait for a dump",
+				Value: 30 * time.Second,
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:  "goroutines",
+				Usage: "Prints the stacks of all goroutines",
+				Flags: []cli.Flag{debugOutputFlag("-")},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return debugDump(ctx, c, "goroutine", url.Values{"debug": {"2"}}, nil)
+				},
+			},
+			{
+				Name:  "heap",
+				Usage: "Saves a heap profile for go tool pprof",
+				Flags: []cli.Flag{
+					debugOutputFlag("heap.pprof"),
+					&cli.BoolFlag{
+						Name:  "gc",
+						Usage: "Whether to run a garbage collection before taking the profile",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					query := url.Values{}
+					if c.Bool("gc") {
+						query.Set("gc", "1")
+					}
+					return debugDump(ctx, c, "heap", query, nil)
+				},
+			},
+			{
+				Name:  "gc",
+				Usage: "Prints the memory and garbage collector statistics",
+				Flags: []cli.Flag{debugOutputFlag("-")},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return debugDump(ctx, c, "heap", url.Values{"debug": {"1"}}, memStatsSection)
+				},
+			},
+		},
+	}
+}
+
+func debugOutputFlag(value string) cli.Flag {
+	return &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "The file to write the dump to. - writes to stdout",
+		Value:   value,
+	}
+}
+
+// debugDump fetches the given pprof profile from the instance at --addr and
+// writes it, optionally passed through filter, to --output.
+func debugDump(ctx context.Context, c *cli.Command, profile string, query url.Values, filter func(io.Writer, io.Reader) error) error {
+	if timeout := c.Duration("timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	u := url.URL{
+		Scheme:   "http",
+		Host:     c.String("addr"),
+		Path:     "/debug/pprof/" + profile,
+		RawQuery: query.Encode(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return errs.Wrapf(errs.InvalidInput, err, "new request")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errs.Wrapf(errs.Unavailable, err, "fetch %s profile", profile)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errs.Newf(errs.Unavailable, "fetch %s profile: unexpected status %s", profile, resp.Status)
+	}
+
+	if filter == nil {
+		filter = func(w io.Writer, r io.Reader) error {
+			_, err := io.Copy(w, r)
+			return err
+		}
+	}
+
+	output := c.String("output")
+	if output == "-" {
+		return filter(c.Root().Writer, resp.Body)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", output, err)
+	}
+
+	if err := filter(f, resp.Body); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write %s: %w", output, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", output, err)
+	}
+
+	fmt.Fprintf(c.Root().Writer, "Wrote %s profile to %s\n", profile, output)
+
+	return nil
+}
+
+// memStatsSection writes the runtime.MemStats section at the end of a heap
+// profile in the legacy text format without the leading "# ".
+func memStatsSection(w io.Writer, r io.Reader) error {
+	var (
+		found   bool
+		scanner = bufio.NewScanner(r)
+	)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !found {
+			found = line == "# runtime.MemStats"
+			continue
+		}
+
+		if _, err := fmt.Fprintln(w, strings.TrimPrefix(line, "# ")); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("heap profile has no runtime.MemStats section")
+	}
+
+	return nil
+}
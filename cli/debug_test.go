@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/errs"
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestNewDebugCommand(t *testing.T) {
+	tele.DisableForTest(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+
+		var out bytes.Buffer
+		root, _ := NewRootCommand(&cli.Command{Name: "test", Writer: &out})
+		err := root.RunWithContextAndArgs(context.Background(), append([]string{"test", "debug"}, args...))
+		return out.String(), err
+	}
+
+	out, err := run(t, "goroutines", "--addr", addr)
+	require.NoError(t, err)
+	assert.Contains(t, out, "goroutine ")
+	assert.Contains(t, out, "TestNewDebugCommand")
+
+	out, err = run(t, "gc", "--addr", addr)
+	require.NoError(t, err)
+	assert.Contains(t, out, "NumGC = ")
+	assert.NotContains(t, out, "# ")
+
+	path := filepath.Join(t.TempDir(), "heap.pprof")
+	out, err = run(t, "heap", "--gc", "--addr", addr, "-o", path)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Wrote heap profile to "+path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x1f, 0x8b}, data[:2], "profile must be gzipped protobuf")
+
+	_, err = run(t, "goroutines", "--addr", "127.0.0.1:1", "--timeout", "1s")
+	assert.ErrorIs(t, err, errs.Unavailable)
+}
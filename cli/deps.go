@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/probe-lab/go-commons/db"
+)
+
+// ErrDependencyUnavailable is returned by the root command's Before hook if
+// not all registered dependencies became reachable within
+// [RootCommandConfig.StartupWait].
+var ErrDependencyUnavailable = errors.New("dependency unavailable")
+
+// Dependency checks whether a service that the application depends on, e.g.,
+// a database or the OTLP collector, is reachable.
+type Dependency func(ctx context.Context) error
+
+type dependency struct {
+	name  string
+	check Dependency
+}
+
+// DependsOn registers a dependency that must be reachable before the
+// application starts. Dependencies are only checked if
+// [RootCommandConfig.StartupWait] is positive. Register them in the Before
+// hook of the command or before running it.
+func (cfg *RootCommandConfig) DependsOn(name string, check Dependency) {
+	cfg.dependencies = append(cfg.dependencies, dependency{name: name, check: check})
+}
+
+// TCPDependency returns a [Dependency] that succeeds if a TCP connection to
+// the given address can be established.
+func TCPDependency(addr string) Dependency {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// PingDependency returns a [Dependency] that pings an open connection.
+func PingDependency(p db.Pinger) Dependency {
+	return p.Ping
+}
+
+// ClickHouseDependency returns a [Dependency] that opens, pings, and closes
+// a connection to the configured ClickHouse database.
+func ClickHouseDependency(cfg *db.ClickHouseConfig) Dependency {
+	return func(ctx context.Context) error {
+		conn, err := cfg.OpenAndPing(ctx)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// HTTPDependency returns a [Dependency] that succeeds if a GET request to
+// the given URL returns a 2xx status code.
+func HTTPDependency(url string) Dependency {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+
+		return nil
+	}
+}
+
+// PostgresDependency returns a [Dependency] that opens, pings, and closes a
+// connection to the Postgres database with the given DSN. The application
+// must register a "postgres" database/sql driver, e.g., by importing
+// github.com/lib/pq. Without a registered driver, it only checks that a TCP
+// connection to the server can be established.
+func PostgresDependency(dsn string) (Dependency, error) {
+	if slices.Contains(sql.Drivers(), "postgres") {
+		return func(ctx context.Context) error {
+			handle, err := sql.Open("postgres", dsn)
+			if err != nil {
+				return err
+			}
+			defer handle.Close()
+
+			return handle.PingContext(ctx)
+		}, nil
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse postgres dsn: %w", err)
+	}
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("postgres dsn host must not be empty")
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "5432"
+	}
+
+	return TCPDependency(net.JoinHostPort(u.Hostname(), port)), nil
+}
+
+// otlpEndpoint returns the host:port of the configured OTLP trace endpoint
+// or, if that is empty, of the endpoint configured by the standard
+// OTEL_EXPORTER_OTLP_* environment variables.
+func otlpEndpoint(configured string) string {
+	endpoint := configured
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		return "localhost:4317"
+	}
+
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		if u.Port() != "" {
+			return u.Host
+		}
+		if u.Scheme == "https" {
+			return net.JoinHostPort(u.Hostname(), "443")
+		}
+		return net.JoinHostPort(u.Hostname(), "4317")
+	}
+
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+// Backoff bounds for dependency checks.
+const (
+	dependencyBackoffMin = 500 * time.Millisecond
+	dependencyBackoffMax = 10 * time.Second
+)
+
+// waitForDependencies checks all dependencies concurrently and retries failed
+// ones with exponential backoff until they succeed or maxWait elapsed.
+func waitForDependencies(ctx context.Context, deps []dependency, maxWait time.Duration) error {
+	if len(deps) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	start := time.Now()
+	slog.Info("Waiting for dependencies", "count", len(deps), "max_wait", maxWait)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		pending []string
+	)
+
+	for _, dep := range deps {
+		wg.Go(func() {
+			if err := waitForDependency(ctx, dep); err != nil {
+				mu.Lock()
+				pending = append(pending, dep.name)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	if len(pending) > 0 {
+		return fmt.Errorf("%w after %s: %s", ErrDependencyUnavailable, maxWait, strings.Join(pending, ", "))
+	}
+
+	slog.Info("All dependencies reachable", "took", time.Since(start).Round(time.Millisecond))
+
+	return nil
+}
+
+// dependencyCheckTimeout bounds the single check of all dependencies with
+// --check-config.dependencies.
+const dependencyCheckTimeout = 10 * time.Second
+
+// checkDependencies checks all dependencies concurrently once without
+// retrying failed ones.
+func checkDependencies(ctx context.Context, deps []dependency, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []string
+	)
+
+	for _, dep := range deps {
+		wg.Go(func() {
+			if err := dep.check(ctx); err != nil {
+				slog.Error("Dependency unreachable", "dependency", dep.name, "err", err)
+				mu.Lock()
+				failed = append(failed, dep.name)
+				mu.Unlock()
+				return
+			}
+			slog.Info("Dependency reachable", "dependency", dep.name)
+		})
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		slices.Sort(failed)
+		return fmt.Errorf("%w: %s", ErrDependencyUnavailable, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func waitForDependency(ctx context.Context, dep dependency) error {
+	backoff := dependencyBackoffMin
+	for attempt := 1; ; attempt++ {
+		err := dep.check(ctx)
+		if err == nil {
+			slog.Debug("Dependency reachable", "dependency", dep.name, "attempts", attempt)
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			slog.Error("Dependency unreachable", "dependency", dep.name, "attempts", attempt, "err", err)
+			return err
+		}
+
+		slog.Info("Waiting for dependency", "dependency", dep.name, "attempt", attempt, "retry_in", backoff, "err", err)
+
+		select {
+		case <-ctx.Done():
+			slog.Error("Dependency unreachable", "dependency", dep.name, "attempts", attempt, "err", err)
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, dependencyBackoffMax)
+	}
+}
+
+func (cfg *RootCommandConfig) dependencyNames() []string {
+	names := make([]string, len(cfg.dependencies))
+	for i, dep := range cfg.dependencies {
+		names[i] = dep.name
+	}
+	return names
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestWaitForDependencies(t *testing.T) {
+	t.Run("eventually reachable", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			attempts := 0
+			deps := []dependency{{name: "db", check: func(ctx context.Context) error {
+				attempts++
+				if attempts < 3 {
+					return errors.New("connection refused")
+				}
+				return nil
+			}}}
+
+			start := time.Now()
+			assert.NoError(t, waitForDependencies(context.Background(), deps, time.Minute))
+			assert.Equal(t, 3, attempts)
+			// 500ms + 1s backoff
+			assert.Equal(t, 1500*time.Millisecond, time.Since(start))
+		})
+	})
+
+	t.Run("max wait exceeded", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			deps := []dependency{
+				{name: "ok", check: func(ctx context.Context) error { return nil }},
+				{name: "db", check: func(ctx context.Context) error { return errors.New("connection refused") }},
+			}
+
+			start := time.Now()
+			err := waitForDependencies(context.Background(), deps, 5*time.Second)
+			assert.ErrorIs(t, err, ErrDependencyUnavailable)
+			assert.ErrorContains(t, err, "db")
+			assert.NotContains(t, err.Error(), "ok")
+			assert.Equal(t, 5*time.Second, time.Since(start))
+		})
+	})
+}
+
+func TestOtlpEndpoint(t *testing.T) {
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{"", "localhost:4317"},
+		{"collector:4317", "collector:4317"},
+		{"http://collector:4318", "collector:4318"},
+		{"http://collector", "collector:4317"},
+		{"https://collector", "collector:443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", tt.env)
+			assert.Equal(t, tt.want, otlpEndpoint(""))
+		})
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "collector:4317")
+	assert.Equal(t, "tempo:4317", otlpEndpoint("tempo:4317"))
+	assert.Equal(t, "tempo:443", otlpEndpoint("https://tempo"))
+}
+
+func TestRootCommand_checkConfig(t *testing.T) {
+	tele.DisableForTest(t)
+
+	run := func(t *testing.T, dbErr error, args ...string) (bool, error) {
+		t.Helper()
+
+		ran := false
+		root, cfg := NewRootCommand(&cli.Command{
+			Name: "test",
+			Commands: []*cli.Command{{
+				Name: "serve",
+				Action: func(context.Context, *cli.Command) error {
+					ran = true
+					return nil
+				},
+			}},
+		})
+		cfg.DependsOn("db", func(context.Context) error { return dbErr })
+
+		err := root.RunWithContextAndArgs(context.Background(), append([]string{"test"}, args...))
+		return ran, err
+	}
+
+	ran, err := run(t, nil, "serve")
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	ran, err = run(t, errors.New("connection refused"), "--check-config", "serve")
+	require.NoError(t, err)
+	assert.False(t, ran, "the action must not run")
+
+	ran, err = run(t, nil, "serve", "--check-config", "--check-config.dependencies")
+	require.NoError(t, err)
+	assert.False(t, ran)
+
+	ran, err = run(t, errors.New("connection refused"), "serve", "--check-config", "--check-config.dependencies")
+	assert.ErrorIs(t, err, ErrDependencyUnavailable)
+	assert.Equal(t, ExitUnavailable, ExitCode(err))
+	assert.ErrorContains(t, err, "dependency unavailable: db")
+	assert.False(t, ran)
+
+	ran, err = run(t, nil, "--check-config", "--log.level=bogus", "serve")
+	assert.Equal(t, ExitInvalid, ExitCode(err))
+	assert.False(t, ran)
+}
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/cpuguy83/go-md2man/v2/md2man"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/errs"
+)
+
+// docsEscaper escapes the characters of flag usages that markdown would
+// interpret, e.g., the underscores in OTEL_EXPORTER_OTLP_ENDPOINT.
+var docsEscaper = strings.NewReplacer("|", `\|`, "_", `\_`, "*", `\*`)
+
+// docsManSection is the manual section of the generated man page. Our
+// binaries are daemons, which belong to the system administration section.
+const docsManSection = 8
+
+// NewDocsCommand returns a hidden "docs" command that renders all visible
+// commands of the root command with their usage, flags, environment
+// variables, and defaults as markdown or as a man page. Generate the
+// reference documentation with it so that it stays in sync with the flag
+// definitions:
+//
+//	app docs > docs/cli.md
+//	app docs --format man > app.8
+//
+// The root command created by [NewRootCommand] includes it. Defaults of
+// secret flags, see [SecretEnvVars], are omitted.
+func NewDocsCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "docs",
+		Usage:  "Prints the documentation of all commands and flags as markdown or man page",
+		Hidden: true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "The output format (markdown, man)",
+				Value: "markdown",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			var sb strings.Builder
+			writeDocsMarkdown(&sb, c.Root())
+
+			switch format := c.String("format"); format {
+			case "markdown":
+				_, err := io.WriteString(c.Root().Writer, sb.String())
+				return err
+			case "man":
+				title := fmt.Sprintf("%% %s %d\n\n", strings.ToUpper(c.Root().Name), docsManSection)
+				_, err := c.Root().Writer.Write(md2man.Render([]byte(title + sb.String())))
+				return err
+			default:
+				return errs.Newf(errs.InvalidInput, "unsupported docs format %q", format)
+			}
+		},
+	}
+}
+
+// writeDocsMarkdown renders the root command and all its visible
+// subcommands. Subcommands are listed flat with their full path so that
+// deeply nested commands don't run out of heading levels.
+func writeDocsMarkdown(sb *strings.Builder, root *cli.Command) {
+	fmt.Fprintf(sb, "# %s\n\n", root.Name)
+	writeDocsDescription(sb, root)
+
+	fmt.Fprintf(sb, "```\n%s [global options] [command [command options]]\n```\n\n", root.Name)
+
+	if entries := docsFlagEntries(root); len(entries) > 0 {
+		sb.WriteString("## Global Options\n\n")
+		writeDocsFlags(sb, entries, "###")
+	}
+
+	commands := docsCommands(root)
+	if len(commands) == 0 {
+		return
+	}
+
+	sb.WriteString("## Commands\n\n")
+
+	var walk func(path string, cmds []*cli.Command)
+	walk = func(path string, cmds []*cli.Command) {
+		for _, cmd := range cmds {
+			cmdPath := path + " " + cmd.Name
+
+			fmt.Fprintf(sb, "### %s\n\n", cmdPath)
+			writeDocsDescription(sb, cmd)
+
+			if len(cmd.Aliases) > 0 {
+				fmt.Fprintf(sb, "Aliases: %s\n\n", strings.Join(cmd.Aliases, ", "))
+			}
+
+			subcommands := docsCommands(cmd)
+			entries := docsFlagEntries(cmd)
+
+			synopsis := cmdPath
+			if len(subcommands) > 0 {
+				synopsis += " <command>"
+			}
+			if len(entries) > 0 {
+				synopsis += " [command options]"
+			}
+			if cmd.ArgsUsage != "" {
+				synopsis += " " + cmd.ArgsUsage
+			}
+			fmt.Fprintf(sb, "```\n%s\n```\n\n", synopsis)
+
+			if len(entries) > 0 {
+				writeDocsFlags(sb, entries, "####")
+			}
+
+			walk(cmdPath, subcommands)
+		}
+	}
+	walk(root.Name, commands)
+}
+
+func writeDocsDescription(sb *strings.Builder, cmd *cli.Command) {
+	if cmd.Usage != "" {
+		sb.WriteString(cmd.Usage + "\n\n")
+	}
+
+	if cmd.Description != "" {
+		sb.WriteString(strings.TrimSpace(cmd.Description) + "\n\n")
+	}
+}
+
+// writeDocsFlags writes a table of the given flags per category.
+func writeDocsFlags(sb *strings.Builder, entries []envTemplateEntry, heading string) {
+	category := "-"
+	for _, e := range entries {
+		if e.category != category {
+			if category != "-" {
+				sb.WriteString("\n")
+			}
+
+			category = e.category
+			if category != "" {
+				fmt.Fprintf(sb, "%s %s\n\n", heading, strings.TrimSuffix(category, ":"))
+			}
+
+			sb.WriteString("| Flag | Environment variable | Default | Description |\n")
+			sb.WriteString("|---|---|---|---|\n")
+		}
+
+		value := "`" + e.value + "`"
+		if e.secret {
+			value = "(secret)"
+		} else if e.value == "" {
+			value = ""
+		}
+
+		env := make([]string, len(e.env))
+		for i, name := range e.env {
+			env[i] = "`" + name + "`"
+		}
+
+		fmt.Fprintf(sb, "| %s | %s | %s | %s |\n", e.flag, strings.Join(env, ", "), value, docsEscaper.Replace(e.usage))
+	}
+	sb.WriteString("\n")
+}
+
+// docsFlagEntries describes the visible flags of cmd sorted by category
+// while keeping their order within a category. The flag column holds all
+// names of the flag.
+func docsFlagEntries(cmd *cli.Command) []envTemplateEntry {
+	var entries []envTemplateEntry
+	for _, f := range cmd.VisibleFlags() {
+		entry, ok := newEnvTemplateEntry(f)
+		if !ok || entry.flag == "help" {
+			continue
+		}
+
+		names := make([]string, len(f.Names()))
+		for i, name := range f.Names() {
+			if len(name) == 1 {
+				names[i] = "`-" + name + "`"
+			} else {
+				names[i] = "`--" + name + "`"
+			}
+		}
+		entry.flag = strings.Join(names, ", ")
+
+		entries = append(entries, entry)
+	}
+
+	slices.SortStableFunc(entries, func(a, b envTemplateEntry) int {
+		return strings.Compare(a.category, b.category)
+	})
+
+	return entries
+}
+
+// docsCommands returns the visible subcommands of cmd without the help
+// command.
+func docsCommands(cmd *cli.Command) []*cli.Command {
+	return slices.DeleteFunc(cmd.VisibleCommands(), func(c *cli.Command) bool {
+		return c.Name == "help"
+	})
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/db"
+	"github.com/probe-lab/go-commons/errs"
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestNewDocsCommand(t *testing.T) {
+	tele.DisableForTest(t)
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+
+		var out bytes.Buffer
+		cmd := &cli.Command{Name: "test", Usage: "A test service", Writer: &out}
+		root, _ := NewRootCommand(cmd)
+
+		chCfg := db.DefaultClickHouseConfig("test")
+		cmd.Commands = append(cmd.Commands, &cli.Command{
+			Name:      "serve",
+			Usage:     "Serves the API",
+			ArgsUsage: "[addr]",
+			Flags:     ClickHouseFlags("test", chCfg),
+			Commands:  []*cli.Command{{Name: "admin", Usage: "Serves the admin API"}},
+		})
+
+		err := root.RunWithContextAndArgs(context.Background(), append([]string{"test", "docs"}, args...))
+		return out.String(), err
+	}
+
+	md, err := run(t)
+	require.NoError(t, err)
+	assert.Contains(t, md, "# test\n\nA test service\n\n")
+	assert.Contains(t, md, "## Global Options\n\n| Flag | Environment variable | Default | Description |\n|---|---|---|---|\n| `--env.file` | `TEST_ENV_FILE` |  |")
+	assert.Contains(t, md, "\n\n### Logging Configuration\n\n| Flag | Environment variable | Default | Description |\n")
+	assert.Contains(t, md, "| `--log.level` | `TEST_LOG_LEVEL` | `info` |")
+	assert.Contains(t, md, "### test serve\n\nServes the API\n\n```\ntest serve <command> [command options] [addr]\n```\n\n#### Database Configuration\n\n")
+	assert.Contains(t, md, "| `--clickhouse.password` | `TEST_CLICKHOUSE_PASSWORD`, `TEST_CLICKHOUSE_PASSWORD_FILE` | (secret) |")
+	assert.Contains(t, md, "### test serve admin\n\nServes the admin API\n\n")
+	assert.Contains(t, md, "### test version\n")
+	assert.NotContains(t, md, "test docs", "hidden commands must be omitted")
+	assert.NotContains(t, md, "--help")
+
+	man, err := run(t, "--format", "man")
+	require.NoError(t, err)
+	assert.Contains(t, man, ".TH TEST 8")
+	assert.Contains(t, man, ".SH Global Options")
+	assert.Contains(t, man, "OTEL_EXPORTER_OTLP_*", "usages must be escaped")
+
+	_, err = run(t, "--format", "html")
+	assert.ErrorIs(t, err, errs.InvalidInput)
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// loadEnvFile sets the environment variables of the dotenv file at path that
+// are not already set, so that the real environment takes precedence over
+// the file. It returns the keys that were set.
+func loadEnvFile(path string) ([]string, error) {
+	vars, err := readEnvFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, kv := range vars {
+		if _, found := os.LookupEnv(kv[0]); found {
+			continue
+		}
+
+		if err := os.Setenv(kv[0], kv[1]); err != nil {
+			return nil, fmt.Errorf("set %s: %w", kv[0], err)
+		}
+		keys = append(keys, kv[0])
+	}
+
+	return keys, nil
+}
+
+// readEnvFile parses the dotenv file at path without changing the
+// environment.
+func readEnvFile(path string) ([][2]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	vars, err := parseDotenv(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return vars, nil
+}
+
+// applyEnvSources populates the flags of the invoked commands that were
+// neither set on the command line nor from their sources during flag parsing
+// from their sources again, e.g., after environment variables were loaded
+// from a dotenv file.
+func applyEnvSources(cmd *cli.Command) error {
+	for _, c := range invokedCommands(cmd) {
+		for _, f := range c.Flags {
+			if err := f.PostParse(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseDotenv parses KEY=VALUE lines in the order they appear. Blank lines
+// and lines starting with # are skipped, and an optional "export " prefix is
+// removed. Values may be single-quoted (taken literally) or double-quoted
+// (supporting \n, \t, \", and \\ escapes). Unquoted values end at a " #"
+// comment and are trimmed.
+func parseDotenv(r io.Reader) ([][2]string, error) {
+	var (
+		vars    [][2]string
+		scanner = bufio.NewScanner(r)
+		lineNum int
+	)
+
+	for scanner.Scan() {
+		lineNum++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+
+		value, err := parseDotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		vars = append(vars, [2]string{key, value})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+// parseDotenvValue unquotes a single value of a dotenv line.
+func parseDotenvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch quote := value[0]; quote {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return value[1 : end+1], nil
+
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+			switch {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+
+	default:
+		if idx := strings.Index(value, " #"); idx >= 0 {
+			value = value[:idx]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/log"
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestParseDotenv(t *testing.T) {
+	input := `
+# comment
+PLAIN=value
+export EXPORTED=exported
+SPACED = spaced value   # trailing comment
+EMPTY=
+SINGLE='literal \n # value'
+DOUBLE="line1\nline2 \"quoted\""
+URL=http://localhost:8080/#anchor
+`
+
+	vars, err := parseDotenv(strings.NewReader(input))
+	require.NoError(t, err)
+
+	assert.Equal(t, [][2]string{
+		{"PLAIN", "value"},
+		{"EXPORTED", "exported"},
+		{"SPACED", "spaced value"},
+		{"EMPTY", ""},
+		{"SINGLE", `literal \n # value`},
+		{"DOUBLE", "line1\nline2 \"quoted\""},
+		{"URL", "http://localhost:8080/#anchor"},
+	}, vars)
+
+	for _, invalid := range []string{"NOVALUE", "=value", "TWO KEYS=value", `OPEN="value`, "OPEN='value"} {
+		_, err := parseDotenv(strings.NewReader(invalid))
+		assert.ErrorContains(t, err, "line 1", invalid)
+	}
+}
+
+func TestRootCommand_envFile(t *testing.T) {
+	tele.DisableForTest(t)
+
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "DOTENVTEST_LOG_LEVEL=debug\nDOTENVTEST_METRICS_PORT=9999\nDOTENVTEST_SHUTDOWN_GRACE=5s\nDOTENVTEST_SUB=from-file\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	// let t.Setenv restore the environment after the file was loaded
+	for _, key := range []string{"DOTENVTEST_LOG_LEVEL", "DOTENVTEST_METRICS_PORT", "DOTENVTEST_SUB"} {
+		t.Setenv(key, "")
+		require.NoError(t, os.Unsetenv(key))
+	}
+	t.Setenv("DOTENVTEST_SHUTDOWN_GRACE", "7s")
+
+	var sub string
+	root, cfg := NewRootCommand(&cli.Command{
+		Name: "dotenvtest",
+		Commands: []*cli.Command{{
+			Name: "sub",
+			Flags: []cli.Flag{&cli.StringFlag{
+				Name:        "sub",
+				Sources:     cli.EnvVars("DOTENVTEST_SUB"),
+				Destination: &sub,
+			}},
+			Action: func(context.Context, *cli.Command) error { return nil },
+		}},
+	})
+
+	err := root.RunWithContextAndArgs(context.Background(), []string{"dotenvtest", "--env.file", path, "--metrics.port", "1234", "sub"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, 1234, cfg.Metrics.Port, "command line must take precedence over the env file")
+	assert.Equal(t, "7s", cfg.ShutdownGrace.String(), "environment must take precedence over the env file")
+	assert.Equal(t, "from-file", sub)
+}
+
+func TestRootCommand_envFileMissing(t *testing.T) {
+	tele.DisableForTest(t)
+
+	root, _ := NewRootCommand(&cli.Command{Name: "test", Action: func(context.Context, *cli.Command) error { return nil }})
+
+	err := root.RunWithContextAndArgs(context.Background(), []string{"test", "--env.file", filepath.Join(t.TempDir(), "missing.env")})
+	assert.Equal(t, ExitInvalid, ExitCode(err))
+}
+
+func TestRootCommand_reloadLogLevel(t *testing.T) {
+	tele.DisableForTest(t)
+	t.Cleanup(func() { _, _ = log.SetLevel("info") })
+
+	tests := []struct {
+		name     string
+		env      string // DOTENVTEST_LOG_LEVEL in the environment
+		args     []string
+		reloaded string // env file content on reload
+		want     string
+	}{
+		{
+			name:     "from env file",
+			reloaded: "DOTENVTEST_LOG_LEVEL=error\n",
+			want:     "error",
+		},
+		{
+			name:     "removed from env file",
+			reloaded: "",
+			want:     "info",
+		},
+		{
+			name:     "flag takes precedence",
+			args:     []string{"--log.level", "warn"},
+			reloaded: "DOTENVTEST_LOG_LEVEL=error\n",
+			want:     "warn",
+		},
+		{
+			name:     "environment takes precedence",
+			env:      "warn",
+			reloaded: "DOTENVTEST_LOG_LEVEL=error\n",
+			want:     "warn",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			require.NoError(t, os.WriteFile(path, []byte("DOTENVTEST_LOG_LEVEL=debug\n"), 0o600))
+
+			t.Setenv("DOTENVTEST_LOG_LEVEL", tt.env)
+			if tt.env == "" {
+				require.NoError(t, os.Unsetenv("DOTENVTEST_LOG_LEVEL"))
+			}
+
+			root, cfg := NewRootCommand(&cli.Command{
+				Name:   "dotenvtest",
+				Action: func(context.Context, *cli.Command) error { return nil },
+			})
+
+			args := append([]string{"dotenvtest", "--env.file", path}, tt.args...)
+			require.NoError(t, root.RunWithContextAndArgs(context.Background(), args))
+
+			require.NoError(t, os.WriteFile(path, []byte(tt.reloaded), 0o600))
+
+			_, err := cfg.Reload.Reload(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Log.Level)
+		})
+	}
+}
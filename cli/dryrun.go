@@ -0,0 +1,26 @@
+package cli
+
+import "context"
+
+// dryRunCtxKey is the context key under which the dry-run mode is stored.
+type dryRunCtxKey struct{}
+
+// WithDryRun returns a copy of ctx that carries whether the command runs in
+// dry-run mode. The root command created by [NewRootCommand] sets it from
+// the --dry-run flag in its Before hook.
+func WithDryRun(ctx context.Context, dryRun bool) context.Context {
+	return context.WithValue(ctx, dryRunCtxKey{}, dryRun)
+}
+
+// IsDryRun reports whether the command runs in dry-run mode. Commands that
+// modify data, e.g., migrations, backfills, or cleanups, should only log
+// what they would change in this mode:
+//
+//	if cli.IsDryRun(ctx) {
+//		slog.Info("Would delete rows", "count", n)
+//		return nil
+//	}
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunCtxKey{}).(bool)
+	return dryRun
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestIsDryRun(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, IsDryRun(ctx))
+	assert.True(t, IsDryRun(WithDryRun(ctx, true)))
+	assert.False(t, IsDryRun(WithDryRun(WithDryRun(ctx, true), false)))
+}
+
+func TestRootCommand_dryRun(t *testing.T) {
+	tele.DisableForTest(t)
+
+	run := func(t *testing.T, args ...string) bool {
+		t.Helper()
+
+		var dryRun bool
+		root, _ := NewRootCommand(&cli.Command{
+			Name: "test",
+			Commands: []*cli.Command{{
+				Name: "cleanup",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					dryRun = IsDryRun(ctx)
+					return nil
+				},
+			}},
+		})
+
+		require.NoError(t, root.RunWithContextAndArgs(context.Background(), append([]string{"test"}, args...)))
+		return dryRun
+	}
+
+	assert.False(t, run(t, "cleanup"))
+	assert.True(t, run(t, "--dry-run", "cleanup"))
+	assert.True(t, run(t, "cleanup", "--dry-run"))
+
+	t.Setenv("TEST_DRY_RUN", "true")
+	assert.True(t, run(t, "cleanup"))
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// warnUnknownEnvVars logs a warning for every environment variable that
+// starts with the env prefix but doesn't belong to any flag of cmd or its
+// subcommands, as typos in variable names otherwise go unnoticed. Variables
+// in allowed are read by the application itself and are not reported.
+func warnUnknownEnvVars(cmd *cli.Command, prefix string, allowed []string) {
+	for _, unknown := range unknownEnvVars(cmd, prefix, os.Environ(), allowed) {
+		if unknown.suggestion != "" {
+			slog.Warn("Unknown environment variable", "env", unknown.key, "did_you_mean", unknown.suggestion)
+		} else {
+			slog.Warn("Unknown environment variable", "env", unknown.key)
+		}
+	}
+}
+
+// unknownEnvVar is an environment variable with the env prefix that doesn't
+// belong to a flag, and the most similar known variable, if any.
+type unknownEnvVar struct {
+	key        string
+	suggestion string
+}
+
+// unknownEnvVars returns the variables of environ, given as key=value
+// pairs, that start with prefix but are neither used by a flag of cmd or
+// its subcommands nor contained in allowed. An empty prefix matches all
+// variables, so nothing is reported.
+func unknownEnvVars(cmd *cli.Command, prefix string, environ []string, allowed []string) []unknownEnvVar {
+	if prefix == "" {
+		return nil
+	}
+
+	known := map[string]bool{}
+	for _, key := range allowed {
+		known[key] = true
+	}
+
+	var walk func(cmd *cli.Command)
+	walk = func(cmd *cli.Command) {
+		for _, f := range cmd.Flags {
+			if docFlag, ok := f.(cli.DocGenerationFlag); ok {
+				for _, key := range docFlag.GetEnvVars() {
+					known[key] = true
+				}
+			}
+		}
+
+		for _, sub := range cmd.Commands {
+			walk(sub)
+		}
+	}
+	walk(cmd)
+
+	var unknown []unknownEnvVar
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, prefix) || known[key] {
+			continue
+		}
+
+		unknown = append(unknown, unknownEnvVar{key: key, suggestion: suggestEnvVar(key, known)})
+	}
+
+	slices.SortFunc(unknown, func(a, b unknownEnvVar) int {
+		return strings.Compare(a.key, b.key)
+	})
+
+	return unknown
+}
+
+// suggestEnvVar returns the known variable that is closest to key if it is
+// at most a few edits away, so that typos like CLICKHOUSE_PASWORD get a
+// helpful hint.
+func suggestEnvVar(key string, known map[string]bool) string {
+	var (
+		suggestion string
+		best       = 3 // suggest only up to two edits
+	)
+
+	for candidate := range known {
+		if d := editDistance(key, candidate); d < best || (d == best && candidate < suggestion) {
+			suggestion, best = candidate, d
+		}
+	}
+
+	return suggestion
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
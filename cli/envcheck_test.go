@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+func TestUnknownEnvVars(t *testing.T) {
+	cmd := &cli.Command{
+		Name: "app",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "log.level", Sources: cli.EnvVars("APP_LOG_LEVEL")},
+		},
+		Commands: []*cli.Command{{
+			Name: "serve",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "clickhouse.password", Sources: SecretEnvVars("APP_CLICKHOUSE_PASSWORD")},
+			},
+		}},
+	}
+
+	environ := []string{
+		"HOME=/root",
+		"APP_LOG_LEVEL=debug",
+		"APP_CLICKHOUSE_PASSWORD_FILE=/run/secrets/ch",
+		"APP_CLICKHOUSE_PASWORD=secret",
+		"APP_LOGLEVEL=debug",
+		"APP_FEATURE_X=on",
+		"APP_UNRELATED=1",
+	}
+
+	got := unknownEnvVars(cmd, "APP_", environ, []string{"APP_FEATURE_X"})
+	assert.Equal(t, []unknownEnvVar{
+		{key: "APP_CLICKHOUSE_PASWORD", suggestion: "APP_CLICKHOUSE_PASSWORD"},
+		{key: "APP_LOGLEVEL", suggestion: "APP_LOG_LEVEL"},
+		{key: "APP_UNRELATED"},
+	}, got)
+
+	assert.Empty(t, unknownEnvVars(cmd, "", environ, nil))
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("abc", "abc"))
+	assert.Equal(t, 1, editDistance("abc", "ab"))
+	assert.Equal(t, 1, editDistance("abc", "abd"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/errs"
+)
+
+// envTemplateEntry describes a flag that can be set via the environment.
+type envTemplateEntry struct {
+	flag     string
+	env      []string
+	usage    string
+	value    string
+	category string
+	secret   bool
+}
+
+// NewEnvTemplateCommand returns a hidden "env-template" command that prints
+// every flag of the root command and its subcommands together with its
+// environment variable, default, and usage, either as a commented
+// .env.example file or as a markdown table. Generate the deployment
+// documentation with it so that it stays in sync with the code:
+//
+//	app env-template > .env.example
+//	app env-template --format markdown > docs/configuration.md
+//
+// The root command created by [NewRootCommand] includes it. Defaults of
+// secret flags, see [SecretEnvVars], are omitted.
+func NewEnvTemplateCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "env-template",
+		Usage:  "Prints all environment variables with their defaults as .env file or markdown table",
+		Hidden: true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "The output format (env, markdown)",
+				Value: "env",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			entries := envTemplateEntries(c.Root())
+
+			switch format := c.String("format"); format {
+			case "env":
+				return writeEnvTemplate(c.Root().Writer, entries)
+			case "markdown":
+				return writeMarkdownTemplate(c.Root().Writer, entries)
+			default:
+				return errs.Newf(errs.InvalidInput, "unsupported env template format %q", format)
+			}
+		},
+	}
+}
+
+// envTemplateEntries collects the flags of cmd and all its subcommands that
+// have environment variables. Flags are deduplicated by name and sorted by
+// category while keeping their order within a category.
+func envTemplateEntries(cmd *cli.Command) []envTemplateEntry {
+	var (
+		entries []envTemplateEntry
+		seen    = map[string]bool{}
+		walk    func(cmd *cli.Command)
+	)
+
+	walk = func(cmd *cli.Command) {
+		for _, f := range cmd.Flags {
+			entry, ok := newEnvTemplateEntry(f)
+			if !ok || len(entry.env) == 0 || seen[entry.flag] {
+				continue
+			}
+			seen[entry.flag] = true
+
+			entries = append(entries, entry)
+		}
+
+		for _, sub := range cmd.Commands {
+			walk(sub)
+		}
+	}
+	walk(cmd)
+
+	slices.SortStableFunc(entries, func(a, b envTemplateEntry) int {
+		return strings.Compare(a.category, b.category)
+	})
+
+	return entries
+}
+
+// newEnvTemplateEntry describes the given flag and reports whether it
+// supports documentation. The default of secret flags is omitted.
+func newEnvTemplateEntry(f cli.Flag) (envTemplateEntry, bool) {
+	docFlag, ok := f.(cli.DocGenerationFlag)
+	if !ok {
+		return envTemplateEntry{}, false
+	}
+
+	entry := envTemplateEntry{
+		flag:  f.Names()[0],
+		env:   docFlag.GetEnvVars(),
+		usage: docFlag.GetUsage(),
+	}
+
+	if catFlag, ok := f.(cli.CategorizableFlag); ok {
+		entry.category = catFlag.GetCategory()
+	}
+
+	for i := 1; i < len(entry.env); i++ {
+		entry.secret = entry.secret || entry.env[i] == entry.env[0]+"_FILE"
+	}
+
+	if !entry.secret && docFlag.TakesValue() {
+		entry.value = envTemplateValue(docFlag.GetValue())
+	} else if !entry.secret {
+		entry.value = "false"
+		if docFlag.GetValue() == "true" {
+			entry.value = "true"
+		}
+	}
+
+	return entry, true
+}
+
+// envTemplateValue converts the string representation of a flag default to
+// the format of an environment variable, e.g., `"a", "b"` to `a,b`.
+func envTemplateValue(value string) string {
+	parts := strings.Split(value, ", ")
+	for i, part := range parts {
+		if unquoted, err := strconv.Unquote(part); err == nil {
+			parts[i] = unquoted
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func writeEnvTemplate(w io.Writer, entries []envTemplateEntry) error {
+	var sb strings.Builder
+
+	category := "-"
+	for _, e := range entries {
+		if e.category != category {
+			category = e.category
+			name := strings.TrimSuffix(category, ":")
+			if name == "" {
+				name = "General Configuration"
+			}
+			fmt.Fprintf(&sb, "\n# %s\n", name)
+		}
+
+		fmt.Fprintf(&sb, "\n# %s (--%s)\n", e.usage, e.flag)
+		if e.secret {
+			fmt.Fprintf(&sb, "# Secret: alternatively set %s to the path of a file with the value.\n", e.env[0]+"_FILE")
+		}
+		fmt.Fprintf(&sb, "%s=%s\n", e.env[0], e.value)
+	}
+
+	_, err := io.WriteString(w, strings.TrimPrefix(sb.String(), "\n"))
+	return err
+}
+
+func writeMarkdownTemplate(w io.Writer, entries []envTemplateEntry) error {
+	var sb strings.Builder
+
+	sb.WriteString("| Environment variable | Flag | Default | Description |\n")
+	sb.WriteString("|---|---|---|---|\n")
+	for _, e := range entries {
+		value := "`" + e.value + "`"
+		if e.secret {
+			value = "(secret)"
+		} else if e.value == "" {
+			value = ""
+		}
+
+		fmt.Fprintf(&sb, "| `%s` | `--%s` | %s | %s |\n", e.env[0], e.flag, value, strings.ReplaceAll(e.usage, "|", `\|`))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/db"
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestNewEnvTemplateCommand(t *testing.T) {
+	tele.DisableForTest(t)
+
+	run := func(t *testing.T, args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+		cmd := &cli.Command{Name: "test", Writer: &out}
+		root, _ := NewRootCommand(cmd)
+
+		chCfg := db.DefaultClickHouseConfig("test")
+		cmd.Commands = append(cmd.Commands, &cli.Command{
+			Name:  "serve",
+			Flags: ClickHouseFlags("test", chCfg),
+		})
+
+		require.NoError(t, root.RunWithContextAndArgs(context.Background(), append([]string{"test", "env-template"}, args...)))
+		return out.String()
+	}
+
+	env := run(t)
+	assert.Contains(t, env, "# Database Configuration\n")
+	assert.Contains(t, env, "# The address where ClickHouse is hosted (--clickhouse.host)\nTEST_CLICKHOUSE_HOST=127.0.0.1\n")
+	assert.Contains(t, env, "TEST_CLICKHOUSE_ASYNC_INSERT_WAIT=true\n")
+	assert.Contains(t, env, "TEST_LOG_LEVEL=info\n")
+	assert.Contains(t, env, "# Secret: alternatively set TEST_CLICKHOUSE_PASSWORD_FILE to the path of a file with the value.\nTEST_CLICKHOUSE_PASSWORD=\n")
+	assert.NotContains(t, env, "password\n")
+
+	md := run(t, "--format", "markdown")
+	assert.Contains(t, md, "| `TEST_CLICKHOUSE_HOST` | `--clickhouse.host` | `127.0.0.1` | The address where ClickHouse is hosted |\n")
+	assert.Contains(t, md, "| `TEST_CLICKHOUSE_PASSWORD` | `--clickhouse.password` | (secret) |")
+}
+
+func Test_envTemplateValue(t *testing.T) {
+	assert.Equal(t, "info", envTemplateValue(`"info"`))
+	assert.Equal(t, "a,b", envTemplateValue(`"a", "b"`))
+	assert.Equal(t, "30s", envTemplateValue("30s"))
+	assert.Equal(t, "", envTemplateValue(""))
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/errs"
+)
+
+// Exit codes returned by [RootCommand.Run] and used by [RootCommand.Main].
+// Scripts and CI jobs can rely on them to tell configuration problems apart
+// from unavailable dependencies and runtime failures.
+const (
+	ExitOK          = 0
+	ExitFailure     = 1   // any error that is not classified otherwise
+	ExitInvalid     = 2   // invalid flags, arguments, or configuration
+	ExitUnavailable = 3   // a dependency, e.g., a database, is unreachable
+	ExitInterrupted = 130 // the command was canceled by a shutdown signal
+)
+
+// ExitError is an error that carries a process exit code and a concise,
+// user-facing message. It implements [cli.ExitCoder].
+type ExitError struct {
+	Code    int
+	Message string
+	Err     error
+}
+
+var _ cli.ExitCoder = (*ExitError)(nil)
+
+// NewExitError returns an [ExitError] with the given code and message that
+// wraps err. The message defaults to the message of err.
+func NewExitError(code int, message string, err error) *ExitError {
+	return &ExitError{Code: code, Message: message, Err: err}
+}
+
+func (e *ExitError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("exit code %d", e.Code)
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode implements [cli.ExitCoder].
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// ExitCode returns the process exit code for err. An [ExitError] in the chain
+// determines the code. Otherwise, errors in the [errs.InvalidInput] category
+// map to [ExitInvalid], [ErrDependencyUnavailable] and errors in the
+// [errs.Unavailable] category map to [ExitUnavailable], canceled contexts map
+// to [ExitInterrupted], and all other errors map to [ExitFailure].
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.Is(err, errs.InvalidInput):
+		return ExitInvalid
+	case errors.Is(err, ErrDependencyUnavailable), errors.Is(err, errs.Unavailable):
+		return ExitUnavailable
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	default:
+		return ExitFailure
+	}
+}
+
+// ExitCodeMapping maps the errors that Match reports to the exit code Code,
+// see [RootCommandConfig.ExitCodes].
+type ExitCodeMapping struct {
+	Match func(err error) bool
+	Code  int
+}
+
+// ExitCodeIs maps errors that match target according to [errors.Is] to code:
+//
+//	cfg.ExitCodes = append(cfg.ExitCodes, cli.ExitCodeIs(ErrLeaseLost, 75))
+func ExitCodeIs(target error, code int) ExitCodeMapping {
+	return ExitCodeMapping{
+		Match: func(err error) bool { return errors.Is(err, target) },
+		Code:  code,
+	}
+}
+
+// ExitCodeAs maps errors that have an error of type E in their chain
+// according to [errors.As] to code:
+//
+//	cfg.ExitCodes = append(cfg.ExitCodes, cli.ExitCodeAs[*MigrationError](4))
+func ExitCodeAs[E error](code int) ExitCodeMapping {
+	return ExitCodeMapping{
+		Match: func(err error) bool {
+			var target E
+			return errors.As(err, &target)
+		},
+		Code: code,
+	}
+}
+
+// exitError classifies err and returns it as an [ExitError]. An [ExitError]
+// in the chain takes precedence over the mappings, which take precedence
+// over the classification of [ExitCode]. It returns nil if err is nil.
+func exitError(err error, mappings ...ExitCodeMapping) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr
+	}
+
+	for _, m := range mappings {
+		if m.Match(err) {
+			return &ExitError{Code: m.Code, Err: err}
+		}
+	}
+
+	return &ExitError{Code: ExitCode(err), Err: err}
+}
+
+// Main runs the command with the process arguments and exits the process.
+// On failure, it prints a single line with the error message to stderr and
+// exits with the code determined by [ExitCode].
+func (r *RootCommand) Main() {
+	err := r.Run()
+	if err == nil {
+		os.Exit(ExitOK)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: %s\n", r.cmd.Name, err)
+	os.Exit(ExitCode(err))
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/db"
+	"github.com/probe-lab/go-commons/errs"
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain", errors.New("boom"), ExitFailure},
+		{"invalid", fmt.Errorf("validate: %w", errs.Newf(errs.InvalidInput, "port must be positive")), ExitInvalid},
+		{"dependency", fmt.Errorf("before: %w", ErrDependencyUnavailable), ExitUnavailable},
+		{"unavailable", errs.Wrap(errs.Unavailable, errors.New("down")), ExitUnavailable},
+		{"canceled", fmt.Errorf("run: %w", context.Canceled), ExitInterrupted},
+		{"explicit", fmt.Errorf("run: %w", NewExitError(42, "custom", nil)), 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestExitError(t *testing.T) {
+	inner := errors.New("inner")
+
+	assert.Equal(t, "concise", NewExitError(ExitFailure, "concise", inner).Error())
+	assert.Equal(t, "inner", NewExitError(ExitFailure, "", inner).Error())
+	assert.ErrorIs(t, NewExitError(ExitFailure, "concise", inner), inner)
+	assert.Equal(t, 42, NewExitError(42, "", nil).ExitCode())
+}
+
+func TestRootCommand_exitCodes(t *testing.T) {
+	tele.DisableForTest(t)
+
+	run := func(t *testing.T, action cli.ActionFunc, args ...string) error {
+		t.Helper()
+		root, _ := NewRootCommand(&cli.Command{Name: "test", Action: action})
+		return root.RunWithContextAndArgs(context.Background(), append([]string{"test"}, args...))
+	}
+
+	noop := func(context.Context, *cli.Command) error { return nil }
+
+	assert.NoError(t, run(t, noop))
+	assert.Equal(t, ExitInvalid, ExitCode(run(t, noop, "--log.level=bogus")))
+	assert.Equal(t, ExitInvalid, ExitCode(run(t, noop, "--unknown-flag")))
+
+	err := run(t, func(context.Context, *cli.Command) error { return errors.New("boom") })
+	var exitErr *ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, ExitFailure, exitErr.ExitCode())
+}
+
+func TestRootCommand_validatesRegisteredConfigs(t *testing.T) {
+	tele.DisableForTest(t)
+
+	root, cfg := NewRootCommand(&cli.Command{Name: "test", Action: func(context.Context, *cli.Command) error { return nil }})
+
+	invalidCH := validCfgFn()
+	invalidCH.Database = ""
+	cfg.Register("clickhouse", invalidCH)
+	cfg.Register("postgres", &db.PostgresConfig{BaseConfig: &db.PostgresBaseConfig{}, Database: "db"})
+	cfg.Register("not a validator", "value")
+
+	err := root.RunWithContextAndArgs(context.Background(), []string{"test"})
+	assert.Equal(t, ExitInvalid, ExitCode(err))
+	assert.ErrorContains(t, err, "clickhouse: database must not be empty")
+	assert.ErrorContains(t, err, "postgres: host must not be empty")
+}
+
+type testMigrationError struct{ version int }
+
+func (e *testMigrationError) Error() string { return fmt.Sprintf("migration %d failed", e.version) }
+
+func TestRootCommand_customExitCodes(t *testing.T) {
+	tele.DisableForTest(t)
+
+	errLeaseLost := errors.New("lease lost")
+
+	run := func(t *testing.T, err error) error {
+		t.Helper()
+		root, cfg := NewRootCommand(&cli.Command{Name: "test", Action: func(context.Context, *cli.Command) error { return err }})
+		cfg.ExitCodes = []ExitCodeMapping{
+			ExitCodeIs(errLeaseLost, 75),
+			ExitCodeAs[*testMigrationError](4),
+		}
+		return root.RunWithContextAndArgs(context.Background(), []string{"test"})
+	}
+
+	assert.Equal(t, 75, ExitCode(run(t, fmt.Errorf("run: %w", errLeaseLost))))
+	assert.Equal(t, 4, ExitCode(run(t, fmt.Errorf("migrate: %w", &testMigrationError{version: 3}))))
+	assert.Equal(t, 42, ExitCode(run(t, NewExitError(42, "", errLeaseLost))), "explicit exit errors take precedence")
+	assert.Equal(t, ExitUnavailable, ExitCode(run(t, errs.Wrap(errs.Unavailable, errors.New("down")))))
+}
+
+func TestRootCommand_signals(t *testing.T) {
+	tele.DisableForTest(t)
+
+	reloaded := make(chan struct{}, 1)
+	root, cfg := NewRootCommand(&cli.Command{
+		Name: "test",
+		Action: func(ctx context.Context, c *cli.Command) error {
+			require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+			select {
+			case <-reloaded:
+			case <-time.After(5 * time.Second):
+				t.Error("reload signal was not handled")
+			}
+
+			require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	cfg.ShutdownSignals = []os.Signal{syscall.SIGUSR1}
+	cfg.ReloadSignals = []os.Signal{syscall.SIGUSR2}
+	cfg.Reload.Register("test", func(context.Context) (string, error) {
+		reloaded <- struct{}{}
+		return "", nil
+	})
+
+	err := root.RunWithContextAndArgs(context.Background(), []string{"test"})
+	assert.Equal(t, ExitInterrupted, ExitCode(err))
+}
+
+func TestRootCommand_tracingFlags(t *testing.T) {
+	tele.DisableForTest(t)
+
+	root, cfg := NewRootCommand(&cli.Command{Name: "test", Action: func(context.Context, *cli.Command) error { return nil }})
+	err := root.RunWithContextAndArgs(context.Background(), []string{
+		"test",
+		"--tracing.sample.ratio", "0.1",
+		"--tracing.endpoint", "collector:4317",
+		"--tracing.headers", "authorization=Bearer s3cr3t,x-tenant=probelab",
+		"--tracing.insecure",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0.1, cfg.Trace.SampleRatio)
+	assert.Equal(t, "collector:4317", cfg.Trace.Endpoint)
+	assert.Equal(t, map[string]string{"authorization": "Bearer s3cr3t", "x-tenant": "probelab"}, cfg.Trace.Headers)
+	assert.True(t, cfg.Trace.Insecure)
+
+	root, _ = NewRootCommand(&cli.Command{Name: "test", Action: func(context.Context, *cli.Command) error { return nil }})
+	err = root.RunWithContextAndArgs(context.Background(), []string{"test", "--tracing.sample.ratio", "2"})
+	assert.Equal(t, ExitInvalid, ExitCode(err))
+}
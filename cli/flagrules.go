@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// FlagRule is a relationship between flags, e.g., that two flags must not
+// be set together, that the root command checks in its Before hook. Register
+// rules with [RootCommandConfig.CheckFlags]. A flag counts as set if it was
+// given on the command line, in the environment, or in the env file.
+type FlagRule struct {
+	names []string
+	check func(set map[string]bool) error
+}
+
+// MutuallyExclusive returns a [FlagRule] that allows at most one of the
+// given flags to be set, e.g., alternative inputs:
+//
+//	cfg.CheckFlags(cli.MutuallyExclusive("input.file", "input.url"))
+func MutuallyExclusive(names ...string) FlagRule {
+	return FlagRule{
+		names: names,
+		check: func(set map[string]bool) error {
+			given := filterFlags(names, set, true)
+			if len(given) > 1 {
+				return fmt.Errorf("flags %s cannot be set together", joinFlags(given))
+			}
+			return nil
+		},
+	}
+}
+
+// RequiredTogether returns a [FlagRule] that requires either all or none of
+// the given flags to be set, e.g., a client certificate and its key:
+//
+//	cfg.CheckFlags(cli.RequiredTogether("clickhouse.ssl.cert", "clickhouse.ssl.key"))
+func RequiredTogether(names ...string) FlagRule {
+	return FlagRule{
+		names: names,
+		check: func(set map[string]bool) error {
+			given := filterFlags(names, set, true)
+			if len(given) == 0 || len(given) == len(names) {
+				return nil
+			}
+			return fmt.Errorf("flags %s must be set together, missing %s", joinFlags(names), joinFlags(filterFlags(names, set, false)))
+		},
+	}
+}
+
+// Requires returns a [FlagRule] that requires the flags required to be set
+// if the flag name is set, e.g., that TLS is enabled if a CA is given:
+//
+//	cfg.CheckFlags(cli.Requires("clickhouse.ssl.ca", "clickhouse.ssl"))
+func Requires(name string, required ...string) FlagRule {
+	return FlagRule{
+		names: append([]string{name}, required...),
+		check: func(set map[string]bool) error {
+			if !set[name] {
+				return nil
+			}
+
+			if missing := filterFlags(required, set, false); len(missing) > 0 {
+				return fmt.Errorf("flag --%s requires %s", name, joinFlags(missing))
+			}
+			return nil
+		},
+	}
+}
+
+// CheckFlags registers rules that the flags of the invoked commands must
+// satisfy. They are checked in the Before hook of the root command, and the
+// command fails with all violations at once. Rules that refer to flags that
+// the invoked commands don't define treat those flags as unset.
+func (cfg *RootCommandConfig) CheckFlags(rules ...FlagRule) {
+	cfg.flagRules = append(cfg.flagRules, rules...)
+}
+
+// checkFlagRules checks the rules against the flags of the commands that
+// are invoked through cmd.
+func checkFlagRules(cmd *cli.Command, rules []FlagRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	cmds := invokedCommands(cmd)
+
+	var violations []error
+	for _, rule := range rules {
+		set := map[string]bool{}
+		for _, name := range rule.names {
+			set[name] = flagIsSet(cmds, name)
+		}
+
+		if err := rule.check(set); err != nil {
+			violations = append(violations, err)
+		}
+	}
+
+	return errors.Join(violations...)
+}
+
+// flagIsSet reports whether any of cmds defines the flag name and it is set.
+func flagIsSet(cmds []*cli.Command, name string) bool {
+	for _, c := range cmds {
+		for _, f := range c.Flags {
+			if slices.Contains(f.Names(), name) && f.IsSet() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterFlags returns the names whose set state equals isSet.
+func filterFlags(names []string, set map[string]bool, isSet bool) []string {
+	var filtered []string
+	for _, name := range names {
+		if set[name] == isSet {
+			filtered = append(filtered, name)
+		}
+	}
+	return filtered
+}
+
+// joinFlags formats names as a list of command line flags.
+func joinFlags(names []string) string {
+	flags := make([]string, len(names))
+	for i, name := range names {
+		flags[i] = "--" + name
+	}
+	return strings.Join(flags, ", ")
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestRootCommandConfig_CheckFlags(t *testing.T) {
+	tele.DisableForTest(t)
+
+	run := func(t *testing.T, args ...string) error {
+		t.Helper()
+
+		root, cfg := NewRootCommand(&cli.Command{
+			Name: "test",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "input.file"},
+				&cli.StringFlag{Name: "input.url"},
+			},
+			Commands: []*cli.Command{{
+				Name: "serve",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "tls.cert"},
+					&cli.StringFlag{Name: "tls.key"},
+					&cli.StringFlag{Name: "tls.ca"},
+					&cli.BoolFlag{Name: "tls", Sources: cli.EnvVars("TEST_TLS")},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error { return nil },
+			}},
+		})
+
+		cfg.CheckFlags(
+			MutuallyExclusive("input.file", "input.url"),
+			RequiredTogether("tls.cert", "tls.key"),
+			Requires("tls.ca", "tls"),
+		)
+
+		return root.RunWithContextAndArgs(context.Background(), append([]string{"test"}, args...))
+	}
+
+	assert.NoError(t, run(t, "serve"))
+	assert.NoError(t, run(t, "--input.file", "a", "serve", "--tls.cert", "c", "--tls.key", "k"))
+	assert.NoError(t, run(t, "serve", "--tls", "--tls.ca", "ca"))
+
+	err := run(t, "--input.file", "a", "--input.url", "b", "serve")
+	assert.ErrorContains(t, err, "flags --input.file, --input.url cannot be set together")
+	assert.Equal(t, ExitInvalid, ExitCode(err))
+
+	err = run(t, "serve", "--tls.cert", "c")
+	assert.ErrorContains(t, err, "flags --tls.cert, --tls.key must be set together, missing --tls.key")
+
+	err = run(t, "serve", "--tls.ca", "ca", "--tls.key", "k")
+	assert.ErrorContains(t, err, "flag --tls.ca requires --tls")
+	assert.ErrorContains(t, err, "missing --tls.cert")
+
+	t.Setenv("TEST_TLS", "true")
+	assert.NoError(t, run(t, "serve", "--tls.ca", "ca"))
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/grpc"
+)
+
+// GRPCServerFlags generates a slice of [cli.Flag] for the address and TLS
+// settings of a [grpc.ServerConfig]. The command created by
+// [NewServeCommand] includes these flags.
+func GRPCServerFlags(envPrefix string, cfg *grpc.ServerConfig) []cli.Flag {
+	envPrefix = buildEnvPrefix(envPrefix)
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "grpc.host",
+			Usage:       "Which network interface the gRPC server should bind to",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_HOST"),
+			Value:       cfg.Host,
+			Destination: &cfg.Host,
+			Category:    flagCategoryServer,
+		},
+		&cli.IntFlag{
+			Name:        "grpc.port",
+			Usage:       "On which port the gRPC server should listen",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_PORT"),
+			Value:       cfg.Port,
+			Destination: &cfg.Port,
+			Category:    flagCategoryServer,
+		},
+		&cli.StringFlag{
+			Name:        "grpc.tls.cert",
+			Usage:       "Path to the PEM encoded certificate of the gRPC server. Enables TLS together with --grpc.tls.key",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_TLS_CERT"),
+			Value:       cfg.TLSCertFile,
+			Destination: &cfg.TLSCertFile,
+			Category:    flagCategoryServer,
+		},
+		&cli.StringFlag{
+			Name:        "grpc.tls.key",
+			Usage:       "Path to the PEM encoded private key of the gRPC server certificate",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_TLS_KEY"),
+			Value:       cfg.TLSKeyFile,
+			Destination: &cfg.TLSKeyFile,
+			Category:    flagCategoryServer,
+		},
+		&cli.StringFlag{
+			Name:        "grpc.tls.client.ca",
+			Usage:       "Path to the PEM encoded CA certificate to verify client certificates with. Requires mutual TLS unless --grpc.tls.client.auth is set",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_TLS_CLIENT_CA"),
+			Value:       cfg.TLSClientCAFile,
+			Destination: &cfg.TLSClientCAFile,
+			Category:    flagCategoryServer,
+		},
+		&cli.StringFlag{
+			Name:        "grpc.tls.client.auth",
+			Usage:       "Whether clients must present a certificate: none, optional, require. Defaults to require if --grpc.tls.client.ca is set",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_TLS_CLIENT_AUTH"),
+			Value:       string(cfg.TLSClientAuth),
+			Destination: (*string)(&cfg.TLSClientAuth),
+			Category:    flagCategoryServer,
+		},
+		&cli.DurationFlag{
+			Name:        "grpc.tls.reload.interval",
+			Usage:       "How often to check the certificate files for changes. Zero disables the checks",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_TLS_RELOAD_INTERVAL"),
+			Value:       cfg.TLSReloadInterval,
+			Destination: &cfg.TLSReloadInterval,
+			Category:    flagCategoryServer,
+		},
+		&cli.IntFlag{
+			Name:        "grpc.msg.size.max.recv",
+			Usage:       "The maximum size in bytes of messages that the gRPC server receives",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_MSG_SIZE_MAX_RECV"),
+			Value:       cfg.MaxRecvMsgSize,
+			Destination: &cfg.MaxRecvMsgSize,
+			Category:    flagCategoryServer,
+		},
+		&cli.IntFlag{
+			Name:        "grpc.msg.size.max.send",
+			Usage:       "The maximum size in bytes of messages that the gRPC server sends",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_MSG_SIZE_MAX_SEND"),
+			Value:       cfg.MaxSendMsgSize,
+			Destination: &cfg.MaxSendMsgSize,
+			Category:    flagCategoryServer,
+		},
+		&cli.DurationFlag{
+			Name:        "grpc.keepalive.time",
+			Usage:       "After how long the gRPC server pings idle connections. Keep it below the idle timeout of load balancers",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_KEEPALIVE_TIME"),
+			Value:       cfg.KeepaliveTime,
+			Destination: &cfg.KeepaliveTime,
+			Category:    flagCategoryServer,
+		},
+		&cli.DurationFlag{
+			Name:        "grpc.keepalive.timeout",
+			Usage:       "How long the gRPC server waits for a ping acknowledgement before closing the connection",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_KEEPALIVE_TIMEOUT"),
+			Value:       cfg.KeepaliveTimeout,
+			Destination: &cfg.KeepaliveTimeout,
+			Category:    flagCategoryServer,
+		},
+		&cli.DurationFlag{
+			Name:        "grpc.keepalive.min-time",
+			Usage:       "The minimum interval at which clients may ping the gRPC server",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_KEEPALIVE_MIN_TIME"),
+			Value:       cfg.KeepaliveMinTime,
+			Destination: &cfg.KeepaliveMinTime,
+			Category:    flagCategoryServer,
+		},
+		&cli.BoolFlag{
+			Name:        "grpc.keepalive.permit-without-stream",
+			Usage:       "Whether clients may ping the gRPC server while they have no active streams",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM"),
+			Value:       cfg.KeepalivePermitWithoutStream,
+			Destination: &cfg.KeepalivePermitWithoutStream,
+			Category:    flagCategoryServer,
+		},
+		&cli.FloatFlag{
+			Name:        "grpc.ratelimit.rate",
+			Usage:       "The number of requests per second that each gRPC method accepts on average. Zero disables the limit",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_RATELIMIT_RATE"),
+			Value:       cfg.RateLimit.Default.Rate,
+			Destination: &cfg.RateLimit.Default.Rate,
+			Category:    flagCategoryServer,
+		},
+		&cli.IntFlag{
+			Name:        "grpc.ratelimit.burst",
+			Usage:       "The number of requests that each gRPC method accepts in a burst above the rate limit",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_RATELIMIT_BURST"),
+			Value:       cfg.RateLimit.Default.Burst,
+			Destination: &cfg.RateLimit.Default.Burst,
+			Category:    flagCategoryServer,
+		},
+		&cli.BoolFlag{
+			Name:        "grpc.ratelimit.per-peer",
+			Usage:       "Whether the rate limits apply to each client IP address separately instead of to all clients together",
+			Sources:     cli.EnvVars(envPrefix + "GRPC_RATELIMIT_PER_PEER"),
+			Value:       cfg.RateLimit.PerPeer,
+			Destination: &cfg.RateLimit.PerPeer,
+			Category:    flagCategoryServer,
+		},
+	}
+}
+
+// GRPCClientFlags generates a slice of [cli.Flag] for a [grpc.ClientConfig].
+// The flags are named <name>.target, <name>.tls, and so on and read from the
+// env vars <PREFIX>_<NAME>_TARGET and so on, so that a command can configure
+// clients for several services:
+//
+//	apiCfg := grpc.DefaultClientConfig()
+//	cmd.Flags = append(cmd.Flags, cli.GRPCClientFlags(cfg.EnvPrefix, "api", apiCfg)...)
+func GRPCClientFlags(envPrefix string, name string, cfg *grpc.ClientConfig) []cli.Flag {
+	envPrefix = buildEnvPrefix(envPrefix) + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name)) + "_"
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        name + ".target",
+			Usage:       "The address of the " + name + " gRPC server, e.g., localhost:8080 or dns:///host:443",
+			Sources:     cli.EnvVars(envPrefix + "TARGET"),
+			Value:       cfg.Target,
+			Destination: &cfg.Target,
+			Category:    flagCategoryClient,
+		},
+		&cli.BoolFlag{
+			Name:        name + ".tls",
+			Usage:       "Whether to connect to the " + name + " gRPC server with TLS",
+			Sources:     cli.EnvVars(envPrefix + "TLS"),
+			Value:       cfg.TLS,
+			Destination: &cfg.TLS,
+			Category:    flagCategoryClient,
+		},
+		&cli.StringFlag{
+			Name:        name + ".tls.ca",
+			Usage:       "Path to the PEM encoded CA certificate to verify the " + name + " gRPC server with. Defaults to the system roots",
+			Sources:     cli.EnvVars(envPrefix + "TLS_CA"),
+			Value:       cfg.TLSCAFile,
+			Destination: &cfg.TLSCAFile,
+			Category:    flagCategoryClient,
+		},
+		&cli.StringFlag{
+			Name:        name + ".tls.cert",
+			Usage:       "Path to the PEM encoded client certificate for mutual TLS with the " + name + " gRPC server",
+			Sources:     cli.EnvVars(envPrefix + "TLS_CERT"),
+			Value:       cfg.TLSCertFile,
+			Destination: &cfg.TLSCertFile,
+			Category:    flagCategoryClient,
+		},
+		&cli.StringFlag{
+			Name:        name + ".tls.key",
+			Usage:       "Path to the PEM encoded private key of the " + name + " client certificate",
+			Sources:     cli.EnvVars(envPrefix + "TLS_KEY"),
+			Value:       cfg.TLSKeyFile,
+			Destination: &cfg.TLSKeyFile,
+			Category:    flagCategoryClient,
+		},
+		&cli.StringFlag{
+			Name:        name + ".tls.servername",
+			Usage:       "The server name to verify the certificate of the " + name + " gRPC server for. Defaults to the host of the target",
+			Sources:     cli.EnvVars(envPrefix + "TLS_SERVERNAME"),
+			Value:       cfg.TLSServerName,
+			Destination: &cfg.TLSServerName,
+			Category:    flagCategoryClient,
+		},
+		&cli.DurationFlag{
+			Name:        name + ".keepalive",
+			Usage:       "How often to ping the " + name + " gRPC server during active calls. Zero disables pings",
+			Sources:     cli.EnvVars(envPrefix + "KEEPALIVE"),
+			Value:       cfg.KeepaliveTime,
+			Destination: &cfg.KeepaliveTime,
+			Category:    flagCategoryClient,
+		},
+		&cli.IntFlag{
+			Name:        name + ".msg.size.max",
+			Usage:       "The maximum size in bytes of messages received from the " + name + " gRPC server",
+			Sources:     cli.EnvVars(envPrefix + "MSG_SIZE_MAX"),
+			Value:       cfg.MaxRecvMsgSize,
+			Destination: &cfg.MaxRecvMsgSize,
+			Category:    flagCategoryClient,
+		},
+	}
+}
@@ -2,58 +2,123 @@ package cli
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/urfave/cli/v3"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/probe-lab/go-commons/errs"
 )
 
+// NewHealthCommand returns a "health" command that checks the gRPC health
+// service at the address given as argument (localhost:8080 by default) or,
+// with --http, an HTTP health endpoint that must return a 2xx status. It is
+// meant for container health checks:
+//
+//	app health --timeout 2s --service api localhost:8080
+//	app health --http http://localhost:8080/healthz
 func NewHealthCommand() *cli.Command {
 	return &cli.Command{
-		Name:   "health",
-		Usage:  "Checks the health of the provided endpoint",
+		Name:      "health",
+		Usage:     "Checks the health of the provided endpoint",
+		ArgsUsage: "[addr]",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "The maximum time to wait for the health check",
+				Value: 5 * time.Second,
+			},
+			&cli.BoolFlag{
+				Name:  "tls",
+				Usage: "Whether to connect to the gRPC server with TLS",
+			},
+			&cli.StringFlag{
+				Name:  "service",
+				Usage: "The gRPC service to check. Empty checks the overall health of the server",
+			},
+			&cli.StringFlag{
+				Name:  "http",
+				Usage: "Checks the given HTTP health endpoint URL instead of the gRPC health service",
+			},
+		},
 		Action: healthAction,
 	}
 }
 
 func healthAction(ctx context.Context, c *cli.Command) error {
+	if timeout := c.Duration("timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if u := c.String("http"); u != "" {
+		if c.Args().Present() || c.IsSet("service") || c.IsSet("tls") {
+			return errs.Newf(errs.InvalidInput, "--http can't be combined with an address, --service, or --tls")
+		}
+		return HTTPDependency(u)(ctx)
+	}
+
 	addr := c.Args().First()
 	if addr == "" {
 		addr = "localhost:8080"
 	}
 
-	options := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	creds := insecure.NewCredentials()
+	if c.Bool("tls") {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
 	}
 
-	conn, err := grpc.NewClient(addr, options...)
-	if err != nil {
-		return fmt.Errorf("new gRPC health client %s: %v", addr, err)
-	}
-	defer func() {
-		if err := conn.Close(); err != nil {
-			slog.Warn("Failed closing gRPC health client", "addr", addr, "err", err)
+	return grpcHealthCheck(addr, c.String("service"), creds)(ctx)
+}
+
+// GRPCHealthDependency returns a [Dependency] that succeeds if the gRPC
+// health service at addr reports the given service as serving. An empty
+// service checks the overall health of the server.
+func GRPCHealthDependency(addr string, service string) Dependency {
+	return grpcHealthCheck(addr, service, insecure.NewCredentials())
+}
+
+// grpcHealthCheck returns a [Dependency] that checks the gRPC health service
+// at addr with the given transport credentials.
+func grpcHealthCheck(addr string, service string, creds credentials.TransportCredentials) Dependency {
+	return func(ctx context.Context) error {
+		options := []grpc.DialOption{
+			grpc.WithTransportCredentials(creds),
+		}
+
+		conn, err := grpc.NewClient(addr, options...)
+		if err != nil {
+			return fmt.Errorf("new gRPC health client %s: %v", addr, err)
 		}
-	}()
+		defer func() {
+			if err := conn.Close(); err != nil {
+				slog.Warn("Failed closing gRPC health client", "addr", addr, "err", err)
+			}
+		}()
 
-	client := healthgrpc.NewHealthClient(conn)
+		client := healthgrpc.NewHealthClient(conn)
 
-	resp, err := client.Check(ctx, &healthgrpc.HealthCheckRequest{})
-	if err != nil {
-		return fmt.Errorf("check health: %v", err)
-	}
+		resp, err := client.Check(ctx, &healthgrpc.HealthCheckRequest{Service: service})
+		if err != nil {
+			return fmt.Errorf("check health: %v", err)
+		}
 
-	switch resp.GetStatus() {
-	case healthgrpc.HealthCheckResponse_SERVING:
-		return nil
-	case healthgrpc.HealthCheckResponse_NOT_SERVING:
-		return fmt.Errorf("health check status is not serving")
-	case healthgrpc.HealthCheckResponse_SERVICE_UNKNOWN:
-		return fmt.Errorf("health check service unknown")
-	default:
-		return fmt.Errorf("unknown health check status")
+		switch resp.GetStatus() {
+		case healthgrpc.HealthCheckResponse_SERVING:
+			return nil
+		case healthgrpc.HealthCheckResponse_NOT_SERVING:
+			return fmt.Errorf("health check status is not serving")
+		case healthgrpc.HealthCheckResponse_SERVICE_UNKNOWN:
+			return fmt.Errorf("health check service unknown")
+		default:
+			return fmt.Errorf("unknown health check status")
+		}
 	}
 }
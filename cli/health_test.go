@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/probe-lab/go-commons/errs"
+)
+
+func TestNewHealthCommand(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus("api", healthgrpc.HealthCheckResponse_SERVING)
+	healthSrv.SetServingStatus("worker", healthgrpc.HealthCheckResponse_NOT_SERVING)
+
+	srv := grpc.NewServer()
+	healthgrpc.RegisterHealthServer(srv, healthSrv)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(httpSrv.Close)
+
+	run := func(args ...string) error {
+		return NewHealthCommand().Run(context.Background(), append([]string{"health"}, args...))
+	}
+
+	addr := ln.Addr().String()
+	assert.NoError(t, run(addr))
+	assert.NoError(t, run("--service", "api", addr))
+	assert.ErrorContains(t, run("--service", "worker", addr), "not serving")
+	assert.Error(t, run("--tls", "--timeout", "500ms", addr), "plaintext server must fail the TLS handshake")
+
+	assert.NoError(t, run("--http", httpSrv.URL+"/healthz"))
+	assert.ErrorContains(t, run("--http", httpSrv.URL+"/ready"), "unexpected status")
+	assert.ErrorIs(t, run("--http", httpSrv.URL+"/healthz", addr), errs.InvalidInput)
+}
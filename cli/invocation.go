@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/probe-lab/go-commons/cli"
+
+// invocation records the duration and outcome of the command that the root
+// command runs as the command.duration histogram and as a span that all
+// spans of the command's action are children of. This way, batch commands
+// report their runtime without any instrumentation of their own.
+type invocation struct {
+	command string
+	start   time.Time
+	span    trace.Span
+
+	// err is the error of the Before hook or the action, if any.
+	err error
+}
+
+// startInvocation starts recording the invocation of the innermost command
+// of c's chain and wraps that command's action to capture its error. It
+// must be called after the meter and tracer providers are initialized.
+func startInvocation(ctx context.Context, c *cli.Command) (context.Context, *invocation) {
+	cmds := invokedCommands(c)
+
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name
+	}
+
+	inv := &invocation{
+		command: strings.Join(names, " "),
+		start:   time.Now(),
+	}
+
+	ctx, inv.span = otel.GetTracerProvider().Tracer(instrumentationName).Start(ctx, inv.command,
+		trace.WithAttributes(attribute.String("command", inv.command)),
+	)
+
+	if leaf := cmds[len(cmds)-1]; leaf.Action != nil {
+		action := leaf.Action
+		leaf.Action = func(ctx context.Context, c *cli.Command) error {
+			inv.err = action(ctx, c)
+			return inv.err
+		}
+	}
+
+	return ctx, inv
+}
+
+// end records the duration and outcome of the invocation. The exit code is
+// derived from the recorded error with the given mappings.
+func (inv *invocation) end(ctx context.Context, mappings ...ExitCodeMapping) {
+	if inv == nil {
+		return
+	}
+
+	status := "success"
+	if inv.err != nil {
+		status = "failure"
+		inv.span.RecordError(inv.err)
+		inv.span.SetStatus(codes.Error, inv.err.Error())
+	}
+
+	exitCode := ExitCode(exitError(inv.err, mappings...))
+	inv.span.SetAttributes(attribute.Int("exit_code", exitCode))
+	inv.span.End()
+
+	duration, err := otel.GetMeterProvider().Meter(instrumentationName).Float64Histogram("command.duration",
+		metric.WithDescription("Duration of command invocations by command and outcome"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		slog.Warn("Failed to create metric instrument", "name", "command.duration", "err", err)
+		return
+	}
+
+	duration.Record(ctx, time.Since(inv.start).Seconds(), metric.WithAttributes(
+		attribute.String("command", inv.command),
+		attribute.String("status", status),
+		attribute.Int("exit_code", exitCode),
+	))
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestRootCommand_recordsInvocation(t *testing.T) {
+	tele.DisableForTest(t)
+
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+
+	errJob := errors.New("job failed")
+
+	run := func(args ...string) error {
+		root, _ := NewRootCommand(&cli.Command{
+			Name: "test",
+			Commands: []*cli.Command{{
+				Name: "job",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "fail"},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					assert.True(t, trace.SpanFromContext(ctx).SpanContext().IsValid(), "action must run within the command span")
+					if c.Bool("fail") {
+						return errJob
+					}
+					return nil
+				},
+			}},
+		})
+		return root.RunWithContextAndArgs(context.Background(), append([]string{"test"}, args...))
+	}
+
+	require.NoError(t, run("job"))
+	require.ErrorIs(t, run("job", "--fail"), errJob)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	var counts = map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "command.duration" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+				command, _ := dp.Attributes.Value(attribute.Key("command"))
+				status, _ := dp.Attributes.Value(attribute.Key("status"))
+				exitCode, _ := dp.Attributes.Value(attribute.Key("exit_code"))
+				counts[command.AsString()+"/"+status.AsString()+"/"+exitCode.Emit()] += dp.Count
+			}
+		}
+	}
+	assert.Equal(t, map[string]uint64{"test job/success/0": 1, "test job/failure/1": 1}, counts)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	assert.Equal(t, "test job", ended[0].Name())
+	assert.Equal(t, codes.Unset, ended[0].Status().Code)
+	assert.Equal(t, codes.Error, ended[1].Status().Code)
+	assert.Equal(t, "job failed", ended[1].Status().Description)
+}
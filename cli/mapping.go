@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/db"
+)
+
+// MappingConfig holds the values of the flags that are registered by
+// [MappingFlags] and the [db.Mapping] that is built from them.
+type MappingConfig struct {
+	// Projects, Networks, and Items are parallel lists that assign an item,
+	// e.g., a database name, to each project/network combination.
+	Projects []string
+	Networks []string
+	Items    []string
+
+	// Strict fails on duplicate project/network combinations instead of
+	// logging a warning and letting the last one win.
+	Strict bool
+
+	// Mapping is built by [MappingConfig.Before] from the other fields.
+	Mapping db.Mapping[string]
+}
+
+// MappingFlags generates a slice of [cli.Flag] for the three parallel lists
+// of a [db.Mapping] and its duplicate handling. The flags are named
+// <name>.projects, <name>.networks, <name>.items, and <name>.strict and read
+// from the env vars <PREFIX>_<NAME>_PROJECTS and so on. Set
+// [MappingConfig.Before] as the Before hook of the command, or call it from
+// there, to build and validate the mapping:
+//
+//	cfg := &cli.MappingConfig{}
+//	cmd := &cli.Command{
+//		Flags:  cli.MappingFlags("NEBULA", "databases", cfg),
+//		Before: cfg.Before,
+//	}
+func MappingFlags(envPrefix string, name string, cfg *MappingConfig) []cli.Flag {
+	envPrefix = buildEnvPrefix(envPrefix) + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name)) + "_"
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:        name + ".projects",
+			Usage:       "The project of each " + name + " item. Separate multiple projects with commas.",
+			Sources:     cli.EnvVars(envPrefix + "PROJECTS"),
+			Value:       cfg.Projects,
+			Destination: &cfg.Projects,
+			Category:    flagCategoryTenant,
+		},
+		&cli.StringSliceFlag{
+			Name:        name + ".networks",
+			Usage:       "The network of each " + name + " item. Separate multiple networks with commas.",
+			Sources:     cli.EnvVars(envPrefix + "NETWORKS"),
+			Value:       cfg.Networks,
+			Destination: &cfg.Networks,
+			Category:    flagCategoryTenant,
+		},
+		&cli.StringSliceFlag{
+			Name:        name + ".items",
+			Usage:       "The " + name + " items of the project/network combinations. Separate multiple items with commas.",
+			Sources:     cli.EnvVars(envPrefix + "ITEMS"),
+			Value:       cfg.Items,
+			Destination: &cfg.Items,
+			Category:    flagCategoryTenant,
+		},
+		&cli.BoolFlag{
+			Name:        name + ".strict",
+			Usage:       "Fail on duplicate project/network combinations instead of letting the last one win",
+			Sources:     cli.EnvVars(envPrefix + "STRICT"),
+			Value:       cfg.Strict,
+			Destination: &cfg.Strict,
+			Category:    flagCategoryTenant,
+		},
+	}
+}
+
+// Before builds [MappingConfig.Mapping] from the flag values. It fails if
+// the lists have different lengths or, if Strict is set, contain duplicate
+// project/network combinations. It has the signature of [cli.BeforeFunc].
+func (cfg *MappingConfig) Before(ctx context.Context, _ *cli.Command) (context.Context, error) {
+	newMapping := db.NewMapping[string]
+	if cfg.Strict {
+		newMapping = db.NewStrictMapping[string]
+	}
+
+	mapping, err := newMapping(cfg.Projects, cfg.Networks, cfg.Items)
+	if err != nil {
+		return ctx, err
+	}
+	cfg.Mapping = mapping
+
+	return ctx, nil
+}
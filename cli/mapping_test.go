@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func runMappingCommand(t *testing.T, cfg *MappingConfig, args ...string) error {
+	t.Helper()
+
+	cmd := &cli.Command{
+		Flags:  MappingFlags("TEST", "databases", cfg),
+		Before: cfg.Before,
+		Action: func(context.Context, *cli.Command) error { return nil },
+	}
+
+	return cmd.Run(context.Background(), append([]string{"test"}, args...))
+}
+
+func TestMappingFlags(t *testing.T) {
+	t.Setenv("TEST_DATABASES_PROJECTS", "ipfs,ipfs")
+	t.Setenv("TEST_DATABASES_NETWORKS", "amino,celestia")
+
+	cfg := &MappingConfig{}
+	require.NoError(t, runMappingCommand(t, cfg, "--databases.items", "ipfs_amino,celestia"))
+
+	item, found := cfg.Mapping.Get("ipfs", "celestia")
+	assert.True(t, found)
+	assert.Equal(t, "celestia", item)
+}
+
+func TestMappingFlags_Errors(t *testing.T) {
+	err := runMappingCommand(t, &MappingConfig{},
+		"--databases.projects", "ipfs,ipfs",
+		"--databases.networks", "amino",
+		"--databases.items", "a,b",
+	)
+	assert.ErrorContains(t, err, "must have the same length")
+
+	args := []string{
+		"--databases.projects", "ipfs,IPFS",
+		"--databases.networks", "amino,Amino",
+		"--databases.items", "a,b",
+	}
+
+	cfg := &MappingConfig{}
+	require.NoError(t, runMappingCommand(t, cfg, args...))
+	item, _ := cfg.Mapping.Get("ipfs", "amino")
+	assert.Equal(t, "b", item)
+
+	err = runMappingCommand(t, &MappingConfig{}, append(args, "--databases.strict")...)
+	assert.ErrorContains(t, err, "duplicate project/network combinations: ipfs/amino")
+}
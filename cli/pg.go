@@ -20,6 +20,22 @@ func PostgresFlags(envPrefix string, cfg *db.PostgresConfig) []cli.Flag {
 	)
 }
 
+// PostgresReplicaFlags extends [PostgresFlags] for the primary with a flag
+// for the address of the read replica endpoint, see
+// [db.PostgresReplicaConfig].
+func PostgresReplicaFlags(envPrefix string, cfg *db.PostgresReplicaConfig) []cli.Flag {
+	return append(PostgresFlags(envPrefix, cfg.Primary),
+		&cli.StringFlag{
+			Name:        "postgres.replica",
+			Usage:       "The host:port address of the Postgres read replica endpoint. Reads use the primary if empty.",
+			Sources:     cli.EnvVars(buildEnvPrefix(envPrefix) + "POSTGRES_REPLICA"),
+			Value:       cfg.Replica,
+			Destination: &cfg.Replica,
+			Category:    flagCategoryDatabase,
+		},
+	)
+}
+
 func PostgresMultiFlags(envPrefix string, cfg *db.PostgresMultiConfig) []cli.Flag {
 	envPrefix = buildEnvPrefix(envPrefix)
 	return append(postgresBaseFlags(envPrefix, cfg.BaseConfig),
@@ -63,7 +79,7 @@ func postgresBaseFlags(envPrefix string, cfg *db.PostgresBaseConfig) []cli.Flag
 		&cli.StringFlag{
 			Name:        "postgres.password",
 			Usage:       "The password for the Postgres user",
-			Sources:     cli.EnvVars(envPrefix + "POSTGRES_PASSWORD"),
+			Sources:     SecretEnvVars(envPrefix + "POSTGRES_PASSWORD"),
 			Value:       cfg.Pass,
 			Destination: &cfg.Pass,
 			Category:    flagCategoryDatabase,
@@ -76,5 +92,109 @@ func postgresBaseFlags(envPrefix string, cfg *db.PostgresBaseConfig) []cli.Flag
 			Destination: &cfg.SSLMode,
 			Category:    flagCategoryDatabase,
 		},
+		&cli.StringFlag{
+			Name:        "postgres.sslrootcert",
+			Usage:       "Path to the CA certificate that verifies the Postgres server certificate",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_SSLROOTCERT"),
+			Value:       cfg.SSLRootCert,
+			Destination: &cfg.SSLRootCert,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.StringFlag{
+			Name:        "postgres.sslcert",
+			Usage:       "Path to the client certificate for Postgres mutual TLS",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_SSLCERT"),
+			Value:       cfg.SSLCert,
+			Destination: &cfg.SSLCert,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.StringFlag{
+			Name:        "postgres.sslkey",
+			Usage:       "Path to the private key of the client certificate for Postgres mutual TLS",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_SSLKEY"),
+			Value:       cfg.SSLKey,
+			Destination: &cfg.SSLKey,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.StringFlag{
+			Name:        "postgres.applicationName",
+			Usage:       "The application name that identifies the connections in pg_stat_activity",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_APPLICATION_NAME"),
+			Value:       cfg.ApplicationName,
+			Destination: &cfg.ApplicationName,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.DurationFlag{
+			Name:        "postgres.statementTimeout",
+			Usage:       "Abort Postgres statements that run longer than this (0 keeps the server default)",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_STATEMENT_TIMEOUT"),
+			Value:       cfg.StatementTimeout,
+			Destination: &cfg.StatementTimeout,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.StringFlag{
+			Name:        "postgres.searchPath",
+			Usage:       "Comma-separated list of Postgres schemas to resolve unqualified names in",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_SEARCH_PATH"),
+			Value:       cfg.SearchPath,
+			Destination: &cfg.SearchPath,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.StringFlag{
+			Name:        "postgres.trace.statements",
+			Usage:       "How SQL statements are recorded on trace spans (full, redacted, none)",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_TRACE_STATEMENTS"),
+			Value:       cfg.TraceStatements,
+			Destination: &cfg.TraceStatements,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.IntFlag{
+			Name:        "postgres.pool.maxOpenConns",
+			Usage:       "The maximum number of open connections to Postgres (0 means unlimited)",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_POOL_MAX_OPEN_CONNS"),
+			Value:       cfg.MaxOpenConns,
+			Destination: &cfg.MaxOpenConns,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.IntFlag{
+			Name:        "postgres.pool.maxIdleConns",
+			Usage:       "The maximum number of idle connections to Postgres (0 keeps the driver default)",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_POOL_MAX_IDLE_CONNS"),
+			Value:       cfg.MaxIdleConns,
+			Destination: &cfg.MaxIdleConns,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.DurationFlag{
+			Name:        "postgres.pool.connMaxLifetime",
+			Usage:       "The maximum time a Postgres connection is reused (0 means forever)",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_POOL_CONN_MAX_LIFETIME"),
+			Value:       cfg.ConnMaxLifetime,
+			Destination: &cfg.ConnMaxLifetime,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.DurationFlag{
+			Name:        "postgres.pool.connMaxIdleTime",
+			Usage:       "The maximum time a Postgres connection may be idle (0 means forever)",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_POOL_CONN_MAX_IDLE_TIME"),
+			Value:       cfg.ConnMaxIdleTime,
+			Destination: &cfg.ConnMaxIdleTime,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.IntFlag{
+			Name:        "postgres.ping.retries",
+			Usage:       "How often the initial Postgres ping is retried while the database is unreachable",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_PING_RETRIES"),
+			Value:       cfg.PingRetries,
+			Destination: &cfg.PingRetries,
+			Category:    flagCategoryDatabase,
+		},
+		&cli.DurationFlag{
+			Name:        "postgres.ping.backoff",
+			Usage:       "The delay before the first Postgres ping retry. It doubles with every further retry.",
+			Sources:     cli.EnvVars(envPrefix + "POSTGRES_PING_BACKOFF"),
+			Value:       cfg.PingBackoff,
+			Destination: &cfg.PingBackoff,
+			Category:    flagCategoryDatabase,
+		},
 	}
 }
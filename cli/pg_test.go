@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/db"
+)
+
+func TestPostgresFlags_PoolAndTLS(t *testing.T) {
+	t.Setenv("TEST_POSTGRES_POOL_MAX_OPEN_CONNS", "20")
+	t.Setenv("TEST_POSTGRES_POOL_CONN_MAX_LIFETIME", "30m")
+	t.Setenv("TEST_POSTGRES_SSLROOTCERT", "/etc/certs/ca.pem")
+
+	cfg := &db.PostgresConfig{BaseConfig: &db.PostgresBaseConfig{}}
+	cmd := &cli.Command{
+		Flags:  PostgresFlags("TEST", cfg),
+		Action: func(context.Context, *cli.Command) error { return nil },
+	}
+
+	err := cmd.Run(context.Background(), []string{"test",
+		"--postgres.pool.maxIdleConns", "5",
+		"--postgres.sslcert", "/etc/certs/client.crt",
+		"--postgres.sslkey", "/etc/certs/client.key",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 20, cfg.BaseConfig.MaxOpenConns)
+	assert.Equal(t, 5, cfg.BaseConfig.MaxIdleConns)
+	assert.Equal(t, 30*time.Minute, cfg.BaseConfig.ConnMaxLifetime)
+	assert.Equal(t, "/etc/certs/ca.pem", cfg.BaseConfig.SSLRootCert)
+	assert.Equal(t, "/etc/certs/client.crt", cfg.BaseConfig.SSLCert)
+	assert.Equal(t, "/etc/certs/client.key", cfg.BaseConfig.SSLKey)
+}
+
+func TestPostgresMultiFlags(t *testing.T) {
+	cfg := &db.PostgresMultiConfig{BaseConfig: &db.PostgresBaseConfig{}}
+	flags := PostgresMultiFlags("TEST", cfg)
+	assert.Len(t, flags, len(PostgresFlags("TEST", &db.PostgresConfig{BaseConfig: cfg.BaseConfig})))
+}
@@ -2,26 +2,42 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
 	"runtime/debug"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/urfave/cli/v3"
 
+	"github.com/probe-lab/go-commons/config"
+	"github.com/probe-lab/go-commons/errs"
+	phttp "github.com/probe-lab/go-commons/http"
 	"github.com/probe-lab/go-commons/log"
+	"github.com/probe-lab/go-commons/maintenance"
+	"github.com/probe-lab/go-commons/reload"
 	"github.com/probe-lab/go-commons/tele"
+	"github.com/probe-lab/go-commons/warmup"
 )
 
 const (
+	flagCategoryAdmin     = "Admin Configuration:"
+	flagCategoryAWS       = "AWS Configuration:"
+	flagCategoryClient    = "Client Configuration:"
 	flagCategoryDatabase  = "Database Configuration:"
 	flagCategoryLogging   = "Logging Configuration:"
+	flagCategoryRuntime   = "Runtime Configuration:"
+	flagCategoryServer    = "Server Configuration:"
 	flagCategoryTelemetry = "Telemetry Configuration:"
+	flagCategoryTenant    = "Tenant Configuration:"
 )
 
 type RootCommand struct {
@@ -36,13 +52,194 @@ type RootCommandConfig struct {
 	Trace         *tele.TraceConfig
 	ShutdownGrace time.Duration
 	EnvPrefix     string
-	AWSRegion     string
+
+	// AWS configures the clients of the AWS SDK, see [AWSConfig.Load].
+	AWS *AWSConfig
+
+	// Runtime controls how GOMAXPROCS and GOMEMLIMIT are derived from the
+	// container limits, see [RuntimeConfig.Apply].
+	Runtime *RuntimeConfig
+
+	// KnownEnvVars are prefixed environment variables that the application
+	// reads on its own. The Before hook warns about all other prefixed
+	// variables that don't belong to a flag, as they are likely typos.
+	KnownEnvVars []string
+
+	// EnvFile is the path to a dotenv file that is loaded in the Before
+	// hook. Its variables don't override the environment, and flags set on
+	// the command line take precedence over both. Empty disables loading.
+	EnvFile string
+
+	// Reload holds the components that are reloaded when the process
+	// receives one of the ReloadSignals or when the admin reload endpoint is
+	// called.
+	Reload *reload.Registry
+
+	// ShutdownSignals cancel the context of the command and trigger the
+	// graceful shutdown. They default to SIGINT and SIGTERM.
+	ShutdownSignals []os.Signal
+
+	// ReloadSignals reload the components registered with Reload. They
+	// default to SIGHUP. A signal must not be both a shutdown and a reload
+	// signal. Empty disables reloading on signals.
+	ReloadSignals []os.Signal
+
+	// ExitCodes map errors returned by the command to process exit codes.
+	// They are checked in order before the default classification of
+	// [ExitCode], so orchestrators can tell application-specific failures
+	// apart.
+	ExitCodes []ExitCodeMapping
+
+	// AdminKeys are the API keys that grant access to the admin endpoints
+	// served alongside the metrics endpoint. Admin endpoints are disabled
+	// if no keys are configured.
+	AdminKeys []string
+
+	// Maintenance holds the maintenance mode state. Pass it to the HTTP
+	// middleware and gRPC server configs so that they reject requests while
+	// maintenance mode is enabled.
+	Maintenance *maintenance.Mode
+
+	// MaintenanceEnabled starts the application in maintenance mode.
+	MaintenanceEnabled bool
+
+	// MaintenanceFile is the path to a sentinel file that enables
+	// maintenance mode for as long as it exists.
+	MaintenanceFile string
+
+	// DryRun requests that commands don't modify any data and only report
+	// what they would do. Commands check it with [IsDryRun].
+	DryRun bool
+
+	// CheckConfig makes the root command exit after parsing the flags and
+	// validating the configuration without running the command's action,
+	// e.g., in CI or before a deployment.
+	CheckConfig bool
+
+	// CheckDependencies additionally checks once that all dependencies
+	// registered with [RootCommandConfig.DependsOn] are reachable if
+	// CheckConfig is set.
+	CheckDependencies bool
+
+	// StartupWait is how long the root command waits for all dependencies
+	// registered with [RootCommandConfig.DependsOn] and, if tracing is
+	// enabled, the OTLP endpoint to become reachable. If they don't, the
+	// command fails with [ErrDependencyUnavailable]. Zero disables the
+	// check.
+	StartupWait time.Duration
+
+	// Warmup holds the warm-up functions of caches, mappings, and similar
+	// components. Pass it to the gRPC server config so that the server only
+	// reports SERVING once all components are warm.
+	Warmup *warmup.Registry
 
 	metricsShutdown func(ctx context.Context) error
 	tracesShutdown  func(ctx context.Context) error
+	reloadStop      func()
+
+	// invocation records the duration and outcome of the invoked command.
+	invocation *invocation
+
+	// secrets resolves flag values that reference secrets in AWS.
+	secrets *awsSecretResolver
+
+	// components are the sub-configs included in the startup summary.
+	components []component
+
+	// dependencies are checked on startup if StartupWait is positive.
+	dependencies []dependency
+
+	// flagRules are checked in the Before hook, see CheckFlags.
+	flagRules []FlagRule
+
+	// logLevelPinned is true if the log level was set on the command line or
+	// in the environment. Both take precedence over the env file and don't
+	// change at runtime.
+	logLevelPinned bool
+}
+
+// reloadLogLevel re-reads the log level from the env file and applies it. If
+// the file no longer sets the level, the level falls back to defaultLevel. It
+// does nothing if there is no env file or the level is pinned.
+func (cfg *RootCommandConfig) reloadLogLevel(defaultLevel string) (string, error) {
+	if cfg.logLevelPinned || cfg.EnvFile == "" {
+		return "", nil
+	}
+
+	vars, err := readEnvFile(cfg.EnvFile)
+	if err != nil {
+		return "", err
+	}
+
+	lvl := defaultLevel
+	for _, kv := range vars {
+		if kv[0] == cfg.EnvPrefix+"LOG_LEVEL" {
+			lvl = kv[1]
+		}
+	}
+
+	if strings.EqualFold(lvl, cfg.Log.Level) {
+		return "", nil
+	}
+
+	prev, err := log.SetLevel(lvl)
+	if err != nil {
+		return "", err
+	}
+	cfg.Log.Level = lvl
+
+	return fmt.Sprintf("%s -> %s", prev, lvl), nil
+}
+
+// component is a named sub-config registered with [RootCommandConfig.Register].
+type component struct {
+	name string
+	cfg  any
+}
+
+// Register adds a named sub-config, e.g., a database or gRPC server config,
+// to the startup summary that is logged at the end of the root command's
+// Before hook. Configs should implement [slog.LogValuer] and redact their
+// credentials, as all configs in this module do. Configs that implement
+// [config.Validator] are validated in the Before hook, and the command fails
+// with all validation errors at once.
+func (cfg *RootCommandConfig) Register(name string, c any) {
+	cfg.components = append(cfg.components, component{name: name, cfg: c})
+}
+
+// validateComponents validates all registered sub-configs that implement
+// [config.Validator].
+func (cfg *RootCommandConfig) validateComponents() error {
+	var validators []config.Validator
+	for _, c := range cfg.components {
+		if v, ok := c.cfg.(config.Validator); ok {
+			validators = append(validators, config.Named(c.name, v))
+		}
+	}
+
+	return config.ValidateAll(validators...)
 }
 
-func NewRootCommand(cmd *cli.Command) (*RootCommand, *RootCommandConfig) {
+// RootCommandOption customizes the [RootCommandConfig] in [NewRootCommand]
+// before the root flags are created.
+type RootCommandOption func(cfg *RootCommandConfig)
+
+// WithEnvPrefix overrides the prefix of the environment variables of the
+// root flags, which is derived from the command name by default. Use it for
+// binaries whose name doesn't make a good prefix or to keep the environment
+// variables of a renamed binary. An empty prefix disables prefixing. Pass
+// [RootCommandConfig.EnvPrefix] to the flag helpers, e.g., [ClickHouseFlags],
+// so that all flags share the prefix:
+//
+//	root, cfg := cli.NewRootCommand(cmd, cli.WithEnvPrefix("NEBULA"))
+//	cmd.Flags = append(cmd.Flags, cli.ClickHouseFlags(cfg.EnvPrefix, chCfg)...)
+func WithEnvPrefix(prefix string) RootCommandOption {
+	return func(cfg *RootCommandConfig) {
+		cfg.EnvPrefix = buildEnvPrefix(prefix)
+	}
+}
+
+func NewRootCommand(cmd *cli.Command, opts ...RootCommandOption) (*RootCommand, *RootCommandConfig) {
 	cfg := &RootCommandConfig{
 		BuildInfo:     buildInfo(),
 		Log:           log.DefaultConfig(),
@@ -50,12 +247,42 @@ func NewRootCommand(cmd *cli.Command) (*RootCommand, *RootCommandConfig) {
 		Trace:         tele.DefaultTraceConfig(),
 		ShutdownGrace: 30 * time.Second,
 		EnvPrefix:     buildEnvPrefix(cmd.Name),
-		AWSRegion:     "",
+		AWS:           DefaultAWSConfig(),
+		Runtime:       DefaultRuntimeConfig(),
+		KnownEnvVars:  []string{},
+		EnvFile:       "",
+		Reload:        reload.NewRegistry(),
+		AdminKeys:     []string{},
+
+		ShutdownSignals: []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		ReloadSignals:   []os.Signal{syscall.SIGHUP},
+
+		Maintenance:        maintenance.New(false),
+		MaintenanceEnabled: false,
+		MaintenanceFile:    "",
+
+		Warmup: warmup.NewRegistry(),
 
 		metricsShutdown: func(ctx context.Context) error { return nil },
 		tracesShutdown:  func(ctx context.Context) error { return nil },
+		reloadStop:      func() {},
 	}
 
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	cfg.secrets = &awsSecretResolver{
+		load: func(ctx context.Context) (aws.Config, error) { return cfg.AWS.Load(ctx) },
+	}
+
+	// the env file may change at runtime, so pick up a new log level from
+	// it on reload.
+	defaultLevel := cfg.Log.Level
+	cfg.Reload.Register("log.level", func(ctx context.Context) (string, error) {
+		return cfg.reloadLogLevel(defaultLevel)
+	})
+
 	shortCommit := cfg.BuildInfo.ShortCommit()
 	if cfg.BuildInfo.Dirty {
 		shortCommit += "+dirty"
@@ -68,6 +295,13 @@ func NewRootCommand(cmd *cli.Command) (*RootCommand, *RootCommandConfig) {
 	}
 
 	cmd.Flags = append(cmd.Flags, []cli.Flag{
+		&cli.StringFlag{
+			Name:        "env.file",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "ENV_FILE"),
+			Usage:       "Path to a .env file to load environment variables from. Already set variables are not overridden.",
+			Value:       cfg.EnvFile,
+			Destination: &cfg.EnvFile,
+		},
 		&cli.StringFlag{
 			Name:        "log.level",
 			Sources:     cli.EnvVars(cfg.EnvPrefix + "LOG_LEVEL"),
@@ -92,6 +326,46 @@ func NewRootCommand(cmd *cli.Command) (*RootCommand, *RootCommandConfig) {
 			Value:       cfg.Log.Source,
 			Category:    flagCategoryLogging,
 		},
+		&cli.StringFlag{
+			Name:        "log.output",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "LOG_OUTPUT"),
+			Usage:       "Where to write logs to: stderr, stdout, or the path of a file that is rotated.",
+			Destination: &cfg.Log.Output,
+			Value:       cfg.Log.Output,
+			Category:    flagCategoryLogging,
+		},
+		&cli.IntFlag{
+			Name:        "log.rotate.size",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "LOG_ROTATE_SIZE"),
+			Usage:       "The size in megabytes at which the log file is rotated.",
+			Destination: &cfg.Log.Rotation.MaxSizeMB,
+			Value:       cfg.Log.Rotation.MaxSizeMB,
+			Category:    flagCategoryLogging,
+		},
+		&cli.IntFlag{
+			Name:        "log.rotate.backups",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "LOG_ROTATE_BACKUPS"),
+			Usage:       "The number of rotated log files to keep. Zero keeps all.",
+			Destination: &cfg.Log.Rotation.MaxBackups,
+			Value:       cfg.Log.Rotation.MaxBackups,
+			Category:    flagCategoryLogging,
+		},
+		&cli.IntFlag{
+			Name:        "log.rotate.age",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "LOG_ROTATE_AGE"),
+			Usage:       "The number of days to keep rotated log files. Zero keeps them regardless of their age.",
+			Destination: &cfg.Log.Rotation.MaxAgeDays,
+			Value:       cfg.Log.Rotation.MaxAgeDays,
+			Category:    flagCategoryLogging,
+		},
+		&cli.BoolFlag{
+			Name:        "log.rotate.compress",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "LOG_ROTATE_COMPRESS"),
+			Usage:       "Whether to gzip rotated log files.",
+			Destination: &cfg.Log.Rotation.Compress,
+			Value:       cfg.Log.Rotation.Compress,
+			Category:    flagCategoryLogging,
+		},
 		&cli.BoolFlag{
 			Name:        "metrics.enabled",
 			Sources:     cli.EnvVars(cfg.EnvPrefix + "METRICS_ENABLED"),
@@ -124,6 +398,14 @@ func NewRootCommand(cmd *cli.Command) (*RootCommand, *RootCommandConfig) {
 			Destination: &cfg.Metrics.Path,
 			Category:    flagCategoryTelemetry,
 		},
+		&cli.StringFlag{
+			Name:        "metrics.textfile.dir",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "METRICS_TEXTFILE_DIR"),
+			Usage:       "Directory to write a node_exporter textfile collector snapshot of all metrics to on exit. Useful for short-lived commands.",
+			Value:       cfg.Metrics.TextfileDir,
+			Destination: &cfg.Metrics.TextfileDir,
+			Category:    flagCategoryTelemetry,
+		},
 		&cli.BoolFlag{
 			Name:        "tracing.enabled",
 			Sources:     cli.EnvVars(cfg.EnvPrefix + "TRACING_ENABLED"),
@@ -132,6 +414,38 @@ func NewRootCommand(cmd *cli.Command) (*RootCommand, *RootCommandConfig) {
 			Value:       cfg.Trace.Enabled,
 			Category:    flagCategoryTelemetry,
 		},
+		&cli.Float64Flag{
+			Name:        "tracing.sample.ratio",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "TRACING_SAMPLE_RATIO"),
+			Usage:       "The fraction of traces to sample, between 0 and 1",
+			Destination: &cfg.Trace.SampleRatio,
+			Value:       cfg.Trace.SampleRatio,
+			Category:    flagCategoryTelemetry,
+		},
+		&cli.StringFlag{
+			Name:        "tracing.endpoint",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "TRACING_ENDPOINT"),
+			Usage:       "The host:port or URL of the OTLP gRPC collector. Defaults to the OTEL_EXPORTER_OTLP_* environment variables or localhost:4317",
+			Destination: &cfg.Trace.Endpoint,
+			Value:       cfg.Trace.Endpoint,
+			Category:    flagCategoryTelemetry,
+		},
+		&cli.StringMapFlag{
+			Name:        "tracing.headers",
+			Sources:     SecretEnvVars(cfg.EnvPrefix + "TRACING_HEADERS"),
+			Usage:       "Headers to send to the OTLP collector, e.g., for authentication. Separate multiple key=value pairs with commas.",
+			Destination: &cfg.Trace.Headers,
+			Value:       cfg.Trace.Headers,
+			Category:    flagCategoryTelemetry,
+		},
+		&cli.BoolFlag{
+			Name:        "tracing.insecure",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "TRACING_INSECURE"),
+			Usage:       "Whether to connect to the OTLP collector without TLS",
+			Destination: &cfg.Trace.Insecure,
+			Value:       cfg.Trace.Insecure,
+			Category:    flagCategoryTelemetry,
+		},
 		&cli.DurationFlag{
 			Name:        "shutdown.grace",
 			Sources:     cli.EnvVars(cfg.EnvPrefix + "SHUTDOWN_GRACE"),
@@ -140,32 +454,154 @@ func NewRootCommand(cmd *cli.Command) (*RootCommand, *RootCommandConfig) {
 			Destination: &cfg.ShutdownGrace,
 			Hidden:      true,
 		},
+		&cli.StringSliceFlag{
+			Name:        "admin.keys",
+			Sources:     SecretEnvVars(cfg.EnvPrefix + "ADMIN_KEYS"),
+			Usage:       "API keys that grant access to the admin endpoints on the metrics server. Admin endpoints are disabled if empty.",
+			Value:       cfg.AdminKeys,
+			Destination: &cfg.AdminKeys,
+			Category:    flagCategoryAdmin,
+		},
+		&cli.BoolFlag{
+			Name:        "maintenance.enabled",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "MAINTENANCE_ENABLED"),
+			Usage:       "Whether to start in maintenance mode and reject all but health check requests.",
+			Value:       cfg.MaintenanceEnabled,
+			Destination: &cfg.MaintenanceEnabled,
+			Category:    flagCategoryAdmin,
+		},
 		&cli.StringFlag{
-			Name:        "aws.region",
-			Sources:     cli.EnvVars("AWS_REGION"),
-			Usage:       "The AWS region that this service runs in.",
-			Value:       cfg.AWSRegion,
-			Destination: &cfg.AWSRegion,
-			Category:    flagCategoryTelemetry,
+			Name:        "maintenance.file",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "MAINTENANCE_FILE"),
+			Usage:       "Path to a sentinel file that enables maintenance mode for as long as it exists.",
+			Value:       cfg.MaintenanceFile,
+			Destination: &cfg.MaintenanceFile,
+			Category:    flagCategoryAdmin,
+		},
+		&cli.DurationFlag{
+			Name:        "warmup.timeout",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "WARMUP_TIMEOUT"),
+			Usage:       "How long to wait for all components to warm up before the service reports to be serving. Zero waits indefinitely.",
+			Value:       cfg.Warmup.Timeout,
+			Destination: &cfg.Warmup.Timeout,
+			Category:    flagCategoryAdmin,
+		},
+		&cli.DurationFlag{
+			Name:        "startup.wait",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "STARTUP_WAIT"),
+			Usage:       "How long to wait for dependencies like databases to become reachable before exiting with an error. Zero disables the check.",
+			Value:       cfg.StartupWait,
+			Destination: &cfg.StartupWait,
+			Category:    flagCategoryAdmin,
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Sources:     cli.EnvVars(cfg.EnvPrefix + "DRY_RUN"),
+			Usage:       "Whether commands only report what they would change without modifying any data.",
+			Value:       cfg.DryRun,
+			Destination: &cfg.DryRun,
+			Category:    flagCategoryAdmin,
+		},
+		&cli.BoolFlag{
+			Name:        "check-config",
+			Usage:       "Validates the configuration and exits without running the command.",
+			Value:       cfg.CheckConfig,
+			Destination: &cfg.CheckConfig,
+			Category:    flagCategoryAdmin,
+		},
+		&cli.BoolFlag{
+			Name:        "check-config.dependencies",
+			Usage:       "Whether --check-config also checks that databases and other dependencies are reachable.",
+			Value:       cfg.CheckDependencies,
+			Destination: &cfg.CheckDependencies,
+			Category:    flagCategoryAdmin,
 		},
 	}...)
 
+	cmd.Flags = append(cmd.Flags, AWSFlags(cfg.EnvPrefix, cfg.AWS)...)
+	cmd.Flags = append(cmd.Flags, RuntimeFlags(cfg.EnvPrefix, cfg.Runtime)...)
+
+	cmd.Commands = append(cmd.Commands, NewEnvTemplateCommand(), NewDocsCommand(), NewDebugCommand())
+	if cmd.Command("version") == nil {
+		cmd.Commands = append(cmd.Commands, NewVersionCommand())
+	}
+
 	rootCmd := &RootCommand{
 		cmd: cmd,
 		cfg: cfg,
 	}
 
+	// let Run return classified errors instead of exiting the process from
+	// within urfave/cli, see [ExitCode].
+	if rootCmd.cmd.ExitErrHandler == nil {
+		rootCmd.cmd.ExitErrHandler = func(context.Context, *cli.Command, error) {}
+	}
+
+	if rootCmd.cmd.OnUsageError == nil {
+		rootCmd.cmd.OnUsageError = func(ctx context.Context, c *cli.Command, err error, isSubcommand bool) error {
+			return NewExitError(ExitInvalid, "", errs.Wrap(errs.InvalidInput, err))
+		}
+	}
+
 	oldBefore := rootCmd.cmd.Before
-	rootCmd.cmd.Before = func(ctx context.Context, c *cli.Command) (context.Context, error) {
+	rootCmd.cmd.Before = func(ctx context.Context, c *cli.Command) (_ context.Context, err error) {
+		// before the env file is loaded, the flag is only set if it was
+		// given on the command line or in the environment
+		rootCmd.cfg.logLevelPinned = c.IsSet("log.level")
+
+		if err := rootCmd.loadEnvFile(c); err != nil {
+			return ctx, err
+		}
+
 		if err := rootCmd.before(ctx, c); err != nil {
 			return ctx, err
 		}
 
-		if oldBefore == nil {
-			return ctx, nil
+		ctx = WithDryRun(ctx, rootCmd.cfg.DryRun)
+
+		// record the invocation including the remaining startup
+		var inv *invocation
+		ctx, inv = startInvocation(ctx, c)
+		rootCmd.cfg.invocation = inv
+		defer func() {
+			if err != nil && !errors.Is(err, errConfigChecked) {
+				inv.err = err
+			}
+		}()
+
+		if err := checkFlagRules(c, rootCmd.cfg.flagRules); err != nil {
+			return ctx, errs.Wrapf(errs.InvalidInput, err, "invalid flags")
+		}
+
+		if err := resolveAWSSecrets(ctx, c, rootCmd.cfg.secrets); err != nil {
+			return ctx, errs.Wrapf(errs.Unavailable, err, "resolve aws secrets")
+		}
+
+		if oldBefore != nil {
+			var err error
+			ctx, err = oldBefore(ctx, c)
+			if err != nil {
+				return ctx, err
+			}
+		}
+
+		if err := rootCmd.cfg.validateComponents(); err != nil {
+			return ctx, errs.Wrapf(errs.InvalidInput, err, "invalid configuration")
+		}
+
+		rootCmd.logStartupSummary()
+
+		if rootCmd.cfg.CheckConfig {
+			return ctx, rootCmd.checkConfig(ctx)
+		}
+
+		if rootCmd.cfg.StartupWait > 0 {
+			if err := waitForDependencies(ctx, rootCmd.startupDependencies(), rootCmd.cfg.StartupWait); err != nil {
+				return ctx, err
+			}
 		}
 
-		return oldBefore(ctx, c)
+		return ctx, nil
 	}
 
 	oldAfter := rootCmd.cmd.After
@@ -184,20 +620,81 @@ func NewRootCommand(cmd *cli.Command) (*RootCommand, *RootCommandConfig) {
 	return rootCmd, cfg
 }
 
+// loadEnvFile loads the configured dotenv file and populates the flags of
+// the invoked commands that are still unset from the new environment
+// variables.
+func (r *RootCommand) loadEnvFile(c *cli.Command) error {
+	if r.cfg.EnvFile == "" {
+		return nil
+	}
+
+	keys, err := loadEnvFile(r.cfg.EnvFile)
+	if err != nil {
+		return errs.Wrapf(errs.InvalidInput, err, "load env file")
+	}
+
+	if err := applyEnvSources(c); err != nil {
+		return errs.Wrapf(errs.InvalidInput, err, "apply env file")
+	}
+
+	slog.Debug("Loaded env file", "path", r.cfg.EnvFile, "keys", keys)
+
+	return nil
+}
+
 func (r *RootCommand) before(ctx context.Context, c *cli.Command) error {
 	// configure logger
 	slogger, err := log.NewLogger(r.cfg.Log)
 	if err != nil {
-		return fmt.Errorf("create logger: %w", err)
+		return errs.Wrapf(errs.InvalidInput, err, "create logger")
 	}
 
 	// use initialized logger for everything
 	slog.SetDefault(slogger)
 
+	if err := r.cfg.Trace.Validate(); err != nil {
+		return errs.Wrapf(errs.InvalidInput, err, "invalid tracing config")
+	}
+
+	// adapt the Go runtime to the container limits
+	if err := r.cfg.Runtime.Apply(); err != nil {
+		return errs.Wrapf(errs.InvalidInput, err, "invalid runtime config")
+	}
+
 	slog.Debug("Starting " + r.cmd.Name + "...")
 
 	// print all environment variables
-	debugPrintEnvVars()
+	debugPrintEnvVars(r.cmd)
+
+	// point out typos in environment variable names
+	warnUnknownEnvVars(r.cmd, r.cfg.EnvPrefix, r.cfg.KnownEnvVars)
+
+	// expose admin endpoints alongside the metrics endpoint
+	if len(r.cfg.AdminKeys) > 0 {
+		users := make([]string, len(r.cfg.AdminKeys))
+		for i := range users {
+			users[i] = "admin"
+		}
+		auth := phttp.MiddlewareAuthentication(r.cfg.AdminKeys, users)
+		if r.cfg.Metrics.Handlers == nil {
+			r.cfg.Metrics.Handlers = map[string]http.Handler{}
+		}
+		r.cfg.Metrics.Handlers["/admin/reload"] = auth(r.cfg.Reload.Handler())
+		r.cfg.Metrics.Handlers["/admin/maintenance"] = auth(r.cfg.Maintenance.Handler())
+		r.cfg.Metrics.Handlers["/admin/config"] = auth(r.cfg.SnapshotHandler())
+	}
+
+	// configure maintenance mode
+	if r.cfg.MaintenanceEnabled {
+		r.cfg.Maintenance.Enable("")
+	}
+
+	if r.cfg.MaintenanceFile != "" {
+		go r.cfg.Maintenance.WatchFile(ctx, r.cfg.MaintenanceFile, 5*time.Second)
+	}
+
+	// reload registered components on the reload signals
+	r.cfg.reloadStop = reloadOnSignal(ctx, r.cfg.Reload, r.cfg.ReloadSignals...)
 
 	// initialize metrics server - don't prohibit startup
 	r.cfg.metricsShutdown, err = tele.ServeMetrics(r.cfg.Metrics)
@@ -214,32 +711,95 @@ func (r *RootCommand) before(ctx context.Context, c *cli.Command) error {
 	return nil
 }
 
-func (r *RootCommand) Run() error {
-	ctx, cancel := signalContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer cancel()
+// logStartupSummary logs a single structured statement describing the
+// version, the enabled subsystems, and all registered sub-configs.
+func (r *RootCommand) logStartupSummary() {
+	attrs := []any{
+		"version", r.cmd.Version,
+		"go", runtime.Version(),
+	}
 
-	return r.cmd.Run(ctx, os.Args)
+	for _, attr := range r.cfg.snapshotAttrs() {
+		attrs = append(attrs, attr)
+	}
+
+	slog.Info("Started "+r.cmd.Name, attrs...)
 }
 
-func (r *RootCommand) RunWithContext(ctx context.Context) error {
-	// the main application context
-	ctx, cancel := signalContext(ctx, syscall.SIGINT, syscall.SIGTERM)
-	defer cancel()
+// Command returns the wrapped command.
+func (r *RootCommand) Command() *cli.Command {
+	return r.cmd
+}
+
+// Config returns the configuration that the flags of the root command
+// populate.
+func (r *RootCommand) Config() *RootCommandConfig {
+	return r.cfg
+}
 
-	return r.cmd.Run(ctx, os.Args)
+func (r *RootCommand) Run() error {
+	return r.run(context.Background(), os.Args)
+}
+
+func (r *RootCommand) RunWithContext(ctx context.Context) error {
+	return r.run(ctx, os.Args)
 }
 
 func (r *RootCommand) RunWithContextAndArgs(ctx context.Context, args []string) error {
+	return r.run(ctx, args)
+}
+
+func (r *RootCommand) run(ctx context.Context, args []string) error {
 	// the main application context
-	ctx, cancel := signalContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	ctx, cancel := signalContext(ctx, r.cfg.ShutdownSignals...)
 	defer cancel()
 
-	return r.cmd.Run(ctx, args)
+	err := r.cmd.Run(ctx, args)
+	if errors.Is(err, errConfigChecked) {
+		err = nil
+	}
+
+	return exitError(err, r.cfg.ExitCodes...)
+}
+
+// startupDependencies returns the registered dependencies and, if tracing
+// is enabled, the OTLP endpoint.
+func (r *RootCommand) startupDependencies() []dependency {
+	deps := r.cfg.dependencies
+	if r.cfg.Trace.Enabled {
+		deps = append([]dependency{{name: "otlp", check: TCPDependency(otlpEndpoint(r.cfg.Trace.Endpoint))}}, deps...)
+	}
+	return deps
+}
+
+// errConfigChecked stops the root command after a successful configuration
+// check without running the command's action. [RootCommand.Run] returns nil
+// instead.
+var errConfigChecked = errors.New("configuration checked")
+
+// checkConfig optionally checks that all dependencies are reachable and
+// returns [errConfigChecked] if they are. The flags were already parsed and
+// the configuration validated at this point.
+func (r *RootCommand) checkConfig(ctx context.Context) error {
+	if r.cfg.CheckDependencies {
+		if err := checkDependencies(ctx, r.startupDependencies(), dependencyCheckTimeout); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Configuration is valid")
+
+	return errConfigChecked
 }
 
 func (r *RootCommand) after(ctx context.Context, c *cli.Command) error {
 	defer slog.Debug("Stopped " + r.cmd.Name + " service.")
 
+	r.cfg.reloadStop()
+
+	// record the invocation before the metrics are flushed
+	r.cfg.invocation.end(ctx, r.cfg.ExitCodes...)
+
 	// use a new context as the application context might have been canceled.
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), r.cfg.ShutdownGrace)
 	defer shutdownCancel()
@@ -261,9 +821,14 @@ func (r *RootCommand) after(ctx context.Context, c *cli.Command) error {
 // regular shutdown and actually receiving a signal. This would make the log
 // message below misleading.
 func signalContext(ctx context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
-	sigs := make(chan os.Signal, 1)
 	ctx, cancel := context.WithCancel(ctx)
 
+	// signal.Notify relays all signals if none are given
+	if len(signals) == 0 {
+		return ctx, cancel
+	}
+
+	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, signals...)
 	go func() {
 		defer cancel()
@@ -279,42 +844,145 @@ func signalContext(ctx context.Context, signals ...os.Signal) (context.Context,
 	return ctx, cancel
 }
 
-// debugPrintEnvVars logs all environment variables at debug level.
-// Redacts values of variables containing the string "password".
-func debugPrintEnvVars() {
-	slog.Debug("Environment variables:")
-	for _, kv := range os.Environ() {
-		parts := strings.Split(kv, "=")
-		if len(parts) != 2 {
-			slog.Debug(kv)
-			continue
+// reloadOnSignal reloads all components registered with the given registry
+// whenever the application receives one of the given signals. It stops
+// listening when the context is canceled or the returned function is called.
+func reloadOnSignal(ctx context.Context, reg *reload.Registry, signals ...os.Signal) func() {
+	if len(signals) == 0 {
+		return func() {}
+	}
+
+	sigs := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(ctx)
+
+	signal.Notify(sigs, signals...)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer signal.Stop(sigs)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-sigs:
+				slog.Info("Received reload signal", "signal", sig.String())
+				_, _ = reg.Reload(ctx) // failures are logged by the registry
+			}
 		}
+	}()
 
-		if !strings.Contains(strings.ToLower(parts[0]), "password") {
-			slog.Debug(kv)
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// debugPrintEnvVars logs all environment variables at debug level. Values
+// of secret variables are redacted, see [redactEnvVars].
+func debugPrintEnvVars(cmd *cli.Command) {
+	slog.Debug("Environment variables:")
+	for _, kv := range redactEnvVars(os.Environ(), secretEnvVars(cmd)) {
+		slog.Debug(kv)
+	}
+}
+
+// secretEnvVarWords are parts of variable names that suggest a secret value,
+// so that secrets that aren't read by a secret flag, e.g., those of other
+// tools in the same environment, are redacted as well. Header variables like
+// OTEL_EXPORTER_OTLP_HEADERS usually carry an Authorization header.
+var secretEnvVarWords = []string{"PASSWORD", "KEY", "TOKEN", "SECRET", "HEADERS"}
+
+// redactEnvVars returns the key=value pairs of environ with the non-empty
+// values of the variables in secrets and of variables whose name contains one
+// of [secretEnvVarWords] replaced by asterisks.
+func redactEnvVars(environ []string, secrets map[string]bool) []string {
+	redacted := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		if value == "" || !isSecretEnvVar(key, secrets) {
+			redacted = append(redacted, kv)
 			continue
 		}
 
-		redacted := "*****"
-		if parts[1] == "" {
-			redacted = ""
+		redacted = append(redacted, key+"=*****")
+	}
+
+	return redacted
+}
+
+func isSecretEnvVar(key string, secrets map[string]bool) bool {
+	if secrets[key] {
+		return true
+	}
+
+	upper := strings.ToUpper(key)
+	for _, word := range secretEnvVarWords {
+		if strings.Contains(upper, word) {
+			return true
 		}
+	}
 
-		slog.Debug(strings.Join([]string{parts[0], redacted}, "="))
+	return false
+}
+
+// invokedCommands returns cmd and the chain of subcommands that are invoked
+// by its arguments. urfave/cli parses the flags of the whole chain before it
+// runs the first Before hook, so hooks of the root command can inspect them.
+func invokedCommands(cmd *cli.Command) []*cli.Command {
+	var cmds []*cli.Command
+	for cmd != nil {
+		cmds = append(cmds, cmd)
+		if !cmd.Args().Present() {
+			break
+		}
+		cmd = cmd.Command(cmd.Args().First())
 	}
+
+	return cmds
 }
 
+// buildEnvPrefix derives an environment variable prefix from name, e.g.,
+// "my-app" becomes "MY_APP_". An empty name results in an empty prefix.
 func buildEnvPrefix(name string) string {
-	prefix := strings.ToUpper(name)
+	if name == "" {
+		return ""
+	}
+
+	prefix := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name))
 	if !strings.HasSuffix(prefix, "_") {
 		prefix += "_"
 	}
 	return prefix
 }
 
+// BuildInfo holds the version control and toolchain information that the Go
+// toolchain embeds into the binary.
 type BuildInfo struct {
-	Commit string
-	Dirty  bool
+	Commit string `json:"commit"`
+	Dirty  bool   `json:"dirty"`
+
+	// Time is the commit time of the build.
+	Time time.Time `json:"time,omitzero"`
+
+	// GoVersion is the version of the toolchain that built the binary.
+	GoVersion string `json:"go_version"`
+
+	// Module is the main module of the binary.
+	Module BuildModule `json:"module"`
+
+	// Deps are the dependencies of the main module.
+	Deps []BuildModule `json:"deps,omitempty"`
+}
+
+// BuildModule is a module that is built into the binary.
+type BuildModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+
+	// Replace is the module that replaces this one, if any.
+	Replace *BuildModule `json:"replace,omitempty"`
 }
 
 func (bi *BuildInfo) ShortCommit() string {
@@ -326,22 +994,52 @@ func (bi *BuildInfo) ShortCommit() string {
 }
 
 func buildInfo() *BuildInfo {
-	bi := &BuildInfo{}
-	if info, ok := debug.ReadBuildInfo(); ok {
-		for _, setting := range info.Settings {
-			switch setting.Key {
-			case "vcs.revision":
-				bi.Commit = setting.Value
-
-			case "vcs.modified":
-				dirty, err := strconv.ParseBool(setting.Value)
-				if err != nil {
-					panic(err)
-				}
-				bi.Dirty = dirty
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return &BuildInfo{GoVersion: runtime.Version()}
+	}
+
+	return newBuildInfo(info)
+}
+
+func newBuildInfo(info *debug.BuildInfo) *BuildInfo {
+	bi := &BuildInfo{
+		GoVersion: info.GoVersion,
+		Module:    newBuildModule(&info.Main),
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			bi.Commit = setting.Value
+
+		case "vcs.modified":
+			dirty, err := strconv.ParseBool(setting.Value)
+			if err != nil {
+				panic(err)
+			}
+			bi.Dirty = dirty
+
+		case "vcs.time":
+			t, err := time.Parse(time.RFC3339, setting.Value)
+			if err == nil {
+				bi.Time = t
 			}
 		}
 	}
 
+	for _, dep := range info.Deps {
+		bi.Deps = append(bi.Deps, newBuildModule(dep))
+	}
+
 	return bi
 }
+
+func newBuildModule(m *debug.Module) BuildModule {
+	bm := BuildModule{Path: m.Path, Version: m.Version}
+	if m.Replace != nil {
+		replace := newBuildModule(m.Replace)
+		bm.Replace = &replace
+	}
+	return bm
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/db"
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestBuildEnvPrefix(t *testing.T) {
+	assert.Equal(t, "NEBULA_", buildEnvPrefix("nebula"))
+	assert.Equal(t, "NEBULA_", buildEnvPrefix("NEBULA_"))
+	assert.Equal(t, "ANTS_WATCH_", buildEnvPrefix("ants-watch"))
+	assert.Equal(t, "PROBELAB_API_", buildEnvPrefix("probelab.api"))
+	assert.Equal(t, "", buildEnvPrefix(""))
+}
+
+func TestNewRootCommand_envPrefix(t *testing.T) {
+	tele.DisableForTest(t)
+
+	t.Setenv("OLDNAME_LOG_LEVEL", "debug")
+	t.Setenv("OLDNAME_CLICKHOUSE_DATABASE", "legacy")
+
+	chCfg := db.DefaultClickHouseConfig("newname")
+	cmd := &cli.Command{Name: "new-name", Action: func(context.Context, *cli.Command) error { return nil }}
+	root, cfg := NewRootCommand(cmd, WithEnvPrefix("OLDNAME"))
+	cmd.Flags = append(cmd.Flags, ClickHouseFlags(cfg.EnvPrefix, chCfg)...)
+
+	require.NoError(t, root.RunWithContextAndArgs(context.Background(), []string{"new-name"}))
+
+	assert.Equal(t, "OLDNAME_", cfg.EnvPrefix)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, "legacy", chCfg.Database)
+
+	_, cfg = NewRootCommand(&cli.Command{Name: "new-name"})
+	assert.Equal(t, "NEW_NAME_", cfg.EnvPrefix)
+
+	_, cfg = NewRootCommand(&cli.Command{Name: "new-name"}, WithEnvPrefix(""))
+	assert.Equal(t, "", cfg.EnvPrefix)
+}
+
+func TestSecretEnvVars(t *testing.T) {
+	cmd := &cli.Command{Name: "app"}
+	_, cfg := NewRootCommand(cmd)
+	cmd.Commands = []*cli.Command{{
+		Name:  "sub",
+		Flags: ClickHouseFlags(cfg.EnvPrefix, db.DefaultClickHouseConfig("app")),
+	}}
+
+	secrets := secretEnvVars(cmd)
+	for _, key := range []string{"APP_ADMIN_KEYS", "APP_ADMIN_KEYS_FILE", "APP_CLICKHOUSE_PASSWORD", "APP_CLICKHOUSE_PASSWORD_FILE", "APP_TRACING_HEADERS", "APP_TRACING_HEADERS_FILE"} {
+		assert.True(t, secrets[key], key)
+	}
+
+	for _, key := range []string{"APP_LOG_LEVEL", "APP_CLICKHOUSE_USER"} {
+		assert.False(t, secrets[key], key)
+	}
+}
+
+func TestRedactEnvVars(t *testing.T) {
+	environ := []string{
+		"APP_LOG_LEVEL=debug",
+		"APP_ADMIN_KEYS=alice:s3cr3t",
+		"APP_ADMIN_KEYS_FILE=/run/secrets/admin_keys",
+		"APP_CUSTOM=a=b",
+		"APP_CUSTOM_SECRET=c2VjcmV0==",
+		"CLICKHOUSE_PASSWORD=hunter2",
+		"GITHUB_TOKEN=ghp_abc",
+		"AWS_SECRET_ACCESS_KEY=abc",
+		"OTEL_EXPORTER_OTLP_HEADERS=Authorization=Bearer abc",
+		"APP_EMPTY_PASSWORD=",
+		"NOVALUE",
+	}
+
+	secrets := map[string]bool{"APP_ADMIN_KEYS": true, "APP_ADMIN_KEYS_FILE": true, "APP_CUSTOM": true}
+
+	assert.Equal(t, []string{
+		"APP_LOG_LEVEL=debug",
+		"APP_ADMIN_KEYS=*****",
+		"APP_ADMIN_KEYS_FILE=*****",
+		"APP_CUSTOM=*****",
+		"APP_CUSTOM_SECRET=*****",
+		"CLICKHOUSE_PASSWORD=*****",
+		"GITHUB_TOKEN=*****",
+		"AWS_SECRET_ACCESS_KEY=*****",
+		"OTEL_EXPORTER_OTLP_HEADERS=*****",
+		"APP_EMPTY_PASSWORD=",
+		"NOVALUE",
+	}, redactEnvVars(environ, secrets))
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// cgroupRoot is where the cgroup filesystem is mounted. Tests replace it.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// RuntimeConfig controls how the Go runtime adapts to the resource limits of
+// the container that the service runs in. Explicit GOMAXPROCS and
+// GOMEMLIMIT environment variables always take precedence.
+type RuntimeConfig struct {
+	// AutoMaxProcs keeps GOMAXPROCS in line with the cgroup CPU limit as
+	// the Go runtime does by default since Go 1.25. Disabling it sets
+	// GOMAXPROCS to the number of CPUs of the host instead.
+	AutoMaxProcs bool
+
+	// AutoMemLimit sets the soft memory limit of the Go runtime to
+	// MemLimitRatio of the cgroup memory limit, so that the garbage
+	// collector works harder before the container gets OOM-killed.
+	AutoMemLimit bool
+
+	// MemLimitRatio is the fraction of the cgroup memory limit that is used
+	// as the soft memory limit. The remainder is headroom for memory that
+	// isn't managed by the Go runtime.
+	MemLimitRatio float64
+}
+
+// DefaultRuntimeConfig returns a [RuntimeConfig] that derives GOMAXPROCS and
+// GOMEMLIMIT from the container limits.
+func DefaultRuntimeConfig() *RuntimeConfig {
+	return &RuntimeConfig{
+		AutoMaxProcs:  true,
+		AutoMemLimit:  true,
+		MemLimitRatio: 0.9,
+	}
+}
+
+// Validate validates the runtime configuration.
+func (cfg *RuntimeConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if cfg.MemLimitRatio <= 0 || cfg.MemLimitRatio > 1 {
+		return fmt.Errorf("memory limit ratio must be in (0, 1], got %v", cfg.MemLimitRatio)
+	}
+
+	return nil
+}
+
+// LogValue implements [slog.LogValuer].
+func (cfg *RuntimeConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("auto_maxprocs", cfg.AutoMaxProcs),
+		slog.Bool("auto_memlimit", cfg.AutoMemLimit),
+		slog.Float64("memlimit_ratio", cfg.MemLimitRatio),
+	)
+}
+
+// Apply configures GOMAXPROCS and the soft memory limit of the Go runtime.
+// The root command created by [NewRootCommand] calls it in its Before hook.
+// Failing to read the cgroup limits is logged but doesn't fail.
+func (cfg *RuntimeConfig) Apply() error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	if os.Getenv("GOMAXPROCS") == "" {
+		if cfg.AutoMaxProcs {
+			runtime.SetDefaultGOMAXPROCS()
+		} else {
+			runtime.GOMAXPROCS(runtime.NumCPU())
+		}
+	}
+
+	if cfg.AutoMemLimit && os.Getenv("GOMEMLIMIT") == "" {
+		limit, found, err := cgroupMemoryLimit(cgroupRoot)
+		if err != nil {
+			slog.Warn("Failed to read cgroup memory limit", "err", err)
+		} else if found {
+			debug.SetMemoryLimit(int64(float64(limit) * cfg.MemLimitRatio))
+		}
+	}
+
+	memLimit := debug.SetMemoryLimit(-1)
+
+	attrs := []any{"gomaxprocs", runtime.GOMAXPROCS(0)}
+	if memLimit == math.MaxInt64 {
+		attrs = append(attrs, "gomemlimit", "off")
+	} else {
+		attrs = append(attrs, "gomemlimit", memLimit)
+	}
+	slog.Debug("Configured Go runtime", attrs...)
+
+	return nil
+}
+
+// cgroupMemoryLimit returns the memory limit in bytes of the cgroup mounted
+// at root. It supports cgroup v2 and v1 and reports whether a limit is set.
+func cgroupMemoryLimit(root string) (int64, bool, error) {
+	// cgroup v2
+	data, err := os.ReadFile(filepath.Join(root, "memory.max"))
+	if errors.Is(err, fs.ErrNotExist) {
+		// cgroup v1
+		data, err = os.ReadFile(filepath.Join(root, "memory", "memory.limit_in_bytes"))
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, false, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse cgroup memory limit %q: %w", value, err)
+	}
+
+	// cgroup v1 reports a page-aligned math.MaxInt64 if there is no limit
+	if limit <= 0 || limit >= math.MaxInt64&^0xfff {
+		return 0, false, nil
+	}
+
+	return limit, true, nil
+}
+
+// RuntimeFlags generates a slice of [cli.Flag] for the adaption of the Go
+// runtime to the container limits. The root command created by
+// [NewRootCommand] includes these flags for [RootCommandConfig.Runtime].
+func RuntimeFlags(envPrefix string, cfg *RuntimeConfig) []cli.Flag {
+	envPrefix = buildEnvPrefix(envPrefix)
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "runtime.maxprocs.auto",
+			Usage:       "Whether to derive GOMAXPROCS from the container CPU limit instead of the number of host CPUs.",
+			Sources:     cli.EnvVars(envPrefix + "RUNTIME_MAXPROCS_AUTO"),
+			Value:       cfg.AutoMaxProcs,
+			Destination: &cfg.AutoMaxProcs,
+			Category:    flagCategoryRuntime,
+		},
+		&cli.BoolFlag{
+			Name:        "runtime.memlimit.auto",
+			Usage:       "Whether to derive GOMEMLIMIT from the container memory limit.",
+			Sources:     cli.EnvVars(envPrefix + "RUNTIME_MEMLIMIT_AUTO"),
+			Value:       cfg.AutoMemLimit,
+			Destination: &cfg.AutoMemLimit,
+			Category:    flagCategoryRuntime,
+		},
+		&cli.FloatFlag{
+			Name:        "runtime.memlimit.ratio",
+			Usage:       "The fraction of the container memory limit to use as GOMEMLIMIT.",
+			Sources:     cli.EnvVars(envPrefix + "RUNTIME_MEMLIMIT_RATIO"),
+			Value:       cfg.MemLimitRatio,
+			Destination: &cfg.MemLimitRatio,
+			Category:    flagCategoryRuntime,
+		},
+	}
+}
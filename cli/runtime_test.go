@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/errs"
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestCgroupMemoryLimit(t *testing.T) {
+	write := func(t *testing.T, path string, content string) string {
+		t.Helper()
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(content), 0o644))
+		return root
+	}
+
+	tests := []struct {
+		name      string
+		root      string
+		wantLimit int64
+		wantFound bool
+		wantErr   bool
+	}{
+		{name: "v2", root: write(t, "memory.max", "536870912\n"), wantLimit: 536870912, wantFound: true},
+		{name: "v2 unlimited", root: write(t, "memory.max", "max\n"), wantFound: false},
+		{name: "v1", root: write(t, "memory/memory.limit_in_bytes", "1073741824\n"), wantLimit: 1073741824, wantFound: true},
+		{name: "v1 unlimited", root: write(t, "memory/memory.limit_in_bytes", "9223372036854771712\n"), wantFound: false},
+		{name: "no cgroup", root: t.TempDir(), wantFound: false},
+		{name: "invalid", root: write(t, "memory.max", "lots\n"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, found, err := cgroupMemoryLimit(tt.root)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.wantLimit, limit)
+		})
+	}
+}
+
+func TestRuntimeConfig_Apply(t *testing.T) {
+	t.Setenv("GOMAXPROCS", "")
+	t.Setenv("GOMEMLIMIT", "")
+
+	prevLimit := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() {
+		debug.SetMemoryLimit(prevLimit)
+		runtime.SetDefaultGOMAXPROCS()
+	})
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "memory.max"), []byte("1000000000\n"), 0o644))
+
+	prevRoot := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = prevRoot })
+
+	cfg := DefaultRuntimeConfig()
+	require.NoError(t, cfg.Apply())
+	assert.EqualValues(t, 900_000_000, debug.SetMemoryLimit(-1))
+
+	debug.SetMemoryLimit(math.MaxInt64)
+	cfg.AutoMemLimit = false
+	cfg.AutoMaxProcs = false
+	require.NoError(t, cfg.Apply())
+	assert.EqualValues(t, int64(math.MaxInt64), debug.SetMemoryLimit(-1))
+	assert.Equal(t, runtime.NumCPU(), runtime.GOMAXPROCS(0))
+
+	t.Setenv("GOMEMLIMIT", "100MiB")
+	cfg.AutoMemLimit = true
+	require.NoError(t, cfg.Apply())
+	assert.EqualValues(t, int64(math.MaxInt64), debug.SetMemoryLimit(-1), "GOMEMLIMIT must take precedence")
+
+	cfg.MemLimitRatio = 1.5
+	assert.ErrorContains(t, cfg.Apply(), "memory limit ratio must be in (0, 1]")
+}
+
+func TestRootCommand_runtimeFlags(t *testing.T) {
+	tele.DisableForTest(t)
+
+	root, _ := NewRootCommand(&cli.Command{
+		Name:   "test",
+		Action: func(context.Context, *cli.Command) error { return nil },
+	})
+
+	err := root.RunWithContextAndArgs(context.Background(), []string{"test", "--runtime.memlimit.ratio", "0"})
+	assert.ErrorIs(t, err, errs.InvalidInput)
+	assert.ErrorContains(t, err, "invalid runtime config")
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// SecretEnvVars returns the value sources for a secret flag. The value is read
+// from the environment variable key or, if that is not set, from the file
+// whose path is given by the environment variable key+"_FILE". This supports
+// Docker and Kubernetes secret mounts without wrapper scripts, e.g.:
+//
+//	CLICKHOUSE_PASSWORD_FILE=/run/secrets/clickhouse_password
+//
+// Trailing newlines in the file are removed.
+func SecretEnvVars(key string) cli.ValueSourceChain {
+	return cli.NewValueSourceChain(cli.EnvVar(key), &envFileValueSource{key: key + "_FILE"})
+}
+
+// secretEnvVars returns the environment variables of the secret flags of
+// cmd and its subcommands, i.e., of the flags whose sources were created with
+// [SecretEnvVars], including the variables that hold the paths of secret
+// files.
+func secretEnvVars(cmd *cli.Command) map[string]bool {
+	secrets := map[string]bool{}
+
+	var walk func(cmd *cli.Command)
+	walk = func(cmd *cli.Command) {
+		for _, f := range cmd.Flags {
+			docFlag, ok := f.(cli.DocGenerationFlag)
+			if !ok {
+				continue
+			}
+
+			env := docFlag.GetEnvVars()
+			if !slices.ContainsFunc(env, func(key string) bool { return key == env[0]+"_FILE" }) {
+				continue
+			}
+
+			for _, key := range env {
+				secrets[key] = true
+			}
+		}
+
+		for _, sub := range cmd.Commands {
+			walk(sub)
+		}
+	}
+	walk(cmd)
+
+	return secrets
+}
+
+// envFileValueSource looks up a value from the file referenced by an
+// environment variable.
+type envFileValueSource struct {
+	key string
+}
+
+var (
+	_ cli.ValueSource    = (*envFileValueSource)(nil)
+	_ cli.EnvValueSource = (*envFileValueSource)(nil)
+)
+
+func (e *envFileValueSource) Lookup() (string, bool) {
+	path, found := os.LookupEnv(e.key)
+	if !found || path == "" {
+		return "", false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// the flag falls back to its default, which is rarely intended for
+		// secrets, so make the failure visible.
+		slog.Warn("Failed to read secret file", "env", e.key, "path", path, "err", err)
+		return "", false
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), true
+}
+
+func (e *envFileValueSource) IsFromEnv() bool { return true }
+func (e *envFileValueSource) Key() string     { return e.key }
+
+func (e *envFileValueSource) String() string {
+	return fmt.Sprintf("file from environment variable %q", e.key)
+}
+
+func (e *envFileValueSource) GoString() string {
+	return fmt.Sprintf("&envFileValueSource{key:%q}", e.key)
+}
@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/errs"
+	"github.com/probe-lab/go-commons/grpc"
+	phttp "github.com/probe-lab/go-commons/http"
+	"github.com/probe-lab/go-commons/service"
+)
+
+// ServeConfig configures the servers of the command created by
+// [NewServeCommand].
+type ServeConfig struct {
+	GRPC *grpc.ServerConfig
+	HTTP *HTTPServerConfig
+}
+
+// HTTPServerConfig configures the HTTP server of the command created by
+// [NewServeCommand].
+type HTTPServerConfig struct {
+	// Enabled starts the HTTP server next to the gRPC server.
+	Enabled bool
+
+	// Listener, if set, is used instead of listening on Host and Port,
+	// which must then be empty, e.g., in tests.
+	Listener net.Listener
+	Host     string
+	Port     int
+
+	// ReadHeaderTimeout bounds the time to read the request headers.
+	ReadHeaderTimeout time.Duration
+
+	// DrainLogInterval is the interval at which the number of remaining
+	// in-flight requests is logged during graceful shutdown.
+	DrainLogInterval time.Duration
+}
+
+// DefaultServeConfig returns a [ServeConfig] with a gRPC server on
+// localhost:8080 and an HTTP server on localhost:8081.
+func DefaultServeConfig() *ServeConfig {
+	return &ServeConfig{
+		GRPC: grpc.DefaultServerConfig(),
+		HTTP: &HTTPServerConfig{
+			Enabled:           true,
+			Host:              "localhost",
+			Port:              8081,
+			ReadHeaderTimeout: 10 * time.Second,
+			DrainLogInterval:  time.Second,
+		},
+	}
+}
+
+// Validate validates the server configurations.
+func (cfg *ServeConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if err := cfg.GRPC.Validate(); err != nil {
+		return fmt.Errorf("grpc: %w", err)
+	}
+
+	if cfg.HTTP == nil {
+		return fmt.Errorf("http config is nil")
+	}
+
+	if !cfg.HTTP.Enabled {
+		return nil
+	}
+
+	if cfg.HTTP.Listener != nil {
+		if cfg.HTTP.Host != "" || cfg.HTTP.Port != 0 {
+			return fmt.Errorf("http listener and host or port cannot both be set")
+		}
+	} else if cfg.HTTP.Host == "" {
+		return fmt.Errorf("no http listener provided and host is empty")
+	} else if cfg.HTTP.Port < 0 || cfg.HTTP.Port > 65535 {
+		return fmt.Errorf("http port must be between 0 and 65535, got %d", cfg.HTTP.Port)
+	}
+
+	if cfg.HTTP.ReadHeaderTimeout < 0 {
+		return fmt.Errorf("http read header timeout must not be negative")
+	}
+
+	if cfg.HTTP.DrainLogInterval <= 0 {
+		return fmt.Errorf("http drain log interval must be positive")
+	}
+
+	return nil
+}
+
+// LogValue implements [slog.LogValuer].
+func (cfg *ServeConfig) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Any("grpc", cfg.GRPC),
+		slog.Bool("http.enabled", cfg.HTTP.Enabled),
+	}
+
+	if cfg.HTTP.Enabled {
+		attrs = append(attrs, slog.String("http.addr", cfg.HTTP.addr()))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func (cfg *HTTPServerConfig) addr() string {
+	if cfg.Listener != nil {
+		return cfg.Listener.Addr().String()
+	}
+	return net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+}
+
+// ServeFlags generates a slice of [cli.Flag] for the gRPC and HTTP servers
+// of a [ServeConfig].
+func ServeFlags(envPrefix string, cfg *ServeConfig) []cli.Flag {
+	envPrefix = buildEnvPrefix(envPrefix)
+	return append(GRPCServerFlags(envPrefix, cfg.GRPC),
+		&cli.BoolFlag{
+			Name:        "http.enabled",
+			Usage:       "Whether to start the HTTP server",
+			Sources:     cli.EnvVars(envPrefix + "HTTP_ENABLED"),
+			Value:       cfg.HTTP.Enabled,
+			Destination: &cfg.HTTP.Enabled,
+			Category:    flagCategoryServer,
+		},
+		&cli.StringFlag{
+			Name:        "http.host",
+			Usage:       "Which network interface the HTTP server should bind to",
+			Sources:     cli.EnvVars(envPrefix + "HTTP_HOST"),
+			Value:       cfg.HTTP.Host,
+			Destination: &cfg.HTTP.Host,
+			Category:    flagCategoryServer,
+		},
+		&cli.IntFlag{
+			Name:        "http.port",
+			Usage:       "On which port the HTTP server should listen",
+			Sources:     cli.EnvVars(envPrefix + "HTTP_PORT"),
+			Value:       cfg.HTTP.Port,
+			Destination: &cfg.HTTP.Port,
+			Category:    flagCategoryServer,
+		},
+	)
+}
+
+// Servers are handed to the [ServeFunc] to register gRPC services, HTTP
+// handlers, and background workers before the servers start.
+type Servers struct {
+	// GRPC is the gRPC server, which already serves the health service.
+	GRPC *grpc.Server
+
+	// HTTP is the handler of the HTTP server, which already serves
+	// /healthz.
+	HTTP *http.ServeMux
+
+	services []service.Service
+}
+
+// Add adds services, e.g., background workers, that run alongside the
+// servers. They are stopped after the servers stopped accepting requests.
+func (s *Servers) Add(services ...service.Service) {
+	s.services = append(s.services, services...)
+}
+
+// ServeFunc registers the services of an application with the servers.
+type ServeFunc func(ctx context.Context, s *Servers) error
+
+// NewServeCommand returns a "serve" command that runs a gRPC server with
+// health checks and, if enabled, an HTTP server until the process receives
+// a shutdown signal:
+//
+//	serveCfg := cli.DefaultServeConfig()
+//	root.Commands = append(root.Commands, cli.NewServeCommand(rootCfg, serveCfg,
+//		func(ctx context.Context, s *cli.Servers) error {
+//			pb.RegisterAPIServer(s.GRPC, api)
+//			s.HTTP.Handle("/v1/", apiHandler)
+//			s.Add(service.Func("inserter", inserter.Run))
+//			return nil
+//		},
+//	))
+//
+// Both servers honor the maintenance mode of the root command, the gRPC
+// server reports SERVING only after the root command's warm-up functions
+// returned, and TLS certificates are reloaded with the root command's reload
+// registry. On shutdown, the gRPC server stops first, then the HTTP server,
+// and then the services added with [Servers.Add], all within the shutdown
+// grace period. The metrics endpoint is served by the root command.
+func NewServeCommand(rootCfg *RootCommandConfig, cfg *ServeConfig, setup ServeFunc) *cli.Command {
+	rootCfg.Register("serve", cfg)
+
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "Starts the gRPC and HTTP servers",
+		Flags: ServeFlags(rootCfg.EnvPrefix, cfg),
+		Action: func(ctx context.Context, c *cli.Command) error {
+			if cfg.GRPC.Maintenance == nil {
+				cfg.GRPC.Maintenance = rootCfg.Maintenance
+			}
+
+			if cfg.GRPC.Warmup == nil {
+				cfg.GRPC.Warmup = rootCfg.Warmup
+			}
+
+			grpcSrv, err := grpc.NewServer(cfg.GRPC)
+			if err != nil {
+				return errs.Wrapf(errs.InvalidInput, err, "new grpc server")
+			}
+
+			if cfg.GRPC.TLSCertFile != "" {
+				rootCfg.Reload.Register("grpc.tls", grpcSrv.ReloadTLS)
+			}
+
+			servers := &Servers{
+				GRPC: grpcSrv,
+				HTTP: http.NewServeMux(),
+			}
+
+			servers.HTTP.HandleFunc("GET /healthz", func(rw http.ResponseWriter, r *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			})
+
+			if err := setup(ctx, servers); err != nil {
+				return err
+			}
+
+			group := service.NewGroup()
+			group.ShutdownTimeout = rootCfg.ShutdownGrace
+			group.Add(servers.services...)
+
+			if cfg.HTTP.Enabled {
+				httpSvc, err := newHTTPService(cfg.HTTP, rootCfg.Maintenance, servers.HTTP)
+				if err != nil {
+					return err
+				}
+				group.Add(httpSvc)
+			}
+
+			group.Add(service.New("grpc",
+				func(context.Context) error { return grpcSrv.ListenAndServe() },
+				func(ctx context.Context) error {
+					done := make(chan struct{})
+					go func() {
+						defer close(done)
+						grpcSrv.Shutdown()
+					}()
+
+					select {
+					case <-done:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				},
+			))
+
+			return group.Run(ctx)
+		},
+	}
+}
+
+// newHTTPService returns a service that serves handler with the standard
+// middlewares and drains in-flight requests on shutdown.
+func newHTTPService(cfg *HTTPServerConfig, m phttp.Maintenance, handler http.Handler) (service.Service, error) {
+	inflight, err := phttp.NewInFlight(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{
+		Addr: cfg.addr(),
+		Handler: phttp.MiddlewareChain(
+			phttp.MiddlewareRecover,
+			phttp.MiddlewareRequestID,
+			inflight.Middleware(),
+			phttp.MiddlewareMaintenance(m, "/healthz"),
+		)(handler),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+	}
+
+	return service.New("http",
+		func(context.Context) error {
+			lis := cfg.Listener
+			if lis == nil {
+				var err error
+				if lis, err = net.Listen("tcp", srv.Addr); err != nil {
+					return fmt.Errorf("tcp listen on %s: %w", srv.Addr, err)
+				}
+			}
+
+			slog.Info("Starting HTTP server", "addr", lis.Addr())
+			if err := srv.Serve(lis); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+		func(ctx context.Context) error {
+			return inflight.Shutdown(ctx, srv, cfg.DrainLogInterval)
+		},
+	), nil
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/probe-lab/go-commons/service"
+	"github.com/probe-lab/go-commons/tele"
+)
+
+func TestServeConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultServeConfig().Validate())
+
+	var nilCfg *ServeConfig
+	assert.Error(t, nilCfg.Validate())
+
+	cfg := DefaultServeConfig()
+	cfg.HTTP.Port = 70000
+	assert.Error(t, cfg.Validate())
+
+	cfg.HTTP.Enabled = false
+	assert.NoError(t, cfg.Validate())
+
+	cfg = DefaultServeConfig()
+	cfg.HTTP.Listener = &net.TCPListener{}
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultServeConfig()
+	cfg.GRPC.Port = -1
+	assert.Error(t, cfg.Validate())
+}
+
+func TestNewServeCommand(t *testing.T) {
+	tele.DisableForTest(t)
+
+	grpcLis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	httpLis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	serveCfg := DefaultServeConfig()
+	serveCfg.GRPC.Listener, serveCfg.GRPC.Host, serveCfg.GRPC.Port = grpcLis, "", 0
+	serveCfg.HTTP.Listener, serveCfg.HTTP.Host, serveCfg.HTTP.Port = httpLis, "", 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	workerStopped := make(chan struct{})
+
+	cmd := &cli.Command{Name: "test"}
+	root, rootCfg := NewRootCommand(cmd)
+	cmd.Commands = append(cmd.Commands, NewServeCommand(rootCfg, serveCfg, func(ctx context.Context, s *Servers) error {
+		s.HTTP.HandleFunc("GET /hello", func(rw http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(rw, "hello")
+		})
+		s.Add(service.Func("worker", func(ctx context.Context) error {
+			<-ctx.Done()
+			close(workerStopped)
+			return nil
+		}))
+		return nil
+	}))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- root.RunWithContextAndArgs(ctx, []string{"test", "serve"})
+	}()
+
+	httpURL := "http://" + httpLis.Addr().String()
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		resp, err := http.Get(httpURL + "/hello")
+		if !assert.NoError(c, err) {
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(c, "hello", string(body))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	resp, err := http.Get(httpURL + "/healthz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	conn, err := grpc.NewClient(grpcLis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		res, err := healthv1.NewHealthClient(conn).Check(ctx, &healthv1.HealthCheckRequest{})
+		if assert.NoError(c, err) {
+			assert.Equal(c, healthv1.HealthCheckResponse_SERVING, res.GetStatus())
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rootCfg.Maintenance.Enable("test")
+	resp, err = http.Get(httpURL + "/hello")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	rootCfg.Maintenance.Disable()
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("serve command did not stop")
+	}
+
+	select {
+	case <-workerStopped:
+	default:
+		t.Fatal("worker was not stopped")
+	}
+}
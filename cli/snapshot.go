@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	phttp "github.com/probe-lab/go-commons/http"
+)
+
+// snapshotAttrs returns the configuration of the root command and all
+// registered sub-configs as slog attributes. Sub-configs redact their
+// credentials in their LogValue methods.
+func (cfg *RootCommandConfig) snapshotAttrs() []slog.Attr {
+	maintenanceEnabled, _ := cfg.Maintenance.Enabled()
+
+	attrs := []slog.Attr{
+		slog.Group("build",
+			"commit", cfg.BuildInfo.Commit,
+			"dirty", cfg.BuildInfo.Dirty,
+		),
+		slog.Any("log", cfg.Log),
+		slog.Any("metrics", cfg.Metrics),
+		slog.Any("tracing", cfg.Trace),
+		slog.Any("aws", cfg.AWS),
+		slog.Any("runtime", cfg.Runtime),
+		slog.Group("admin",
+			"enabled", len(cfg.AdminKeys) > 0,
+			"keys", len(cfg.AdminKeys),
+		),
+		slog.Bool("dry_run", cfg.DryRun),
+		slog.Group("maintenance",
+			"enabled", maintenanceEnabled,
+			"file", cfg.MaintenanceFile,
+		),
+		slog.Any("reload", cfg.Reload.Names()),
+		slog.Any("warmup", cfg.Warmup.Names()),
+		slog.Any("dependencies", cfg.dependencyNames()),
+	}
+
+	for _, c := range cfg.components {
+		attrs = append(attrs, slog.Any(c.name, c.cfg))
+	}
+
+	return attrs
+}
+
+// Snapshot returns a redacted, JSON-serializable view of the root command's
+// configuration and all sub-configs registered with
+// [RootCommandConfig.Register]. It is derived from the same [slog.LogValuer]
+// implementations as the startup summary, so credentials are redacted in
+// one place only.
+func (cfg *RootCommandConfig) Snapshot() map[string]any {
+	return attrsToMap(cfg.snapshotAttrs())
+}
+
+// SnapshotHandler returns an [http.Handler] that responds with the
+// [RootCommandConfig.Snapshot]. The root command serves it at /admin/config
+// if admin keys are configured.
+func (cfg *RootCommandConfig) SnapshotHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			rw.Header().Set("Allow", http.MethodGet)
+			phttp.EncodeErr(rw, http.StatusMethodNotAllowed, "config must be requested with a GET request")
+			return
+		}
+
+		phttp.Encode(rw, http.StatusOK, cfg.Snapshot())
+	})
+}
+
+// NewConfigCommand returns a command with a "print" subcommand that writes
+// the [RootCommandConfig.Snapshot] as indented JSON to stdout.
+func NewConfigCommand(cfg *RootCommandConfig) *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Inspects the effective configuration",
+		Commands: []*cli.Command{
+			{
+				Name:  "print",
+				Usage: "Prints the effective configuration with redacted credentials as JSON",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					enc := json.NewEncoder(os.Stdout)
+					enc.SetIndent("", "  ")
+					return enc.Encode(cfg.Snapshot())
+				},
+			},
+		},
+	}
+}
+
+// attrsToMap converts the given attributes into a map that can be encoded as
+// JSON. Groups become nested maps.
+func attrsToMap(attrs []slog.Attr) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, attr := range attrs {
+		m[attr.Key] = valueToAny(attr.Value)
+	}
+	return m
+}
+
+func valueToAny(v slog.Value) any {
+	v = v.Resolve()
+
+	switch v.Kind() {
+	case slog.KindGroup:
+		return attrsToMap(v.Group())
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		return v.Any()
+	default:
+		return v.Any()
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func TestRootCommandConfig_Snapshot(t *testing.T) {
+	_, cfg := NewRootCommand(&cli.Command{Name: "test"})
+	cfg.AdminKeys = []string{"secret-key"}
+	cfg.Register("clickhouse", validCfgFn())
+
+	snapshot := cfg.Snapshot()
+
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-key")
+
+	ch, ok := snapshot["clickhouse"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "*****", ch["password"])
+	assert.Equal(t, "database", ch["database"])
+
+	admin, ok := snapshot["admin"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, true, admin["enabled"])
+	assert.Equal(t, int64(1), admin["keys"])
+
+	assert.Equal(t, []string{"log.level"}, snapshot["reload"])
+}
+
+func TestRootCommandConfig_SnapshotHandler(t *testing.T) {
+	_, cfg := NewRootCommand(&cli.Command{Name: "test"})
+	cfg.Register("clickhouse", validCfgFn())
+
+	rec := httptest.NewRecorder()
+	cfg.SnapshotHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Contains(t, body.Data, "clickhouse")
+
+	rec = httptest.NewRecorder()
+	cfg.SnapshotHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/config", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/tenant"
+)
+
+// TenantLimitsFlags generates a slice of [cli.Flag] for configuring the
+// per-tenant limits of a [tenant.Limiter]. The limits of individual tenants
+// are read from a JSON file, while the remaining flags define the default
+// limit that all other tenants share.
+func TenantLimitsFlags(envPrefix string, cfg *tenant.LimitsConfig) []cli.Flag {
+	envPrefix = buildEnvPrefix(envPrefix)
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "tenant.limits.file",
+			Usage:       "Path to a JSON file with per project/network limits",
+			Sources:     cli.EnvVars(envPrefix + "TENANT_LIMITS_FILE"),
+			Value:       cfg.File,
+			Destination: &cfg.File,
+			Category:    flagCategoryTenant,
+		},
+		&cli.Int64Flag{
+			Name:        "tenant.limits.concurrency",
+			Usage:       "Maximum number of concurrent requests of all tenants without a limit in the file (0 means unlimited)",
+			Sources:     cli.EnvVars(envPrefix + "TENANT_LIMITS_CONCURRENCY"),
+			Value:       cfg.Default.Concurrency,
+			Destination: &cfg.Default.Concurrency,
+			Category:    flagCategoryTenant,
+		},
+		&cli.Float64Flag{
+			Name:        "tenant.limits.rate",
+			Usage:       "Maximum number of requests per second of all tenants without a limit in the file (0 means unlimited)",
+			Sources:     cli.EnvVars(envPrefix + "TENANT_LIMITS_RATE"),
+			Value:       cfg.Default.Rate,
+			Destination: &cfg.Default.Rate,
+			Category:    flagCategoryTenant,
+		},
+		&cli.IntFlag{
+			Name:        "tenant.limits.burst",
+			Usage:       "Number of requests of all tenants without a limit in the file that may exceed the rate at once",
+			Sources:     cli.EnvVars(envPrefix + "TENANT_LIMITS_BURST"),
+			Value:       cfg.Default.Burst,
+			Destination: &cfg.Default.Burst,
+			Category:    flagCategoryTenant,
+		},
+		&cli.DurationFlag{
+			Name:        "tenant.limits.wait",
+			Usage:       "How long a request waits for a free concurrency slot of its tenant before it is rejected",
+			Sources:     cli.EnvVars(envPrefix + "TENANT_LIMITS_WAIT"),
+			Value:       cfg.MaxWait,
+			Destination: &cfg.MaxWait,
+			Category:    flagCategoryTenant,
+		},
+	}
+}
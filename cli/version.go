@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/errs"
+)
+
+// NewVersionCommand returns a "version" command that prints the commit,
+// dirty flag, build time, Go version, and the versions of the main module
+// and all its dependencies as embedded by the Go toolchain, either human
+// readable or as JSON:
+//
+//	app version
+//	app version --format json | jq -r .commit
+//
+// The root command created by [NewRootCommand] includes it unless the
+// application defines its own version command.
+func NewVersionCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "version",
+		Usage: "Prints the build and dependency versions of this binary",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "The output format (text, json)",
+				Value: "text",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			bi := buildInfo()
+
+			switch format := c.String("format"); format {
+			case "text":
+				return writeVersionText(c.Root().Writer, bi)
+			case "json":
+				enc := json.NewEncoder(c.Root().Writer)
+				enc.SetIndent("", "  ")
+				return enc.Encode(bi)
+			default:
+				return errs.Newf(errs.InvalidInput, "unsupported version format %q", format)
+			}
+		},
+	}
+}
+
+func writeVersionText(w io.Writer, bi *BuildInfo) error {
+	var sb strings.Builder
+
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+	commit := bi.Commit
+	if commit == "" {
+		commit = "unknown"
+	} else if bi.Dirty {
+		commit += " (dirty)"
+	}
+	fmt.Fprintf(tw, "Commit:\t%s\n", commit)
+
+	if !bi.Time.IsZero() {
+		fmt.Fprintf(tw, "Build time:\t%s\n", bi.Time.UTC().Format(time.RFC3339))
+	}
+
+	fmt.Fprintf(tw, "Go version:\t%s\n", bi.GoVersion)
+
+	if bi.Module.Path != "" {
+		fmt.Fprintf(tw, "Module:\t%s\n", buildModuleString(bi.Module))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(bi.Deps) > 0 {
+		sb.WriteString("\nDependencies:\n")
+		tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		for _, dep := range bi.Deps {
+			fmt.Fprintf(tw, "  %s\n", strings.Replace(buildModuleString(dep), " ", "\t", 1))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// buildModuleString formats a module as "path version", followed by its
+// replacement, if any.
+func buildModuleString(m BuildModule) string {
+	s := strings.TrimSpace(m.Path + " " + m.Version)
+	if m.Replace != nil {
+		s += " => " + buildModuleString(*m.Replace)
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func TestNewBuildInfo(t *testing.T) {
+	bi := newBuildInfo(&debug.BuildInfo{
+		GoVersion: "go1.25.1",
+		Main:      debug.Module{Path: "github.com/probe-lab/app", Version: "v1.2.3"},
+		Deps: []*debug.Module{
+			{Path: "github.com/urfave/cli/v3", Version: "v3.8.0"},
+			{Path: "github.com/probe-lab/go-commons", Version: "v0.1.0", Replace: &debug.Module{Path: "../go-commons"}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.modified", Value: "true"},
+			{Key: "vcs.time", Value: "2025-06-01T12:00:00Z"},
+		},
+	})
+
+	assert.Equal(t, "0123456789abcdef", bi.Commit)
+	assert.True(t, bi.Dirty)
+	assert.Equal(t, "2025-06-01T12:00:00Z", bi.Time.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, "go1.25.1", bi.GoVersion)
+	assert.Equal(t, BuildModule{Path: "github.com/probe-lab/app", Version: "v1.2.3"}, bi.Module)
+	require.Len(t, bi.Deps, 2)
+	assert.Equal(t, "../go-commons", bi.Deps[1].Replace.Path)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeVersionText(&buf, bi))
+	out := buf.String()
+	assert.Contains(t, out, "Commit:      0123456789abcdef (dirty)\n")
+	assert.Contains(t, out, "Build time:  2025-06-01T12:00:00Z\n")
+	assert.Contains(t, out, "Module:      github.com/probe-lab/app v1.2.3\n")
+	assert.Contains(t, out, "  github.com/urfave/cli/v3         v3.8.0\n")
+	assert.Contains(t, out, "  github.com/probe-lab/go-commons  v0.1.0 => ../go-commons\n")
+}
+
+func TestNewVersionCommand(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Name:     "test",
+		Writer:   &buf,
+		Commands: []*cli.Command{NewVersionCommand()},
+	}
+
+	require.NoError(t, cmd.Run(context.Background(), []string{"test", "version", "--format", "json"}))
+
+	var bi BuildInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &bi))
+	assert.NotEmpty(t, bi.GoVersion)
+
+	assert.Error(t, cmd.Run(context.Background(), []string{"test", "version", "--format", "yaml"}))
+}
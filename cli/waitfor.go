@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/probe-lab/go-commons/db"
+	"github.com/probe-lab/go-commons/errs"
+)
+
+// NewWaitForCommand returns a "wait-for" command that blocks until all given
+// targets are reachable or the timeout elapsed. It replaces wait-for-it.sh
+// scripts in compose files and CI:
+//
+//	app wait-for --timeout 2m clickhouse:9000 http://collector:13133 grpc://api:8080
+//
+// Targets are tcp://host:port or plain host:port addresses, http(s):// URLs
+// that must return a 2xx status, grpc://host:port[/service] gRPC health
+// endpoints, and clickhouse:// or postgres:// DSNs. The command fails with
+// [ErrDependencyUnavailable] if a target is still unreachable after the
+// timeout, which maps to [ExitUnavailable].
+func NewWaitForCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "wait-for",
+		Usage:     "Waits until the given targets become reachable",
+		ArgsUsage: "<target>...",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "The maximum time to wait for all targets",
+				Value: time.Minute,
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			targets := c.Args().Slice()
+			if len(targets) == 0 {
+				return errs.Newf(errs.InvalidInput, "wait-for requires at least one target")
+			}
+
+			deps := make([]dependency, len(targets))
+			for i, target := range targets {
+				dep, err := parseWaitForTarget(target)
+				if err != nil {
+					return errs.Wrapf(errs.InvalidInput, err, "parse target %s", target)
+				}
+				deps[i] = dep
+			}
+
+			return waitForDependencies(ctx, deps, c.Duration("timeout"))
+		},
+	}
+}
+
+// parseWaitForTarget returns the dependency for a wait-for target. The name
+// of the dependency omits credentials.
+func parseWaitForTarget(target string) (dependency, error) {
+	if !strings.Contains(target, "://") {
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			return dependency{}, err
+		}
+		return dependency{name: target, check: TCPDependency(target)}, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return dependency{}, err
+	}
+
+	name := u.Redacted()
+
+	switch u.Scheme {
+	case "tcp":
+		if u.Port() == "" {
+			return dependency{}, fmt.Errorf("tcp target must include a port")
+		}
+		return dependency{name: name, check: TCPDependency(u.Host)}, nil
+
+	case "http", "https":
+		return dependency{name: name, check: HTTPDependency(target)}, nil
+
+	case "grpc":
+		if u.Port() == "" {
+			return dependency{}, fmt.Errorf("grpc target must include a port")
+		}
+		service := strings.TrimPrefix(u.Path, "/")
+		return dependency{name: name, check: GRPCHealthDependency(u.Host, service)}, nil
+
+	case "clickhouse":
+		cfg, err := db.ParseClickHouseDSN(target)
+		if err != nil {
+			return dependency{}, err
+		}
+		return dependency{name: name, check: ClickHouseDependency(cfg)}, nil
+
+	case "postgres", "postgresql":
+		check, err := PostgresDependency(target)
+		if err != nil {
+			return dependency{}, err
+		}
+		return dependency{name: name, check: check}, nil
+
+	default:
+		return dependency{}, fmt.Errorf("unsupported target scheme %q", u.Scheme)
+	}
+}
@@ -0,0 +1,62 @@
+// Package config provides helpers that are shared by the configuration
+// structs of this module and the services that use them.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Validator is implemented by configuration structs that can check their
+// fields for validity, e.g., all configs in this module.
+type Validator interface {
+	Validate() error
+}
+
+// named is a [Validator] whose errors are prefixed with a name.
+type named struct {
+	name string
+	v    Validator
+}
+
+// Named returns a [Validator] that prefixes the errors of v with name so
+// that the errors of [ValidateAll] tell which config is invalid.
+func Named(name string, v Validator) Validator {
+	return &named{name: name, v: v}
+}
+
+func (n *named) Validate() error {
+	if err := validate(n.v); err != nil {
+		return fmt.Errorf("%s: %w", n.name, err)
+	}
+	return nil
+}
+
+// ValidateAll validates all validators and returns the joined errors of all
+// that failed instead of stopping at the first one, so that everything that
+// is wrong can be reported at once. Nil validators are reported as errors.
+func ValidateAll(validators ...Validator) error {
+	var errs []error
+	for _, v := range validators {
+		if err := validate(v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate calls v.Validate and reports nil interfaces and nil pointers as
+// errors instead of panicking in Validate implementations that don't check
+// their receiver.
+func validate(v Validator) error {
+	if v == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return fmt.Errorf("config is nil")
+	}
+
+	return v.Validate()
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	err error
+}
+
+func (c *testConfig) Validate() error {
+	return c.err
+}
+
+func TestValidateAll(t *testing.T) {
+	assert.NoError(t, ValidateAll())
+	assert.NoError(t, ValidateAll(&testConfig{}, Named("db", &testConfig{})))
+
+	errHost := errors.New("host must not be empty")
+	errPort := errors.New("port must be a positive integer")
+
+	err := ValidateAll(
+		Named("clickhouse", &testConfig{err: errHost}),
+		&testConfig{},
+		Named("postgres", &testConfig{err: errPort}),
+	)
+	assert.ErrorIs(t, err, errHost)
+	assert.ErrorIs(t, err, errPort)
+	assert.EqualError(t, err, "clickhouse: host must not be empty\npostgres: port must be a positive integer")
+}
+
+func TestValidateAll_Nil(t *testing.T) {
+	var cfg *testConfig
+	assert.EqualError(t, ValidateAll(nil, Named("db", cfg)), "config is nil\ndb: config is nil")
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"iter"
 	"log/slog"
+	"reflect"
 	"regexp"
 	"runtime"
 	"sync"
@@ -15,6 +17,8 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+
+	"github.com/probe-lab/go-commons/sem"
 )
 
 // ErrStopped is returned by Submit and Flush when called after Stop.
@@ -31,6 +35,15 @@ var (
 type BatchInserterConfig[T any] struct {
 	// MaxBatchSize is the maximum number of rows to buffer before flushing.
 	MaxBatchSize int
+	// MaxBatchBytes is the approximate maximum number of bytes to buffer
+	// before flushing. This bounds the memory of batches with large rows.
+	// Zero disables the byte limit.
+	MaxBatchBytes int
+	// RowSize returns the approximate size of a row in bytes and is used to
+	// enforce MaxBatchBytes and to record the db_insert.bytes metric. If nil,
+	// the size is estimated from the row's in-memory representation via
+	// reflection if MaxBatchBytes is set and not recorded otherwise.
+	RowSize func(row T) int
 	// FlushInterval is the maximum time between flushes.
 	FlushInterval time.Duration
 	// ChannelBuffer is the capacity of the internal row channel. A larger buffer
@@ -38,10 +51,28 @@ type BatchInserterConfig[T any] struct {
 	// busy flushing. The memory cost is ChannelBuffer * sizeof(T), allocated
 	// upfront. Defaults to MaxBatchSize when zero.
 	ChannelBuffer int
+	// FlushLimiter bounds the number of concurrent flushes. Share a single
+	// semaphore between inserters to limit the number of batches that are
+	// simultaneously in flight to ClickHouse. Each flush acquires a weight
+	// of one. If nil, flushes are not limited.
+	FlushLimiter *sem.Weighted
 	// Meter is the OTel meter used to record batch inserter metrics. If nil,
 	// the global meter provider is used, which is a no-op when
 	// [tele.ServeMetrics] has not been called with metrics enabled.
 	Meter metric.Meter
+	// Insert holds the settings for inserts into Distributed and Replicated
+	// tables that are sent with every batch. If nil, the server defaults apply.
+	Insert *ClickHouseInsertOptions
+	// DeduplicationToken returns the insert_deduplication_token for a batch
+	// and overrides [ClickHouseInsertOptions.DeduplicationToken]. Use
+	// [HashDeduplicationToken] to derive the token from the rows. If nil, no
+	// per-batch token is sent.
+	DeduplicationToken func(rows []T) string
+	// DeadLetter persists the rows of failed flushes, e.g., to a file with
+	// [NewFileDeadLetter] or a secondary table with [NewTableDeadLetter].
+	// Rows that were dead-lettered are not dropped. If the sink fails too,
+	// the rows are dropped. If nil, rows of failed flushes are dropped.
+	DeadLetter DeadLetterSink[T]
 	// OnDroppedRows is called when a flush fails and rows are dropped.
 	// The slice contains the rows that were lost; the error is the flush error.
 	// The callback is always invoked in addition to slog error logging.
@@ -76,6 +107,16 @@ func (cfg *BatchInserterConfig[T]) Validate() error {
 		return fmt.Errorf("channel buffer must be a non-negative integer")
 	}
 
+	if cfg.MaxBatchBytes < 0 {
+		return fmt.Errorf("max batch bytes must be a non-negative integer")
+	}
+
+	if cfg.Insert != nil {
+		if err := cfg.Insert.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -94,7 +135,8 @@ func (cfg *BatchInserterConfig[T]) Validate() error {
 //
 // Rows are dropped on flush failure. The [driver.Batch] is a stateful protocol
 // object that cannot be retried; re-batching would require a new PrepareBatch
-// call anyway. Use [BatchInserterConfig.OnDroppedRows] to handle dropped rows.
+// call anyway. Use [BatchInserterConfig.DeadLetter] to persist the rows of
+// failed flushes or [BatchInserterConfig.OnDroppedRows] to handle dropped rows.
 //
 // Call [BatchInserter.Start] before [BatchInserter.Submit] or [BatchInserter.Flush],
 // and [BatchInserter.Stop] to drain and shut down.
@@ -117,11 +159,17 @@ type BatchInserter[T any] struct {
 	// initialized when Start is called.
 	buf []T
 
+	// bufBytes is the approximate size of all rows in buf. Owned exclusively
+	// by the run goroutine.
+	bufBytes int
+
 	// OTel instruments; always valid (no-op if metrics not configured).
-	mRowsFlushed   metric.Int64Counter     // total rows successfully flushed
-	mRowsDropped   metric.Int64Counter     // total rows lost due to flush errors
-	mFlushDuration metric.Float64Histogram // time per flush operation (seconds)
-	mFlushSize     metric.Int64Histogram   // rows per flush attempt
+	mRowsFlushed      metric.Int64Counter     // total rows successfully flushed
+	mRowsDropped      metric.Int64Counter     // total rows lost due to flush errors
+	mRowsDeadLettered metric.Int64Counter     // total rows persisted to the dead letter sink
+	mFlushDuration    metric.Float64Histogram // time per flush operation (seconds)
+	mFlushSize        metric.Int64Histogram   // rows per flush attempt
+	mInsert           *InsertMetrics          // shared db_insert.* instruments
 }
 
 // NewBatchInserter creates a new [BatchInserter]. Call [BatchInserter.Start]
@@ -181,6 +229,12 @@ func NewBatchInserter[T any](conn driver.Conn, table string, cfg *BatchInserterC
 		slog.Warn("Failed to create metric instrument", "name", "batch_inserter.rows_dropped", "err", err)
 	}
 
+	if b.mRowsDeadLettered, err = meter.Int64Counter("batch_inserter.rows_dead_lettered",
+		metric.WithDescription("Total number of rows of failed flushes persisted to the dead letter sink"),
+	); err != nil {
+		slog.Warn("Failed to create metric instrument", "name", "batch_inserter.rows_dead_lettered", "err", err)
+	}
+
 	if b.mFlushDuration, err = meter.Float64Histogram("batch_inserter.flush_duration",
 		metric.WithDescription("Duration of each flush operation"),
 		metric.WithUnit("s"),
@@ -194,6 +248,8 @@ func NewBatchInserter[T any](conn driver.Conn, table string, cfg *BatchInserterC
 		slog.Warn("Failed to create metric instrument", "name", "batch_inserter.flush_size", "err", err)
 	}
 
+	b.mInsert = NewInsertMetrics(meter)
+
 	return b, nil
 }
 
@@ -221,12 +277,13 @@ func (b *BatchInserter[T]) run(ctx context.Context) {
 	for {
 		select {
 		case row := <-b.rowCh:
-			b.buf = append(b.buf, row)
-			if len(b.buf) < b.cfg.MaxBatchSize {
+			b.add(row)
+			trigger, full := b.full()
+			if !full {
 				continue
 			}
 
-			_ = b.doFlush(ctx, "size") // error logged and forwarded via OnDroppedRows
+			_ = b.doFlush(ctx, trigger) // error logged and forwarded via OnDroppedRows
 			ticker.Reset(b.cfg.FlushInterval)
 
 		case <-ticker.C:
@@ -247,6 +304,32 @@ func (b *BatchInserter[T]) run(ctx context.Context) {
 	}
 }
 
+// add appends the row to buf and accounts for its size. Owned exclusively by
+// the run goroutine.
+func (b *BatchInserter[T]) add(row T) {
+	b.buf = append(b.buf, row)
+
+	if b.cfg.RowSize != nil {
+		b.bufBytes += b.cfg.RowSize(row)
+	} else if b.cfg.MaxBatchBytes > 0 {
+		b.bufBytes += estimateSize(reflect.ValueOf(row))
+	}
+}
+
+// full reports whether buf reached the row or byte limit together with the
+// corresponding flush trigger. Owned exclusively by the run goroutine.
+func (b *BatchInserter[T]) full() (string, bool) {
+	if len(b.buf) >= b.cfg.MaxBatchSize {
+		return "size", true
+	}
+
+	if b.cfg.MaxBatchBytes > 0 && b.bufBytes >= b.cfg.MaxBatchBytes {
+		return "bytes", true
+	}
+
+	return "", false
+}
+
 // drain flushes all remaining rows during shutdown. It loops until both buf
 // and rowCh are empty. On a flush error, any rows still in rowCh are drained
 // and reported via OnDroppedRows. Owned exclusively by the run goroutine.
@@ -270,12 +353,16 @@ func (b *BatchInserter[T]) doFlush(ctx context.Context, trigger string) error {
 	// Drain any rows buffered in rowCh so that a flush issued right
 	// after Submit (which returns as soon as the channel accepts the row)
 	// captures all pending rows regardless of select ordering.
-	// Cap at MaxBatchSize to keep flush payloads bounded.
+	// Cap at MaxBatchSize and MaxBatchBytes to keep flush payloads bounded.
 drain:
-	for len(b.buf) < b.cfg.MaxBatchSize {
+	for {
+		if _, full := b.full(); full {
+			break
+		}
+
 		select {
 		case row := <-b.rowCh:
-			b.buf = append(b.buf, row)
+			b.add(row)
 		default:
 			break drain
 		}
@@ -287,11 +374,12 @@ drain:
 
 	// Capture rows and allocate a new backing array for buf so that the
 	// rows slice remains stable for the OnDroppedRows callback.
-	rows := b.buf
+	rows, rowBytes := b.buf, b.bufBytes
 	b.buf = make([]T, 0, b.cfg.MaxBatchSize)
+	b.bufBytes = 0
 
 	start := time.Now()
-	err := b.sendBatch(ctx, rows)
+	err := b.limitedSendBatch(ctx, rows)
 	elapsed := time.Since(start)
 
 	flushAttrs := metric.WithAttributes(
@@ -300,8 +388,13 @@ drain:
 	)
 	b.mFlushDuration.Record(ctx, elapsed.Seconds(), flushAttrs)
 	b.mFlushSize.Record(ctx, int64(len(rows)), flushAttrs)
+	b.mInsert.Record(ctx, b.table, len(rows), rowBytes, elapsed, err)
 
 	if err != nil {
+		if b.deadLetter(ctx, rows, trigger, err) {
+			return err
+		}
+
 		b.mRowsDropped.Add(ctx, int64(len(rows)), metric.WithAttributes(attrKeyTable.String(b.table)))
 		slog.Error("Failed to flush batch",
 			"table", b.table,
@@ -319,6 +412,45 @@ drain:
 	return nil
 }
 
+// deadLetter passes the rows of a failed flush to the dead letter sink, if
+// configured, and reports whether the rows were persisted. Owned exclusively
+// by the run goroutine.
+func (b *BatchInserter[T]) deadLetter(ctx context.Context, rows []T, trigger string, flushErr error) bool {
+	if b.cfg.DeadLetter == nil {
+		return false
+	}
+
+	if err := b.cfg.DeadLetter.DeadLetter(ctx, b.table, rows, flushErr); err != nil {
+		slog.Error("Failed to dead-letter rows of failed flush", "table", b.table, "rows", len(rows), "err", err)
+		return false
+	}
+
+	b.mRowsDeadLettered.Add(ctx, int64(len(rows)), metric.WithAttributes(attrKeyTable.String(b.table)))
+	slog.Warn("Failed to flush batch, dead-lettered rows",
+		"table", b.table,
+		"dead_lettered_rows", len(rows),
+		"trigger", trigger,
+		"err", flushErr,
+	)
+
+	return true
+}
+
+// limitedSendBatch sends the batch after acquiring the flush limiter, if
+// configured. The time spent waiting counts towards the flush duration.
+func (b *BatchInserter[T]) limitedSendBatch(ctx context.Context, rows []T) error {
+	if b.cfg.FlushLimiter == nil {
+		return b.sendBatch(ctx, rows)
+	}
+
+	if err := b.cfg.FlushLimiter.Acquire(ctx, 1); err != nil {
+		return fmt.Errorf("acquire flush limiter: %w", err)
+	}
+	defer b.cfg.FlushLimiter.Release(1)
+
+	return b.sendBatch(ctx, rows)
+}
+
 // Submit submits a row to the batch. It blocks until the background goroutine
 // accepts the row, the context is canceled, or Stop has been called.
 func (b *BatchInserter[T]) Submit(ctx context.Context, row T) error {
@@ -353,6 +485,18 @@ func (b *BatchInserter[T]) Submit(ctx context.Context, row T) error {
 	}
 }
 
+// SubmitSeq submits all rows of the sequence. It stops at the first row that
+// cannot be submitted and returns the corresponding error. Rows that were
+// submitted before remain in the batch.
+func (b *BatchInserter[T]) SubmitSeq(ctx context.Context, rows iter.Seq[T]) error {
+	for row := range rows {
+		if err := b.Submit(ctx, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Flush requests an immediate flush of all buffered rows and blocks until the
 // flush completes, the context is canceled, or Stop has been called.
 func (b *BatchInserter[T]) Flush(ctx context.Context) error {
@@ -396,26 +540,101 @@ func (b *BatchInserter[T]) Stop(ctx context.Context) error {
 	}
 }
 
+// insertOptions returns the insert options for the given batch or nil if
+// neither insert options nor a deduplication token function are configured.
+func (b *BatchInserter[T]) insertOptions(rows []T) *ClickHouseInsertOptions {
+	if b.cfg.Insert == nil && b.cfg.DeduplicationToken == nil {
+		return nil
+	}
+
+	var opts ClickHouseInsertOptions
+	if b.cfg.Insert != nil {
+		opts = *b.cfg.Insert
+	}
+
+	if b.cfg.DeduplicationToken != nil {
+		opts.DeduplicationToken = b.cfg.DeduplicationToken(rows)
+	}
+
+	return &opts
+}
+
 func (b *BatchInserter[T]) sendBatch(ctx context.Context, rows []T) error {
-	batch, err := b.conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", b.table))
+	if opts := b.insertOptions(rows); opts != nil {
+		ctx = opts.Context(ctx)
+	}
+
+	return insertStructs(ctx, b.conn, b.table, rows)
+}
+
+// insertStructs inserts the rows into table in a single batch.
+func insertStructs[T any](ctx context.Context, conn driver.Conn, table string, rows []T) error {
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", table))
 	if err != nil {
-		return fmt.Errorf("prepare batch for %s: %w", b.table, err)
+		return fmt.Errorf("prepare batch for %s: %w", table, err)
 	}
 
 	for i := range rows {
 		if err := batch.AppendStruct(&rows[i]); err != nil {
 			_ = batch.Abort()
-			return fmt.Errorf("append struct to %s: %w", b.table, err)
+			return fmt.Errorf("append struct to %s: %w", table, err)
 		}
 	}
 
 	if err := batch.Send(); err != nil {
-		return fmt.Errorf("send batch for %s: %w", b.table, err)
+		return fmt.Errorf("send batch for %s: %w", table, err)
 	}
 
 	return nil
 }
 
+var timeType = reflect.TypeFor[time.Time]()
+
+// estimateSize approximates the number of bytes the given value occupies in
+// memory, including the contents of strings, slices, maps, and pointers.
+func estimateSize(v reflect.Value) int {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return 0
+	case reflect.String:
+		return v.Len()
+	case reflect.Slice, reflect.Array:
+		switch v.Type().Elem().Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Struct, reflect.Map, reflect.Pointer, reflect.Interface:
+			n := 0
+			for i := range v.Len() {
+				n += estimateSize(v.Index(i))
+			}
+			return n
+		default:
+			return v.Len() * int(v.Type().Elem().Size())
+		}
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return 8 // don't follow the location pointer
+		}
+		n := 0
+		for i := range v.NumField() {
+			n += estimateSize(v.Field(i))
+		}
+		return n
+	case reflect.Map:
+		n := 0
+		iter := v.MapRange()
+		for iter.Next() {
+			n += estimateSize(iter.Key()) + estimateSize(iter.Value())
+		}
+		return n
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		return estimateSize(v.Elem())
+	default:
+		return int(v.Type().Size())
+	}
+}
+
 // batchLifecycle is satisfied by [BatchInserter][T], enabling [BatchInserterGroup]
 // to manage multiple typed inserters without type parameters.
 type batchLifecycle interface {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"reflect"
 	"runtime"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -15,6 +16,9 @@ import (
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/probe-lab/go-commons/iterutil"
+	"github.com/probe-lab/go-commons/sem"
 )
 
 // Compile-time interface checks.
@@ -166,6 +170,24 @@ func TestBatchInserterConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "negative max batch bytes",
+			cfgFn: func() *BatchInserterConfig[testRow] {
+				cfg := DefaultBatchInserterConfig[testRow]()
+				cfg.MaxBatchBytes = -1
+				return cfg
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid insert quorum",
+			cfgFn: func() *BatchInserterConfig[testRow] {
+				cfg := DefaultBatchInserterConfig[testRow]()
+				cfg.Insert = &ClickHouseInsertOptions{Quorum: "majority"}
+				return cfg
+			},
+			wantErr: true,
+		},
 		{
 			name: "channel buffer exceeds max batch size",
 			cfgFn: func() *BatchInserterConfig[testRow] {
@@ -242,6 +264,21 @@ func TestBatchInserter_Flush_sendsRows(t *testing.T) {
 	require.NoError(t, b.Stop(context.Background()))
 }
 
+func TestBatchInserter_SubmitSeq(t *testing.T) {
+	batch := &mockBatch{}
+	conn := &mockConn{batch: batch}
+	cfg := &BatchInserterConfig[testRow]{MaxBatchSize: 10, FlushInterval: time.Hour}
+	b := newTestInserter(t, conn, cfg)
+	b.Start(context.Background())
+
+	rows := iterutil.Map(slices.Values([]int{1, 2, 3}), func(v int) testRow { return testRow{Value: v} })
+	require.NoError(t, b.SubmitSeq(context.Background(), rows))
+	require.NoError(t, b.Stop(context.Background()))
+
+	assert.Len(t, batch.appended, 3)
+	assert.ErrorIs(t, b.SubmitSeq(context.Background(), rows), ErrStopped)
+}
+
 func TestBatchInserter_Add_flushOnMaxSize(t *testing.T) {
 	batch := &mockBatch{}
 	conn := &mockConn{batch: batch}
@@ -259,6 +296,76 @@ func TestBatchInserter_Add_flushOnMaxSize(t *testing.T) {
 	assert.Len(t, batch.appended, 3)
 }
 
+func TestBatchInserter_Add_flushOnMaxBytes(t *testing.T) {
+	batch := &mockBatch{}
+	conn := &mockConn{batch: batch}
+	cfg := &BatchInserterConfig[testRow]{
+		MaxBatchSize:  100,
+		MaxBatchBytes: 20,
+		RowSize:       func(testRow) int { return 10 },
+		FlushInterval: time.Hour,
+	}
+	b := newTestInserter(t, conn, cfg)
+	b.Start(context.Background())
+
+	for i := range 5 {
+		require.NoError(t, b.Submit(context.Background(), testRow{Value: i}))
+	}
+	require.NoError(t, b.Stop(context.Background()))
+
+	assert.Len(t, batch.appended, 5)
+	for i, size := range batch.sentSizes {
+		assert.LessOrEqual(t, size, 2, "send #%d had %d rows", i, size)
+	}
+}
+
+func TestBatchInserter_Flush_flushLimiter(t *testing.T) {
+	batch := &mockBatch{}
+	conn := &mockConn{batch: batch}
+	limiter := sem.NewWeighted("test", 1, nil)
+	cfg := &BatchInserterConfig[testRow]{MaxBatchSize: 10, FlushInterval: time.Hour, FlushLimiter: limiter}
+	b := newTestInserter(t, conn, cfg)
+	b.Start(context.Background())
+
+	require.NoError(t, b.Submit(context.Background(), testRow{Value: 1}))
+
+	// the limiter is exhausted, so the flush must wait until the deadline
+	require.True(t, limiter.TryAcquire(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, b.Flush(ctx))
+	assert.Equal(t, 0, conn.prepareCalls)
+
+	limiter.Release(1)
+	require.NoError(t, b.Stop(context.Background()))
+	assert.Equal(t, 1, conn.prepareCalls)
+	assert.True(t, limiter.TryAcquire(1), "flush must release the limiter")
+}
+
+func Test_estimateSize(t *testing.T) {
+	type row struct {
+		ID        int64
+		Name      string
+		Tags      []string
+		Addrs     []uint32
+		Optional  *string
+		Timestamp time.Time
+	}
+
+	name := "optional"
+	r := row{
+		ID:        1,
+		Name:      "peer",
+		Tags:      []string{"a", "bc"},
+		Addrs:     []uint32{1, 2, 3},
+		Optional:  &name,
+		Timestamp: time.Now(),
+	}
+
+	assert.Equal(t, 8+4+3+12+8+8, estimateSize(reflect.ValueOf(r)))
+	assert.Equal(t, 8+8, estimateSize(reflect.ValueOf(row{ID: 1})))
+}
+
 func TestBatchInserter_Stop_finalFlush(t *testing.T) {
 	batch := &mockBatch{}
 	conn := &mockConn{batch: batch}
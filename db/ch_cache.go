@@ -0,0 +1,274 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	attrKeyCache  = attribute.Key("cache")
+	attrKeyResult = attribute.Key("result")
+)
+
+// CachedConfig holds configuration for a [Cached] query cache.
+type CachedConfig struct {
+	// TTL is the duration for which query results are served from the cache.
+	TTL time.Duration
+	// MaxEntries bounds the number of cached results. When the cache is
+	// full, expired results are evicted first and then the results that
+	// expire soonest.
+	MaxEntries int
+	// Meter is the OTel meter used to record cache metrics. If nil, the
+	// global meter provider is used.
+	Meter metric.Meter
+}
+
+// DefaultCachedConfig returns a [CachedConfig] with sensible defaults.
+func DefaultCachedConfig() *CachedConfig {
+	return &CachedConfig{
+		TTL:        time.Minute,
+		MaxEntries: 1000,
+	}
+}
+
+// Validate checks the [CachedConfig] for validity.
+func (cfg *CachedConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if cfg.TTL <= 0 {
+		return fmt.Errorf("cache ttl must be a positive duration")
+	}
+
+	if cfg.MaxEntries <= 0 {
+		return fmt.Errorf("cache max entries must be a positive integer")
+	}
+
+	return nil
+}
+
+// cacheEntry is a cached or in-flight query result.
+type cacheEntry[T any] struct {
+	ready   chan struct{} // closed when rows and err are set
+	rows    []T
+	err     error
+	expires time.Time
+}
+
+// Cached is a read-through cache for ClickHouse queries that select rows of
+// type T. Results are keyed by the query and its arguments and served from
+// memory for [CachedConfig.TTL], so expensive aggregate queries behind
+// dashboards only hit ClickHouse once per TTL. Concurrent requests for the
+// same uncached key share a single query. Errors are not cached.
+//
+//	stats, _ := db.NewCached[AgentStat]("agent_stats", conn, db.DefaultCachedConfig())
+//	rows, err := stats.Select(ctx, "SELECT agent, count() AS n FROM visits WHERE day = ? GROUP BY agent", day)
+//
+// The returned slices are shared between callers and must not be modified.
+type Cached[T any] struct {
+	name     string
+	cfg      *CachedConfig
+	selectFn func(ctx context.Context, dest *[]T, query string, args ...any) error
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry[T]
+
+	mRequests metric.Int64Counter
+}
+
+// NewCached creates a [Cached] that selects rows from conn. The name is
+// recorded as the cache attribute of the db_cache.requests metric.
+func NewCached[T any](name string, conn driver.Conn, cfg *CachedConfig) (*Cached[T], error) {
+	if conn == nil {
+		return nil, fmt.Errorf("conn must not be nil")
+	}
+
+	return newCached(name, func(ctx context.Context, dest *[]T, query string, args ...any) error {
+		return conn.Select(ctx, dest, query, args...)
+	}, cfg)
+}
+
+func newCached[T any](name string, selectFn func(ctx context.Context, dest *[]T, query string, args ...any) error, cfg *CachedConfig) (*Cached[T], error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("cache config: %w", err)
+	}
+
+	meter := cfg.Meter
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter("github.com/probe-lab/go-commons/db")
+	}
+
+	c := &Cached[T]{
+		name:     name,
+		cfg:      cfg,
+		selectFn: selectFn,
+		entries:  make(map[string]*cacheEntry[T]),
+	}
+
+	var err error
+	if c.mRequests, err = meter.Int64Counter("db_cache.requests",
+		metric.WithDescription("Total number of cached query requests by result (hit, miss, shared)"),
+	); err != nil {
+		return nil, fmt.Errorf("create db_cache.requests counter: %w", err)
+	}
+
+	return c, nil
+}
+
+// Select returns the rows of the query from the cache or runs the query if
+// the result is not cached or expired. The query keeps running if ctx is
+// canceled so that concurrent callers waiting for it still get the result.
+func (c *Cached[T]) Select(ctx context.Context, query string, args ...any) ([]T, error) {
+	key := cacheKey(query, args)
+
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	if found {
+		select {
+		case <-entry.ready:
+			if time.Now().Before(entry.expires) {
+				c.mu.Unlock()
+				c.record(ctx, "hit")
+				return entry.rows, nil
+			}
+			found = false
+		default:
+			// another caller is running the query
+		}
+	}
+
+	if found {
+		c.mu.Unlock()
+		c.record(ctx, "shared")
+		select {
+		case <-entry.ready:
+			return entry.rows, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	entry = &cacheEntry[T]{ready: make(chan struct{})}
+	c.evict()
+	c.entries[key] = entry
+	c.mu.Unlock()
+	c.record(ctx, "miss")
+
+	go c.load(context.WithoutCancel(ctx), key, entry, query, args)
+
+	select {
+	case <-entry.ready:
+		return entry.rows, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Invalidate drops all cached results, e.g., after a write that affects the
+// cached queries. Queries that are in flight are not affected.
+func (c *Cached[T]) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		select {
+		case <-entry.ready:
+			delete(c.entries, key)
+		default:
+		}
+	}
+}
+
+// load runs the query and publishes its result to entry. Failed results are
+// removed from the cache.
+func (c *Cached[T]) load(ctx context.Context, key string, entry *cacheEntry[T], query string, args []any) {
+	var rows []T
+	err := c.selectFn(ctx, &rows, query, args...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.rows = rows
+	entry.err = err
+	entry.expires = time.Now().Add(c.cfg.TTL)
+	close(entry.ready)
+
+	if err != nil && c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+}
+
+// evict makes room for a new entry if the cache is full. It drops expired
+// entries first and then the entry that expires soonest. In-flight entries
+// are never evicted. Must be called with mu held.
+func (c *Cached[T]) evict() {
+	if len(c.entries) < c.cfg.MaxEntries {
+		return
+	}
+
+	now := time.Now()
+	var (
+		oldestKey string
+		oldest    time.Time
+	)
+	for key, entry := range c.entries {
+		select {
+		case <-entry.ready:
+		default:
+			continue
+		}
+
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+
+		if oldestKey == "" || entry.expires.Before(oldest) {
+			oldestKey, oldest = key, entry.expires
+		}
+	}
+
+	if len(c.entries) >= c.cfg.MaxEntries && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+func (c *Cached[T]) record(ctx context.Context, result string) {
+	c.mRequests.Add(ctx, 1, metric.WithAttributes(
+		attrKeyCache.String(c.name),
+		attrKeyResult.String(result),
+	))
+}
+
+// cacheKey derives the cache key from the query and the types and values of
+// its arguments. Each part is length-prefixed and values are JSON-encoded, so
+// that different arguments, e.g., []string{"a b"} and []string{"a", "b"},
+// can't produce the same key and pointers are compared by the values they
+// point to. Values that can't be JSON-encoded fall back to their Go syntax
+// representation.
+func cacheKey(query string, args []any) string {
+	h := sha256.New()
+	write := func(s string) { _, _ = fmt.Fprintf(h, "%d:%s", len(s), s) }
+
+	write(query)
+	for _, arg := range args {
+		write(fmt.Sprintf("%T", arg))
+		if data, err := json.Marshal(arg); err == nil {
+			write("json:" + string(data))
+		} else {
+			write(fmt.Sprintf("go:%#v", arg))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+)
+
+func TestCachedConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultCachedConfig().Validate())
+	assert.Error(t, (*CachedConfig)(nil).Validate())
+	assert.Error(t, (&CachedConfig{TTL: 0, MaxEntries: 1}).Validate())
+	assert.Error(t, (&CachedConfig{TTL: time.Second, MaxEntries: 0}).Validate())
+}
+
+// failingMeter is a meter whose counters can't be created.
+type failingMeter struct {
+	metricnoop.Meter
+}
+
+func (failingMeter) Int64Counter(name string, opts ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return nil, errors.New("failing meter")
+}
+
+func TestNewCached_meterError(t *testing.T) {
+	cfg := DefaultCachedConfig()
+	cfg.Meter = failingMeter{}
+
+	_, err := newCached("test", countingSelect(new(atomic.Int32), nil, nil), cfg)
+	assert.ErrorContains(t, err, "db_cache.requests")
+}
+
+// countingSelect returns a select function that counts its calls and returns
+// a single row with the first argument.
+func countingSelect(calls *atomic.Int32, block <-chan struct{}, err error) func(ctx context.Context, dest *[]testRow, query string, args ...any) error {
+	return func(ctx context.Context, dest *[]testRow, query string, args ...any) error {
+		calls.Add(1)
+		if block != nil {
+			<-block
+		}
+		if err != nil {
+			return err
+		}
+		*dest = []testRow{{Value: args[0].(int)}}
+		return nil
+	}
+}
+
+func TestCached_Select(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var calls atomic.Int32
+		c, err := newCached("test", countingSelect(&calls, nil, nil), DefaultCachedConfig())
+		require.NoError(t, err)
+
+		ctx := context.Background()
+
+		rows, err := c.Select(ctx, "SELECT ?", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []testRow{{Value: 1}}, rows)
+
+		rows, err = c.Select(ctx, "SELECT ?", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []testRow{{Value: 1}}, rows)
+		assert.EqualValues(t, 1, calls.Load())
+
+		// different arguments
+		rows, err = c.Select(ctx, "SELECT ?", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []testRow{{Value: 2}}, rows)
+		assert.EqualValues(t, 2, calls.Load())
+
+		// expired
+		time.Sleep(time.Minute)
+		_, err = c.Select(ctx, "SELECT ?", 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, calls.Load())
+
+		c.Invalidate()
+		_, err = c.Select(ctx, "SELECT ?", 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, 4, calls.Load())
+	})
+}
+
+func TestCached_Select_shared(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var calls atomic.Int32
+		block := make(chan struct{})
+		c, err := newCached("test", countingSelect(&calls, block, nil), DefaultCachedConfig())
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				rows, err := c.Select(context.Background(), "SELECT ?", 1)
+				assert.NoError(t, err)
+				assert.Equal(t, []testRow{{Value: 1}}, rows)
+			})
+		}
+
+		synctest.Wait()
+		close(block)
+		wg.Wait()
+
+		assert.EqualValues(t, 1, calls.Load())
+	})
+}
+
+func TestCached_Select_errorNotCached(t *testing.T) {
+	var calls atomic.Int32
+	errBoom := errors.New("boom")
+	c, err := newCached("test", countingSelect(&calls, nil, errBoom), DefaultCachedConfig())
+	require.NoError(t, err)
+
+	_, err = c.Select(context.Background(), "SELECT ?", 1)
+	assert.ErrorIs(t, err, errBoom)
+	_, err = c.Select(context.Background(), "SELECT ?", 1)
+	assert.ErrorIs(t, err, errBoom)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestCached_Select_canceled(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var calls atomic.Int32
+		block := make(chan struct{})
+		c, err := newCached("test", countingSelect(&calls, block, nil), DefaultCachedConfig())
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = c.Select(ctx, "SELECT ?", 1)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		// the query keeps running and its result is cached
+		close(block)
+		synctest.Wait()
+
+		rows, err := c.Select(context.Background(), "SELECT ?", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []testRow{{Value: 1}}, rows)
+		assert.EqualValues(t, 1, calls.Load())
+	})
+}
+
+func TestCached_evict(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var calls atomic.Int32
+		cfg := DefaultCachedConfig()
+		cfg.MaxEntries = 2
+		c, err := newCached("test", countingSelect(&calls, nil, nil), cfg)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		for i := range 3 {
+			_, err := c.Select(ctx, "SELECT ?", i)
+			require.NoError(t, err)
+			time.Sleep(time.Second)
+		}
+		assert.Len(t, c.entries, 2)
+
+		// the oldest entry was evicted
+		_, err = c.Select(ctx, "SELECT ?", 0)
+		require.NoError(t, err)
+		assert.EqualValues(t, 4, calls.Load())
+	})
+}
+
+func Test_cacheKey(t *testing.T) {
+	assert.Equal(t, cacheKey("SELECT ?", []any{1}), cacheKey("SELECT ?", []any{1}))
+	assert.NotEqual(t, cacheKey("SELECT ?", []any{1}), cacheKey("SELECT ?", []any{"1"}))
+	assert.NotEqual(t, cacheKey("SELECT ?", []any{1}), cacheKey("SELECT ?", []any{2}))
+	assert.NotEqual(t, cacheKey("SELECT 1", nil), cacheKey("SELECT 2", nil))
+}
+
+func Test_cacheKey_collisions(t *testing.T) {
+	type filter struct {
+		Name *string
+	}
+
+	a, b := "a", "a"
+
+	tests := []struct {
+		name  string
+		query string
+		args  []any
+		other []any
+		equal bool
+	}{
+		{"slice elements", "SELECT * FROM t WHERE x IN ?", []any{[]string{"a b"}}, []any{[]string{"a", "b"}}, false},
+		{"argument boundaries", "SELECT ?, ?", []any{"a", "b c"}, []any{"a b", "c"}, false},
+		{"separator in value", "SELECT ?", []any{"a\x00b"}, []any{"a", "b"}, false},
+		{"query and argument", "SELECT ?", []any{"1"}, []any{}, false},
+		{"equal pointers", "SELECT ?", []any{&a}, []any{&b}, true},
+		{"equal pointers in struct", "SELECT ?", []any{filter{Name: &a}}, []any{filter{Name: &b}}, true},
+		{"nil and empty slice", "SELECT ?", []any{[]string(nil)}, []any{[]string{}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cacheKey(tt.query, tt.args) == cacheKey(tt.query, tt.other)
+			assert.Equal(t, tt.equal, got)
+		})
+	}
+
+	// values that can't be JSON-encoded still produce a stable key
+	ch := make(chan int)
+	assert.Equal(t, cacheKey("SELECT ?", []any{ch}), cacheKey("SELECT ?", []any{ch}))
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Modes of [ClickHouseMigrationsConfig.OnCluster].
+const (
+	// OnClusterOff runs the migrations as they are.
+	OnClusterOff = ""
+	// OnClusterInject adds ON CLUSTER '<ClusterName>' to CREATE, ALTER, and
+	// DROP statements that lack an ON CLUSTER clause.
+	OnClusterInject = "inject"
+	// OnClusterValidate fails before running any migration if a CREATE,
+	// ALTER, or DROP statement lacks an ON CLUSTER clause.
+	OnClusterValidate = "validate"
+)
+
+// ddlIdentifier matches a plain, backtick-quoted, or double-quoted identifier.
+const ddlIdentifier = "(?:`(?:[^`\\\\]|\\\\.)+`|\"(?:[^\"\\\\]|\\\\.)+\"|[A-Za-z_][A-Za-z0-9_]*)"
+
+// ddlStatement matches the beginning of a CREATE, ALTER, or DROP statement
+// of a table, view, dictionary, database, or function up to and including
+// the name of the object, which is where ON CLUSTER belongs.
+var ddlStatement = regexp.MustCompile(`(?is)^\s*(?:(?:--[^\n]*(?:\n|$)|/\*.*?\*/)\s*)*` +
+	`(?:CREATE(?:\s+OR\s+REPLACE)?|ALTER|DROP)\s+` +
+	`(?:TEMPORARY\s+)?(?:TABLE|DATABASE|DICTIONARY|FUNCTION|(?:MATERIALIZED\s+|LIVE\s+|WINDOW\s+)?VIEW)` +
+	`(?:\s+IF(?:\s+NOT)?\s+EXISTS)?\s+` + ddlIdentifier + `(?:\.` + ddlIdentifier + `)?`)
+
+// onClusterClause matches an ON CLUSTER clause following an object name.
+var onClusterClause = regexp.MustCompile(`(?i)^\s+ON\s+CLUSTER\b`)
+
+// onClusterFS applies the OnCluster mode to the .sql files of the
+// migrations if ClusterName is set.
+func (cfg *ClickHouseMigrationsConfig) onClusterFS(migrations fs.ReadDirFS) (fs.ReadDirFS, error) {
+	switch cfg.OnCluster {
+	case OnClusterOff, OnClusterInject, OnClusterValidate:
+	default:
+		return nil, fmt.Errorf("migrations on cluster mode must be one of %q, %q, or %q", OnClusterOff, OnClusterInject, OnClusterValidate)
+	}
+
+	if cfg.ClusterName == "" || cfg.OnCluster == OnClusterOff {
+		return migrations, nil
+	}
+
+	if cfg.OnCluster == OnClusterInject {
+		return &replacingFS{ReadDirFS: migrations, replace: func(content []byte) []byte {
+			return []byte(injectOnCluster(string(content), cfg.ClusterName))
+		}}, nil
+	}
+
+	err := fs.WalkDir(migrations, migrationsDir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".sql" {
+			return err
+		}
+
+		data, err := fs.ReadFile(migrations, name)
+		if err != nil {
+			return err
+		}
+
+		if stmt := missingOnCluster(string(data)); stmt != "" {
+			return fmt.Errorf("migration %s: statement %q lacks ON CLUSTER", name, truncateQuery(stmt, 80))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("validate on cluster: %w", err)
+	}
+
+	return migrations, nil
+}
+
+// injectOnCluster adds ON CLUSTER '<cluster>' after the object name of all
+// CREATE, ALTER, and DROP statements in sql that lack an ON CLUSTER clause.
+func injectOnCluster(sql string, cluster string) string {
+	clause := " ON CLUSTER '" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(cluster) + "'"
+
+	var (
+		sb   strings.Builder
+		last int
+	)
+	for _, r := range statementRanges(sql) {
+		stmt := sql[r[0]:r[1]]
+		loc := ddlStatement.FindStringIndex(stmt)
+		if loc == nil || onClusterClause.MatchString(stmt[loc[1]:]) {
+			continue
+		}
+
+		sb.WriteString(sql[last : r[0]+loc[1]])
+		sb.WriteString(clause)
+		last = r[0] + loc[1]
+	}
+	sb.WriteString(sql[last:])
+
+	return sb.String()
+}
+
+// missingOnCluster returns the first CREATE, ALTER, or DROP statement in sql
+// that lacks an ON CLUSTER clause or an empty string if there is none.
+func missingOnCluster(sql string) string {
+	for _, r := range statementRanges(sql) {
+		stmt := sql[r[0]:r[1]]
+		loc := ddlStatement.FindStringIndex(stmt)
+		if loc != nil && !onClusterClause.MatchString(stmt[loc[1]:]) {
+			return strings.TrimSpace(stripComments(stmt))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_injectOnCluster(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "create table",
+			sql:  "CREATE TABLE visits (peer_id String) ENGINE = MergeTree ORDER BY peer_id;",
+			want: "CREATE TABLE visits ON CLUSTER 'main' (peer_id String) ENGINE = MergeTree ORDER BY peer_id;",
+		},
+		{
+			name: "if not exists with database",
+			sql:  "create table if not exists db.`my visits`(a UInt8) ENGINE = Memory",
+			want: "create table if not exists db.`my visits` ON CLUSTER 'main'(a UInt8) ENGINE = Memory",
+		},
+		{
+			name: "materialized view",
+			sql:  "CREATE MATERIALIZED VIEW visits_mv TO visits_agg AS SELECT 1",
+			want: "CREATE MATERIALIZED VIEW visits_mv ON CLUSTER 'main' TO visits_agg AS SELECT 1",
+		},
+		{
+			name: "multiple statements with comments",
+			sql:  "-- add column; see #12\nALTER TABLE visits ADD COLUMN agent String;\n\nDROP TABLE IF EXISTS tmp;\nINSERT INTO visits VALUES ('CREATE TABLE x');\n",
+			want: "-- add column; see #12\nALTER TABLE visits ON CLUSTER 'main' ADD COLUMN agent String;\n\nDROP TABLE IF EXISTS tmp ON CLUSTER 'main';\nINSERT INTO visits VALUES ('CREATE TABLE x');\n",
+		},
+		{
+			name: "existing clause",
+			sql:  "CREATE TABLE visits ON CLUSTER '{cluster}' (a UInt8) ENGINE = Memory",
+			want: "CREATE TABLE visits ON CLUSTER '{cluster}' (a UInt8) ENGINE = Memory",
+		},
+		{
+			name: "other statements",
+			sql:  "INSERT INTO visits SELECT * FROM tmp; OPTIMIZE TABLE visits FINAL",
+			want: "INSERT INTO visits SELECT * FROM tmp; OPTIMIZE TABLE visits FINAL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, injectOnCluster(tt.sql, "main"))
+		})
+	}
+}
+
+func TestClickHouseMigrationsConfig_onClusterFS(t *testing.T) {
+	migrations := fstest.MapFS{
+		"migrations/000001_init.up.sql":    {Data: []byte("CREATE TABLE t ON CLUSTER '{cluster}' (a UInt8) ENGINE = ReplicatedMergeTree")},
+		"migrations/000002_agent.up.sql":   {Data: []byte("ALTER TABLE t ADD COLUMN agent String")},
+		"migrations/000002_agent.down.sql": {Data: []byte("ALTER TABLE t DROP COLUMN agent")},
+	}
+
+	t.Run("inject", func(t *testing.T) {
+		cfg := DefaultClickHouseMigrationsConfig()
+		cfg.ReplicatedTableEngines = true
+		cfg.ClusterName = "main"
+		cfg.OnCluster = OnClusterInject
+
+		rewritten, err := cfg.rewriteFS(migrations)
+		require.NoError(t, err)
+
+		data, err := fs.ReadFile(rewritten, "migrations/000002_agent.up.sql")
+		require.NoError(t, err)
+		assert.Equal(t, "ALTER TABLE t ON CLUSTER 'main' ADD COLUMN agent String", string(data))
+
+		data, err = fs.ReadFile(rewritten, "migrations/000001_init.up.sql")
+		require.NoError(t, err)
+		assert.Equal(t, "CREATE TABLE t ON CLUSTER '{cluster}' (a UInt8) ENGINE = ReplicatedMergeTree", string(data))
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		cfg := DefaultClickHouseMigrationsConfig()
+		cfg.ClusterName = "main"
+		cfg.OnCluster = OnClusterValidate
+
+		_, err := cfg.rewriteFS(migrations)
+		assert.ErrorContains(t, err, "migrations/000002_agent.down.sql")
+		assert.ErrorContains(t, err, "ALTER TABLE t DROP COLUMN agent")
+	})
+
+	t.Run("without cluster", func(t *testing.T) {
+		cfg := DefaultClickHouseMigrationsConfig()
+		cfg.OnCluster = OnClusterValidate
+
+		_, err := cfg.rewriteFS(migrations)
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		cfg := DefaultClickHouseMigrationsConfig()
+		cfg.OnCluster = "always"
+
+		_, err := cfg.rewriteFS(migrations)
+		assert.Error(t, err)
+	})
+}
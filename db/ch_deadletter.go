@@ -0,0 +1,131 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// DeadLetterSink persists the rows of batches that failed to flush so that
+// they can be inspected and re-inserted later instead of being lost, see
+// [BatchInserterConfig.DeadLetter].
+type DeadLetterSink[T any] interface {
+	// DeadLetter persists the rows that failed to be inserted into table
+	// with the given flush error.
+	DeadLetter(ctx context.Context, table string, rows []T, flushErr error) error
+}
+
+// FileDeadLetter is a [DeadLetterSink] that appends failed rows as
+// JSONEachRow lines to <dir>/<table>.jsonl. The keys are the column names
+// from the ch struct tags, so the files can be re-inserted with
+// "INSERT INTO <table> FORMAT JSONEachRow" (with
+// date_time_input_format=best_effort for time columns) or loaded with
+// [LoadClickHouseFixtures].
+type FileDeadLetter[T any] struct {
+	dir string
+	mu  sync.Mutex
+}
+
+var _ DeadLetterSink[any] = (*FileDeadLetter[any])(nil)
+
+// NewFileDeadLetter creates a [FileDeadLetter] that writes to dir. The
+// directory is created if it does not exist.
+func NewFileDeadLetter[T any](dir string) (*FileDeadLetter[T], error) {
+	if dir == "" {
+		return nil, fmt.Errorf("dead letter dir must not be empty")
+	}
+
+	if rt := reflect.TypeFor[T](); rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dead letter row type %s must be a struct", rt)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create dead letter dir: %w", err)
+	}
+
+	return &FileDeadLetter[T]{dir: dir}, nil
+}
+
+// DeadLetter appends the rows to the file of the table.
+func (d *FileDeadLetter[T]) DeadLetter(_ context.Context, table string, rows []T, _ error) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range rows {
+		if err := enc.Encode(columnValues(reflect.ValueOf(&rows[i]).Elem())); err != nil {
+			return fmt.Errorf("encode dead letter row %d: %w", i, err)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, err := os.OpenFile(filepath.Join(d.dir, table+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open dead letter file: %w", err)
+	}
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write dead letter file: %w", err)
+	}
+
+	return f.Close()
+}
+
+// TableDeadLetter is a [DeadLetterSink] that inserts failed rows into a
+// secondary ClickHouse table with the same columns, e.g., on a different
+// connection or a table without the constraints that made the insert fail.
+type TableDeadLetter[T any] struct {
+	conn  driver.Conn
+	table string
+}
+
+var _ DeadLetterSink[any] = (*TableDeadLetter[any])(nil)
+
+// NewTableDeadLetter creates a [TableDeadLetter] that inserts into table
+// via conn.
+func NewTableDeadLetter[T any](conn driver.Conn, table string) (*TableDeadLetter[T], error) {
+	if conn == nil {
+		return nil, fmt.Errorf("conn must not be nil")
+	}
+
+	if !validTableName.MatchString(table) {
+		return nil, fmt.Errorf("dead letter table name %q contains invalid characters", table)
+	}
+
+	return &TableDeadLetter[T]{conn: conn, table: table}, nil
+}
+
+// DeadLetter inserts the rows into the dead letter table.
+func (d *TableDeadLetter[T]) DeadLetter(ctx context.Context, _ string, rows []T, _ error) error {
+	return insertStructs(ctx, d.conn, d.table, rows)
+}
+
+// columnValues maps the column names of a struct value, see
+// [NewExternalTable], to the field values.
+func columnValues(rv reflect.Value) map[string]any {
+	values := map[string]any{}
+	for _, field := range reflect.VisibleFields(rv.Type()) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("ch"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+
+		values[name] = rv.FieldByIndex(field.Index).Interface()
+	}
+	return values
+}
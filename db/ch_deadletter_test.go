@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deadLetterRecorder is a DeadLetterSink that records the rows it receives
+// or fails with err.
+type deadLetterRecorder struct {
+	rows     []testRow
+	flushErr error
+	err      error
+}
+
+func (d *deadLetterRecorder) DeadLetter(_ context.Context, _ string, rows []testRow, flushErr error) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.rows = append(d.rows, rows...)
+	d.flushErr = flushErr
+	return nil
+}
+
+func TestBatchInserter_DeadLetter(t *testing.T) {
+	sendErr := errors.New("send failed")
+
+	t.Run("persisted", func(t *testing.T) {
+		sink := &deadLetterRecorder{}
+		var dropped int
+		cfg := &BatchInserterConfig[testRow]{
+			MaxBatchSize:  10,
+			FlushInterval: time.Hour,
+			DeadLetter:    sink,
+			OnDroppedRows: func(rows []testRow, _ error) { dropped += len(rows) },
+		}
+		b := newTestInserter(t, &mockConn{batch: &mockBatch{sendErr: sendErr}}, cfg)
+		b.Start(context.Background())
+
+		require.NoError(t, b.Submit(context.Background(), testRow{Value: 42}))
+		assert.ErrorIs(t, b.Flush(context.Background()), sendErr)
+
+		assert.Equal(t, []testRow{{Value: 42}}, sink.rows)
+		assert.ErrorIs(t, sink.flushErr, sendErr)
+		assert.Zero(t, dropped)
+
+		require.NoError(t, b.Stop(context.Background()))
+	})
+
+	t.Run("sink fails", func(t *testing.T) {
+		var dropped int
+		cfg := &BatchInserterConfig[testRow]{
+			MaxBatchSize:  10,
+			FlushInterval: time.Hour,
+			DeadLetter:    &deadLetterRecorder{err: errors.New("disk full")},
+			OnDroppedRows: func(rows []testRow, _ error) { dropped += len(rows) },
+		}
+		b := newTestInserter(t, &mockConn{batch: &mockBatch{sendErr: sendErr}}, cfg)
+		b.Start(context.Background())
+
+		require.NoError(t, b.Submit(context.Background(), testRow{Value: 42}))
+		assert.ErrorIs(t, b.Flush(context.Background()), sendErr)
+		assert.Equal(t, 1, dropped)
+
+		require.NoError(t, b.Stop(context.Background()))
+	})
+}
+
+func TestFileDeadLetter(t *testing.T) {
+	type row struct {
+		PeerID  string `ch:"peer_id"`
+		Agent   string
+		Ignored string `ch:"-"`
+	}
+
+	dir := filepath.Join(t.TempDir(), "dead")
+	sink, err := NewFileDeadLetter[row](dir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, sink.DeadLetter(ctx, "visits", []row{{PeerID: "Qm1", Agent: "a", Ignored: "x"}}, nil))
+	require.NoError(t, sink.DeadLetter(ctx, "visits", []row{{PeerID: "Qm2"}}, nil))
+
+	data, err := os.ReadFile(filepath.Join(dir, "visits.jsonl"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"Agent":"a","peer_id":"Qm1"}`+"\n"+`{"Agent":"","peer_id":"Qm2"}`+"\n", string(data))
+
+	_, err = NewFileDeadLetter[string](dir)
+	assert.Error(t, err)
+}
+
+func TestNewTableDeadLetter(t *testing.T) {
+	_, err := NewTableDeadLetter[testRow](&mockConn{}, "visits; DROP TABLE visits")
+	assert.Error(t, err)
+
+	batch := &mockBatch{}
+	sink, err := NewTableDeadLetter[testRow](&mockConn{batch: batch}, "visits_dead_letter")
+	require.NoError(t, err)
+	require.NoError(t, sink.DeadLetter(context.Background(), "visits", []testRow{{Value: 1}}, nil))
+	assert.Len(t, batch.appended, 1)
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/ext"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+)
+
+// externalColumn describes a struct field that becomes a column of an
+// external table.
+type externalColumn struct {
+	name  string
+	typ   string
+	index []int
+	// convert adapts the field value to a type the column accepts, e.g., int
+	// to int64. If nil, the value is appended as is.
+	convert func(v reflect.Value) any
+}
+
+// NewExternalTable builds a ClickHouse external data table with the given
+// name from a slice of structs. External tables are sent along with a query
+// and can be referenced like a regular table, e.g., to join a small in-memory
+// lookup set without creating and dropping a temporary table:
+//
+//	type peer struct {
+//		ID    string `ch:"peer_id"`
+//		Agent string `ch:"agent_version" chtype:"LowCardinality(String)"`
+//	}
+//
+//	table, err := db.NewExternalTable("peers", peers)
+//	...
+//	rows, err := conn.Query(db.WithExternalTables(ctx, table),
+//		"SELECT v.* FROM visits v JOIN peers p ON v.peer_id = p.peer_id")
+//
+// Exported fields become columns. The ch tag sets the column name, which
+// defaults to the field name, and "-" skips the field. The column type is
+// derived from the field type: strings and byte slices map to String, bools
+// to Bool, integers and floats to the respective (U)Int and Float types,
+// int and uint to Int64 and UInt64, [time.Time] to DateTime64(9), pointers
+// to Nullable, and other slices to Array. The chtype tag overrides the
+// derived type.
+func NewExternalTable[T any](name string, rows []T) (*ext.Table, error) {
+	rt := reflect.TypeFor[T]()
+	if rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("external table %s: row type %s must be a struct", name, rt)
+	}
+
+	var (
+		cols []externalColumn
+		opts []func(t *ext.Table) error
+	)
+	for _, field := range reflect.VisibleFields(rt) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		colName := field.Name
+		if tag, ok := field.Tag.Lookup("ch"); ok {
+			if tag == "-" {
+				continue
+			}
+			colName = tag
+		}
+
+		col := externalColumn{name: colName, index: field.Index}
+		col.typ, col.convert = externalColumnType(field.Type)
+		if tag, ok := field.Tag.Lookup("chtype"); ok {
+			col.typ = tag
+		}
+
+		if col.typ == "" {
+			return nil, fmt.Errorf("external table %s: unsupported type %s of field %s, set the chtype tag", name, field.Type, field.Name)
+		}
+
+		cols = append(cols, col)
+		opts = append(opts, ext.Column(col.name, column.Type(col.typ)))
+	}
+
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("external table %s: row type %s has no columns", name, rt)
+	}
+
+	table, err := ext.NewTable(name, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("external table %s: %w", name, err)
+	}
+
+	values := make([]any, len(cols))
+	for i := range rows {
+		rv := reflect.ValueOf(&rows[i]).Elem()
+		for j, col := range cols {
+			v := rv.FieldByIndex(col.index)
+			if col.convert != nil {
+				values[j] = col.convert(v)
+			} else {
+				values[j] = v.Interface()
+			}
+		}
+
+		if err := table.Append(values...); err != nil {
+			return nil, fmt.Errorf("external table %s: append row %d: %w", name, i, err)
+		}
+	}
+
+	return table, nil
+}
+
+// WithExternalTables returns a context that sends the given external tables
+// with queries that are executed with it, see [NewExternalTable].
+func WithExternalTables(ctx context.Context, tables ...*ext.Table) context.Context {
+	return clickhouse.Context(ctx, clickhouse.WithExternalTable(tables...))
+}
+
+// externalColumnType returns the ClickHouse type for the given Go type and an
+// optional conversion of its values. It returns an empty type if the Go type
+// is not supported.
+func externalColumnType(t reflect.Type) (string, func(v reflect.Value) any) {
+	if t == timeType {
+		return "DateTime64(9)", nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "String", nil
+	case reflect.Bool:
+		return "Bool", nil
+	case reflect.Int:
+		return "Int64", func(v reflect.Value) any { return v.Int() }
+	case reflect.Uint:
+		return "UInt64", func(v reflect.Value) any { return v.Uint() }
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("Int%d", t.Bits()), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("UInt%d", t.Bits()), nil
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("Float%d", t.Bits()), nil
+	case reflect.Pointer:
+		elem, convert := externalColumnType(t.Elem())
+		if elem == "" || convert != nil {
+			return "", nil
+		}
+		return "Nullable(" + elem + ")", nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "String", nil
+		}
+		elem, convert := externalColumnType(t.Elem())
+		if elem == "" || convert != nil {
+			return "", nil
+		}
+		return "Array(" + elem + ")", nil
+	default:
+		return "", nil
+	}
+}
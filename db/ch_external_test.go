@@ -0,0 +1,58 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/probe-lab/go-commons/ptr"
+)
+
+func TestNewExternalTable(t *testing.T) {
+	type row struct {
+		ID        string `ch:"peer_id"`
+		Agent     string `chtype:"LowCardinality(String)"`
+		Count     int
+		Port      uint16
+		Score     float64
+		Online    bool
+		SeenAt    time.Time
+		Protocols []string
+		Latency   *float32
+		Raw       []byte
+		Skipped   string `ch:"-"`
+		internal  string
+	}
+
+	rows := []row{
+		{ID: "a", Agent: "kubo", Count: 1, Port: 4001, Score: 0.5, Online: true, SeenAt: time.Unix(0, 0), Protocols: []string{"/ipfs/id"}, Latency: ptr.From[float32](1.5), Raw: []byte("x"), internal: "x"},
+		{ID: "b", Agent: "lotus", Count: 2},
+	}
+
+	table, err := NewExternalTable("peers", rows)
+	require.NoError(t, err)
+
+	assert.Equal(t, "peers", table.Name())
+	assert.Equal(t, "peer_id String, Agent LowCardinality(String), Count Int64, Port UInt16, Score Float64, "+
+		"Online Bool, SeenAt DateTime64(9), Protocols Array(String), Latency Nullable(Float32), Raw String", table.Structure())
+	assert.Equal(t, 2, table.Block().Rows())
+}
+
+func TestNewExternalTable_errors(t *testing.T) {
+	_, err := NewExternalTable("ints", []int{1, 2})
+	assert.Error(t, err)
+
+	type unsupported struct {
+		Labels map[string]string
+	}
+	_, err = NewExternalTable("unsupported", []unsupported{{}})
+	assert.ErrorContains(t, err, "Labels")
+
+	type empty struct {
+		Skipped string `ch:"-"`
+	}
+	_, err = NewExternalTable("empty", []empty{{}})
+	assert.Error(t, err)
+}
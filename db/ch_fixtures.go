@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// fixtureOrderPrefix matches an optional ordering prefix of fixture file
+// names, e.g., "01_" in "01_visits.csv".
+var fixtureOrderPrefix = regexp.MustCompile(`^[0-9]+[_-]`)
+
+// LoadClickHouseFixtures seeds a test database with the fixture files in
+// fsys that match the given glob patterns, or all files in the root of fsys
+// if no pattern is given. Files are loaded in lexical order of their paths,
+// so prefix them with numbers to control the order. The file extension
+// determines how a file is loaded:
+//
+//   - .sql files contain statements separated by semicolons that are
+//     executed one after another.
+//   - .csv files are inserted with the CSVWithNames format, i.e., the first
+//     line must hold the column names.
+//   - .jsonl and .ndjson files are inserted with the JSONEachRow format.
+//
+// The table of CSV and JSONEachRow files is the file name without extension
+// and ordering prefix, e.g., "01_visits.csv" is inserted into "visits".
+// Use an embed.FS to share the fixtures with the tests:
+//
+//	//go:embed testdata/fixtures
+//	var fixtures embed.FS
+//
+//	sub, _ := fs.Sub(fixtures, "testdata/fixtures")
+//	err := db.LoadClickHouseFixtures(ctx, conn, sub)
+func LoadClickHouseFixtures(ctx context.Context, conn driver.Conn, fsys fs.FS, patterns ...string) error {
+	if conn == nil {
+		return fmt.Errorf("conn must not be nil")
+	}
+
+	return loadFixtures(ctx, func(ctx context.Context, query string) error {
+		return conn.Exec(ctx, query)
+	}, fsys, patterns...)
+}
+
+func loadFixtures(ctx context.Context, exec func(ctx context.Context, query string) error, fsys fs.FS, patterns ...string) error {
+	if len(patterns) == 0 {
+		patterns = []string{"*"}
+	}
+
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return fmt.Errorf("glob fixtures %s: %w", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	slices.Sort(files)
+	files = slices.Compact(files)
+
+	for _, file := range files {
+		info, err := fs.Stat(fsys, file)
+		if err != nil {
+			return fmt.Errorf("stat fixture %s: %w", file, err)
+		} else if info.IsDir() {
+			continue
+		}
+
+		queries, err := fixtureQueries(fsys, file)
+		if err != nil {
+			return fmt.Errorf("read fixture %s: %w", file, err)
+		}
+
+		for _, query := range queries {
+			if err := exec(ctx, query); err != nil {
+				return fmt.Errorf("load fixture %s: %w", file, err)
+			}
+		}
+
+		slog.Debug("Loaded clickhouse fixture", "file", file, "queries", len(queries))
+	}
+
+	return nil
+}
+
+// fixtureQueries returns the queries that load the given fixture file.
+func fixtureQueries(fsys fs.FS, file string) ([]string, error) {
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := path.Ext(file)
+
+	var format string
+	switch ext {
+	case ".sql":
+		return splitStatements(string(data)), nil
+	case ".csv":
+		format = "CSVWithNames"
+	case ".jsonl", ".ndjson":
+		format = "JSONEachRow"
+	default:
+		return nil, fmt.Errorf("unsupported fixture file extension %q", ext)
+	}
+
+	table := fixtureOrderPrefix.ReplaceAllString(strings.TrimSuffix(path.Base(file), ext), "")
+	if !validTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid fixture table name %q", table)
+	}
+
+	if strings.TrimSpace(string(data)) == "" {
+		return nil, nil
+	}
+
+	return []string{fmt.Sprintf("INSERT INTO %s FORMAT %s\n%s", table, format, data)}, nil
+}
+
+// splitStatements splits SQL into its semicolon-separated statements. It
+// ignores semicolons in quoted strings, quoted identifiers, and comments.
+// Empty statements are dropped.
+func splitStatements(sql string) []string {
+	var statements []string
+	for _, r := range statementRanges(sql) {
+		if stmt := strings.TrimSpace(sql[r[0]:r[1]]); stripComments(stmt) != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+// statementRanges returns the start and end offsets of the semicolon-separated
+// statements in sql. The ranges exclude the semicolons but include the
+// surrounding whitespace and comments.
+func statementRanges(sql string) [][2]int {
+	var (
+		ranges [][2]int
+		start  int
+	)
+
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			// skip to the closing quote, honoring backslash escapes
+			for i++; i < len(sql) && sql[i] != c; i++ {
+				if sql[i] == '\\' {
+					i++
+				}
+			}
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(sql)
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(sql)
+			}
+		case c == ';':
+			ranges = append(ranges, [2]int{start, i})
+			start = i + 1
+		}
+	}
+
+	if start < len(sql) {
+		ranges = append(ranges, [2]int{start, len(sql)})
+	}
+
+	return ranges
+}
+
+// stripComments removes line comments from a statement so that statements
+// consisting only of comments can be detected.
+func stripComments(stmt string) string {
+	var sb strings.Builder
+	for line := range strings.Lines(stmt) {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			sb.WriteString(line)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitStatements(t *testing.T) {
+	sql := `
+-- schema; for tests
+CREATE TABLE visits (peer_id String, agent String) ENGINE = Memory;
+
+INSERT INTO visits VALUES ('a;b', 'it\'s; fine'), ("c", ` + "`d;`" + `);
+/* block; comment */ SELECT 1;
+-- trailing comment
+`
+
+	assert.Equal(t, []string{
+		"-- schema; for tests\nCREATE TABLE visits (peer_id String, agent String) ENGINE = Memory",
+		`INSERT INTO visits VALUES ('a;b', 'it\'s; fine'), ("c", ` + "`d;`" + `)`,
+		"/* block; comment */ SELECT 1",
+	}, splitStatements(sql))
+
+	assert.Empty(t, splitStatements(" ; \n;"))
+}
+
+func Test_loadFixtures(t *testing.T) {
+	fsys := fstest.MapFS{
+		"00_schema.sql":     {Data: []byte("CREATE TABLE visits (peer_id String) ENGINE = Memory;\nCREATE TABLE dials (peer_id String) ENGINE = Memory;")},
+		"01_visits.csv":     {Data: []byte("peer_id\nQm1\nQm2\n")},
+		"02_dials.jsonl":    {Data: []byte(`{"peer_id":"Qm1"}` + "\n")},
+		"03_empty.ndjson":   {Data: []byte("\n")},
+		"README.md":         {Data: []byte("fixtures")},
+		"nested/visits.sql": {Data: []byte("SELECT 1")},
+	}
+
+	var queries []string
+	exec := func(ctx context.Context, query string) error {
+		queries = append(queries, query)
+		return nil
+	}
+
+	require.NoError(t, loadFixtures(context.Background(), exec, fsys, "*.sql", "*.csv", "*.jsonl", "*.ndjson"))
+	assert.Equal(t, []string{
+		"CREATE TABLE visits (peer_id String) ENGINE = Memory",
+		"CREATE TABLE dials (peer_id String) ENGINE = Memory",
+		"INSERT INTO visits FORMAT CSVWithNames\npeer_id\nQm1\nQm2\n",
+		"INSERT INTO dials FORMAT JSONEachRow\n{\"peer_id\":\"Qm1\"}\n",
+	}, queries)
+
+	// all files in the root including the unsupported README
+	err := loadFixtures(context.Background(), exec, fsys)
+	assert.ErrorContains(t, err, `unsupported fixture file extension ".md"`)
+
+	err = loadFixtures(context.Background(), exec, fstest.MapFS{"bad-table!.csv": {Data: []byte("a\n1\n")}})
+	assert.ErrorContains(t, err, "invalid fixture table name")
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// ClickHouseInsertOptions holds the ClickHouse settings that control inserts
+// into Distributed and Replicated tables. Pass them to a [BatchInserter] via
+// [BatchInserterConfig.Insert] or attach them to the context of a single
+// insert with [ClickHouseInsertOptions.Context]. The zero value leaves all
+// settings to the server defaults.
+type ClickHouseInsertOptions struct {
+	// DistributedSync makes inserts into Distributed tables return only after
+	// the data was written to all shards (insert_distributed_sync).
+	DistributedSync bool
+	// Quorum is the number of replicas that must acknowledge an insert
+	// (insert_quorum). Use "auto" for a majority of replicas. Empty disables
+	// quorum writes.
+	Quorum string
+	// QuorumTimeout is the time to wait for the quorum (insert_quorum_timeout).
+	// Zero uses the server default.
+	QuorumTimeout time.Duration
+	// QuorumSequential disables parallel quorum inserts
+	// (insert_quorum_parallel=0) so that reads with
+	// select_sequential_consistency see all acknowledged inserts.
+	QuorumSequential bool
+	// DeduplicationToken is sent as insert_deduplication_token so that
+	// replicated tables drop an insert if a block with the same token was
+	// already written. [BatchInserter] generates a token per batch with
+	// [BatchInserterConfig.DeduplicationToken] instead.
+	DeduplicationToken string
+}
+
+// Validate checks the [ClickHouseInsertOptions] for validity.
+func (o *ClickHouseInsertOptions) Validate() error {
+	if o.Quorum != "" && o.Quorum != "auto" {
+		if n, err := strconv.Atoi(o.Quorum); err != nil || n <= 0 {
+			return fmt.Errorf("insert quorum must be \"auto\" or a positive integer, got %q", o.Quorum)
+		}
+	}
+
+	if o.QuorumTimeout < 0 {
+		return fmt.Errorf("insert quorum timeout must be a non-negative duration")
+	}
+
+	if o.Quorum == "" && (o.QuorumTimeout > 0 || o.QuorumSequential) {
+		return fmt.Errorf("insert quorum timeout and sequential quorum require an insert quorum")
+	}
+
+	return nil
+}
+
+// Settings returns the ClickHouse settings for the options. Settings that are
+// left at their zero value are omitted.
+func (o *ClickHouseInsertOptions) Settings() clickhouse.Settings {
+	settings := clickhouse.Settings{}
+
+	if o.DistributedSync {
+		settings["insert_distributed_sync"] = 1
+	}
+
+	switch o.Quorum {
+	case "":
+	case "auto":
+		settings["insert_quorum"] = "auto"
+	default:
+		n, _ := strconv.Atoi(o.Quorum)
+		settings["insert_quorum"] = n
+	}
+
+	if o.QuorumTimeout > 0 {
+		settings["insert_quorum_timeout"] = o.QuorumTimeout.Milliseconds()
+	}
+
+	if o.QuorumSequential {
+		settings["insert_quorum_parallel"] = 0
+	}
+
+	if o.DeduplicationToken != "" {
+		settings["insert_deduplication_token"] = o.DeduplicationToken
+	}
+
+	return settings
+}
+
+// Context returns a context that applies the options to queries that are
+// executed with it. It replaces any settings previously attached to ctx with
+// [clickhouse.WithSettings].
+func (o *ClickHouseInsertOptions) Context(ctx context.Context) context.Context {
+	return clickhouse.Context(ctx, clickhouse.WithSettings(o.Settings()))
+}
+
+// LogValue implements the [slog.LogValuer] interface.
+func (o *ClickHouseInsertOptions) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("distributed_sync", o.DistributedSync),
+		slog.String("quorum", o.Quorum),
+		slog.Duration("quorum_timeout", o.QuorumTimeout),
+		slog.Bool("quorum_sequential", o.QuorumSequential),
+	)
+}
+
+// HashDeduplicationToken derives an insert_deduplication_token from the
+// contents of the given rows. Sending the same rows twice, e.g., after a
+// consumer restart replays its input, yields the same token, so replicated
+// tables drop the second insert. The rows are hashed via their %+v
+// representation; rows containing pointers must provide their own token
+// function because the representation includes addresses.
+func HashDeduplicationToken[T any](rows []T) string {
+	h := sha256.New()
+	for i := range rows {
+		_, _ = fmt.Fprintf(h, "%+v\n", rows[i])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
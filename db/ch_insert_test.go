@@ -0,0 +1,83 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClickHouseInsertOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ClickHouseInsertOptions
+		wantErr bool
+	}{
+		{name: "zero", opts: ClickHouseInsertOptions{}},
+		{name: "auto quorum", opts: ClickHouseInsertOptions{Quorum: "auto", QuorumTimeout: time.Minute, QuorumSequential: true}},
+		{name: "numeric quorum", opts: ClickHouseInsertOptions{Quorum: "2"}},
+		{name: "zero quorum", opts: ClickHouseInsertOptions{Quorum: "0"}, wantErr: true},
+		{name: "invalid quorum", opts: ClickHouseInsertOptions{Quorum: "majority"}, wantErr: true},
+		{name: "negative timeout", opts: ClickHouseInsertOptions{Quorum: "2", QuorumTimeout: -time.Second}, wantErr: true},
+		{name: "timeout without quorum", opts: ClickHouseInsertOptions{QuorumTimeout: time.Second}, wantErr: true},
+		{name: "sequential without quorum", opts: ClickHouseInsertOptions{QuorumSequential: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClickHouseInsertOptions_Settings(t *testing.T) {
+	assert.Empty(t, (&ClickHouseInsertOptions{}).Settings())
+
+	opts := &ClickHouseInsertOptions{
+		DistributedSync:    true,
+		Quorum:             "2",
+		QuorumTimeout:      30 * time.Second,
+		QuorumSequential:   true,
+		DeduplicationToken: "token",
+	}
+	assert.Equal(t, clickhouse.Settings{
+		"insert_distributed_sync":    1,
+		"insert_quorum":              2,
+		"insert_quorum_timeout":      int64(30000),
+		"insert_quorum_parallel":     0,
+		"insert_deduplication_token": "token",
+	}, opts.Settings())
+
+	assert.Equal(t, clickhouse.Settings{"insert_quorum": "auto"}, (&ClickHouseInsertOptions{Quorum: "auto"}).Settings())
+}
+
+func TestHashDeduplicationToken(t *testing.T) {
+	a := HashDeduplicationToken([]testRow{{Value: 1}, {Value: 2}})
+	assert.Len(t, a, 64)
+	assert.Equal(t, a, HashDeduplicationToken([]testRow{{Value: 1}, {Value: 2}}))
+	assert.NotEqual(t, a, HashDeduplicationToken([]testRow{{Value: 2}, {Value: 1}}))
+	assert.NotEqual(t, a, HashDeduplicationToken([]testRow{{Value: 1}}))
+}
+
+func TestBatchInserter_insertOptions(t *testing.T) {
+	rows := []testRow{{Value: 1}}
+
+	b := newTestInserter(t, &mockConn{batch: &mockBatch{}}, DefaultBatchInserterConfig[testRow]())
+	assert.Nil(t, b.insertOptions(rows))
+
+	cfg := DefaultBatchInserterConfig[testRow]()
+	cfg.Insert = &ClickHouseInsertOptions{DistributedSync: true, DeduplicationToken: "static"}
+	cfg.DeduplicationToken = HashDeduplicationToken[testRow]
+	b = newTestInserter(t, &mockConn{batch: &mockBatch{}}, cfg)
+
+	opts := b.insertOptions(rows)
+	assert.True(t, opts.DistributedSync)
+	assert.Equal(t, HashDeduplicationToken(rows), opts.DeduplicationToken)
+	assert.Equal(t, "static", cfg.Insert.DeduplicationToken, "must not modify the shared options")
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/google/uuid"
+)
+
+// killQueryTimeout bounds the KILL QUERY that [ExecWithQueryID] sends after
+// its context was canceled.
+const killQueryTimeout = 10 * time.Second
+
+// queryIDKey is the context key for the query ID set by [ContextWithQueryID].
+type queryIDKey struct{}
+
+// ContextWithQueryID returns a context that runs ClickHouse queries with
+// the given query ID. Prefer it over clickhouse.WithQueryID because the
+// helpers and wrappers of this package cannot read query IDs set by the
+// driver option and would replace them with a random one.
+func ContextWithQueryID(ctx context.Context, queryID string) context.Context {
+	ctx = context.WithValue(ctx, queryIDKey{}, queryID)
+	return clickhouse.Context(ctx, clickhouse.WithQueryID(queryID))
+}
+
+// QueryIDFromContext returns the query ID set by [ContextWithQueryID].
+func QueryIDFromContext(ctx context.Context) (string, bool) {
+	queryID, ok := ctx.Value(queryIDKey{}).(string)
+	return queryID, ok
+}
+
+// ensureQueryID returns ctx with a random query ID unless it already
+// carries one, together with the query ID.
+func ensureQueryID(ctx context.Context) (context.Context, string) {
+	if queryID, ok := QueryIDFromContext(ctx); ok {
+		return ctx, queryID
+	}
+
+	queryID := uuid.NewString()
+	return ContextWithQueryID(ctx, queryID), queryID
+}
+
+// ExecWithQueryID runs the statement on conn with the query ID of ctx or a
+// random one and returns the query ID, e.g., to include it in logs and API
+// responses so that operators can find the statement in system.processes
+// and cancel it with [KillQuery]. If ctx is canceled while the statement
+// runs, ExecWithQueryID kills it on the server because the server may keep
+// running it after the client went away.
+func ExecWithQueryID(ctx context.Context, conn driver.Conn, query string, args ...any) (string, error) {
+	ctx, queryID := ensureQueryID(ctx)
+
+	slog.Debug("Running clickhouse statement", "query_id", queryID)
+
+	err := conn.Exec(ctx, query, args...)
+	if err == nil || ctx.Err() == nil {
+		return queryID, err
+	}
+
+	killCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), killQueryTimeout)
+	defer cancel()
+
+	if killErr := KillQuery(killCtx, conn, queryID); killErr != nil {
+		slog.Warn("Failed to kill canceled clickhouse statement", "query_id", queryID, "err", killErr)
+	}
+
+	return queryID, err
+}
+
+// KillQuery asks the server to cancel the query with the given ID. It does
+// not wait for the query to stop. Killing a query that is not running is
+// not an error.
+func KillQuery(ctx context.Context, conn driver.Conn, queryID string) error {
+	if queryID == "" {
+		return fmt.Errorf("query id must not be empty")
+	}
+
+	// the kill statement must not reuse the query ID of ctx, which may
+	// belong to the query that is being killed
+	ctx = ContextWithQueryID(ctx, uuid.NewString())
+
+	if err := conn.Exec(ctx, "KILL QUERY WHERE query_id = ? ASYNC", queryID); err != nil {
+		return fmt.Errorf("kill query %s: %w", queryID, err)
+	}
+
+	return nil
+}
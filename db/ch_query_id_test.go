@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// execRecorder is a mockConn that records executed statements and their
+// query IDs. Statements other than KILL QUERY block until ctx is done if
+// block is set.
+type execRecorder struct {
+	mockConn
+	block    bool
+	queries  []string
+	args     [][]any
+	queryIDs []string
+}
+
+func (c *execRecorder) Exec(ctx context.Context, query string, args ...any) error {
+	queryID, _ := QueryIDFromContext(ctx)
+	c.queries = append(c.queries, query)
+	c.args = append(c.args, args)
+	c.queryIDs = append(c.queryIDs, queryID)
+
+	if c.block && query != "KILL QUERY WHERE query_id = ? ASYNC" {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func TestExecWithQueryID(t *testing.T) {
+	conn := &execRecorder{}
+
+	queryID, err := ExecWithQueryID(context.Background(), conn, "OPTIMIZE TABLE visits FINAL")
+	require.NoError(t, err)
+	assert.NotEmpty(t, queryID)
+	assert.Equal(t, []string{queryID}, conn.queryIDs)
+
+	queryID, err = ExecWithQueryID(ContextWithQueryID(context.Background(), "my-query"), conn, "SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, "my-query", queryID)
+	assert.Equal(t, "my-query", conn.queryIDs[1])
+}
+
+func TestExecWithQueryID_canceled(t *testing.T) {
+	conn := &execRecorder{block: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	queryID, err := ExecWithQueryID(ctx, conn, "OPTIMIZE TABLE visits FINAL")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	require.Len(t, conn.queries, 2)
+	assert.Equal(t, "KILL QUERY WHERE query_id = ? ASYNC", conn.queries[1])
+	assert.Equal(t, []any{queryID}, conn.args[1])
+	assert.NotEqual(t, queryID, conn.queryIDs[1])
+}
+
+func TestKillQuery(t *testing.T) {
+	assert.Error(t, KillQuery(context.Background(), &execRecorder{}, ""))
+}
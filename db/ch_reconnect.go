@@ -0,0 +1,356 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// ReconnectConfig holds configuration for [WithReconnect].
+type ReconnectConfig struct {
+	// MaxRetries is the number of times a call is retried on a new
+	// connection after it failed with a broken connection error.
+	MaxRetries int
+	// Backoff is the delay before the first reconnect. It doubles with
+	// every further attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultReconnectConfig returns a [ReconnectConfig] with sensible defaults.
+func DefaultReconnectConfig() *ReconnectConfig {
+	return &ReconnectConfig{
+		MaxRetries: 3,
+		Backoff:    100 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+	}
+}
+
+// Validate checks the [ReconnectConfig] for validity.
+func (cfg *ReconnectConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if cfg.MaxRetries < 0 {
+		return fmt.Errorf("reconnect max retries must not be negative")
+	}
+
+	if cfg.Backoff <= 0 {
+		return fmt.Errorf("reconnect backoff must be a positive duration")
+	}
+
+	if cfg.MaxBackoff < cfg.Backoff {
+		return fmt.Errorf("reconnect max backoff must not be smaller than the backoff")
+	}
+
+	return nil
+}
+
+// reconnectConn is a [driver.Conn] that re-opens the underlying connection
+// when a call fails because the connection broke.
+type reconnectConn struct {
+	open func(ctx context.Context) (driver.Conn, error)
+	cfg  *ReconnectConfig
+
+	mu   sync.RWMutex
+	conn *trackedConn
+}
+
+// trackedConn counts the calls, rows, and batches that use a connection, so
+// that a replaced connection is only closed once all of them are done. The
+// connection is a pool, so a broken connection error of one call doesn't mean
+// that the reads and writes of other calls fail as well.
+type trackedConn struct {
+	driver.Conn
+
+	mu      sync.Mutex
+	active  int
+	retired bool
+}
+
+// acquire marks the connection as used. Must be called with the lock of the
+// owning [reconnectConn] held, so that retired connections aren't acquired.
+func (t *trackedConn) acquire() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active++
+}
+
+// release marks one use of the connection as done and closes the connection
+// if it was the last use of a retired connection.
+func (t *trackedConn) release() {
+	t.mu.Lock()
+	t.active--
+	closeConn := t.retired && t.active == 0
+	t.mu.Unlock()
+
+	if closeConn {
+		t.close()
+	}
+}
+
+// retire closes the connection once it is no longer used.
+func (t *trackedConn) retire() {
+	t.mu.Lock()
+	t.retired = true
+	closeConn := t.active == 0
+	t.mu.Unlock()
+
+	if closeConn {
+		t.close()
+	}
+}
+
+func (t *trackedConn) close() {
+	if err := t.Conn.Close(); err != nil {
+		slog.Debug("Failed to close broken clickhouse connection", "err", err)
+	}
+}
+
+var _ driver.Conn = (*reconnectConn)(nil)
+
+// WithReconnect opens a connection with open and returns a [driver.Conn]
+// that transparently re-opens it when a call fails with an EOF, broken
+// pipe, or connection reset error, e.g., because ClickHouse Cloud closed
+// an idle connection. The failed call is retried on the new connection up
+// to [ReconnectConfig.MaxRetries] times with exponential backoff.
+//
+//	conn, err := db.WithReconnect(ctx, cfg.OpenAndPing, db.DefaultReconnectConfig())
+//
+// Select, Query, QueryRow, Exec, AsyncInsert, PrepareBatch, and Ping are
+// retried. Prepared batches and returned rows are bound to the connection
+// they were created on and are not retried. A replaced connection is only
+// closed once all calls, rows, and batches that use it are done, so that a
+// broken connection error of one call doesn't abort unrelated reads and
+// writes. Rows must therefore be closed and batches sent, aborted, or closed.
+// Note that a retried insert may be applied twice if the connection broke
+// after the server received it.
+func WithReconnect(ctx context.Context, open func(ctx context.Context) (driver.Conn, error), cfg *ReconnectConfig) (driver.Conn, error) {
+	if open == nil {
+		return nil, fmt.Errorf("open must not be nil")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("reconnect config: %w", err)
+	}
+
+	conn, err := open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reconnectConn{open: open, cfg: cfg, conn: &trackedConn{Conn: conn}}, nil
+}
+
+// isBrokenConn returns true if err indicates that the connection to the
+// server is no longer usable.
+func isBrokenConn(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, net.ErrClosed)
+}
+
+// current returns the connection that calls should use.
+func (c *reconnectConn) current() *trackedConn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn
+}
+
+// acquire returns the current connection and marks it as used. The caller
+// must release it.
+func (c *reconnectConn) acquire() *trackedConn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.conn.acquire()
+	return c.conn
+}
+
+// retry calls fn with the current connection and re-opens the connection
+// if fn fails with a broken connection error. The connection is used until fn
+// returns; fn must acquire it again to use it afterward.
+func (c *reconnectConn) retry(ctx context.Context, op string, fn func(conn *trackedConn) error) error {
+	backoff := c.cfg.Backoff
+	for attempt := 0; ; attempt++ {
+		conn := c.acquire()
+
+		err := fn(conn)
+		conn.release()
+		if err == nil || !isBrokenConn(err) || attempt >= c.cfg.MaxRetries {
+			return err
+		}
+
+		slog.Warn("Reconnecting to clickhouse after broken connection", "op", op, "attempt", attempt+1, "retry_in", backoff, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, c.cfg.MaxBackoff)
+
+		if reconnErr := c.reconnect(ctx, conn); reconnErr != nil {
+			slog.Warn("Failed to reconnect to clickhouse", "op", op, "err", reconnErr)
+		}
+	}
+}
+
+// reconnect replaces broken with a new connection unless another call has
+// already replaced it. The broken connection is closed once it is no longer
+// used.
+func (c *reconnectConn) reconnect(ctx context.Context, broken *trackedConn) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != broken {
+		return nil
+	}
+
+	conn, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.conn = &trackedConn{Conn: conn}
+	broken.retire()
+
+	return nil
+}
+
+func (c *reconnectConn) Contributors() []string {
+	return c.current().Contributors()
+}
+
+func (c *reconnectConn) ServerVersion() (*driver.ServerVersion, error) {
+	return c.current().ServerVersion()
+}
+
+func (c *reconnectConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	return c.retry(ctx, "Select", func(conn *trackedConn) error {
+		return conn.Select(ctx, dest, query, args...)
+	})
+}
+
+func (c *reconnectConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	var rows driver.Rows
+	err := c.retry(ctx, "Query", func(conn *trackedConn) error {
+		r, err := conn.Query(ctx, query, args...)
+		if err != nil || r == nil {
+			rows = r
+			return err
+		}
+
+		conn.acquire()
+		rows = &trackedRows{Rows: r, release: conn.release}
+		return nil
+	})
+	return rows, err
+}
+
+func (c *reconnectConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	var row driver.Row
+	_ = c.retry(ctx, "QueryRow", func(conn *trackedConn) error {
+		row = conn.QueryRow(ctx, query, args...)
+		if row == nil {
+			return nil
+		}
+		return row.Err()
+	})
+	return row
+}
+
+func (c *reconnectConn) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	var batch driver.Batch
+	err := c.retry(ctx, "PrepareBatch", func(conn *trackedConn) error {
+		b, err := conn.PrepareBatch(ctx, query, opts...)
+		if err != nil || b == nil {
+			batch = b
+			return err
+		}
+
+		conn.acquire()
+		batch = &trackedBatch{Batch: b, release: conn.release}
+		return nil
+	})
+	return batch, err
+}
+
+func (c *reconnectConn) Exec(ctx context.Context, query string, args ...any) error {
+	return c.retry(ctx, "Exec", func(conn *trackedConn) error {
+		return conn.Exec(ctx, query, args...)
+	})
+}
+
+func (c *reconnectConn) AsyncInsert(ctx context.Context, query string, wait bool, args ...any) error {
+	return c.retry(ctx, "AsyncInsert", func(conn *trackedConn) error {
+		return conn.AsyncInsert(ctx, query, wait, args...)
+	})
+}
+
+func (c *reconnectConn) Ping(ctx context.Context) error {
+	return c.retry(ctx, "Ping", func(conn *trackedConn) error {
+		return conn.Ping(ctx)
+	})
+}
+
+func (c *reconnectConn) Stats() driver.Stats {
+	return c.current().Stats()
+}
+
+func (c *reconnectConn) Close() error {
+	return c.current().Close()
+}
+
+// trackedRows releases its connection once the rows are read or closed.
+type trackedRows struct {
+	driver.Rows
+	release func()
+	once    sync.Once
+}
+
+func (r *trackedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.once.Do(r.release)
+	return false
+}
+
+func (r *trackedRows) Close() error {
+	defer r.once.Do(r.release)
+	return r.Rows.Close()
+}
+
+// trackedBatch releases its connection once the batch is sent, aborted, or
+// closed.
+type trackedBatch struct {
+	driver.Batch
+	release func()
+	once    sync.Once
+}
+
+func (b *trackedBatch) Send() error {
+	defer b.once.Do(b.release)
+	return b.Batch.Send()
+}
+
+func (b *trackedBatch) Abort() error {
+	defer b.once.Do(b.release)
+	return b.Batch.Abort()
+}
+
+func (b *trackedBatch) Close() error {
+	defer b.once.Do(b.release)
+	return b.Batch.Close()
+}
@@ -0,0 +1,233 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenConn is a mockConn whose Exec fails with execErr.
+type brokenConn struct {
+	mockConn
+	execErr error
+	closed  bool
+}
+
+func (c *brokenConn) Exec(_ context.Context, _ string, _ ...any) error { return c.execErr }
+func (c *brokenConn) Close() error                                     { c.closed = true; return nil }
+
+func TestWithReconnect(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var conns []*brokenConn
+		open := func(errs ...error) func(ctx context.Context) (driver.Conn, error) {
+			return func(ctx context.Context) (driver.Conn, error) {
+				conn := &brokenConn{execErr: errs[len(conns)]}
+				conns = append(conns, conn)
+				return conn, nil
+			}
+		}
+
+		conn, err := WithReconnect(context.Background(), open(io.EOF, fmt.Errorf("write: %w", syscall.EPIPE), nil), DefaultReconnectConfig())
+		require.NoError(t, err)
+
+		require.NoError(t, conn.Exec(context.Background(), "SELECT 1"))
+		require.Len(t, conns, 3)
+		assert.True(t, conns[0].closed)
+		assert.True(t, conns[1].closed)
+		assert.False(t, conns[2].closed)
+
+		// other errors are returned without reconnecting
+		conns = nil
+		boom := errors.New("boom")
+		conn, err = WithReconnect(context.Background(), open(boom), DefaultReconnectConfig())
+		require.NoError(t, err)
+		assert.ErrorIs(t, conn.Exec(context.Background(), "SELECT 1"), boom)
+		assert.Len(t, conns, 1)
+
+		// retries are bounded
+		conns = nil
+		cfg := DefaultReconnectConfig()
+		cfg.MaxRetries = 1
+		conn, err = WithReconnect(context.Background(), open(io.EOF, io.EOF), cfg)
+		require.NoError(t, err)
+		assert.ErrorIs(t, conn.Exec(context.Background(), "SELECT 1"), io.EOF)
+		assert.Len(t, conns, 2)
+	})
+}
+
+func TestReconnectConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultReconnectConfig().Validate())
+
+	cfg := DefaultReconnectConfig()
+	cfg.MaxRetries = -1
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultReconnectConfig()
+	cfg.MaxBackoff = cfg.Backoff / 2
+	assert.Error(t, cfg.Validate())
+}
+
+// sharedConn is a mockConn that records whether its rows and batches are
+// used after it was closed.
+type sharedConn struct {
+	mockConn
+	execErr        error
+	closes         atomic.Int32
+	usedAfterClose atomic.Bool
+}
+
+func (c *sharedConn) use() {
+	if c.closes.Load() > 0 {
+		c.usedAfterClose.Store(true)
+	}
+}
+
+func (c *sharedConn) Exec(_ context.Context, _ string, _ ...any) error { return c.execErr }
+func (c *sharedConn) Close() error                                     { c.closes.Add(1); return nil }
+
+func (c *sharedConn) Query(_ context.Context, _ string, _ ...any) (driver.Rows, error) {
+	c.use()
+	return &sharedRows{conn: c, left: 2}, nil
+}
+
+func (c *sharedConn) PrepareBatch(_ context.Context, _ string, _ ...driver.PrepareBatchOption) (driver.Batch, error) {
+	c.use()
+	return &sharedBatch{conn: c}, nil
+}
+
+type sharedRows struct {
+	driver.Rows
+	conn *sharedConn
+	left int
+}
+
+func (r *sharedRows) Next() bool {
+	r.conn.use()
+	r.left--
+	return r.left >= 0
+}
+
+func (r *sharedRows) Close() error {
+	r.conn.use()
+	return nil
+}
+
+type sharedBatch struct {
+	mockBatch
+	conn *sharedConn
+}
+
+func (b *sharedBatch) Send() error {
+	b.conn.use()
+	return nil
+}
+
+// openShared returns an open function whose first broken connections fail
+// Exec with io.EOF.
+func openShared(conns *[]*sharedConn, broken int) func(ctx context.Context) (driver.Conn, error) {
+	var mu sync.Mutex
+	return func(ctx context.Context) (driver.Conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		conn := &sharedConn{}
+		if len(*conns) < broken {
+			conn.execErr = io.EOF
+		}
+		*conns = append(*conns, conn)
+		return conn, nil
+	}
+}
+
+func TestWithReconnect_keepsBrokenConnInUse(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var conns []*sharedConn
+		conn, err := WithReconnect(context.Background(), openShared(&conns, 1), DefaultReconnectConfig())
+		require.NoError(t, err)
+
+		rows, err := conn.Query(context.Background(), "SELECT 1")
+		require.NoError(t, err)
+
+		batch, err := conn.PrepareBatch(context.Background(), "INSERT INTO t")
+		require.NoError(t, err)
+
+		// another call breaks the connection and replaces it
+		require.NoError(t, conn.Exec(context.Background(), "SELECT 1"))
+		require.Len(t, conns, 2)
+		assert.Zero(t, conns[0].closes.Load())
+
+		// the rows and the batch still use the replaced connection
+		for rows.Next() {
+		}
+		require.NoError(t, rows.Close())
+		assert.Zero(t, conns[0].closes.Load())
+
+		require.NoError(t, batch.Send())
+		assert.EqualValues(t, 1, conns[0].closes.Load())
+		assert.False(t, conns[0].usedAfterClose.Load())
+
+		// releasing again doesn't close the connection twice
+		require.NoError(t, batch.Close())
+		require.NoError(t, rows.Close())
+		assert.EqualValues(t, 1, conns[0].closes.Load())
+		assert.Zero(t, conns[1].closes.Load())
+	})
+}
+
+func TestWithReconnect_concurrent(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var conns []*sharedConn
+		conn, err := WithReconnect(context.Background(), openShared(&conns, 3), DefaultReconnectConfig())
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				for range 20 {
+					rows, err := conn.Query(context.Background(), "SELECT 1")
+					if !assert.NoError(t, err) {
+						return
+					}
+					for rows.Next() {
+						time.Sleep(time.Millisecond)
+					}
+					assert.NoError(t, rows.Close())
+
+					batch, err := conn.PrepareBatch(context.Background(), "INSERT INTO t")
+					if !assert.NoError(t, err) {
+						return
+					}
+					time.Sleep(time.Millisecond)
+					assert.NoError(t, batch.Send())
+				}
+			})
+		}
+
+		wg.Go(func() {
+			assert.NoError(t, conn.Exec(context.Background(), "SELECT 1"))
+		})
+
+		wg.Wait()
+
+		require.Len(t, conns, 4)
+		for i, c := range conns {
+			assert.False(t, c.usedAfterClose.Load(), i)
+			if i < len(conns)-1 {
+				assert.EqualValues(t, 1, c.closes.Load(), i)
+			} else {
+				assert.Zero(t, c.closes.Load(), i)
+			}
+		}
+	})
+}
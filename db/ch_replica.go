@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// attrKeyRole distinguishes the spans and stats metrics of the writer and
+// reader connections of a [ClickHouseReplicaConfig].
+var attrKeyRole = attribute.Key("db.clickhouse.role")
+
+// ClickHouseReplicaConfig extends a [ClickHouseConfig] for the primary with
+// the addresses of read replicas. API services use it to route heavy
+// analytical SELECTs to the replicas while keeping inserts on the primary.
+type ClickHouseReplicaConfig struct {
+	// Primary configures the writer connection. The reader connection uses
+	// the same credentials, database, TLS, and settings.
+	Primary *ClickHouseConfig
+
+	// Replicas are the host:port addresses of the read replicas. The reader
+	// connection balances new connections round-robin across them. If empty,
+	// reads use the writer connection.
+	Replicas []string
+}
+
+// DefaultClickHouseReplicaConfig returns a [ClickHouseReplicaConfig] for
+// local use that reads from the primary, see [DefaultClickHouseConfig].
+func DefaultClickHouseReplicaConfig(name string) *ClickHouseReplicaConfig {
+	return &ClickHouseReplicaConfig{
+		Primary: DefaultClickHouseConfig(name),
+	}
+}
+
+// Validate checks the [ClickHouseReplicaConfig] for validity. Replica
+// addresses must be of the form host:port.
+func (cfg *ClickHouseReplicaConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	for _, addr := range cfg.Replicas {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return fmt.Errorf("replica address %q must be of the form host:port: %w", addr, err)
+		}
+
+		if host == "" {
+			return fmt.Errorf("replica address %q must include a host", addr)
+		}
+
+		if p, err := strconv.Atoi(port); err != nil || p <= 0 {
+			return fmt.Errorf("replica address %q must include a positive port", addr)
+		}
+	}
+
+	return cfg.Primary.Validate()
+}
+
+// LogValue implements [slog.LogValuer] and redacts the password.
+func (cfg *ClickHouseReplicaConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Any("primary", cfg.Primary),
+		slog.Any("replicas", cfg.Replicas),
+	)
+}
+
+// ReaderOptions returns the [clickhouse.Options] of the reader connection. It
+// returns the options of the primary if no replicas are configured.
+func (cfg *ClickHouseReplicaConfig) ReaderOptions() *clickhouse.Options {
+	opts := cfg.Primary.Options()
+	if len(cfg.Replicas) == 0 {
+		return opts
+	}
+
+	opts.Addr = cfg.Replicas
+	opts.ConnOpenStrategy = clickhouse.ConnOpenRoundRobin
+
+	return opts
+}
+
+// ClickHouseReadWriteConns holds the connections that are opened by
+// [ClickHouseReplicaConfig.OpenAndPing]. Use Writer for inserts and queries
+// that must observe them, and Reader for analytical queries. Reader is the
+// same connection as Writer if no replicas are configured.
+type ClickHouseReadWriteConns struct {
+	Writer driver.Conn
+	Reader driver.Conn
+}
+
+// Close closes both connections.
+func (c *ClickHouseReadWriteConns) Close() error {
+	if c.Reader == c.Writer {
+		return c.Writer.Close()
+	}
+
+	return errors.Join(c.Writer.Close(), c.Reader.Close())
+}
+
+// Ping pings both connections.
+func (c *ClickHouseReadWriteConns) Ping(ctx context.Context) error {
+	if err := c.Writer.Ping(ctx); err != nil {
+		return fmt.Errorf("ping writer: %w", err)
+	}
+
+	if c.Reader == c.Writer {
+		return nil
+	}
+
+	if err := c.Reader.Ping(ctx); err != nil {
+		return fmt.Errorf("ping reader: %w", err)
+	}
+
+	return nil
+}
+
+// OpenAndPing opens and pings the writer connection to the primary and the
+// reader connection to the replicas. The spans and stats metrics of both
+// connections carry a db.clickhouse.role attribute of "writer" or "reader".
+func (cfg *ClickHouseReplicaConfig) OpenAndPing(ctx context.Context) (*ClickHouseReadWriteConns, error) {
+	writer, err := openAndPing(ctx, cfg.Primary.Options(), attrKeyRole.String("writer"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Replicas) == 0 {
+		return &ClickHouseReadWriteConns{Writer: writer, Reader: writer}, nil
+	}
+
+	reader, err := openAndPing(ctx, cfg.ReaderOptions(), attrKeyRole.String("reader"))
+	if err != nil {
+		_ = writer.Close()
+		return nil, fmt.Errorf("open replicas: %w", err)
+	}
+
+	return &ClickHouseReadWriteConns{Writer: writer, Reader: reader}, nil
+}
@@ -0,0 +1,265 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/probe-lab/go-commons/tenant"
+)
+
+// ErrNoRoute is returned by [ClickHouseRouter] when no connection is
+// configured for the requested project and network and the fallback policy
+// does not provide one.
+var ErrNoRoute = errors.New("no clickhouse connection for tenant")
+
+var (
+	attrKeyProject = attribute.Key("project")
+	attrKeyNetwork = attribute.Key("network")
+	attrKeyOutcome = attribute.Key("outcome")
+)
+
+// FallbackPolicy determines how a [ClickHouseRouter] handles requests for a
+// project/network combination that has no dedicated connection.
+type FallbackPolicy int
+
+const (
+	// FallbackReject returns [ErrNoRoute] for unknown tenants.
+	FallbackReject FallbackPolicy = iota
+	// FallbackDefault routes unknown tenants to the connection of
+	// [ClickHouseRouterConfig.DefaultDatabase].
+	FallbackDefault
+)
+
+func (p FallbackPolicy) String() string {
+	switch p {
+	case FallbackReject:
+		return "reject"
+	case FallbackDefault:
+		return "default"
+	default:
+		return fmt.Sprintf("FallbackPolicy(%d)", int(p))
+	}
+}
+
+// ClickHouseRouterConfig holds configuration for a [ClickHouseRouter].
+type ClickHouseRouterConfig struct {
+	// Projects and Networks assign a project/network combination to each
+	// entry in [ClickHouseMultiConfig.Databases]. All three slices must
+	// have the same length.
+	Projects []string
+	Networks []string
+
+	// Fallback determines what happens for unknown tenants.
+	Fallback FallbackPolicy
+
+	// DefaultDatabase is the database that unknown tenants are routed to
+	// if Fallback is [FallbackDefault]. It must be one of
+	// [ClickHouseMultiConfig.Databases].
+	DefaultDatabase string
+
+	// Meter is the OTel meter used to record routing metrics. If nil, the
+	// global meter provider is used.
+	Meter metric.Meter
+}
+
+// DefaultClickHouseRouterConfig returns a [ClickHouseRouterConfig] that
+// rejects unknown tenants.
+func DefaultClickHouseRouterConfig() *ClickHouseRouterConfig {
+	return &ClickHouseRouterConfig{
+		Fallback: FallbackReject,
+	}
+}
+
+// Validate checks the [ClickHouseRouterConfig] against the databases of the
+// given [ClickHouseMultiConfig].
+func (cfg *ClickHouseRouterConfig) Validate(databases []string) error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if len(cfg.Projects) != len(databases) || len(cfg.Networks) != len(databases) {
+		return fmt.Errorf("projects (%d), networks (%d) and databases (%d) must have the same length", len(cfg.Projects), len(cfg.Networks), len(databases))
+	}
+
+	switch cfg.Fallback {
+	case FallbackReject:
+	case FallbackDefault:
+		found := false
+		for _, db := range databases {
+			if db == cfg.DefaultDatabase {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("default database %q must be one of the configured databases", cfg.DefaultDatabase)
+		}
+	default:
+		return fmt.Errorf("unknown fallback policy: %s", cfg.Fallback)
+	}
+
+	return nil
+}
+
+// ClickHouseRouter routes requests to a ClickHouse connection based on the
+// project and network carried in the request context (see [tenant.NewContext]).
+type ClickHouseRouter struct {
+	mapping  Mapping[driver.Conn]
+	fallback driver.Conn
+	policy   FallbackPolicy
+	conns    []driver.Conn
+
+	mRequests metric.Int64Counter
+}
+
+// NewClickHouseRouter creates a [ClickHouseRouter] for the given mapping.
+// The fallback connection is only used with [FallbackDefault] and may be nil
+// otherwise. Use [OpenClickHouseRouter] to open the connections from a
+// [ClickHouseMultiConfig].
+func NewClickHouseRouter(mapping Mapping[driver.Conn], fallback driver.Conn, cfg *ClickHouseRouterConfig) (*ClickHouseRouter, error) {
+	if cfg == nil {
+		cfg = DefaultClickHouseRouterConfig()
+	}
+
+	if cfg.Fallback == FallbackDefault && fallback == nil {
+		return nil, fmt.Errorf("fallback connection must not be nil with %s fallback policy", cfg.Fallback)
+	}
+
+	meter := cfg.Meter
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter("github.com/probe-lab/go-commons/db")
+	}
+
+	r := &ClickHouseRouter{
+		mapping:  mapping,
+		fallback: fallback,
+		policy:   cfg.Fallback,
+	}
+
+	mapping.ForEach(func(_ string, _ string, conn driver.Conn) {
+		r.conns = append(r.conns, conn)
+	})
+
+	var err error
+	if r.mRequests, err = meter.Int64Counter("clickhouse_router.requests",
+		metric.WithDescription("Total number of routed ClickHouse requests by tenant and outcome"),
+	); err != nil {
+		return nil, fmt.Errorf("create clickhouse_router.requests counter: %w", err)
+	}
+
+	return r, nil
+}
+
+// OpenClickHouseRouter opens and pings all databases of the given
+// [ClickHouseMultiConfig] and returns a [ClickHouseRouter] that maps the
+// project/network combinations of cfg onto them. All connections are closed
+// again if any of them fails.
+func OpenClickHouseRouter(ctx context.Context, multi *ClickHouseMultiConfig, cfg *ClickHouseRouterConfig) (*ClickHouseRouter, error) {
+	if err := multi.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid clickhouse config: %w", err)
+	}
+
+	if err := cfg.Validate(multi.Databases); err != nil {
+		return nil, fmt.Errorf("invalid clickhouse router config: %w", err)
+	}
+
+	conns, err := multi.OpenAndPing(ctx)
+	if err != nil {
+		closeConns(conns)
+		return nil, err
+	}
+
+	mapping, err := NewMapping(cfg.Projects, cfg.Networks, conns)
+	if err != nil {
+		closeConns(conns)
+		return nil, err
+	}
+
+	var fallback driver.Conn
+	if cfg.Fallback == FallbackDefault {
+		for i, db := range multi.Databases {
+			if db == cfg.DefaultDatabase {
+				fallback = conns[i]
+				break
+			}
+		}
+	}
+
+	r, err := NewClickHouseRouter(mapping, fallback, cfg)
+	if err != nil {
+		closeConns(conns)
+		return nil, err
+	}
+
+	// keep all connections, including the ones that were shadowed by
+	// duplicate project/network combinations, so that Close releases them.
+	r.conns = conns
+
+	return r, nil
+}
+
+// Conn returns the connection for the tenant in ctx. If ctx carries no
+// tenant or the tenant is unknown, the fallback policy applies.
+func (r *ClickHouseRouter) Conn(ctx context.Context) (driver.Conn, error) {
+	project, network, _ := tenant.FromContext(ctx)
+	return r.route(ctx, project, network)
+}
+
+// Get returns the connection for the given project and network, applying
+// the fallback policy if there is none.
+func (r *ClickHouseRouter) Get(ctx context.Context, project string, network string) (driver.Conn, error) {
+	return r.route(ctx, strings.ToLower(project), strings.ToLower(network))
+}
+
+func (r *ClickHouseRouter) route(ctx context.Context, project string, network string) (driver.Conn, error) {
+	if conn, found := r.mapping.Get(project, network); found {
+		r.mRequests.Add(ctx, 1, metric.WithAttributes(
+			attrKeyProject.String(project),
+			attrKeyNetwork.String(network),
+			attrKeyOutcome.String("routed"),
+		))
+		return conn, nil
+	}
+
+	// Unknown tenants are recorded without project and network to keep the
+	// metric cardinality bounded by the mapping.
+	if r.policy == FallbackDefault {
+		r.mRequests.Add(ctx, 1, metric.WithAttributes(attrKeyOutcome.String("fallback")))
+		return r.fallback, nil
+	}
+
+	r.mRequests.Add(ctx, 1, metric.WithAttributes(attrKeyOutcome.String("rejected")))
+	return nil, fmt.Errorf("%w: project=%q network=%q", ErrNoRoute, project, network)
+}
+
+// Mapping returns the underlying project/network mapping.
+func (r *ClickHouseRouter) Mapping() Mapping[driver.Conn] {
+	return r.mapping
+}
+
+// Close closes all connections of the router. A fallback connection that was
+// passed to [NewClickHouseRouter] but is not part of the mapping is left open.
+func (r *ClickHouseRouter) Close() error {
+	var errs []error
+	for _, conn := range r.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func closeConns(conns []driver.Conn) {
+	for _, conn := range conns {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}
+}
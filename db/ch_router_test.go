@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/probe-lab/go-commons/tenant"
+)
+
+func TestClickHouseRouterConfig_Validate(t *testing.T) {
+	dbs := []string{"db0", "db1"}
+
+	tests := []struct {
+		name    string
+		cfg     *ClickHouseRouterConfig
+		wantErr bool
+	}{
+		{"nil", nil, true},
+		{"valid reject", &ClickHouseRouterConfig{Projects: []string{"p", "p"}, Networks: []string{"n0", "n1"}}, false},
+		{"length mismatch", &ClickHouseRouterConfig{Projects: []string{"p"}, Networks: []string{"n0", "n1"}}, true},
+		{"valid default", &ClickHouseRouterConfig{Projects: []string{"p", "p"}, Networks: []string{"n0", "n1"}, Fallback: FallbackDefault, DefaultDatabase: "db1"}, false},
+		{"unknown default", &ClickHouseRouterConfig{Projects: []string{"p", "p"}, Networks: []string{"n0", "n1"}, Fallback: FallbackDefault, DefaultDatabase: "db2"}, true},
+		{"unknown policy", &ClickHouseRouterConfig{Projects: []string{"p", "p"}, Networks: []string{"n0", "n1"}, Fallback: FallbackPolicy(42)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate(dbs)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClickHouseRouter(t *testing.T) {
+	conn0, conn1, fallback := &mockConn{}, &mockConn{}, &mockConn{}
+
+	mapping, err := NewMapping[driver.Conn](
+		[]string{"Project", "project"},
+		[]string{"mainnet", "testnet"},
+		[]driver.Conn{conn0, conn1},
+	)
+	require.NoError(t, err)
+
+	t.Run("routes by context", func(t *testing.T) {
+		r, err := NewClickHouseRouter(mapping, nil, nil)
+		require.NoError(t, err)
+
+		conn, err := r.Conn(tenant.NewContext(context.Background(), "PROJECT", "testnet"))
+		require.NoError(t, err)
+		assert.Same(t, conn1, conn)
+
+		conn, err = r.Get(context.Background(), "project", "Mainnet")
+		require.NoError(t, err)
+		assert.Same(t, conn0, conn)
+	})
+
+	t.Run("rejects unknown tenant", func(t *testing.T) {
+		r, err := NewClickHouseRouter(mapping, nil, nil)
+		require.NoError(t, err)
+
+		_, err = r.Conn(tenant.NewContext(context.Background(), "project", "devnet"))
+		assert.ErrorIs(t, err, ErrNoRoute)
+
+		_, err = r.Conn(context.Background())
+		assert.ErrorIs(t, err, ErrNoRoute)
+	})
+
+	t.Run("falls back to default", func(t *testing.T) {
+		cfg := DefaultClickHouseRouterConfig()
+		cfg.Fallback = FallbackDefault
+
+		_, err := NewClickHouseRouter(mapping, nil, cfg)
+		require.Error(t, err)
+
+		r, err := NewClickHouseRouter(mapping, fallback, cfg)
+		require.NoError(t, err)
+
+		conn, err := r.Conn(tenant.NewContext(context.Background(), "other", "mainnet"))
+		require.NoError(t, err)
+		assert.Same(t, fallback, conn)
+	})
+
+	t.Run("records metrics", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+		cfg := DefaultClickHouseRouterConfig()
+		cfg.Meter = provider.Meter("test")
+
+		r, err := NewClickHouseRouter(mapping, nil, cfg)
+		require.NoError(t, err)
+
+		ctx := tenant.NewContext(context.Background(), "project", "mainnet")
+		_, _ = r.Conn(ctx)
+		_, _ = r.Conn(ctx)
+		_, _ = r.Get(ctx, "unknown", "mainnet")
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		require.Len(t, rm.ScopeMetrics, 1)
+		require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+
+		sum, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+		require.True(t, ok)
+
+		counts := map[string]int64{}
+		for _, dp := range sum.DataPoints {
+			outcome, _ := dp.Attributes.Value(attrKeyOutcome)
+			network, _ := dp.Attributes.Value(attrKeyNetwork)
+			counts[outcome.AsString()+"/"+network.AsString()] = dp.Value
+		}
+		assert.Equal(t, map[string]int64{"routed/mainnet": 2, "rejected/": 1}, counts)
+	})
+}
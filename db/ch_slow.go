@@ -0,0 +1,195 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// SlowQueryLogConfig holds configuration for [WithSlowQueryLog].
+type SlowQueryLogConfig struct {
+	// Threshold is the duration after which a query is logged as slow.
+	Threshold time.Duration
+	// MaxQueryLength is the number of bytes of the SQL that are logged.
+	// Longer queries are truncated.
+	MaxQueryLength int
+	// Logger receives the slow query warnings. If nil, the default logger
+	// is used.
+	Logger *slog.Logger
+}
+
+// DefaultSlowQueryLogConfig returns a [SlowQueryLogConfig] with sensible
+// defaults.
+func DefaultSlowQueryLogConfig() *SlowQueryLogConfig {
+	return &SlowQueryLogConfig{
+		Threshold:      time.Second,
+		MaxQueryLength: 1000,
+	}
+}
+
+// Validate checks the [SlowQueryLogConfig] for validity.
+func (cfg *SlowQueryLogConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if cfg.Threshold <= 0 {
+		return fmt.Errorf("slow query threshold must be a positive duration")
+	}
+
+	if cfg.MaxQueryLength <= 0 {
+		return fmt.Errorf("slow query max query length must be a positive integer")
+	}
+
+	return nil
+}
+
+// slowQueryConn wraps a [driver.Conn] and logs calls that take longer than
+// the configured threshold.
+type slowQueryConn struct {
+	driver.Conn
+	cfg    *SlowQueryLogConfig
+	logger *slog.Logger
+}
+
+var _ driver.Conn = (*slowQueryConn)(nil)
+
+// WithSlowQueryLog wraps conn so that Query, QueryRow, Select, Exec,
+// AsyncInsert, PrepareBatch, and the Send of prepared batches that exceed
+// [SlowQueryLogConfig.Threshold] are logged at warn level with the
+// truncated SQL, the duration, and the query ID. Every call gets a random
+// query ID unless one was set with [ContextWithQueryID], so that a logged
+// query can be looked up in system.query_log. The duration of Query covers
+// the time until the first block arrives, not reading all rows.
+//
+//	conn, err = db.WithSlowQueryLog(conn, db.DefaultSlowQueryLogConfig())
+func WithSlowQueryLog(conn driver.Conn, cfg *SlowQueryLogConfig) (driver.Conn, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("conn must not be nil")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("slow query log config: %w", err)
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &slowQueryConn{Conn: conn, cfg: cfg, logger: logger}, nil
+}
+
+// start ensures that ctx carries a query ID and returns a function that logs
+// the call if it took longer than the threshold.
+func (c *slowQueryConn) start(ctx context.Context, op string, query string) (context.Context, func(err error)) {
+	ctx, queryID := ensureQueryID(ctx)
+
+	start := time.Now()
+	return ctx, func(err error) {
+		c.log(ctx, op, query, queryID, time.Since(start), err)
+	}
+}
+
+func (c *slowQueryConn) log(ctx context.Context, op string, query string, queryID string, duration time.Duration, err error) {
+	if duration < c.cfg.Threshold {
+		return
+	}
+
+	attrs := []any{
+		"op", op,
+		"query_id", queryID,
+		"duration", duration,
+		"threshold", c.cfg.Threshold,
+		"query", truncateQuery(query, c.cfg.MaxQueryLength),
+	}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+
+	c.logger.WarnContext(ctx, "Slow clickhouse query", attrs...)
+}
+
+func (c *slowQueryConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	ctx, done := c.start(ctx, "Query", query)
+	rows, err := c.Conn.Query(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (c *slowQueryConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	ctx, done := c.start(ctx, "QueryRow", query)
+	row := c.Conn.QueryRow(ctx, query, args...)
+	if row != nil {
+		done(row.Err())
+	} else {
+		done(nil)
+	}
+	return row
+}
+
+func (c *slowQueryConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, done := c.start(ctx, "Select", query)
+	err := c.Conn.Select(ctx, dest, query, args...)
+	done(err)
+	return err
+}
+
+func (c *slowQueryConn) Exec(ctx context.Context, query string, args ...any) error {
+	ctx, done := c.start(ctx, "Exec", query)
+	err := c.Conn.Exec(ctx, query, args...)
+	done(err)
+	return err
+}
+
+func (c *slowQueryConn) AsyncInsert(ctx context.Context, query string, wait bool, args ...any) error {
+	ctx, done := c.start(ctx, "AsyncInsert", query)
+	err := c.Conn.AsyncInsert(ctx, query, wait, args...)
+	done(err)
+	return err
+}
+
+func (c *slowQueryConn) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	ctx, done := c.start(ctx, "PrepareBatch", query)
+	batch, err := c.Conn.PrepareBatch(ctx, query, opts...)
+	done(err)
+	if err != nil {
+		return batch, err
+	}
+
+	return &slowQueryBatch{Batch: batch, conn: c, ctx: ctx, query: query}, nil
+}
+
+// slowQueryBatch logs slow sends of a prepared batch. The batch keeps the
+// context it was prepared with, so the send shares the query ID.
+type slowQueryBatch struct {
+	driver.Batch
+	conn  *slowQueryConn
+	ctx   context.Context
+	query string
+}
+
+func (b *slowQueryBatch) Send() error {
+	_, done := b.conn.start(b.ctx, "Send", b.query)
+	err := b.Batch.Send()
+	done(err)
+	return err
+}
+
+// truncateQuery shortens query to at most n bytes without splitting a UTF-8
+// character and marks truncated queries with an ellipsis.
+func truncateQuery(query string, n int) string {
+	if len(query) <= n {
+		return query
+	}
+
+	for n > 0 && !utf8.RuneStart(query[n]) {
+		n--
+	}
+
+	return query[:n] + "…"
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sleepConn is a mockConn whose Exec takes the given duration.
+type sleepConn struct {
+	mockConn
+	sleep time.Duration
+}
+
+func (c *sleepConn) Exec(_ context.Context, _ string, _ ...any) error {
+	time.Sleep(c.sleep)
+	return nil
+}
+
+func TestWithSlowQueryLog(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var buf bytes.Buffer
+
+		cfg := DefaultSlowQueryLogConfig()
+		cfg.MaxQueryLength = 12
+		cfg.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+
+		inner := &sleepConn{sleep: 500 * time.Millisecond}
+		conn, err := WithSlowQueryLog(inner, cfg)
+		require.NoError(t, err)
+
+		require.NoError(t, conn.Exec(context.Background(), "SELECT 1"))
+		assert.Empty(t, buf.String())
+
+		inner.sleep = 2 * time.Second
+		ctx := ContextWithQueryID(context.Background(), "my-query")
+		require.NoError(t, conn.Exec(ctx, "SELECT count() FROM visits"))
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "WARN", entry["level"])
+		assert.Equal(t, "Exec", entry["op"])
+		assert.Equal(t, "my-query", entry["query_id"])
+		assert.Equal(t, "SELECT count…", entry["query"])
+		assert.EqualValues(t, 2*time.Second, entry["duration"])
+
+		buf.Reset()
+		require.NoError(t, conn.Exec(context.Background(), "SELECT 1"))
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.NotEmpty(t, entry["query_id"])
+		assert.NotEqual(t, "my-query", entry["query_id"])
+	})
+}
+
+func TestSlowQueryLogConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultSlowQueryLogConfig().Validate())
+	assert.Error(t, (&SlowQueryLogConfig{MaxQueryLength: 10}).Validate())
+	assert.Error(t, (&SlowQueryLogConfig{Threshold: time.Second}).Validate())
+
+	_, err := WithSlowQueryLog(nil, DefaultSlowQueryLogConfig())
+	assert.Error(t, err)
+}
+
+func Test_truncateQuery(t *testing.T) {
+	assert.Equal(t, "SELECT 1", truncateQuery("SELECT 1", 8))
+	assert.Equal(t, "SELECT…", truncateQuery("SELECT 1", 6))
+	assert.Equal(t, "SELECT '…", truncateQuery("SELECT 'ä'", 9))
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+)
+
+// ReportClickHouseStatsMetrics registers observable gauges that report the
+// connection pool stats of conn, similar to what otelsql.ReportDBStatsMetrics
+// does for Postgres. The gauges carry the db.system and db.namespace
+// attributes. If meter is nil, the global meter provider is used. Call
+// Unregister on the returned registration once conn is closed. Connections
+// returned by [ClickHouseConfig.OpenAndPing] already report their stats and
+// unregister on Close.
+func ReportClickHouseStatsMetrics(conn driver.Conn, database string, meter metric.Meter) (metric.Registration, error) {
+	return reportClickHouseStatsMetrics(conn, meter, []attribute.KeyValue{
+		semconv.DBSystemClickhouse,
+		semconv.DBNamespace(database),
+	})
+}
+
+func reportClickHouseStatsMetrics(conn driver.Conn, meter metric.Meter, kvs []attribute.KeyValue) (metric.Registration, error) {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter("github.com/probe-lab/go-commons/db")
+	}
+
+	maxOpenConns, err := meter.Int64ObservableGauge("clickhouse.connections_max_open",
+		metric.WithDescription("Maximum number of open connections to the database"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create clickhouse.connections_max_open gauge: %w", err)
+	}
+
+	maxIdleConns, err := meter.Int64ObservableGauge("clickhouse.connections_max_idle",
+		metric.WithDescription("Maximum number of idle connections to the database"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create clickhouse.connections_max_idle gauge: %w", err)
+	}
+
+	openConns, err := meter.Int64ObservableGauge("clickhouse.connections_open",
+		metric.WithDescription("The number of established connections both in use and idle"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create clickhouse.connections_open gauge: %w", err)
+	}
+
+	inUseConns, err := meter.Int64ObservableGauge("clickhouse.connections_in_use",
+		metric.WithDescription("The number of connections currently in use"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create clickhouse.connections_in_use gauge: %w", err)
+	}
+
+	idleConns, err := meter.Int64ObservableGauge("clickhouse.connections_idle",
+		metric.WithDescription("The number of idle connections"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create clickhouse.connections_idle gauge: %w", err)
+	}
+
+	attrs := metric.WithAttributes(kvs...)
+
+	return meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		stats := conn.Stats()
+
+		o.ObserveInt64(maxOpenConns, int64(stats.MaxOpenConns), attrs)
+		o.ObserveInt64(maxIdleConns, int64(stats.MaxIdleConns), attrs)
+		o.ObserveInt64(openConns, int64(stats.Open), attrs)
+		o.ObserveInt64(inUseConns, int64(stats.Open-stats.Idle), attrs)
+		o.ObserveInt64(idleConns, int64(stats.Idle), attrs)
+
+		return nil
+	}, maxOpenConns, maxIdleConns, openConns, inUseConns, idleConns)
+}
+
+// statsConn unregisters the stats metrics of a connection when it is closed.
+type statsConn struct {
+	driver.Conn
+	reg metric.Registration
+}
+
+func (c *statsConn) Close() error {
+	return errors.Join(c.Conn.Close(), c.reg.Unregister())
+}
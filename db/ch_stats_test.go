@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type statsMockConn struct {
+	mockConn
+	stats driver.Stats
+}
+
+func (m *statsMockConn) Stats() driver.Stats { return m.stats }
+
+func TestReportClickHouseStatsMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	conn := &statsMockConn{stats: driver.Stats{MaxOpenConns: 10, MaxIdleConns: 5, Open: 4, Idle: 1}}
+
+	reg, err := ReportClickHouseStatsMetrics(conn, "testdb", provider.Meter("test"))
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	got := map[string]int64{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		gauge, ok := m.Data.(metricdata.Gauge[int64])
+		require.True(t, ok)
+		require.Len(t, gauge.DataPoints, 1)
+
+		ns, _ := gauge.DataPoints[0].Attributes.Value("db.namespace")
+		assert.Equal(t, "testdb", ns.AsString())
+
+		got[m.Name] = gauge.DataPoints[0].Value
+	}
+
+	assert.Equal(t, map[string]int64{
+		"clickhouse.connections_max_open": 10,
+		"clickhouse.connections_max_idle": 5,
+		"clickhouse.connections_open":     4,
+		"clickhouse.connections_in_use":   3,
+		"clickhouse.connections_idle":     1,
+	}, got)
+
+	// closing the connection unregisters the callback
+	wrapped := &statsConn{Conn: conn, reg: reg}
+	require.NoError(t, wrapped.Close())
+
+	rm = metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			gauge := m.Data.(metricdata.Gauge[int64])
+			assert.Empty(t, gauge.DataPoints, m.Name)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// attrKeyDBStatement is the statement attribute that otelsql records for our
+// Postgres spans. We use the same key so that ClickHouse and Postgres spans
+// can be queried alike.
+var attrKeyDBStatement = attribute.Key("db.statement")
+
+// tracedConn wraps a [driver.Conn] and creates a client span for every call
+// that talks to the ClickHouse server.
+type tracedConn struct {
+	driver.Conn
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+}
+
+var _ driver.Conn = (*tracedConn)(nil)
+
+// WithTracing wraps conn so that Query, QueryRow, Select, Exec, AsyncInsert,
+// PrepareBatch, and the Send of prepared batches create OpenTelemetry spans
+// with the db.system, db.namespace, and db.statement attributes. If tp is
+// nil, the global tracer provider is used. Connections returned by
+// [ClickHouseConfig.OpenAndPing] are already wrapped.
+func WithTracing(conn driver.Conn, database string, tp trace.TracerProvider) driver.Conn {
+	return withTracing(conn, tp, []attribute.KeyValue{
+		semconv.DBSystemClickhouse,
+		semconv.DBNamespace(database),
+	})
+}
+
+func withTracing(conn driver.Conn, tp trace.TracerProvider, attrs []attribute.KeyValue) *tracedConn {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return &tracedConn{
+		Conn:   conn,
+		tracer: tp.Tracer("github.com/probe-lab/go-commons/db"),
+		attrs:  attrs,
+	}
+}
+
+func (c *tracedConn) start(ctx context.Context, op string, query string) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, "clickhouse."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(c.attrs...),
+		trace.WithAttributes(attrKeyDBStatement.String(query)),
+	)
+}
+
+func (c *tracedConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	ctx, span := c.start(ctx, "Query", query)
+	defer span.End()
+
+	rows, err := c.Conn.Query(ctx, query, args...)
+	recordSpanError(span, err)
+	return rows, err
+}
+
+func (c *tracedConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	ctx, span := c.start(ctx, "QueryRow", query)
+	defer span.End()
+
+	row := c.Conn.QueryRow(ctx, query, args...)
+	if row != nil {
+		recordSpanError(span, row.Err())
+	}
+	return row
+}
+
+func (c *tracedConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := c.start(ctx, "Select", query)
+	defer span.End()
+
+	err := c.Conn.Select(ctx, dest, query, args...)
+	recordSpanError(span, err)
+	return err
+}
+
+func (c *tracedConn) Exec(ctx context.Context, query string, args ...any) error {
+	ctx, span := c.start(ctx, "Exec", query)
+	defer span.End()
+
+	err := c.Conn.Exec(ctx, query, args...)
+	recordSpanError(span, err)
+	return err
+}
+
+func (c *tracedConn) AsyncInsert(ctx context.Context, query string, wait bool, args ...any) error {
+	ctx, span := c.start(ctx, "AsyncInsert", query)
+	defer span.End()
+
+	err := c.Conn.AsyncInsert(ctx, query, wait, args...)
+	recordSpanError(span, err)
+	return err
+}
+
+func (c *tracedConn) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	ctx, span := c.start(ctx, "PrepareBatch", query)
+	defer span.End()
+
+	batch, err := c.Conn.PrepareBatch(ctx, query, opts...)
+	recordSpanError(span, err)
+	if err != nil {
+		return batch, err
+	}
+
+	return &tracedBatch{Batch: batch, conn: c, ctx: ctx, query: query}, nil
+}
+
+// tracedBatch creates a span for sending a prepared batch. The span is a
+// child of the PrepareBatch span because the batch keeps the context it was
+// prepared with.
+type tracedBatch struct {
+	driver.Batch
+	conn  *tracedConn
+	ctx   context.Context
+	query string
+}
+
+func (b *tracedBatch) Send() error {
+	_, span := b.conn.start(b.ctx, "Send", b.query)
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("db.clickhouse.batch.rows", b.Batch.Rows()))
+
+	err := b.Batch.Send()
+	recordSpanError(span, err)
+	return err
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanAttr(t *testing.T, attrs []attribute.KeyValue, key attribute.Key) string {
+	t.Helper()
+	for _, a := range attrs {
+		if a.Key == key {
+			return a.Value.Emit()
+		}
+	}
+	t.Fatalf("attribute %s not found", key)
+	return ""
+}
+
+func TestWithTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	conn := WithTracing(&mockConn{batch: &mockBatch{}}, "testdb", tp)
+	ctx := context.Background()
+
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE t (a UInt8)"))
+
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO t")
+	require.NoError(t, err)
+	require.NoError(t, batch.Append(1))
+	require.NoError(t, batch.Send())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, "clickhouse.Exec", spans[0].Name())
+	assert.Equal(t, "clickhouse", spanAttr(t, spans[0].Attributes(), "db.system"))
+	assert.Equal(t, "testdb", spanAttr(t, spans[0].Attributes(), "db.namespace"))
+	assert.Equal(t, "CREATE TABLE t (a UInt8)", spanAttr(t, spans[0].Attributes(), "db.statement"))
+
+	assert.Equal(t, "clickhouse.PrepareBatch", spans[1].Name())
+	assert.Equal(t, "clickhouse.Send", spans[2].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[2].Parent().SpanID())
+	assert.Equal(t, "INSERT INTO t", spanAttr(t, spans[2].Attributes(), "db.statement"))
+}
+
+func TestWithTracing_error(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	conn := WithTracing(&mockConn{prepareErr: errors.New("boom")}, "testdb", tp)
+
+	_, err := conn.PrepareBatch(context.Background(), "INSERT INTO t")
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "boom", spans[0].Status().Description)
+}
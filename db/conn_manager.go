@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// ConnManager owns one connection of type T per project/network combination
+// of a [Mapping], e.g., for multi-network services that write every network
+// to its own database. It implements [Pinger], so it can be passed to a
+// [HealthChecker], and closes all connections with [ConnManager.Close].
+type ConnManager[T any] struct {
+	conns Mapping[T]
+	ping  func(ctx context.Context, conn T) error
+	close func(conn T) error
+}
+
+var _ Pinger = (*ConnManager[any])(nil)
+
+// OpenConnManager opens a connection for every config in cfgs with open.
+// The manager pings connections with ping and closes them with close. If a
+// connection fails to open, all connections that were opened so far are
+// closed. Use [OpenClickHouseConnManager] or [OpenPostgresConnManager] for
+// the configs of this package.
+func OpenConnManager[C any, T any](
+	ctx context.Context,
+	cfgs Mapping[C],
+	open func(ctx context.Context, cfg C) (T, error),
+	ping func(ctx context.Context, conn T) error,
+	close func(conn T) error,
+) (*ConnManager[T], error) {
+	if cfgs.Len() == 0 {
+		return nil, fmt.Errorf("mapping must not be empty")
+	}
+
+	m := &ConnManager[T]{
+		conns: Mapping[T]{},
+		ping:  ping,
+		close: close,
+	}
+
+	err := cfgs.ForEachE(ctx, func(project string, network string, cfg C) error {
+		conn, err := open(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		if _, found := m.conns[project]; !found {
+			m.conns[project] = map[string]T{}
+		}
+		m.conns[project][network] = conn
+
+		return nil
+	})
+	if err != nil {
+		if closeErr := m.Close(); closeErr != nil {
+			slog.Warn("Failed to close connections after open failed", "err", closeErr)
+		}
+		return nil, fmt.Errorf("open connection %w", err)
+	}
+
+	return m, nil
+}
+
+// OpenClickHouseConnManager opens a ClickHouse connection for every config
+// in cfgs with [ClickHouseConfig.OpenAndPing].
+func OpenClickHouseConnManager(ctx context.Context, cfgs Mapping[*ClickHouseConfig]) (*ConnManager[driver.Conn], error) {
+	return OpenConnManager(ctx, cfgs,
+		func(ctx context.Context, cfg *ClickHouseConfig) (driver.Conn, error) {
+			return cfg.OpenAndPing(ctx)
+		},
+		func(ctx context.Context, conn driver.Conn) error { return conn.Ping(ctx) },
+		func(conn driver.Conn) error { return conn.Close() },
+	)
+}
+
+// OpenPostgresConnManager opens a Postgres handle for every config in cfgs
+// with [PostgresConfig.OpenAndPing].
+func OpenPostgresConnManager(ctx context.Context, cfgs Mapping[*PostgresConfig]) (*ConnManager[*sql.DB], error) {
+	return OpenConnManager(ctx, cfgs,
+		func(ctx context.Context, cfg *PostgresConfig) (*sql.DB, error) {
+			return cfg.OpenAndPing(ctx)
+		},
+		func(ctx context.Context, handle *sql.DB) error { return handle.PingContext(ctx) },
+		func(handle *sql.DB) error { return handle.Close() },
+	)
+}
+
+// Get returns the connection of the given project and network.
+func (m *ConnManager[T]) Get(project string, network string) (T, bool) {
+	return m.conns.Get(project, network)
+}
+
+// Mapping returns the connections keyed by project and network. The
+// returned mapping must not be modified.
+func (m *ConnManager[T]) Mapping() Mapping[T] {
+	return m.conns
+}
+
+// Ping pings all connections concurrently and returns the joined errors of
+// all failed pings.
+func (m *ConnManager[T]) Ping(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	m.conns.ForEach(func(project string, network string, conn T) {
+		wg.Go(func() {
+			if err := m.ping(ctx, conn); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("ping %s/%s: %w", project, network, err))
+				mu.Unlock()
+			}
+		})
+	})
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Close closes all connections and returns the joined errors of all that
+// failed to close.
+func (m *ConnManager[T]) Close() error {
+	var errs []error
+	m.conns.ForEach(func(project string, network string, conn T) {
+		if err := m.close(conn); err != nil {
+			errs = append(errs, fmt.Errorf("close %s/%s: %w", project, network, err))
+		}
+	})
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeManagedConn struct {
+	name    string
+	pingErr error
+	closed  bool
+}
+
+func openFakeConnManager(ctx context.Context, cfgs Mapping[string], failOn string) (*ConnManager[*fakeManagedConn], []*fakeManagedConn, error) {
+	var opened []*fakeManagedConn
+	m, err := OpenConnManager(ctx, cfgs,
+		func(_ context.Context, name string) (*fakeManagedConn, error) {
+			if name == failOn {
+				return nil, errors.New("connection refused")
+			}
+			conn := &fakeManagedConn{name: name}
+			opened = append(opened, conn)
+			return conn, nil
+		},
+		func(_ context.Context, conn *fakeManagedConn) error { return conn.pingErr },
+		func(conn *fakeManagedConn) error {
+			conn.closed = true
+			return nil
+		},
+	)
+	return m, opened, err
+}
+
+func TestConnManager(t *testing.T) {
+	cfgs, err := NewMapping([]string{"ipfs", "ipfs"}, []string{"amino", "celestia"}, []string{"db1", "db2"})
+	require.NoError(t, err)
+
+	m, opened, err := openFakeConnManager(context.Background(), cfgs, "")
+	require.NoError(t, err)
+	require.Len(t, opened, 2)
+
+	conn, found := m.Get("ipfs", "celestia")
+	require.True(t, found)
+	assert.Equal(t, "db2", conn.name)
+	assert.Equal(t, 2, m.Mapping().Len())
+
+	assert.NoError(t, m.Ping(context.Background()))
+	conn.pingErr = errors.New("timeout")
+	assert.ErrorContains(t, m.Ping(context.Background()), "ping ipfs/celestia: timeout")
+
+	require.NoError(t, m.Close())
+	for _, conn := range opened {
+		assert.True(t, conn.closed)
+	}
+}
+
+func TestOpenConnManager_closesOnFailure(t *testing.T) {
+	cfgs, err := NewMapping([]string{"ipfs", "ipfs"}, []string{"amino", "celestia"}, []string{"db1", "db2"})
+	require.NoError(t, err)
+
+	m, opened, err := openFakeConnManager(context.Background(), cfgs, "db2")
+	assert.ErrorContains(t, err, "open connection ipfs/celestia: connection refused")
+	assert.Nil(t, m)
+	require.Len(t, opened, 1)
+	assert.True(t, opened[0].closed)
+
+	_, _, err = openFakeConnManager(context.Background(), Mapping[string]{}, "")
+	assert.Error(t, err)
+}
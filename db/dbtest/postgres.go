@@ -0,0 +1,191 @@
+// Package dbtest provides harnesses for integration tests that run against
+// real databases in Docker containers.
+package dbtest
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	mpgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the pgx database/sql driver
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"github.com/probe-lab/go-commons/db"
+)
+
+// PostgresConfig holds configuration for [StartPostgres].
+type PostgresConfig struct {
+	// Image is the Postgres Docker image to start.
+	Image string
+	// Database, User, and Pass are the name of the database that is created
+	// in the container and the credentials of its owner.
+	Database string
+	User     string
+	Pass     string
+	// Migrations are applied to the database before it is handed out. Each
+	// source must contain a migrations directory with golang-migrate files.
+	// Several sources are merged with [db.MergeMigrations].
+	Migrations []fs.ReadDirFS
+}
+
+// DefaultPostgresConfig returns a [PostgresConfig] with sensible defaults
+// that applies the given migrations.
+func DefaultPostgresConfig(migrations ...fs.ReadDirFS) *PostgresConfig {
+	return &PostgresConfig{
+		Image:      "postgres:17-alpine",
+		Database:   "test",
+		User:       "test",
+		Pass:       "test",
+		Migrations: migrations,
+	}
+}
+
+// Validate checks the [PostgresConfig] for validity.
+func (cfg *PostgresConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if cfg.Image == "" {
+		return fmt.Errorf("image must not be empty")
+	}
+
+	if cfg.Database == "" {
+		return fmt.Errorf("database must not be empty")
+	}
+
+	if cfg.User == "" {
+		return fmt.Errorf("user must not be empty")
+	}
+
+	if cfg.Pass == "" {
+		return fmt.Errorf("password must not be empty")
+	}
+
+	return nil
+}
+
+// Postgres is a running Postgres container that is returned by
+// [StartPostgres].
+type Postgres struct {
+	// DB is an open handle to the migrated database.
+	DB *sql.DB
+	// Config points to the database in the container, e.g., to open a pgx
+	// pool with [db.PostgresConfig.OpenPool] or to configure the service
+	// under test.
+	Config *db.PostgresConfig
+}
+
+// StartPostgres starts a Postgres container, applies the migrations of cfg,
+// and returns a handle to the database. The handle is closed and the
+// container is removed when the test finishes. The test is skipped if
+// Docker is not available and fails if anything else goes wrong.
+//
+//	pg := dbtest.StartPostgres(t, dbtest.DefaultPostgresConfig(migrations.FS))
+//	_, err := pg.DB.ExecContext(ctx, "INSERT INTO peers ...")
+func StartPostgres(t *testing.T, cfg *PostgresConfig) *Postgres {
+	t.Helper()
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("postgres harness config: %s", err)
+	}
+
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	ctx := t.Context()
+
+	ctr, err := tcpostgres.Run(ctx, cfg.Image,
+		tcpostgres.WithDatabase(cfg.Database),
+		tcpostgres.WithUsername(cfg.User),
+		tcpostgres.WithPassword(cfg.Pass),
+		tcpostgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(t, ctr)
+	if err != nil {
+		t.Fatalf("start postgres container: %s", err)
+	}
+
+	host, err := ctr.Host(ctx)
+	if err != nil {
+		t.Fatalf("get postgres container host: %s", err)
+	}
+
+	port, err := ctr.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		t.Fatalf("get postgres container port: %s", err)
+	}
+
+	pgCfg := &db.PostgresConfig{
+		BaseConfig: &db.PostgresBaseConfig{
+			Host:    host,
+			Port:    int(port.Num()),
+			User:    cfg.User,
+			Pass:    cfg.Pass,
+			SSLMode: "disable",
+		},
+		Database: cfg.Database,
+	}
+
+	handle, err := sql.Open("pgx", pgCfg.SourceName())
+	if err != nil {
+		t.Fatalf("open postgres database: %s", err)
+	}
+	t.Cleanup(func() { _ = handle.Close() })
+
+	if err := handle.PingContext(ctx); err != nil {
+		t.Fatalf("ping postgres database: %s", err)
+	}
+
+	if len(cfg.Migrations) > 0 {
+		if err := migratePostgres(pgCfg, cfg.Migrations); err != nil {
+			t.Fatalf("migrate postgres database: %s", err)
+		}
+	}
+
+	return &Postgres{DB: handle, Config: pgCfg}
+}
+
+// migratePostgres applies all up migrations to the database that cfg points
+// to. It uses a separate handle because the migrate driver closes its handle.
+func migratePostgres(cfg *db.PostgresConfig, migrations []fs.ReadDirFS) error {
+	merged, err := db.MergeMigrations(migrations...)
+	if err != nil {
+		return err
+	}
+
+	handle, err := sql.Open("pgx", cfg.SourceName())
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+
+	mdriver, err := mpgx.WithInstance(handle, &mpgx.Config{DatabaseName: cfg.Database})
+	if err != nil {
+		_ = handle.Close()
+		return fmt.Errorf("create migrate driver: %w", err)
+	}
+
+	sourceDriver, err := iofs.New(merged, "migrations")
+	if err != nil {
+		_ = mdriver.Close()
+		return fmt.Errorf("create iofs migrations source: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", sourceDriver, cfg.Database, mdriver)
+	if err != nil {
+		_ = mdriver.Close()
+		return fmt.Errorf("create migrate instance: %w", err)
+	}
+	defer func() { _, _ = m.Close() }()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("apply migrations: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package dbtest
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultPostgresConfig().Validate())
+	assert.Error(t, (*PostgresConfig)(nil).Validate())
+
+	cfg := DefaultPostgresConfig()
+	cfg.Image = ""
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultPostgresConfig()
+	cfg.Pass = ""
+	assert.Error(t, cfg.Validate())
+}
+
+func TestStartPostgres(t *testing.T) {
+	migrations := fstest.MapFS{
+		"migrations/000001_peers.up.sql":   {Data: []byte("CREATE TABLE peers (id TEXT PRIMARY KEY);")},
+		"migrations/000001_peers.down.sql": {Data: []byte("DROP TABLE peers;")},
+	}
+
+	pg := StartPostgres(t, DefaultPostgresConfig(migrations))
+
+	_, err := pg.DB.ExecContext(t.Context(), "INSERT INTO peers (id) VALUES ($1)", "12D3KooW")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, pg.DB.QueryRowContext(t.Context(), "SELECT count(*) FROM peers").Scan(&count))
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "test", pg.Config.Database)
+}
@@ -5,7 +5,8 @@
 //
 // [BatchInserter] buffers rows of a given struct type in memory and flushes
 // them to ClickHouse in batches, either when the buffer reaches [BatchInserterConfig.MaxBatchSize]
-// or when [BatchInserterConfig.FlushInterval] elapses. This matches ClickHouse's
+// rows, when it exceeds [BatchInserterConfig.MaxBatchBytes] bytes, or when
+// [BatchInserterConfig.FlushInterval] elapses. This matches ClickHouse's
 // optimal write pattern of thousands of rows per insert.
 //
 // Struct fields are mapped to ClickHouse columns via `ch` struct tags, as
@@ -63,17 +64,106 @@
 //	defer cancel()
 //	if err := group.Stop(shutdownCtx); err != nil { ... }
 //
+// # Replicated and Distributed Tables
+//
+// [BatchInserterConfig.Insert] sends [ClickHouseInsertOptions] with every
+// batch, e.g., synchronous distributed inserts and quorum writes.
+// [BatchInserterConfig.DeduplicationToken] attaches an
+// insert_deduplication_token per batch so that replicated tables drop
+// re-sent batches:
+//
+//	cfg.Insert = &db.ClickHouseInsertOptions{DistributedSync: true, Quorum: "auto"}
+//	cfg.DeduplicationToken = db.HashDeduplicationToken[VisitRow]
+//
+// # Dead Letters
+//
+// [BatchInserterConfig.DeadLetter] persists the rows of failed flushes
+// instead of dropping them, either as JSONEachRow files with
+// [NewFileDeadLetter] or in a secondary table with [NewTableDeadLetter]:
+//
+//	cfg.DeadLetter, err = db.NewFileDeadLetter[VisitRow]("/var/lib/crawler/dead-letter")
+//
 // # Metrics
 //
 // [BatchInserter] emits OpenTelemetry metrics automatically via the global
 // meter provider (configured by [tele.ServeMetrics]). The following instruments
 // are recorded with a "table" attribute and, where relevant, a "trigger" attribute
-// indicating what caused the flush ("size", "interval", "manual", or "stop"):
+// indicating what caused the flush ("size", "bytes", "interval", "manual", or "stop"):
+//
+//   - batch_inserter.rows_flushed       (counter)   — successfully inserted rows
+//   - batch_inserter.rows_dropped       (counter)   — rows lost due to flush errors
+//   - batch_inserter.rows_dead_lettered (counter)   — rows of failed flushes persisted to the dead letter sink
+//   - batch_inserter.flush_duration     (histogram) — time taken per flush, in seconds
+//   - batch_inserter.flush_size         (histogram) — number of rows per flush attempt
 //
-//   - batch_inserter.rows_flushed   (counter)   — successfully inserted rows
-//   - batch_inserter.rows_dropped   (counter)   — rows lost due to flush errors
-//   - batch_inserter.flush_duration (histogram) — time taken per flush, in seconds
-//   - batch_inserter.flush_size     (histogram) — number of rows per flush attempt
+// In addition, every flush is recorded in the shared db_insert.* instruments
+// of [InsertMetrics], which custom writers can record as well so that all
+// writers report their throughput under the same names.
 //
 // To use a custom meter instead of the global one, set [BatchInserterConfig.Meter].
+//
+// # Health Checks
+//
+// [HealthChecker] periodically pings one or more connections and reports
+// changes of their availability through [HealthCheckerConfig.OnChange], e.g.,
+// to the gRPC health server:
+//
+//	cfg := db.DefaultHealthCheckerConfig()
+//	cfg.OnChange = func(healthy bool, err error) {
+//	    status := healthpb.HealthCheckResponse_SERVING
+//	    if !healthy {
+//	        status = healthpb.HealthCheckResponse_NOT_SERVING
+//	    }
+//	    server.SetServingStatus("", status)
+//	}
+//
+//	checker, err := db.NewHealthChecker(cfg, conn)
+//	if err != nil { ... }
+//	go checker.Run(ctx)
+//
+// ClickHouse connections, pgx pools, and the read/write pairs of the replica
+// configs implement [Pinger] directly; database/sql handles are wrapped with
+// [SQLPinger]. [PingAll] checks several connections once, e.g., in a
+// readiness probe:
+//
+//	err := db.PingAll(ctx, 2*time.Second, chConn, db.SQLPinger(pgHandle))
+//
+// # Multi-Tenant Routing
+//
+// [ClickHouseRouter] combines a [ClickHouseMultiConfig] with a [Mapping] of
+// project/network combinations so that requests are routed to the database of
+// their tenant. The tenant travels in the context:
+//
+//	cfg := db.DefaultClickHouseRouterConfig()
+//	cfg.Projects = []string{"ipfs", "ipfs"}
+//	cfg.Networks = []string{"amino", "celestia"}
+//	cfg.Fallback = db.FallbackDefault
+//	cfg.DefaultDatabase = "ipfs_amino"
+//
+//	router, err := db.OpenClickHouseRouter(ctx, multiCfg, cfg)
+//	if err != nil { ... }
+//	defer router.Close()
+//
+//	conn, err := router.Conn(tenant.NewContext(ctx, "ipfs", "amino"))
+//
+// Each lookup is counted in clickhouse_router.requests with an "outcome"
+// attribute ("routed", "fallback", or "rejected") and, for routed requests,
+// the "project" and "network" attributes.
+//
+// Services that need a connection per tenant with a full config each, e.g.,
+// on different clusters, use a [ConnManager] instead. It opens a connection
+// for every entry of a [Mapping] of configs, implements [Pinger] for the
+// [HealthChecker], and closes all connections at once:
+//
+//	conns, err := db.OpenClickHouseConnManager(ctx, cfgs)
+//	if err != nil { ... }
+//	defer conns.Close()
+//
+//	conn, found := conns.Get("ipfs", "amino")
+//
+// The tenant.Limiter bounds the number of concurrent requests and the request
+// rate of each tenant so that one heavy tenant can't starve the cluster for
+// all others. The HTTP and gRPC servers enforce it with
+// http.MiddlewareTenantLimit and grpc.ServerConfig.TenantLimiter, which also
+// store the tenant in the request context for the router.
 package db
@@ -0,0 +1,310 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Formats of [Export].
+const (
+	// ExportCSV writes a header row with the column names followed by one
+	// row per result row. Composite values are encoded as JSON.
+	ExportCSV = "csv"
+	// ExportNDJSON writes one JSON object per result row with the column
+	// names as keys.
+	ExportNDJSON = "ndjson"
+)
+
+// exportRows is the common interface of the result sets of the handles that
+// [Export] supports.
+type exportRows interface {
+	Columns() ([]string, error)
+	Next() bool
+	Values() ([]any, error)
+	Err() error
+	Close() error
+}
+
+// Export runs query against handle and streams the result set to w in the
+// given format, [ExportCSV] or [ExportNDJSON], without holding it in
+// memory. It returns the number of exported rows. The handle must be a
+// ClickHouse [driver.Conn], a [sql.DB], or a [pgxpool.Pool]:
+//
+//	n, err := db.Export(ctx, os.Stdout, db.ExportCSV, conn, "SELECT * FROM visits WHERE day = ?", day)
+//
+// Byte slices are written as strings and times in RFC 3339 format.
+func Export(ctx context.Context, w io.Writer, format string, handle any, query string, args ...any) (int64, error) {
+	switch format {
+	case ExportCSV, ExportNDJSON:
+	default:
+		return 0, fmt.Errorf("export format must be one of %q or %q", ExportCSV, ExportNDJSON)
+	}
+
+	var (
+		rows exportRows
+		err  error
+	)
+	switch h := handle.(type) {
+	case driver.Conn:
+		var chRows driver.Rows
+		chRows, err = h.Query(ctx, query, args...)
+		rows = &clickHouseExportRows{rows: chRows}
+	case *sql.DB:
+		var sqlRows *sql.Rows
+		sqlRows, err = h.QueryContext(ctx, query, args...)
+		rows = &sqlExportRows{rows: sqlRows}
+	case *pgxpool.Pool:
+		var pgxRows pgx.Rows
+		pgxRows, err = h.Query(ctx, query, args...)
+		rows = &pgxExportRows{rows: pgxRows}
+	default:
+		return 0, fmt.Errorf("unsupported export handle %T", handle)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("export query: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return writeExport(w, format, rows)
+}
+
+// writeExport writes all rows to w in the given format.
+func writeExport(w io.Writer, format string, rows exportRows) (int64, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("export columns: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+
+	var (
+		cw     *csv.Writer
+		record []string
+	)
+	if format == ExportCSV {
+		cw = csv.NewWriter(bw)
+		if err := cw.Write(columns); err != nil {
+			return 0, fmt.Errorf("write export header: %w", err)
+		}
+		record = make([]string, len(columns))
+	}
+
+	var n int64
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return n, fmt.Errorf("scan export row %d: %w", n, err)
+		}
+
+		if format == ExportCSV {
+			for i, v := range values {
+				record[i], err = csvValue(exportValue(v))
+				if err != nil {
+					return n, fmt.Errorf("encode export row %d column %s: %w", n, columns[i], err)
+				}
+			}
+			err = cw.Write(record)
+		} else {
+			err = writeNDJSONRow(bw, columns, values)
+		}
+		if err != nil {
+			return n, fmt.Errorf("write export row %d: %w", n, err)
+		}
+
+		n++
+	}
+
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("export rows: %w", err)
+	}
+
+	if cw != nil {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return n, fmt.Errorf("write export: %w", err)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return n, fmt.Errorf("write export: %w", err)
+	}
+
+	return n, nil
+}
+
+// writeNDJSONRow writes a JSON object with the columns in result order.
+func writeNDJSONRow(w *bufio.Writer, columns []string, values []any) error {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		val, err := json.Marshal(exportValue(values[i]))
+		if err != nil {
+			return fmt.Errorf("encode column %s: %w", column, err)
+		}
+		buf.Write(val)
+	}
+	buf.WriteString("}\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// exportValue dereferences pointers, e.g., of nullable ClickHouse columns,
+// and converts byte slices to strings.
+func exportValue(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() {
+		return nil
+	}
+
+	if b, ok := rv.Interface().([]byte); ok {
+		return string(b)
+	}
+
+	return rv.Interface()
+}
+
+// csvValue formats a value for a CSV cell.
+func csvValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		data, err := json.Marshal(v)
+		return string(data), err
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// clickHouseExportRows scans ClickHouse rows into values of the scan types
+// of their columns.
+type clickHouseExportRows struct {
+	rows  driver.Rows
+	types []reflect.Type
+	dest  []any
+}
+
+func (r *clickHouseExportRows) Columns() ([]string, error) {
+	return r.rows.Columns(), nil
+}
+
+func (r *clickHouseExportRows) Next() bool { return r.rows.Next() }
+
+func (r *clickHouseExportRows) Values() ([]any, error) {
+	if r.dest == nil {
+		for _, ct := range r.rows.ColumnTypes() {
+			r.types = append(r.types, ct.ScanType())
+		}
+		r.dest = make([]any, len(r.types))
+	}
+
+	for i, t := range r.types {
+		r.dest[i] = reflect.New(t).Interface()
+	}
+
+	if err := r.rows.Scan(r.dest...); err != nil {
+		return nil, err
+	}
+
+	return r.dest, nil
+}
+
+func (r *clickHouseExportRows) Err() error   { return r.rows.Err() }
+func (r *clickHouseExportRows) Close() error { return r.rows.Close() }
+
+// sqlExportRows scans database/sql rows into values of the driver's types.
+type sqlExportRows struct {
+	rows    *sql.Rows
+	columns int
+}
+
+func (r *sqlExportRows) Columns() ([]string, error) {
+	columns, err := r.rows.Columns()
+	r.columns = len(columns)
+	return columns, err
+}
+
+func (r *sqlExportRows) Next() bool { return r.rows.Next() }
+
+func (r *sqlExportRows) Values() ([]any, error) {
+	values := make([]any, r.columns)
+	dest := make([]any, r.columns)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	if err := r.rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func (r *sqlExportRows) Err() error   { return r.rows.Err() }
+func (r *sqlExportRows) Close() error { return r.rows.Close() }
+
+// pgxExportRows returns the values of pgx rows as decoded by pgx.
+type pgxExportRows struct {
+	rows pgx.Rows
+}
+
+func (r *pgxExportRows) Columns() ([]string, error) {
+	fields := r.rows.FieldDescriptions()
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.Name
+	}
+	return columns, nil
+}
+
+func (r *pgxExportRows) Next() bool             { return r.rows.Next() }
+func (r *pgxExportRows) Values() ([]any, error) { return r.rows.Values() }
+func (r *pgxExportRows) Err() error             { return r.rows.Err() }
+
+func (r *pgxExportRows) Close() error {
+	r.rows.Close()
+	return nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeExportRows struct {
+	columns []string
+	rows    [][]any
+	next    int
+}
+
+func (r *fakeExportRows) Columns() ([]string, error) { return r.columns, nil }
+func (r *fakeExportRows) Next() bool                 { r.next++; return r.next <= len(r.rows) }
+func (r *fakeExportRows) Values() ([]any, error)     { return r.rows[r.next-1], nil }
+func (r *fakeExportRows) Err() error                 { return nil }
+func (r *fakeExportRows) Close() error               { return nil }
+
+func newFakeExportRows() *fakeExportRows {
+	agent := "kubo"
+	return &fakeExportRows{
+		columns: []string{"peer_id", "agent", "visited_at", "protocols", "raw"},
+		rows: [][]any{
+			{"12D3KooA", &agent, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), []string{"/ipfs/kad/1.0.0"}, []byte("a,b")},
+			{"12D3KooB", (*string)(nil), time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC), []string{}, []byte(`q"t`)},
+		},
+	}
+}
+
+func TestWriteExport_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := writeExport(&buf, ExportCSV, newFakeExportRows())
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.Equal(t, `peer_id,agent,visited_at,protocols,raw
+12D3KooA,kubo,2024-01-02T03:04:05Z,"[""/ipfs/kad/1.0.0""]","a,b"
+12D3KooB,,2024-01-02T03:04:06Z,[],"q""t"
+`, buf.String())
+}
+
+func TestWriteExport_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := writeExport(&buf, ExportNDJSON, newFakeExportRows())
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.Equal(t, `{"peer_id":"12D3KooA","agent":"kubo","visited_at":"2024-01-02T03:04:05Z","protocols":["/ipfs/kad/1.0.0"],"raw":"a,b"}
+{"peer_id":"12D3KooB","agent":null,"visited_at":"2024-01-02T03:04:06Z","protocols":[],"raw":"q\"t"}
+`, buf.String())
+}
+
+func TestExport_Errors(t *testing.T) {
+	var buf bytes.Buffer
+
+	_, err := Export(context.Background(), &buf, "xml", &mockConn{}, "SELECT 1")
+	assert.ErrorContains(t, err, "export format")
+
+	_, err = Export(context.Background(), &buf, ExportCSV, "handle", "SELECT 1")
+	assert.ErrorContains(t, err, "unsupported export handle string")
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Pinger is implemented by database connections that can be checked for
+// availability, e.g., a ClickHouse [driver.Conn], a [pgxpool.Pool],
+// [ClickHouseReadWriteConns], and [PostgresReadWriteHandles]. Wrap a
+// [sql.DB] with [SQLPinger].
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+var (
+	_ Pinger = (driver.Conn)(nil)
+	_ Pinger = (*pgxpool.Pool)(nil)
+	_ Pinger = (*ClickHouseReadWriteConns)(nil)
+	_ Pinger = (*PostgresReadWriteHandles)(nil)
+)
+
+// PingerFunc adapts a function to the [Pinger] interface.
+type PingerFunc func(ctx context.Context) error
+
+// Ping calls f.
+func (f PingerFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+// SQLPinger returns a [Pinger] for a database/sql handle, e.g., one that is
+// opened by [PostgresConfig.OpenAndPing].
+func SQLPinger(handle *sql.DB) Pinger {
+	return PingerFunc(handle.PingContext)
+}
+
+// PingAll pings all connections concurrently within the timeout and returns
+// the joined errors of all failed pings. Use it for one-off readiness
+// probes and [HealthChecker] for periodic checks.
+func PingAll(ctx context.Context, timeout time.Duration, conns ...Pinger) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	errs := make([]error, len(conns))
+
+	var wg sync.WaitGroup
+	for i, conn := range conns {
+		wg.Go(func() {
+			if err := conn.Ping(ctx); err != nil {
+				errs[i] = fmt.Errorf("ping connection %d: %w", i, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// HealthCheckerConfig holds configuration for a [HealthChecker].
+type HealthCheckerConfig struct {
+	// Interval is the time between two health checks.
+	Interval time.Duration
+	// Timeout bounds the duration of a single health check across all
+	// connections.
+	Timeout time.Duration
+	// OnChange is called with the result of the first health check and
+	// whenever the health status changes afterward. The error is nil if all
+	// connections are healthy. Use it to update, e.g., the gRPC health
+	// server or an HTTP readiness endpoint.
+	OnChange func(healthy bool, err error)
+}
+
+// DefaultHealthCheckerConfig returns a [HealthCheckerConfig] with sensible
+// defaults.
+func DefaultHealthCheckerConfig() *HealthCheckerConfig {
+	return &HealthCheckerConfig{
+		Interval: 10 * time.Second,
+		Timeout:  5 * time.Second,
+	}
+}
+
+// Validate checks the [HealthCheckerConfig] for validity.
+func (cfg *HealthCheckerConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("interval must be a positive duration")
+	}
+
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout must be a positive duration")
+	}
+
+	return nil
+}
+
+// HealthChecker periodically pings a set of database connections and reports
+// whether all of them are available.
+type HealthChecker struct {
+	cfg   *HealthCheckerConfig
+	conns []Pinger
+
+	mu      sync.RWMutex
+	checked bool  // whether at least one check completed
+	err     error // result of the last check
+}
+
+// NewHealthChecker creates a new [HealthChecker] for the given connections.
+// Call [HealthChecker.Run] to start checking.
+func NewHealthChecker(cfg *HealthCheckerConfig, conns ...Pinger) (*HealthChecker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("health checker config: %w", err)
+	}
+
+	if len(conns) == 0 {
+		return nil, fmt.Errorf("at least one connection must be given")
+	}
+
+	return &HealthChecker{cfg: cfg, conns: conns}, nil
+}
+
+// Run checks the connections immediately and then every interval until the
+// context is canceled.
+func (h *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		h.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check pings all connections once with [PingAll], records the result, and
+// returns the joined errors of all failed pings.
+func (h *HealthChecker) Check(ctx context.Context) error {
+	err := PingAll(ctx, h.cfg.Timeout, h.conns...)
+
+	h.mu.Lock()
+	changed := !h.checked || (h.err == nil) != (err == nil)
+	h.checked = true
+	h.err = err
+	h.mu.Unlock()
+
+	if changed && h.cfg.OnChange != nil {
+		h.cfg.OnChange(err == nil, err)
+	}
+
+	return err
+}
+
+// Healthy reports the result of the last check. It returns false before the
+// first check completed.
+func (h *HealthChecker) Healthy() (bool, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if !h.checked {
+		return false, fmt.Errorf("not checked yet")
+	}
+
+	return h.err == nil, h.err
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPinger struct {
+	err atomic.Pointer[error]
+}
+
+func (m *mockPinger) Ping(context.Context) error {
+	if err := m.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func (m *mockPinger) fail(err error) { m.err.Store(&err) }
+func (m *mockPinger) recover()       { m.err.Store(nil) }
+
+func TestNewHealthChecker(t *testing.T) {
+	_, err := NewHealthChecker(DefaultHealthCheckerConfig())
+	assert.Error(t, err, "no connections")
+
+	_, err = NewHealthChecker(&HealthCheckerConfig{Interval: 0, Timeout: time.Second}, &mockPinger{})
+	assert.Error(t, err, "invalid interval")
+
+	_, err = NewHealthChecker(DefaultHealthCheckerConfig(), &mockPinger{})
+	assert.NoError(t, err)
+}
+
+func TestHealthChecker_Check(t *testing.T) {
+	type change struct {
+		healthy bool
+		err     error
+	}
+	var changes []change
+
+	cfg := DefaultHealthCheckerConfig()
+	cfg.OnChange = func(healthy bool, err error) {
+		changes = append(changes, change{healthy: healthy, err: err})
+	}
+
+	healthy, unhealthy := &mockPinger{}, &mockPinger{}
+	h, err := NewHealthChecker(cfg, healthy, unhealthy)
+	require.NoError(t, err)
+
+	ok, err := h.Healthy()
+	assert.False(t, ok)
+	assert.Error(t, err)
+
+	ctx := context.Background()
+
+	// the first check always reports
+	require.NoError(t, h.Check(ctx))
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].healthy)
+
+	// unchanged status does not report
+	require.NoError(t, h.Check(ctx))
+	assert.Len(t, changes, 1)
+
+	pingErr := errors.New("connection refused")
+	unhealthy.fail(pingErr)
+	assert.ErrorIs(t, h.Check(ctx), pingErr)
+	require.Len(t, changes, 2)
+	assert.False(t, changes[1].healthy)
+	assert.ErrorIs(t, changes[1].err, pingErr)
+
+	ok, err = h.Healthy()
+	assert.False(t, ok)
+	assert.ErrorIs(t, err, pingErr)
+
+	unhealthy.recover()
+	require.NoError(t, h.Check(ctx))
+	require.Len(t, changes, 3)
+	assert.True(t, changes[2].healthy)
+}
+
+func TestHealthChecker_Run(t *testing.T) {
+	cfg := &HealthCheckerConfig{Interval: time.Millisecond, Timeout: time.Second}
+	h, err := NewHealthChecker(cfg, &mockPinger{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		ok, _ := h.Healthy()
+		return ok
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestPingAll(t *testing.T) {
+	ctx := context.Background()
+
+	healthy := &mockPinger{}
+	assert.NoError(t, PingAll(ctx, time.Second, healthy, PingerFunc(func(context.Context) error { return nil })))
+
+	errBoom := errors.New("boom")
+	unhealthy := &mockPinger{}
+	unhealthy.fail(errBoom)
+
+	err := PingAll(ctx, time.Second, healthy, unhealthy)
+	assert.ErrorIs(t, err, errBoom)
+	assert.ErrorContains(t, err, "ping connection 1")
+
+	slow := PingerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, PingAll(ctx, time.Millisecond, slow), context.DeadlineExceeded)
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// InsertMetrics records the throughput of writers under metric names that
+// are shared across services so that dashboards work for all of them:
+//
+//   - db_insert.rows     (counter)   — rows written
+//   - db_insert.bytes    (counter)   — approximate bytes written
+//   - db_insert.duration (histogram) — latency of each insert, in seconds
+//   - db_insert.failures (counter)   — failed inserts
+//
+// All instruments carry a "table" attribute. [BatchInserter] and
+// [CopyLoader] record them automatically; writers that insert on their own
+// call [InsertMetrics.Record] after every insert.
+type InsertMetrics struct {
+	mRows     metric.Int64Counter
+	mBytes    metric.Int64Counter
+	mDuration metric.Float64Histogram
+	mFailures metric.Int64Counter
+}
+
+// NewInsertMetrics creates the [InsertMetrics] instruments with the given
+// meter. If meter is nil, the global meter provider is used.
+func NewInsertMetrics(meter metric.Meter) *InsertMetrics {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter("github.com/probe-lab/go-commons/db")
+	}
+
+	m := &InsertMetrics{}
+
+	var err error
+	if m.mRows, err = meter.Int64Counter("db_insert.rows",
+		metric.WithDescription("Total number of rows written"),
+	); err != nil {
+		slog.Warn("Failed to create metric instrument", "name", "db_insert.rows", "err", err)
+	}
+
+	if m.mBytes, err = meter.Int64Counter("db_insert.bytes",
+		metric.WithDescription("Approximate number of bytes written"),
+		metric.WithUnit("By"),
+	); err != nil {
+		slog.Warn("Failed to create metric instrument", "name", "db_insert.bytes", "err", err)
+	}
+
+	if m.mDuration, err = meter.Float64Histogram("db_insert.duration",
+		metric.WithDescription("Duration of each insert"),
+		metric.WithUnit("s"),
+	); err != nil {
+		slog.Warn("Failed to create metric instrument", "name", "db_insert.duration", "err", err)
+	}
+
+	if m.mFailures, err = meter.Int64Counter("db_insert.failures",
+		metric.WithDescription("Total number of failed inserts"),
+	); err != nil {
+		slog.Warn("Failed to create metric instrument", "name", "db_insert.failures", "err", err)
+	}
+
+	return m
+}
+
+// Record records an insert of rows into table that took duration. Pass the
+// approximate size of the rows as bytes or zero if it is unknown. If err is
+// not nil, the insert is counted as a failure and its rows and bytes are
+// not counted as written.
+func (m *InsertMetrics) Record(ctx context.Context, table string, rows int, bytes int, duration time.Duration, err error) {
+	attrs := metric.WithAttributes(attrKeyTable.String(table))
+
+	m.mDuration.Record(ctx, duration.Seconds(), attrs)
+
+	if err != nil {
+		m.mFailures.Add(ctx, 1, attrs)
+		return
+	}
+
+	m.mRows.Add(ctx, int64(rows), attrs)
+	if bytes > 0 {
+		m.mBytes.Add(ctx, int64(bytes), attrs)
+	}
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestInsertMetrics_Record(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	m := NewInsertMetrics(provider.Meter("test"))
+
+	ctx := context.Background()
+	m.Record(ctx, "visits", 10, 1024, time.Second, nil)
+	m.Record(ctx, "visits", 5, 0, time.Second, nil)
+	m.Record(ctx, "visits", 3, 300, time.Second, errors.New("boom"))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	sums := map[string]int64{}
+	var durations uint64
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		switch data := m.Data.(type) {
+		case metricdata.Sum[int64]:
+			require.Len(t, data.DataPoints, 1)
+			table, _ := data.DataPoints[0].Attributes.Value(attrKeyTable)
+			assert.Equal(t, "visits", table.AsString())
+			sums[m.Name] = data.DataPoints[0].Value
+		case metricdata.Histogram[float64]:
+			require.Len(t, data.DataPoints, 1)
+			durations = data.DataPoints[0].Count
+		}
+	}
+
+	assert.Equal(t, map[string]int64{
+		"db_insert.rows":     15,
+		"db_insert.bytes":    1024,
+		"db_insert.failures": 1,
+	}, sums)
+	assert.EqualValues(t, 3, durations)
+}
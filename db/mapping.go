@@ -1,18 +1,35 @@
 package db
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"strings"
 )
 
 type Mapping[T any] map[string]map[string]T
 
 func NewMapping[T any](projects []string, networks []string, items []T) (Mapping[T], error) {
+	return newMapping(projects, networks, items, false)
+}
+
+// NewStrictMapping is like [NewMapping] but returns an error that lists all
+// duplicate project/network combinations instead of letting the last
+// duplicate win. Use it where a silent overwrite would route data to the
+// wrong destination.
+func NewStrictMapping[T any](projects []string, networks []string, items []T) (Mapping[T], error) {
+	return newMapping(projects, networks, items, true)
+}
+
+func newMapping[T any](projects []string, networks []string, items []T, strict bool) (Mapping[T], error) {
 	if len(projects) != len(networks) || len(networks) != len(items) {
 		return nil, fmt.Errorf("projects (%d), networks (%d) and %T (%d) must have the same length", len(projects), len(networks), items, len(items))
 	}
 
+	var duplicates []string
+
 	mapping := make(Mapping[T])
 	for i, project := range projects {
 		project = strings.ToLower(project)
@@ -24,6 +41,12 @@ func NewMapping[T any](projects []string, networks []string, items []T) (Mapping
 		}
 
 		if _, found := mapping[project][network]; found {
+			if strict {
+				if key := project + "/" + network; !slices.Contains(duplicates, key) {
+					duplicates = append(duplicates, key)
+				}
+				continue
+			}
 			slog.Warn("Duplicate network for same project", "project", project, "network", network)
 		}
 
@@ -31,6 +54,10 @@ func NewMapping[T any](projects []string, networks []string, items []T) (Mapping
 
 	}
 
+	if len(duplicates) > 0 {
+		return nil, fmt.Errorf("duplicate project/network combinations: %s", strings.Join(duplicates, ", "))
+	}
+
 	return mapping, nil
 }
 
@@ -51,3 +78,47 @@ func (d Mapping[T]) ForEach(fn func(project string, network string, item T)) {
 		}
 	}
 }
+
+// ForEachE calls fn for every project/network combination in sorted order
+// and stops at the first error. It returns the error of fn or the context
+// error if ctx is canceled before all combinations were visited.
+func (d Mapping[T]) ForEachE(ctx context.Context, fn func(project string, network string, item T) error) error {
+	for _, project := range d.Projects() {
+		for _, network := range d.Networks(project) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if err := fn(project, network, d[project][network]); err != nil {
+				return fmt.Errorf("%s/%s: %w", project, network, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Projects returns the projects of the mapping in sorted order.
+func (d Mapping[T]) Projects() []string {
+	return slices.Sorted(maps.Keys(d))
+}
+
+// Networks returns the networks of the given project in sorted order or nil
+// if the project is not in the mapping.
+func (d Mapping[T]) Networks(project string) []string {
+	networks, found := d[project]
+	if !found {
+		return nil
+	}
+
+	return slices.Sorted(maps.Keys(networks))
+}
+
+// Len returns the number of project/network combinations in the mapping.
+func (d Mapping[T]) Len() int {
+	n := 0
+	for _, networks := range d {
+		n += len(networks)
+	}
+	return n
+}
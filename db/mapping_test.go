@@ -1,6 +1,8 @@
 package db
 
 import (
+	"context"
+	"errors"
 	"sort"
 	"testing"
 
@@ -101,3 +103,77 @@ func TestNewMapping(t *testing.T) {
 		assert.Equal(t, []string{"item0", "item1"}, gotItems)
 	})
 }
+
+func TestMapping_Introspection(t *testing.T) {
+	mapping, err := NewMapping(
+		[]string{"ipfs", "ipfs", "filecoin"},
+		[]string{"celestia", "amino", "mainnet"},
+		[]string{"db1", "db2", "db3"},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"filecoin", "ipfs"}, mapping.Projects())
+	assert.Equal(t, []string{"amino", "celestia"}, mapping.Networks("ipfs"))
+	assert.Nil(t, mapping.Networks("unknown"))
+	assert.Equal(t, 3, mapping.Len())
+
+	empty := Mapping[string]{}
+	assert.Empty(t, empty.Projects())
+	assert.Zero(t, empty.Len())
+}
+
+func TestNewStrictMapping(t *testing.T) {
+	_, err := NewStrictMapping(
+		[]string{"ipfs", "IPFS", "filecoin", "ipfs", "filecoin"},
+		[]string{"amino", "Amino", "mainnet", "amino", "mainnet"},
+		[]string{"db1", "db2", "db3", "db4", "db5"},
+	)
+	assert.EqualError(t, err, "duplicate project/network combinations: ipfs/amino, filecoin/mainnet")
+
+	_, err = NewStrictMapping([]string{"ipfs"}, []string{}, []string{})
+	assert.Error(t, err)
+
+	mapping, err := NewStrictMapping([]string{"ipfs", "ipfs"}, []string{"amino", "celestia"}, []string{"db1", "db2"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, mapping.Len())
+}
+
+func TestMapping_ForEachE(t *testing.T) {
+	mapping, err := NewMapping(
+		[]string{"ipfs", "ipfs", "filecoin"},
+		[]string{"celestia", "amino", "mainnet"},
+		[]string{"db1", "db2", "db3"},
+	)
+	assert.NoError(t, err)
+
+	var visited []string
+	err = mapping.ForEachE(context.Background(), func(project string, network string, item string) error {
+		visited = append(visited, project+"/"+network+"="+item)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"filecoin/mainnet=db3", "ipfs/amino=db2", "ipfs/celestia=db1"}, visited)
+
+	errBoom := errors.New("boom")
+	visited = nil
+	err = mapping.ForEachE(context.Background(), func(project string, network string, item string) error {
+		visited = append(visited, item)
+		if item == "db2" {
+			return errBoom
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errBoom)
+	assert.ErrorContains(t, err, "ipfs/amino")
+	assert.Equal(t, []string{"db3", "db2"}, visited)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	visited = nil
+	err = mapping.ForEachE(ctx, func(project string, network string, item string) error {
+		visited = append(visited, item)
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"db3"}, visited)
+}
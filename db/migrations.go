@@ -0,0 +1,175 @@
+package db
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// migrationsDir is the directory of a migrations filesystem that holds the
+// migration files.
+const migrationsDir = "migrations"
+
+// MergeMigrations merges the migrations directories of several sources into
+// a single source, e.g., the shared base schema of a library and the
+// migrations of a service:
+//
+//	migrations, err := db.MergeMigrations(commons.Migrations, service.Migrations)
+//
+// The migrations of all sources are applied in the order of their versions,
+// so the sources must use distinct version numbers. MergeMigrations fails if
+// two sources contain the same file or the same version and direction.
+func MergeMigrations(sources ...fs.ReadDirFS) (fs.ReadDirFS, error) {
+	switch len(sources) {
+	case 0:
+		return nil, fmt.Errorf("no migration sources given")
+	case 1:
+		return sources[0], nil
+	}
+
+	merged := &mergedFS{
+		first: sources[0],
+		files: map[string]fs.ReadDirFS{},
+	}
+
+	type origin struct {
+		source int
+		file   string
+	}
+	versions := map[string]origin{}
+
+	for i, src := range sources {
+		entries, err := src.ReadDir(migrationsDir)
+		if err != nil {
+			return nil, fmt.Errorf("read migrations of source %d: %w", i, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			name := entry.Name()
+			if _, found := merged.files[name]; found {
+				return nil, fmt.Errorf("migration %s of source %d exists in another source", name, i)
+			}
+
+			if m, err := source.DefaultParse(name); err == nil {
+				key := fmt.Sprintf("%d.%s", m.Version, m.Direction)
+				if other, found := versions[key]; found {
+					return nil, fmt.Errorf("migration %s of source %d has the same version as %s of source %d", name, i, other.file, other.source)
+				}
+				versions[key] = origin{source: i, file: name}
+			}
+
+			merged.files[name] = src
+			merged.entries = append(merged.entries, entry)
+		}
+	}
+
+	slices.SortFunc(merged.entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return merged, nil
+}
+
+// MigrationStatus describes the applied and pending migrations of a
+// database.
+type MigrationStatus struct {
+	// Version is the currently applied migration version. It is zero if no
+	// migrations have been applied yet.
+	Version uint
+
+	// Dirty is set if the last migration failed and the database must be
+	// fixed by hand and forced to a version.
+	Dirty bool
+
+	// Migrations are the migrations of the source in the order of their
+	// versions.
+	Migrations []MigrationInfo
+}
+
+// MigrationInfo describes a single migration of a source.
+type MigrationInfo struct {
+	Version    uint
+	Identifier string
+
+	// Applied is set if the migration version is not newer than the applied
+	// version of the database.
+	Applied bool
+}
+
+// Pending returns the number of migrations that are not applied yet.
+func (s *MigrationStatus) Pending() int {
+	pending := 0
+	for _, m := range s.Migrations {
+		if !m.Applied {
+			pending++
+		}
+	}
+	return pending
+}
+
+// newMigrationStatus lists the up migrations of the source and, if any
+// migrations were applied, marks all migrations up to version as applied.
+func newMigrationStatus(migrations fs.ReadDirFS, applied bool, version uint, dirty bool) (*MigrationStatus, error) {
+	entries, err := migrations.ReadDir(migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	status := &MigrationStatus{Version: version, Dirty: dirty}
+	for _, entry := range entries {
+		m, err := source.DefaultParse(entry.Name())
+		if err != nil || entry.IsDir() || m.Direction != source.Up {
+			continue
+		}
+
+		status.Migrations = append(status.Migrations, MigrationInfo{
+			Version:    m.Version,
+			Identifier: m.Identifier,
+			Applied:    applied && m.Version <= version,
+		})
+	}
+
+	slices.SortFunc(status.Migrations, func(a, b MigrationInfo) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return status, nil
+}
+
+// mergedFS is a [fs.ReadDirFS] whose migrations directory contains the files
+// of several sources.
+type mergedFS struct {
+	first   fs.ReadDirFS
+	files   map[string]fs.ReadDirFS
+	entries []fs.DirEntry
+}
+
+func (m *mergedFS) Open(name string) (fs.File, error) {
+	if name == migrationsDir {
+		return m.first.Open(name)
+	}
+
+	if dir, file := path.Split(name); dir == migrationsDir+"/" {
+		if src, found := m.files[file]; found {
+			return src.Open(name)
+		}
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *mergedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != migrationsDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return slices.Clone(m.entries), nil
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeMigrations(t *testing.T) {
+	base := fstest.MapFS{
+		"migrations/000001_create_peers.up.sql":   {Data: []byte("CREATE TABLE peers")},
+		"migrations/000001_create_peers.down.sql": {Data: []byte("DROP TABLE peers")},
+	}
+	service := fstest.MapFS{
+		"migrations/000100_create_visits.up.sql":   {Data: []byte("CREATE TABLE visits")},
+		"migrations/000100_create_visits.down.sql": {Data: []byte("DROP TABLE visits")},
+		"migrations/000002_add_agent.up.sql":       {Data: []byte("ALTER TABLE peers")},
+	}
+
+	merged, err := MergeMigrations(base, service)
+	require.NoError(t, err)
+
+	entries, err := fs.ReadDir(merged, "migrations")
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{
+		"000001_create_peers.down.sql",
+		"000001_create_peers.up.sql",
+		"000002_add_agent.up.sql",
+		"000100_create_visits.down.sql",
+		"000100_create_visits.up.sql",
+	}, names)
+
+	data, err := fs.ReadFile(merged, "migrations/000100_create_visits.up.sql")
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE visits", string(data))
+
+	data, err = fs.ReadFile(merged, "migrations/000001_create_peers.up.sql")
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE peers", string(data))
+
+	_, err = merged.Open("migrations/000003_missing.up.sql")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	t.Run("single source", func(t *testing.T) {
+		single, err := MergeMigrations(base)
+		require.NoError(t, err)
+		assert.Equal(t, fs.ReadDirFS(base), single)
+	})
+
+	t.Run("duplicate version", func(t *testing.T) {
+		dup := fstest.MapFS{"migrations/000001_other.up.sql": {Data: []byte("SELECT 1")}}
+		_, err := MergeMigrations(base, dup)
+		assert.ErrorContains(t, err, "same version")
+	})
+
+	t.Run("no sources", func(t *testing.T) {
+		_, err := MergeMigrations()
+		assert.Error(t, err)
+	})
+}
+
+func TestNewMigrationStatus(t *testing.T) {
+	migrations := fstest.MapFS{
+		"migrations/000002_add_agent.up.sql":       {Data: []byte("ALTER TABLE peers")},
+		"migrations/000001_create_peers.up.sql":    {Data: []byte("CREATE TABLE peers")},
+		"migrations/000001_create_peers.down.sql":  {Data: []byte("DROP TABLE peers")},
+		"migrations/000100_create_visits.up.sql":   {Data: []byte("CREATE TABLE visits")},
+		"migrations/000100_create_visits.down.sql": {Data: []byte("DROP TABLE visits")},
+		"migrations/README.md":                     {Data: []byte("docs")},
+	}
+
+	status, err := newMigrationStatus(migrations, true, 2, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, &MigrationStatus{
+		Version: 2,
+		Dirty:   true,
+		Migrations: []MigrationInfo{
+			{Version: 1, Identifier: "create_peers", Applied: true},
+			{Version: 2, Identifier: "add_agent", Applied: true},
+			{Version: 100, Identifier: "create_visits", Applied: false},
+		},
+	}, status)
+	assert.Equal(t, 1, status.Pending())
+
+	status, err = newMigrationStatus(migrations, false, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Pending())
+}
+
+func TestClickHouseMigrationsConfig_mergesSources(t *testing.T) {
+	base := fstest.MapFS{"migrations/000001_base.up.sql": {Data: []byte("SELECT 1")}}
+	dup := fstest.MapFS{"migrations/000001_other.up.sql": {Data: []byte("SELECT 1")}}
+
+	cfg := DefaultClickHouseMigrationsConfig()
+	opt := DefaultClickHouseConfig("test").Options()
+	ctx := context.Background()
+
+	// all methods merge the sources before they connect to the database
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"apply", func() error { return cfg.Apply(opt, base, dup) }},
+		{"down", func() error { return cfg.Down(ctx, opt, base, dup) }},
+		{"steps", func() error { return cfg.Steps(ctx, opt, -1, base, dup) }},
+		{"force", func() error { return cfg.Force(ctx, opt, 1, base, dup) }},
+		{"version", func() error {
+			_, _, err := cfg.Version(ctx, opt, base, dup)
+			return err
+		}},
+		{"status", func() error {
+			_, err := cfg.Status(ctx, opt, base, dup)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorContains(t, tt.fn(), "same version")
+		})
+	}
+}
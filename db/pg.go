@@ -3,22 +3,81 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/uptrace/opentelemetry-go-extra/otelsql"
 	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
 )
 
+// Statement capture modes for [PostgresBaseConfig.TraceStatements].
+const (
+	StatementsFull     = "full"     // record statements as is
+	StatementsRedacted = "redacted" // replace literals with ?
+	StatementsNone     = "none"     // record empty statements
+)
+
 type PostgresBaseConfig struct {
 	Host    string
 	Port    int
 	User    string
 	Pass    string
 	SSLMode string
+
+	// SSLRootCert is the path to the CA certificate that verifies the server
+	// certificate, e.g., the RDS CA bundle with sslmode verify-full.
+	// SSLCert and SSLKey are the paths to the client certificate and its
+	// private key for mutual TLS and must be set together. Empty paths are
+	// omitted from the connection string.
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	// ApplicationName identifies the connections in pg_stat_activity and the
+	// server logs, e.g., the service name.
+	ApplicationName string
+	// StatementTimeout aborts statements that run longer than the timeout
+	// on the server. It is sent with millisecond precision. Zero keeps the
+	// server default.
+	StatementTimeout time.Duration
+	// SearchPath is the comma-separated list of schemas that unqualified
+	// names are resolved in. Empty keeps the server default.
+	SearchPath string
+
+	// TraceStatements controls how SQL statements are recorded in the
+	// db.statement attribute of trace spans. Use [StatementsFull] in
+	// development and [StatementsRedacted] or [StatementsNone] in production
+	// to keep literal values out of traces. Query arguments are never
+	// recorded. Defaults to [StatementsFull] if empty.
+	TraceStatements string
+
+	// MaxOpenConns and MaxIdleConns bound the number of open and idle
+	// connections of a database handle. ConnMaxLifetime and ConnMaxIdleTime
+	// bound how long a connection is reused and how long it may stay idle.
+	// Zero values keep the driver defaults. Pools opened with
+	// [PostgresConfig.OpenPool] apply all but MaxIdleConns.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// PingRetries is the number of times the initial ping is retried after
+	// it failed, e.g., while the database is still booting next to the
+	// service in compose or Kubernetes. PingBackoff is the delay before the
+	// first retry and doubles with every further retry up to 30 seconds.
+	// Zero PingRetries fails on the first failed ping.
+	PingRetries int
+	PingBackoff time.Duration
 }
 
+// pingBackoffMax bounds the delay between ping retries.
+const pingBackoffMax = 30 * time.Second
+
 func (cfg *PostgresBaseConfig) Validate() error {
 	if cfg == nil {
 		return fmt.Errorf("config is nil")
@@ -44,9 +103,132 @@ func (cfg *PostgresBaseConfig) Validate() error {
 		return fmt.Errorf("sslmode must not be empty")
 	}
 
+	if (cfg.SSLCert == "") != (cfg.SSLKey == "") {
+		return fmt.Errorf("sslcert and sslkey must be set together")
+	}
+
+	if cfg.StatementTimeout < 0 {
+		return fmt.Errorf("statement timeout must not be negative")
+	}
+
+	switch cfg.TraceStatements {
+	case "", StatementsFull, StatementsRedacted, StatementsNone:
+	default:
+		return fmt.Errorf("trace statements must be one of %s, %s, or %s", StatementsFull, StatementsRedacted, StatementsNone)
+	}
+
+	if cfg.MaxOpenConns < 0 {
+		return fmt.Errorf("max open conns must not be negative")
+	}
+
+	if cfg.MaxIdleConns < 0 {
+		return fmt.Errorf("max idle conns must not be negative")
+	}
+
+	if cfg.ConnMaxLifetime < 0 {
+		return fmt.Errorf("conn max lifetime must not be negative")
+	}
+
+	if cfg.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("conn max idle time must not be negative")
+	}
+
+	if cfg.PingRetries < 0 {
+		return fmt.Errorf("ping retries must not be negative")
+	}
+
+	if cfg.PingRetries > 0 && cfg.PingBackoff <= 0 {
+		return fmt.Errorf("ping backoff must be a positive duration if ping retries are enabled")
+	}
+
 	return nil
 }
 
+// ping calls ping and retries it with exponential backoff up to PingRetries
+// times or until the context is canceled.
+func (cfg *PostgresBaseConfig) ping(ctx context.Context, ping func(ctx context.Context) error) error {
+	backoff := cfg.PingBackoff
+	for attempt := 0; ; attempt++ {
+		err := ping(ctx)
+		if err == nil || attempt >= cfg.PingRetries {
+			return err
+		}
+
+		slog.Info("Waiting for postgres", "host", cfg.Host, "attempt", attempt+1, "retry_in", backoff, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, pingBackoffMax)
+	}
+}
+
+// applyPoolSettings applies the connection pool settings to the handle.
+func (cfg *PostgresBaseConfig) applyPoolSettings(handle *sql.DB) {
+	if cfg.MaxOpenConns > 0 {
+		handle.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+
+	if cfg.MaxIdleConns > 0 {
+		handle.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+
+	if cfg.ConnMaxLifetime > 0 {
+		handle.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+
+	if cfg.ConnMaxIdleTime > 0 {
+		handle.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+}
+
+// otelsqlOptions returns the instrumentation options for the database
+// handles.
+func (cfg *PostgresBaseConfig) otelsqlOptions() []otelsql.Option {
+	opts := []otelsql.Option{
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+	}
+
+	switch cfg.TraceStatements {
+	case StatementsRedacted:
+		opts = append(opts, otelsql.WithQueryFormatter(redactStatement))
+	case StatementsNone:
+		opts = append(opts, otelsql.WithQueryFormatter(func(string) string { return "" }))
+	}
+
+	return opts
+}
+
+// LogValue implements [slog.LogValuer] and redacts the password.
+func (cfg *PostgresBaseConfig) LogValue() slog.Value {
+	return slog.GroupValue(cfg.logAttrs()...)
+}
+
+func (cfg *PostgresBaseConfig) logAttrs() []slog.Attr {
+	return []slog.Attr{
+		slog.String("host", cfg.Host),
+		slog.Int("port", cfg.Port),
+		slog.String("user", cfg.User),
+		slog.String("password", redact(cfg.Pass)),
+		slog.String("sslmode", cfg.SSLMode),
+		slog.String("sslrootcert", cfg.SSLRootCert),
+		slog.String("sslcert", cfg.SSLCert),
+		slog.String("sslkey", cfg.SSLKey),
+		slog.String("application_name", cfg.ApplicationName),
+		slog.Duration("statement_timeout", cfg.StatementTimeout),
+		slog.String("search_path", cfg.SearchPath),
+		slog.String("trace_statements", cfg.TraceStatements),
+		slog.Int("max_open_conns", cfg.MaxOpenConns),
+		slog.Int("max_idle_conns", cfg.MaxIdleConns),
+		slog.Duration("conn_max_lifetime", cfg.ConnMaxLifetime),
+		slog.Duration("conn_max_idle_time", cfg.ConnMaxIdleTime),
+		slog.Int("ping_retries", cfg.PingRetries),
+		slog.Duration("ping_backoff", cfg.PingBackoff),
+	}
+}
+
 type PostgresConfig struct {
 	BaseConfig *PostgresBaseConfig
 	Database   string
@@ -64,16 +246,97 @@ func (cfg *PostgresConfig) Validate() error {
 	return cfg.BaseConfig.Validate()
 }
 
+// LogValue implements [slog.LogValuer] and redacts the password.
+func (cfg *PostgresConfig) LogValue() slog.Value {
+	return slog.GroupValue(append(cfg.BaseConfig.logAttrs(), slog.String("database", cfg.Database))...)
+}
+
+// SourceName returns the connection string of the configured database. It
+// contains the password, so use [PostgresConfig.RedactedSourceName] for
+// logging.
 func (cfg *PostgresConfig) SourceName() string {
-	return fmt.Sprintf(
+	return cfg.sourceName(cfg.BaseConfig.Pass)
+}
+
+// RedactedSourceName returns the connection string of the configured
+// database with the password masked. It is safe to log.
+func (cfg *PostgresConfig) RedactedSourceName() string {
+	return cfg.sourceName(redact(cfg.BaseConfig.Pass))
+}
+
+func (cfg *PostgresConfig) sourceName(pass string) string {
+	dsn := fmt.Sprintf(
 		"host=%s port=%d dbname=%s user=%s password=%s sslmode=%s",
 		cfg.BaseConfig.Host,
 		cfg.BaseConfig.Port,
 		cfg.Database,
 		cfg.BaseConfig.User,
-		cfg.BaseConfig.Pass,
+		pass,
 		cfg.BaseConfig.SSLMode,
 	)
+
+	for _, param := range []struct{ key, value string }{
+		{"sslrootcert", cfg.BaseConfig.SSLRootCert},
+		{"sslcert", cfg.BaseConfig.SSLCert},
+		{"sslkey", cfg.BaseConfig.SSLKey},
+		{"application_name", cfg.BaseConfig.ApplicationName},
+		{"statement_timeout", cfg.BaseConfig.statementTimeout()},
+		{"search_path", cfg.BaseConfig.SearchPath},
+	} {
+		if param.value != "" {
+			dsn += " " + param.key + "=" + quoteSourceValue(param.value)
+		}
+	}
+
+	return dsn
+}
+
+// statementTimeout returns the statement_timeout parameter in milliseconds
+// or an empty string if no timeout is configured.
+func (cfg *PostgresBaseConfig) statementTimeout() string {
+	if cfg.StatementTimeout <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(max(cfg.StatementTimeout.Milliseconds(), 1), 10)
+}
+
+// quoteSourceValue quotes a connection string value if it contains spaces,
+// quotes, or backslashes, e.g., a certificate path.
+func quoteSourceValue(value string) string {
+	if !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// OpenAndPing opens a handle to the configured database with the same
+// OpenTelemetry instrumentation as [PostgresMultiConfig.OpenAndPing] and
+// pings it to verify the connection.
+func (cfg *PostgresConfig) OpenAndPing(ctx context.Context) (*sql.DB, error) {
+	slog.Debug("Initializing database handle", "source", cfg.RedactedSourceName())
+
+	return cfg.open(ctx)
+}
+
+// open opens, instruments, and pings the database handle. The handle is
+// closed if the ping fails. The extra options are applied to the traces and
+// the stats metrics of the handle.
+func (cfg *PostgresConfig) open(ctx context.Context, extra ...otelsql.Option) (*sql.DB, error) {
+	handle, err := otelsql.Open("postgres", cfg.SourceName(), append(cfg.BaseConfig.otelsqlOptions(), extra...)...)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s database: %w", cfg.Database, err)
+	}
+
+	otelsql.ReportDBStatsMetrics(handle, extra...)
+	cfg.BaseConfig.applyPoolSettings(handle)
+
+	// Ping database to verify connection.
+	if err = cfg.BaseConfig.ping(ctx, handle.PingContext); err != nil {
+		_ = handle.Close()
+		return nil, fmt.Errorf("pinging %s database: %w", cfg.Database, err)
+	}
+
+	return handle, nil
 }
 
 type PostgresMultiConfig struct {
@@ -99,8 +362,13 @@ func (cfg *PostgresMultiConfig) Validate() error {
 	return cfg.BaseConfig.Validate()
 }
 
+// LogValue implements [slog.LogValuer] and redacts the password.
+func (cfg *PostgresMultiConfig) LogValue() slog.Value {
+	return slog.GroupValue(append(cfg.BaseConfig.logAttrs(), slog.Any("databases", cfg.Databases))...)
+}
+
 func (cfg *PostgresMultiConfig) OpenAndPing(ctx context.Context) ([]*sql.DB, error) {
-	slog.Info("Initializing database handles",
+	slog.Debug("Initializing database handles",
 		"host", cfg.BaseConfig.Host,
 		"port", cfg.BaseConfig.Port,
 		"user", cfg.BaseConfig.User,
@@ -115,22 +383,38 @@ func (cfg *PostgresMultiConfig) OpenAndPing(ctx context.Context) ([]*sql.DB, err
 			Database:   database,
 		}
 
-		handle, err := otelsql.Open("postgres", pgCfg.SourceName(),
-			otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
-		)
+		slog.Debug("Initializing database handle", "source", pgCfg.RedactedSourceName())
+
+		handle, err := pgCfg.open(ctx)
 		if err != nil {
-			return handles, fmt.Errorf("opening %s database: %w", database, err)
+			return handles, err
 		}
 
-		otelsql.ReportDBStatsMetrics(handle)
-
 		handles[i] = handle
-
-		// Ping database to verify connection.
-		if err = handle.PingContext(ctx); err != nil {
-			return handles, fmt.Errorf("pinging database: %w", err)
-		}
 	}
 
 	return handles, nil
 }
+
+// statementLiteral matches string literals, positional parameters, and
+// numeric literals in SQL statements.
+var statementLiteral = regexp.MustCompile(`'(?:[^']|'')*'|\$\d+|\b\d+(?:\.\d+)?\b`)
+
+// redactStatement replaces the string and numeric literals in a SQL statement
+// with ? and keeps positional parameters.
+func redactStatement(query string) string {
+	return statementLiteral.ReplaceAllStringFunc(query, func(lit string) string {
+		if strings.HasPrefix(lit, "$") {
+			return lit
+		}
+		return "?"
+	})
+}
+
+// redact masks non-empty secrets for logging.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "*****"
+}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// PostgresCopier is implemented by [pgx.Conn], [pgx.Tx], and
+// [pgxpool.Pool].
+type PostgresCopier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// CopyLoaderConfig holds configuration for a [CopyLoader].
+type CopyLoaderConfig struct {
+	// Table is the table to load into, optionally qualified with the schema,
+	// e.g., "public.peers".
+	Table string
+	// Columns are the columns that the values of each row are copied into,
+	// in the same order.
+	Columns []string
+	// BatchSize is the number of rows that are sent with a single COPY.
+	// Larger batches are faster but hold more rows in memory and lose more
+	// progress if a COPY fails.
+	BatchSize int
+	// Meter is the OTel meter used to record the db_insert metrics, see
+	// [InsertMetrics]. If nil, the global meter provider is used.
+	Meter metric.Meter
+}
+
+// DefaultCopyLoaderConfig returns a [CopyLoaderConfig] with sensible
+// defaults for the given table and columns.
+func DefaultCopyLoaderConfig(table string, columns ...string) *CopyLoaderConfig {
+	return &CopyLoaderConfig{
+		Table:     table,
+		Columns:   columns,
+		BatchSize: 10_000,
+	}
+}
+
+// Validate checks the [CopyLoaderConfig] for validity.
+func (cfg *CopyLoaderConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if !validTableName.MatchString(cfg.Table) {
+		return fmt.Errorf("copy table name %q contains invalid characters", cfg.Table)
+	}
+
+	if len(cfg.Columns) == 0 {
+		return fmt.Errorf("at least one column must be specified")
+	}
+
+	for _, column := range cfg.Columns {
+		if column == "" {
+			return fmt.Errorf("column name must not be empty")
+		}
+	}
+
+	if cfg.BatchSize <= 0 {
+		return fmt.Errorf("copy batch size must be a positive integer")
+	}
+
+	return nil
+}
+
+// CopyLoader streams rows of type T into a Postgres table with
+// COPY FROM STDIN in batches of [CopyLoaderConfig.BatchSize] rows. It is
+// meant for backfills and other bulk loads that would take much longer
+// with one INSERT per row:
+//
+//	loader, err := db.NewCopyLoader(pool, db.DefaultCopyLoaderConfig("peers", "id", "agent"), func(p Peer) []any {
+//		return []any{p.ID, p.Agent}
+//	})
+//	...
+//	n, err := loader.Load(ctx, peers)
+//
+// Each batch is a separate COPY, so the rows of earlier batches stay in the
+// table if a later batch fails. Pass a [pgx.Tx] to load all rows or none.
+// A CopyLoader is not safe for concurrent use.
+type CopyLoader[T any] struct {
+	conn    PostgresCopier
+	cfg     *CopyLoaderConfig
+	table   pgx.Identifier
+	values  func(row T) []any
+	metrics *InsertMetrics
+
+	buf    [][]any
+	copied int64
+}
+
+// NewCopyLoader creates a new [CopyLoader] that copies into conn. values
+// returns the column values of a row in the order of
+// [CopyLoaderConfig.Columns].
+func NewCopyLoader[T any](conn PostgresCopier, cfg *CopyLoaderConfig, values func(row T) []any) (*CopyLoader[T], error) {
+	if conn == nil {
+		return nil, fmt.Errorf("conn must not be nil")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("copy loader config: %w", err)
+	}
+
+	if values == nil {
+		return nil, fmt.Errorf("values must not be nil")
+	}
+
+	return &CopyLoader[T]{
+		conn:    conn,
+		cfg:     cfg,
+		table:   pgx.Identifier(strings.Split(cfg.Table, ".")),
+		values:  values,
+		metrics: NewInsertMetrics(cfg.Meter),
+		buf:     make([][]any, 0, cfg.BatchSize),
+	}, nil
+}
+
+// Add buffers a row and copies the buffered rows once the batch is full.
+func (l *CopyLoader[T]) Add(ctx context.Context, row T) error {
+	vals := l.values(row)
+	if len(vals) != len(l.cfg.Columns) {
+		return fmt.Errorf("row has %d values for %d columns", len(vals), len(l.cfg.Columns))
+	}
+
+	l.buf = append(l.buf, vals)
+	if len(l.buf) < l.cfg.BatchSize {
+		return nil
+	}
+
+	return l.Flush(ctx)
+}
+
+// Flush copies the buffered rows. The buffer is cleared even if the COPY
+// fails.
+func (l *CopyLoader[T]) Flush(ctx context.Context) error {
+	if len(l.buf) == 0 {
+		return nil
+	}
+
+	rows := len(l.buf)
+	start := time.Now()
+	n, err := l.conn.CopyFrom(ctx, l.table, l.cfg.Columns, pgx.CopyFromRows(l.buf))
+	l.metrics.Record(ctx, l.cfg.Table, int(n), 0, time.Since(start), err)
+
+	clear(l.buf)
+	l.buf = l.buf[:0]
+
+	if err != nil {
+		return fmt.Errorf("copy %d rows into %s: %w", rows, l.cfg.Table, err)
+	}
+
+	l.copied += n
+
+	slog.Debug("Copied rows", "table", l.cfg.Table, "rows", n, "total", l.copied, "took", time.Since(start))
+
+	return nil
+}
+
+// Load adds all rows, flushes the remaining ones, and returns the number of
+// rows this loader copied so far. It stops at the first failed batch.
+func (l *CopyLoader[T]) Load(ctx context.Context, rows iter.Seq[T]) (int64, error) {
+	for row := range rows {
+		if err := l.Add(ctx, row); err != nil {
+			return l.copied, err
+		}
+	}
+
+	if err := l.Flush(ctx); err != nil {
+		return l.copied, err
+	}
+
+	return l.copied, nil
+}
+
+// Copied returns the number of rows this loader copied so far.
+func (l *CopyLoader[T]) Copied() int64 {
+	return l.copied
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type mockCopier struct {
+	table   pgx.Identifier
+	columns []string
+	batches [][][]any
+	err     error
+}
+
+func (m *mockCopier) CopyFrom(_ context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+
+	m.table = tableName
+	m.columns = columnNames
+
+	var batch [][]any
+	for rowSrc.Next() {
+		vals, err := rowSrc.Values()
+		if err != nil {
+			return 0, err
+		}
+		batch = append(batch, slices.Clone(vals))
+	}
+	m.batches = append(m.batches, batch)
+
+	return int64(len(batch)), nil
+}
+
+type copyRow struct {
+	ID    int
+	Agent string
+}
+
+func copyRowValues(r copyRow) []any { return []any{r.ID, r.Agent} }
+
+func TestCopyLoaderConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfgFn   func() *CopyLoaderConfig
+		wantErr bool
+	}{
+		{
+			name:    "default",
+			cfgFn:   func() *CopyLoaderConfig { return DefaultCopyLoaderConfig("public.peers", "id") },
+			wantErr: false,
+		},
+		{
+			name:    "nil",
+			cfgFn:   func() *CopyLoaderConfig { return nil },
+			wantErr: true,
+		},
+		{
+			name:    "invalid table",
+			cfgFn:   func() *CopyLoaderConfig { return DefaultCopyLoaderConfig("peers; DROP TABLE x", "id") },
+			wantErr: true,
+		},
+		{
+			name:    "no columns",
+			cfgFn:   func() *CopyLoaderConfig { return DefaultCopyLoaderConfig("peers") },
+			wantErr: true,
+		},
+		{
+			name:    "empty column",
+			cfgFn:   func() *CopyLoaderConfig { return DefaultCopyLoaderConfig("peers", "id", "") },
+			wantErr: true,
+		},
+		{
+			name: "zero batch size",
+			cfgFn: func() *CopyLoaderConfig {
+				cfg := DefaultCopyLoaderConfig("peers", "id")
+				cfg.BatchSize = 0
+				return cfg
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfgFn().Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCopyLoader_Load(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	cfg := DefaultCopyLoaderConfig("public.peers", "id", "agent")
+	cfg.BatchSize = 2
+	cfg.Meter = provider.Meter("test")
+
+	copier := &mockCopier{}
+	loader, err := NewCopyLoader(copier, cfg, copyRowValues)
+	require.NoError(t, err)
+
+	rows := []copyRow{{1, "kubo"}, {2, "helia"}, {3, "boxo"}}
+	n, err := loader.Load(context.Background(), slices.Values(rows))
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+	assert.EqualValues(t, 3, loader.Copied())
+
+	assert.Equal(t, pgx.Identifier{"public", "peers"}, copier.table)
+	assert.Equal(t, []string{"id", "agent"}, copier.columns)
+	assert.Equal(t, [][][]any{
+		{{1, "kubo"}, {2, "helia"}},
+		{{3, "boxo"}},
+	}, copier.batches)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		if m.Name == "db_insert.rows" {
+			assert.EqualValues(t, 3, m.Data.(metricdata.Sum[int64]).DataPoints[0].Value)
+		}
+	}
+}
+
+func TestCopyLoader_Errors(t *testing.T) {
+	cfg := DefaultCopyLoaderConfig("peers", "id", "agent")
+	cfg.BatchSize = 1
+
+	_, err := NewCopyLoader(nil, cfg, copyRowValues)
+	assert.Error(t, err)
+
+	_, err = NewCopyLoader[copyRow](&mockCopier{}, cfg, nil)
+	assert.Error(t, err)
+
+	loader, err := NewCopyLoader(&mockCopier{}, cfg, func(r copyRow) []any { return []any{r.ID} })
+	require.NoError(t, err)
+	assert.ErrorContains(t, loader.Add(context.Background(), copyRow{}), "1 values for 2 columns")
+
+	copier := &mockCopier{err: errors.New("boom")}
+	loader, err = NewCopyLoader(copier, cfg, copyRowValues)
+	require.NoError(t, err)
+
+	n, err := loader.Load(context.Background(), slices.Values([]copyRow{{1, "kubo"}, {2, "helia"}}))
+	assert.ErrorContains(t, err, "copy 1 rows into peers: boom")
+	assert.Zero(t, n)
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// NotificationHandler is called for every notification that a [Listener]
+// receives on one of its channels.
+type NotificationHandler func(ctx context.Context, channel string, payload string)
+
+// ListenerConfig holds configuration for a [Listener].
+type ListenerConfig struct {
+	// Channels are the channels to LISTEN on.
+	Channels []string
+
+	// Backoff is the delay before the first reconnect after the connection
+	// broke. It doubles with every further attempt up to MaxBackoff and is
+	// reset once the listener is subscribed again.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// OnSubscribe is called whenever the listener subscribed to all channels
+	// on a new connection. Notifications sent while the listener was
+	// disconnected are lost, so use it to reload the state the notifications
+	// refer to. Optional.
+	OnSubscribe func(ctx context.Context)
+}
+
+// DefaultListenerConfig returns a [ListenerConfig] with sensible defaults
+// for the given channels.
+func DefaultListenerConfig(channels ...string) *ListenerConfig {
+	return &ListenerConfig{
+		Channels:   channels,
+		Backoff:    time.Second,
+		MaxBackoff: 30 * time.Second,
+	}
+}
+
+// Validate checks the [ListenerConfig] for validity.
+func (cfg *ListenerConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if len(cfg.Channels) == 0 {
+		return fmt.Errorf("at least one channel must be specified")
+	}
+
+	for _, channel := range cfg.Channels {
+		if channel == "" {
+			return fmt.Errorf("channel name must not be empty")
+		}
+	}
+
+	if cfg.Backoff <= 0 {
+		return fmt.Errorf("listener backoff must be a positive duration")
+	}
+
+	if cfg.MaxBackoff < cfg.Backoff {
+		return fmt.Errorf("listener max backoff must not be smaller than the backoff")
+	}
+
+	return nil
+}
+
+// Listener subscribes to Postgres channels with LISTEN and passes every
+// notification to a [NotificationHandler]. It holds a dedicated connection
+// and reconnects with exponential backoff when the connection breaks.
+//
+//	listener, err := db.NewListener(db.DefaultListenerConfig("peers_changed"), pgCfg.Connect, handler)
+//	...
+//	go listener.Run(ctx)
+type Listener struct {
+	cfg     *ListenerConfig
+	connect func(ctx context.Context) (*pgx.Conn, error)
+	handler NotificationHandler
+}
+
+// NewListener creates a new [Listener] that opens its connections with
+// connect, e.g., [PostgresConfig.Connect]. Call [Listener.Run] to start
+// listening.
+func NewListener(cfg *ListenerConfig, connect func(ctx context.Context) (*pgx.Conn, error), handler NotificationHandler) (*Listener, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("listener config: %w", err)
+	}
+
+	if connect == nil {
+		return nil, fmt.Errorf("connect must not be nil")
+	}
+
+	if handler == nil {
+		return nil, fmt.Errorf("handler must not be nil")
+	}
+
+	return &Listener{cfg: cfg, connect: connect, handler: handler}, nil
+}
+
+// Run listens until the context is canceled. The handler is called
+// sequentially in the order the notifications arrive, so a slow handler
+// delays the following notifications.
+func (l *Listener) Run(ctx context.Context) {
+	backoff := l.cfg.Backoff
+	for {
+		subscribed, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if subscribed {
+			backoff = l.cfg.Backoff
+		}
+
+		slog.Warn("Postgres listener disconnected", "channels", l.cfg.Channels, "retry_in", backoff, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, l.cfg.MaxBackoff)
+	}
+}
+
+// listen opens a connection, subscribes to all channels, and dispatches
+// notifications until the connection breaks or the context is canceled.
+// It reports whether the subscription succeeded.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	conn, err := l.connect(ctx)
+	if err != nil {
+		return false, fmt.Errorf("connect: %w", err)
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := conn.Close(closeCtx); err != nil {
+			slog.Debug("Failed to close postgres listener connection", "err", err)
+		}
+	}()
+
+	for _, channel := range l.cfg.Channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return false, fmt.Errorf("listen on %s: %w", channel, err)
+		}
+	}
+
+	slog.Debug("Postgres listener subscribed", "channels", l.cfg.Channels)
+
+	if l.cfg.OnSubscribe != nil {
+		l.cfg.OnSubscribe(ctx)
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, fmt.Errorf("wait for notification: %w", err)
+		}
+
+		l.handler(ctx, n.Channel, n.Payload)
+	}
+}
+
+// Connect opens a single pgx connection to the configured database with the
+// same otelpgx tracing as [PostgresConfig.OpenPool], e.g., for a [Listener].
+func (cfg *PostgresConfig) Connect(ctx context.Context) (*pgx.Conn, error) {
+	connCfg, err := pgx.ParseConfig(cfg.SourceName())
+	if err != nil {
+		return nil, fmt.Errorf("parse %s database config: %w", cfg.Database, err)
+	}
+
+	connCfg.Tracer = cfg.BaseConfig.pgxTracer()
+
+	conn, err := pgx.ConnectConfig(ctx, connCfg)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s database: %w", cfg.Database, err)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenerConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfgFn   func() *ListenerConfig
+		wantErr bool
+	}{
+		{
+			name:    "default",
+			cfgFn:   func() *ListenerConfig { return DefaultListenerConfig("events") },
+			wantErr: false,
+		},
+		{
+			name:    "nil",
+			cfgFn:   func() *ListenerConfig { return nil },
+			wantErr: true,
+		},
+		{
+			name:    "no channels",
+			cfgFn:   func() *ListenerConfig { return DefaultListenerConfig() },
+			wantErr: true,
+		},
+		{
+			name:    "empty channel",
+			cfgFn:   func() *ListenerConfig { return DefaultListenerConfig("events", "") },
+			wantErr: true,
+		},
+		{
+			name: "max backoff smaller than backoff",
+			cfgFn: func() *ListenerConfig {
+				cfg := DefaultListenerConfig("events")
+				cfg.MaxBackoff = cfg.Backoff / 2
+				return cfg
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfgFn().Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewListener(t *testing.T) {
+	connect := func(context.Context) (*pgx.Conn, error) { return nil, nil }
+	handler := func(context.Context, string, string) {}
+
+	_, err := NewListener(DefaultListenerConfig(), connect, handler)
+	assert.Error(t, err)
+
+	_, err = NewListener(DefaultListenerConfig("events"), nil, handler)
+	assert.Error(t, err)
+
+	_, err = NewListener(DefaultListenerConfig("events"), connect, nil)
+	assert.Error(t, err)
+
+	_, err = NewListener(DefaultListenerConfig("events"), connect, handler)
+	assert.NoError(t, err)
+}
+
+func TestListener_RunBackoff(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		cfg := DefaultListenerConfig("events")
+		cfg.Backoff = time.Second
+		cfg.MaxBackoff = 3 * time.Second
+
+		var attempts []time.Duration
+		start := time.Now()
+		connect := func(context.Context) (*pgx.Conn, error) {
+			attempts = append(attempts, time.Since(start))
+			return nil, fmt.Errorf("connection refused")
+		}
+
+		listener, err := NewListener(cfg, connect, func(context.Context, string, string) {})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+
+		listener.Run(ctx)
+
+		assert.Equal(t, []time.Duration{0, time.Second, 3 * time.Second, 6 * time.Second, 9 * time.Second}, attempts)
+	})
+}
+
+func TestPostgresConfig_Connect(t *testing.T) {
+	// no postgres server runs in tests
+	cfg := validPostgresCfgFn()
+	cfg.BaseConfig.Port = 1
+
+	conn, err := cfg.Connect(context.Background())
+	assert.ErrorContains(t, err, "connecting to database database")
+	assert.Nil(t, conn)
+}
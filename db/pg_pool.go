@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolConfig returns the [pgxpool.Config] for the configured database with
+// otelpgx tracing and the pool settings of [PostgresBaseConfig]. Adjust it before passing it to [pgxpool.NewWithConfig]
+// or use [PostgresConfig.OpenPool] directly.
+func (cfg *PostgresConfig) PoolConfig() (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(cfg.SourceName())
+	if err != nil {
+		return nil, fmt.Errorf("parse %s database config: %w", cfg.Database, err)
+	}
+
+	poolCfg.ConnConfig.Tracer = cfg.BaseConfig.pgxTracer()
+
+	if cfg.BaseConfig.MaxOpenConns > 0 {
+		poolCfg.MaxConns = int32(min(cfg.BaseConfig.MaxOpenConns, math.MaxInt32))
+	}
+
+	if cfg.BaseConfig.ConnMaxLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.BaseConfig.ConnMaxLifetime
+	}
+
+	if cfg.BaseConfig.ConnMaxIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.BaseConfig.ConnMaxIdleTime
+	}
+
+	return poolCfg, nil
+}
+
+// OpenPool opens a pgx connection pool to the configured database and pings
+// it to verify the connection, retrying as configured by
+// [PostgresBaseConfig.PingRetries]. It is the pgx counterpart of
+// [PostgresConfig.OpenAndPing] for services that use pgx directly. Queries
+// are traced according to [PostgresBaseConfig.TraceStatements] and the pool
+// statistics are reported as OpenTelemetry metrics.
+func (cfg *PostgresConfig) OpenPool(ctx context.Context) (*pgxpool.Pool, error) {
+	slog.Debug("Initializing database pool", "source", cfg.RedactedSourceName())
+
+	poolCfg, err := cfg.PoolConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s database pool: %w", cfg.Database, err)
+	}
+
+	if err := otelpgx.RecordStats(pool); err != nil {
+		slog.Warn("Failed to report postgres pool metrics", "err", err)
+	}
+
+	if err := cfg.BaseConfig.ping(ctx, pool.Ping); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("pinging %s database: %w", cfg.Database, err)
+	}
+
+	return pool, nil
+}
+
+// pgxTracer returns the query tracer for pgx connections. It mirrors
+// [PostgresBaseConfig.otelsqlOptions] for database/sql handles.
+func (cfg *PostgresBaseConfig) pgxTracer() pgx.QueryTracer {
+	opts := []otelpgx.Option{
+		otelpgx.WithTrimSQLInSpanName(),
+	}
+
+	switch cfg.TraceStatements {
+	case StatementsRedacted:
+		return &redactingTracer{Tracer: otelpgx.NewTracer(opts...)}
+	case StatementsNone:
+		opts = append(opts, otelpgx.WithDisableSQLStatementInAttributes())
+	}
+
+	return otelpgx.NewTracer(opts...)
+}
+
+// redactingTracer replaces the literals of the statements that are passed to
+// the otelpgx tracer, see [redactStatement]. The statements that pgx
+// executes are not affected.
+type redactingTracer struct {
+	*otelpgx.Tracer
+}
+
+func (t *redactingTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	data.SQL = redactStatement(data.SQL)
+	return t.Tracer.TraceQueryStart(ctx, conn, data)
+}
+
+func (t *redactingTracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	data.SQL = redactStatement(data.SQL)
+	t.Tracer.TraceBatchQuery(ctx, conn, data)
+}
+
+func (t *redactingTracer) TracePrepareStart(ctx context.Context, conn *pgx.Conn, data pgx.TracePrepareStartData) context.Context {
+	data.SQL = redactStatement(data.SQL)
+	return t.Tracer.TracePrepareStart(ctx, conn, data)
+}
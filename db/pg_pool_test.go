@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPostgresConfig_PoolConfig(t *testing.T) {
+	cfg := validPostgresCfgFn()
+
+	poolCfg, err := cfg.PoolConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", poolCfg.ConnConfig.Host)
+	assert.EqualValues(t, 9440, poolCfg.ConnConfig.Port)
+	assert.Equal(t, "database", poolCfg.ConnConfig.Database)
+	assert.IsType(t, &otelpgx.Tracer{}, poolCfg.ConnConfig.Tracer)
+
+	cfg.BaseConfig.MaxOpenConns = 7
+	cfg.BaseConfig.ConnMaxLifetime = time.Hour
+	poolCfg, err = cfg.PoolConfig()
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, poolCfg.MaxConns)
+	assert.Equal(t, time.Hour, poolCfg.MaxConnLifetime)
+
+	cfg.BaseConfig.TraceStatements = StatementsRedacted
+	poolCfg, err = cfg.PoolConfig()
+	require.NoError(t, err)
+	assert.IsType(t, &redactingTracer{}, poolCfg.ConnConfig.Tracer)
+}
+
+func TestRedactingTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	tracer := &redactingTracer{Tracer: otelpgx.NewTracer(otelpgx.WithTracerProvider(tp))}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT * FROM peers WHERE id = 'abc' AND n = $1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "SELECT * FROM peers WHERE id = ? AND n = $1", spanAttr(t, spans[0].Attributes(), "db.query.text"))
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelsql"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// attrKeyPostgresRole distinguishes the spans and stats metrics of the
+// writer and reader handles of a [PostgresReplicaConfig].
+var attrKeyPostgresRole = attribute.Key("db.postgres.role")
+
+// PostgresReplicaConfig extends a [PostgresConfig] for the primary with the
+// address of a read replica endpoint, e.g., the reader endpoint of an RDS or
+// Aurora cluster. Read-heavy API services use it to offload queries to the
+// replicas while keeping writes on the primary.
+type PostgresReplicaConfig struct {
+	// Primary configures the writer handle. The reader handle uses the same
+	// credentials, database, SSL, and pool settings.
+	Primary *PostgresConfig
+
+	// Replica is the host:port address of the read replica endpoint. If
+	// empty, reads use the writer handle.
+	Replica string
+}
+
+// Validate checks the [PostgresReplicaConfig] for validity. The replica
+// address must be of the form host:port.
+func (cfg *PostgresReplicaConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if cfg.Replica != "" {
+		host, port, err := net.SplitHostPort(cfg.Replica)
+		if err != nil {
+			return fmt.Errorf("replica address %q must be of the form host:port: %w", cfg.Replica, err)
+		}
+
+		if host == "" {
+			return fmt.Errorf("replica address %q must include a host", cfg.Replica)
+		}
+
+		if p, err := strconv.Atoi(port); err != nil || p <= 0 {
+			return fmt.Errorf("replica address %q must include a positive port", cfg.Replica)
+		}
+	}
+
+	return cfg.Primary.Validate()
+}
+
+// LogValue implements [slog.LogValuer] and redacts the password.
+func (cfg *PostgresReplicaConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Any("primary", cfg.Primary),
+		slog.String("replica", cfg.Replica),
+	)
+}
+
+// ReaderConfig returns the [PostgresConfig] of the reader handle. It returns
+// the config of the primary if no replica is configured. Call it after
+// [PostgresReplicaConfig.Validate].
+func (cfg *PostgresReplicaConfig) ReaderConfig() *PostgresConfig {
+	if cfg.Replica == "" {
+		return cfg.Primary
+	}
+
+	host, port, _ := net.SplitHostPort(cfg.Replica)
+
+	base := *cfg.Primary.BaseConfig
+	base.Host = host
+	base.Port, _ = strconv.Atoi(port)
+
+	return &PostgresConfig{
+		BaseConfig: &base,
+		Database:   cfg.Primary.Database,
+	}
+}
+
+// PostgresReadWriteHandles holds the handles that are opened by
+// [PostgresReplicaConfig.OpenAndPing]. Use Writer for writes and queries
+// that must observe them, and Reader for everything else. Reader is the
+// same handle as Writer if no replica is configured. Note that replicas lag
+// behind the primary.
+type PostgresReadWriteHandles struct {
+	Writer *sql.DB
+	Reader *sql.DB
+}
+
+// Close closes both handles.
+func (h *PostgresReadWriteHandles) Close() error {
+	if h.Reader == h.Writer {
+		return h.Writer.Close()
+	}
+
+	return errors.Join(h.Writer.Close(), h.Reader.Close())
+}
+
+// Ping pings both handles.
+func (h *PostgresReadWriteHandles) Ping(ctx context.Context) error {
+	if err := h.Writer.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping writer: %w", err)
+	}
+
+	if h.Reader == h.Writer {
+		return nil
+	}
+
+	if err := h.Reader.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping reader: %w", err)
+	}
+
+	return nil
+}
+
+// OpenAndPing opens and pings the writer handle to the primary and the
+// reader handle to the replica. The spans and stats metrics of both handles
+// carry a db.postgres.role attribute of "writer" or "reader".
+func (cfg *PostgresReplicaConfig) OpenAndPing(ctx context.Context) (*PostgresReadWriteHandles, error) {
+	slog.Debug("Initializing database handles",
+		"writer", cfg.Primary.RedactedSourceName(),
+		"reader", cfg.ReaderConfig().RedactedSourceName(),
+	)
+
+	writer, err := cfg.Primary.open(ctx, otelsql.WithAttributes(attrKeyPostgresRole.String("writer")))
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Replica == "" {
+		return &PostgresReadWriteHandles{Writer: writer, Reader: writer}, nil
+	}
+
+	reader, err := cfg.ReaderConfig().open(ctx, otelsql.WithAttributes(attrKeyPostgresRole.String("reader")))
+	if err != nil {
+		_ = writer.Close()
+		return nil, fmt.Errorf("open replica: %w", err)
+	}
+
+	return &PostgresReadWriteHandles{Writer: writer, Reader: reader}, nil
+}
@@ -1,10 +1,15 @@
 package db
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
+	"testing/synctest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -94,6 +99,99 @@ func TestPostgresBaseConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "redacted statements",
+			cfgFn: func() *PostgresBaseConfig {
+				cfg := validPostgresBaseCfgFn()
+				cfg.TraceStatements = StatementsRedacted
+				return cfg
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid trace statements",
+			cfgFn: func() *PostgresBaseConfig {
+				cfg := validPostgresBaseCfgFn()
+				cfg.TraceStatements = "partial"
+				return cfg
+			},
+			wantErr: true,
+		},
+		{
+			name: "pool settings",
+			cfgFn: func() *PostgresBaseConfig {
+				cfg := validPostgresBaseCfgFn()
+				cfg.MaxOpenConns = 10
+				cfg.MaxIdleConns = 5
+				cfg.ConnMaxLifetime = time.Hour
+				cfg.ConnMaxIdleTime = time.Minute
+				return cfg
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative max open conns",
+			cfgFn: func() *PostgresBaseConfig {
+				cfg := validPostgresBaseCfgFn()
+				cfg.MaxOpenConns = -1
+				return cfg
+			},
+			wantErr: true,
+		},
+		{
+			name: "sslcert without sslkey",
+			cfgFn: func() *PostgresBaseConfig {
+				cfg := validPostgresBaseCfgFn()
+				cfg.SSLCert = "/etc/certs/client.crt"
+				return cfg
+			},
+			wantErr: true,
+		},
+		{
+			name: "sslkey without sslcert",
+			cfgFn: func() *PostgresBaseConfig {
+				cfg := validPostgresBaseCfgFn()
+				cfg.SSLKey = "/etc/certs/client.key"
+				return cfg
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative statement timeout",
+			cfgFn: func() *PostgresBaseConfig {
+				cfg := validPostgresBaseCfgFn()
+				cfg.StatementTimeout = -time.Second
+				return cfg
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative ping retries",
+			cfgFn: func() *PostgresBaseConfig {
+				cfg := validPostgresBaseCfgFn()
+				cfg.PingRetries = -1
+				return cfg
+			},
+			wantErr: true,
+		},
+		{
+			name: "ping retries without backoff",
+			cfgFn: func() *PostgresBaseConfig {
+				cfg := validPostgresBaseCfgFn()
+				cfg.PingRetries = 3
+				return cfg
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative conn max lifetime",
+			cfgFn: func() *PostgresBaseConfig {
+				cfg := validPostgresBaseCfgFn()
+				cfg.ConnMaxLifetime = -time.Second
+				return cfg
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -109,6 +207,98 @@ func TestPostgresConfig_Options(t *testing.T) {
 	assert.Equal(t, "host=localhost port=9440 dbname=database user=default password=password sslmode=require", cfg.SourceName())
 }
 
+func TestPostgresConfig_RedactedSourceName(t *testing.T) {
+	cfg := validPostgresCfgFn()
+	cfg.BaseConfig.Pass = "s3cr3t"
+	assert.Equal(t, "host=localhost port=9440 dbname=database user=default password=***** sslmode=require", cfg.RedactedSourceName())
+	assert.Contains(t, cfg.SourceName(), "password=s3cr3t")
+}
+
+func TestPostgresConfig_SourceNameSessionParams(t *testing.T) {
+	cfg := validPostgresCfgFn()
+	cfg.BaseConfig.ApplicationName = "nebula-api"
+	cfg.BaseConfig.StatementTimeout = 30 * time.Second
+	cfg.BaseConfig.SearchPath = "nebula, public"
+
+	assert.Equal(t, "host=localhost port=9440 dbname=database user=default password=password sslmode=require "+
+		"application_name=nebula-api statement_timeout=30000 search_path='nebula, public'", cfg.SourceName())
+
+	poolCfg, err := cfg.PoolConfig()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"application_name":  "nebula-api",
+		"statement_timeout": "30000",
+		"search_path":       "nebula, public",
+	}, poolCfg.ConnConfig.RuntimeParams)
+}
+
+func TestPostgresConfig_SourceNameSSLCerts(t *testing.T) {
+	cfg := validPostgresCfgFn()
+	cfg.BaseConfig.SSLMode = "verify-full"
+	cfg.BaseConfig.SSLRootCert = "/etc/certs/rds ca.pem"
+	cfg.BaseConfig.SSLCert = "/etc/certs/client.crt"
+	cfg.BaseConfig.SSLKey = `/etc/certs/it's.key`
+
+	assert.Equal(t, `host=localhost port=9440 dbname=database user=default password=password sslmode=verify-full `+
+		`sslrootcert='/etc/certs/rds ca.pem' sslcert=/etc/certs/client.crt sslkey='/etc/certs/it\'s.key'`, cfg.SourceName())
+}
+
+func TestPostgresBaseConfig_ping(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		cfg := validPostgresBaseCfgFn()
+		cfg.PingRetries = 3
+		cfg.PingBackoff = time.Second
+
+		var attempts []time.Duration
+		start := time.Now()
+		ping := func(context.Context) error {
+			attempts = append(attempts, time.Since(start))
+			if len(attempts) < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		}
+
+		require.NoError(t, cfg.ping(context.Background(), ping))
+		assert.Equal(t, []time.Duration{0, time.Second, 3 * time.Second}, attempts)
+
+		attempts = nil
+		start = time.Now()
+		cfg.PingRetries = 1
+		assert.ErrorContains(t, cfg.ping(context.Background(), ping), "connection refused")
+		assert.Len(t, attempts, 2)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		attempts = nil
+		assert.ErrorIs(t, cfg.ping(ctx, ping), context.Canceled)
+		assert.Len(t, attempts, 1)
+	})
+}
+
+func TestPostgresConfig_OpenAndPing(t *testing.T) {
+	// no postgres driver is registered in tests
+	handle, err := validPostgresCfgFn().OpenAndPing(context.Background())
+	assert.ErrorContains(t, err, "opening database database")
+	assert.Nil(t, handle)
+}
+
+func Test_redactStatement(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM peers", "SELECT * FROM peers"},
+		{"SELECT * FROM peers WHERE id = $1", "SELECT * FROM peers WHERE id = $1"},
+		{"SELECT * FROM peers2 WHERE agent = 'kubo' AND port = 4001", "SELECT * FROM peers2 WHERE agent = ? AND port = ?"},
+		{"UPDATE t SET v = 'it''s' WHERE score > 0.5", "UPDATE t SET v = ? WHERE score > ?"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, redactStatement(tt.query))
+	}
+}
+
 func TestPostgresConfig_Validate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -222,3 +412,53 @@ func TestPostgresMultiConfig_Options(t *testing.T) {
 		assert.Equal(t, expected, pgCfg.SourceName())
 	}
 }
+
+func TestPostgresReplicaConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		replica string
+		wantErr bool
+	}{
+		{name: "no replica"},
+		{name: "replica", replica: "reader.example.com:5432"},
+		{name: "ipv6 replica", replica: "[::1]:5432"},
+		{name: "missing port", replica: "reader.example.com", wantErr: true},
+		{name: "missing host", replica: ":5432", wantErr: true},
+		{name: "invalid port", replica: "reader.example.com:pg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &PostgresReplicaConfig{Primary: validPostgresCfgFn(), Replica: tt.replica}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	assert.Error(t, (&PostgresReplicaConfig{}).Validate())
+}
+
+func TestPostgresReplicaConfig_ReaderConfig(t *testing.T) {
+	cfg := &PostgresReplicaConfig{Primary: validPostgresCfgFn()}
+	assert.Same(t, cfg.Primary, cfg.ReaderConfig())
+
+	cfg.Replica = "reader.example.com:5433"
+	reader := cfg.ReaderConfig()
+	assert.Equal(t, "reader.example.com", reader.BaseConfig.Host)
+	assert.Equal(t, 5433, reader.BaseConfig.Port)
+	assert.Equal(t, cfg.Primary.Database, reader.Database)
+	assert.Equal(t, cfg.Primary.BaseConfig.Pass, reader.BaseConfig.Pass)
+
+	// the primary is unchanged
+	assert.Equal(t, "localhost", cfg.Primary.BaseConfig.Host)
+	assert.Equal(t, 9440, cfg.Primary.BaseConfig.Port)
+}
+
+func TestPostgresReplicaConfig_OpenAndPing(t *testing.T) {
+	// no postgres driver is registered in tests
+	cfg := &PostgresReplicaConfig{Primary: validPostgresCfgFn(), Replica: "reader.example.com:5432"}
+	handles, err := cfg.OpenAndPing(context.Background())
+	assert.ErrorContains(t, err, "opening database database")
+	assert.Nil(t, handles)
+}
@@ -0,0 +1,242 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/probe-lab/go-commons/errs"
+)
+
+// Dialect is the SQL dialect that a [QueryBuilder] renders.
+type Dialect int
+
+const (
+	// DialectClickHouse renders ? placeholders.
+	DialectClickHouse Dialect = iota
+	// DialectPostgres renders $1, $2, ... placeholders.
+	DialectPostgres
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case DialectClickHouse:
+		return "clickhouse"
+	case DialectPostgres:
+		return "postgres"
+	default:
+		return fmt.Sprintf("Dialect(%d)", int(d))
+	}
+}
+
+// QueryBuilder appends the filter patterns of our APIs to a query: time
+// ranges, IN lists, ordering from a whitelist, and pagination. Values are
+// always passed as arguments, and column names must be plain identifiers,
+// so request parameters never end up in the SQL string:
+//
+//	sql, args, err := db.NewQueryBuilder(db.DialectPostgres).
+//		TimeRange("created_at", req.From, req.To).
+//		In("network", req.Networks).
+//		OrderBy(req.Sort, req.Desc, map[string]string{"time": "created_at", "peer": "peer_id"}).
+//		Paginate(req.Limit, req.Offset).
+//		Build("SELECT * FROM visits")
+//
+// The first error is reported by [QueryBuilder.Build]. Errors caused by
+// request parameters, e.g., an unknown sort key, are in the
+// [errs.InvalidInput] category.
+type QueryBuilder struct {
+	dialect Dialect
+	conds   []string
+	args    []any
+	orders  []string
+	limit   int
+	offset  int
+	err     error
+}
+
+// NewQueryBuilder returns a [QueryBuilder] for the given dialect.
+func NewQueryBuilder(dialect Dialect) *QueryBuilder {
+	return &QueryBuilder{dialect: dialect}
+}
+
+// Where adds a condition. Use ? as the placeholder for the arguments in all
+// dialects. The condition must not contain literal question marks. It is
+// wrapped in parentheses, so that an OR in one condition doesn't escape the
+// others.
+func (b *QueryBuilder) Where(cond string, args ...any) *QueryBuilder {
+	if n := strings.Count(cond, "?"); n != len(args) {
+		return b.fail(fmt.Errorf("condition %q has %d placeholders but %d arguments", cond, n, len(args)))
+	}
+
+	b.conds = append(b.conds, "("+cond+")")
+	b.args = append(b.args, args...)
+	return b
+}
+
+// TimeRange adds the condition from <= col < to. A zero from or to leaves
+// the respective side of the range open.
+func (b *QueryBuilder) TimeRange(col string, from, to time.Time) *QueryBuilder {
+	if !b.validColumn(col) {
+		return b
+	}
+
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return b.fail(errs.Newf(errs.InvalidInput, "time range start %s must be before end %s", from.Format(time.RFC3339), to.Format(time.RFC3339)))
+	}
+
+	if !from.IsZero() {
+		b.Where(col+" >= ?", from)
+	}
+
+	if !to.IsZero() {
+		b.Where(col+" < ?", to)
+	}
+
+	return b
+}
+
+// In adds the condition that col is one of the given values, which must be a
+// slice. An empty slice adds no condition, so that an omitted filter
+// parameter matches all rows.
+func (b *QueryBuilder) In(col string, values any) *QueryBuilder {
+	if !b.validColumn(col) {
+		return b
+	}
+
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Slice {
+		return b.fail(fmt.Errorf("values for %s must be a slice, got %T", col, values))
+	}
+
+	if rv.Len() == 0 {
+		return b
+	}
+
+	args := make([]any, rv.Len())
+	for i := range args {
+		args[i] = rv.Index(i).Interface()
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+	return b.Where(col+" IN ("+placeholders+")", args...)
+}
+
+// OrderBy orders the results by the column that allowed maps the given key
+// to. Keys that are not in allowed are rejected, so that request parameters
+// can be passed as is. An empty key adds no ordering. Subsequent calls add
+// further orderings.
+func (b *QueryBuilder) OrderBy(key string, desc bool, allowed map[string]string) *QueryBuilder {
+	if key == "" {
+		return b
+	}
+
+	col, ok := allowed[key]
+	if !ok {
+		return b.fail(errs.Newf(errs.InvalidInput, "unsupported sort key %q", key))
+	}
+
+	if !b.validColumn(col) {
+		return b
+	}
+
+	if desc {
+		b.orders = append(b.orders, col+" DESC")
+	} else {
+		b.orders = append(b.orders, col+" ASC")
+	}
+
+	return b
+}
+
+// Paginate limits the results to limit rows starting at offset. A zero limit
+// returns all rows.
+func (b *QueryBuilder) Paginate(limit, offset int) *QueryBuilder {
+	if limit < 0 {
+		return b.fail(errs.Newf(errs.InvalidInput, "limit must be a non-negative integer"))
+	}
+
+	if offset < 0 {
+		return b.fail(errs.Newf(errs.InvalidInput, "offset must be a non-negative integer"))
+	}
+
+	b.limit = limit
+	b.offset = offset
+	return b
+}
+
+// Build appends the WHERE, ORDER BY, LIMIT, and OFFSET clauses to query and
+// returns the SQL string together with its arguments. The query must not
+// contain placeholders itself.
+func (b *QueryBuilder) Build(query string) (string, []any, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(query)
+
+	if len(b.conds) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(b.conds, " AND "))
+	}
+
+	if len(b.orders) > 0 {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(b.orders, ", "))
+	}
+
+	if b.limit > 0 {
+		sb.WriteString(" LIMIT ")
+		sb.WriteString(strconv.Itoa(b.limit))
+	}
+
+	if b.offset > 0 {
+		sb.WriteString(" OFFSET ")
+		sb.WriteString(strconv.Itoa(b.offset))
+	}
+
+	sql := sb.String()
+	if b.dialect == DialectPostgres {
+		sql = numberPlaceholders(sql)
+	}
+
+	return sql, b.args, nil
+}
+
+// validColumn reports whether col is a plain, optionally qualified
+// identifier and records an error otherwise.
+func (b *QueryBuilder) validColumn(col string) bool {
+	if !validTableName.MatchString(col) {
+		b.fail(fmt.Errorf("column name %q contains invalid characters", col))
+		return false
+	}
+	return true
+}
+
+// fail records the first error.
+func (b *QueryBuilder) fail(err error) *QueryBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// numberPlaceholders replaces the ? placeholders in sql with $1, $2, ...
+func numberPlaceholders(sql string) string {
+	var (
+		sb strings.Builder
+		n  int
+	)
+	for _, r := range sql {
+		if r != '?' {
+			sb.WriteRune(r)
+			continue
+		}
+		n++
+		sb.WriteByte('$')
+		sb.WriteString(strconv.Itoa(n))
+	}
+	return sb.String()
+}
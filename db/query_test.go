@@ -0,0 +1,89 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/probe-lab/go-commons/errs"
+)
+
+func TestQueryBuilder_Build(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	allowed := map[string]string{"time": "created_at", "peer": "v.peer_id"}
+
+	build := func(d Dialect) (string, []any, error) {
+		return NewQueryBuilder(d).
+			Where("project = ?", "ipfs").
+			TimeRange("created_at", from, to).
+			In("v.network", []string{"mainnet", "testnet"}).
+			In("agent", []string{}).
+			OrderBy("time", true, allowed).
+			OrderBy("peer", false, allowed).
+			Paginate(100, 200).
+			Build("SELECT * FROM visits v")
+	}
+
+	sql, args, err := build(DialectClickHouse)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM visits v WHERE (project = ?) AND (created_at >= ?) AND (created_at < ?) AND (v.network IN (?, ?)) "+
+		"ORDER BY created_at DESC, v.peer_id ASC LIMIT 100 OFFSET 200", sql)
+	assert.Equal(t, []any{"ipfs", from, to, "mainnet", "testnet"}, args)
+
+	sql, _, err = build(DialectPostgres)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM visits v WHERE (project = $1) AND (created_at >= $2) AND (created_at < $3) AND (v.network IN ($4, $5)) "+
+		"ORDER BY created_at DESC, v.peer_id ASC LIMIT 100 OFFSET 200", sql)
+}
+
+func TestQueryBuilder_Build_or(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sql, args, err := NewQueryBuilder(DialectPostgres).
+		Where("a = ? OR b = ?", 1, 2).
+		TimeRange("t", from, time.Time{}).
+		Build("SELECT * FROM x")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM x WHERE (a = $1 OR b = $2) AND (t >= $3)", sql)
+	assert.Equal(t, []any{1, 2, from}, args)
+}
+
+func TestQueryBuilder_Build_empty(t *testing.T) {
+	sql, args, err := NewQueryBuilder(DialectPostgres).
+		TimeRange("created_at", time.Time{}, time.Time{}).
+		OrderBy("", false, nil).
+		Paginate(0, 0).
+		Build("SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1", sql)
+	assert.Empty(t, args)
+}
+
+func TestQueryBuilder_Build_errors(t *testing.T) {
+	from := time.Now()
+
+	tests := []struct {
+		name        string
+		b           *QueryBuilder
+		wantInvalid bool
+	}{
+		{"placeholder mismatch", NewQueryBuilder(DialectClickHouse).Where("a = ? AND b = ?", 1), false},
+		{"invalid column", NewQueryBuilder(DialectClickHouse).In("network; DROP TABLE visits", []string{"x"}), false},
+		{"values not a slice", NewQueryBuilder(DialectClickHouse).In("network", "mainnet"), false},
+		{"inverted time range", NewQueryBuilder(DialectClickHouse).TimeRange("created_at", from, from.Add(-time.Hour)), true},
+		{"unknown sort key", NewQueryBuilder(DialectClickHouse).OrderBy("created_at; --", false, map[string]string{"time": "created_at"}), true},
+		{"negative limit", NewQueryBuilder(DialectClickHouse).Paginate(-1, 0), true},
+		{"negative offset", NewQueryBuilder(DialectClickHouse).Paginate(10, -1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.b.Build("SELECT 1")
+			require.Error(t, err)
+			assert.Equal(t, tt.wantInvalid, errs.Category(err) == errs.InvalidInput)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package errs provides error categories that map consistently to HTTP status
+// codes and gRPC codes. Handlers classify errors by wrapping them with one of
+// the sentinel categories and let [HTTPStatus] or [GRPCCode] pick the status
+// instead of choosing it by hand:
+//
+//	peer, err := store.GetPeer(ctx, id)
+//	if errors.Is(err, sql.ErrNoRows) {
+//	    return errs.Wrap(errs.NotFound, err)
+//	}
+//
+//	if id == "" {
+//	    return errs.Newf(errs.InvalidInput, "peer id must not be empty")
+//	}
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The error categories. Use [errors.Is] to check whether an error belongs to
+// a category.
+var (
+	NotFound     = errors.New("not found")
+	InvalidInput = errors.New("invalid input")
+	Unavailable  = errors.New("unavailable")
+	Conflict     = errors.New("conflict")
+)
+
+// categories lists all known categories together with their HTTP status and
+// gRPC code. The order matters if an error belongs to multiple categories:
+// the first match wins.
+var categories = []struct {
+	err    error
+	status int
+	code   codes.Code
+}{
+	{err: InvalidInput, status: http.StatusBadRequest, code: codes.InvalidArgument},
+	{err: NotFound, status: http.StatusNotFound, code: codes.NotFound},
+	{err: Conflict, status: http.StatusConflict, code: codes.AlreadyExists},
+	{err: Unavailable, status: http.StatusServiceUnavailable, code: codes.Unavailable},
+}
+
+// categorized marks an error with a category while keeping both in the error
+// chain.
+type categorized struct {
+	category error
+	err      error
+}
+
+func (c *categorized) Error() string {
+	return c.err.Error()
+}
+
+func (c *categorized) Unwrap() []error {
+	return []error{c.category, c.err}
+}
+
+// Wrap marks err with the given category. The returned error reports the
+// same message as err and satisfies both errors.Is(result, category) and
+// errors.Is(result, err). Wrap returns nil if err is nil.
+func Wrap(category error, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &categorized{category: category, err: err}
+}
+
+// Wrapf marks err with the given category and prefixes its message with the
+// formatted string, similar to fmt.Errorf("...: %w", err). Wrapf returns nil
+// if err is nil.
+func Wrapf(category error, err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &categorized{
+		category: category,
+		err:      fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), err),
+	}
+}
+
+// Newf creates a new error with the formatted message that belongs to the
+// given category.
+func Newf(category error, format string, args ...any) error {
+	return &categorized{category: category, err: fmt.Errorf(format, args...)}
+}
+
+// Category returns the category of the given error or nil if the error
+// doesn't belong to any known category.
+func Category(err error) error {
+	for _, c := range categories {
+		if errors.Is(err, c.err) {
+			return c.err
+		}
+	}
+	return nil
+}
+
+// HTTPStatus returns the HTTP status code for the given error. It returns
+// 200 for a nil error and 500 for errors that don't belong to any category.
+func HTTPStatus(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+
+	for _, c := range categories {
+		if errors.Is(err, c.err) {
+			return c.status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
+
+// GRPCCode returns the gRPC code for the given error. Categories take
+// precedence over a gRPC status further down the chain, and errors that only
+// carry a gRPC status keep its code. It returns [codes.OK] for a nil error
+// and [codes.Unknown] for errors that don't belong to any category.
+func GRPCCode(err error) codes.Code {
+	switch {
+	case err == nil:
+		return codes.OK
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	}
+
+	for _, c := range categories {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+
+	return codes.Unknown
+}
+
+// GRPCStatus converts the given error into a gRPC status error with the code
+// returned by [GRPCCode]. Errors that already carry a gRPC status with that
+// code are returned unchanged to keep the status details. It returns nil for
+// a nil error.
+func GRPCStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	code := GRPCCode(err)
+	if s, ok := status.FromError(err); ok && s.Code() == code {
+		return err
+	}
+
+	return status.Error(code, err.Error())
+}
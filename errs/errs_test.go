@@ -0,0 +1,66 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWrap(t *testing.T) {
+	base := errors.New("no rows")
+	err := Wrap(NotFound, base)
+
+	assert.ErrorIs(t, err, NotFound)
+	assert.ErrorIs(t, err, base)
+	assert.Equal(t, "no rows", err.Error())
+	assert.Nil(t, Wrap(NotFound, nil))
+
+	err = Wrapf(Unavailable, base, "query %s", "peers")
+	assert.ErrorIs(t, err, Unavailable)
+	assert.ErrorIs(t, err, base)
+	assert.Equal(t, "query peers: no rows", err.Error())
+	assert.Nil(t, Wrapf(Unavailable, nil, "query"))
+
+	// categories survive further wrapping
+	err = fmt.Errorf("handler: %w", Newf(Conflict, "peer %d exists", 1))
+	assert.ErrorIs(t, err, Conflict)
+	assert.Equal(t, Conflict, Category(err))
+	assert.Nil(t, Category(base))
+}
+
+func TestMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   codes.Code
+	}{
+		{name: "nil", err: nil, wantStatus: http.StatusOK, wantCode: codes.OK},
+		{name: "not found", err: Newf(NotFound, "x"), wantStatus: http.StatusNotFound, wantCode: codes.NotFound},
+		{name: "invalid input", err: Newf(InvalidInput, "x"), wantStatus: http.StatusBadRequest, wantCode: codes.InvalidArgument},
+		{name: "unavailable", err: Newf(Unavailable, "x"), wantStatus: http.StatusServiceUnavailable, wantCode: codes.Unavailable},
+		{name: "conflict", err: Newf(Conflict, "x"), wantStatus: http.StatusConflict, wantCode: codes.AlreadyExists},
+		{name: "deadline", err: fmt.Errorf("x: %w", context.DeadlineExceeded), wantStatus: http.StatusGatewayTimeout, wantCode: codes.DeadlineExceeded},
+		{name: "grpc status", err: status.Error(codes.PermissionDenied, "x"), wantStatus: http.StatusInternalServerError, wantCode: codes.PermissionDenied},
+		{name: "category wraps grpc status", err: Wrap(NotFound, status.Error(codes.Internal, "x")), wantStatus: http.StatusNotFound, wantCode: codes.NotFound},
+		{name: "unclassified", err: errors.New("x"), wantStatus: http.StatusInternalServerError, wantCode: codes.Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantStatus, HTTPStatus(tt.err))
+			assert.Equal(t, tt.wantCode, GRPCCode(tt.err))
+			assert.Equal(t, tt.wantCode, status.Code(GRPCStatus(tt.err)))
+		})
+	}
+}
+
+func TestGRPCStatus_unchanged(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", status.Error(codes.PermissionDenied, "x"))
+	assert.Equal(t, err, GRPCStatus(err))
+}
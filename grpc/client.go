@@ -0,0 +1,431 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/stats"
+
+	"github.com/probe-lab/go-commons/errs"
+)
+
+// ClientTelemetryConfig configures the client-side metrics and logging
+// interceptors.
+type ClientTelemetryConfig struct {
+	// Meter is used to record client metrics. If nil, the global meter
+	// provider is used.
+	Meter metric.Meter
+
+	// LogSampleRate is the fraction of successful calls that are logged,
+	// between 0 and 1. Failed calls are always logged.
+	LogSampleRate float64
+
+	// LogOpts are additional options for the logging interceptors.
+	LogOpts []logging.Option
+}
+
+// DefaultClientTelemetryConfig returns a [ClientTelemetryConfig] that logs
+// all failed and one percent of the successful calls.
+func DefaultClientTelemetryConfig() *ClientTelemetryConfig {
+	return &ClientTelemetryConfig{
+		LogSampleRate: 0.01,
+	}
+}
+
+// ClientTelemetryDialOptions returns the dial options that instrument a client
+// connection consistently with the server: OpenTelemetry tracing and metrics
+// via otelgrpc, per-method latency and retry metrics, and sampled logging.
+func ClientTelemetryDialOptions(cfg *ClientTelemetryConfig) []grpc.DialOption {
+	if cfg == nil {
+		cfg = DefaultClientTelemetryConfig()
+	}
+
+	m := newClientMetrics(cfg.Meter)
+
+	return []grpc.DialOption{
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithStatsHandler(attemptsHandler{}),
+		grpc.WithChainUnaryInterceptor(
+			m.unaryInterceptor(),
+			LoggingUnaryClientInterceptor(cfg.LogSampleRate, cfg.LogOpts...),
+		),
+		grpc.WithChainStreamInterceptor(
+			m.streamInterceptor(),
+			LoggingStreamClientInterceptor(cfg.LogSampleRate, cfg.LogOpts...),
+		),
+	}
+}
+
+// ClientConfig configures a client connection created with [NewClient].
+type ClientConfig struct {
+	// Target is the address of the server in gRPC name syntax, e.g.,
+	// "localhost:8080" or "dns:///api.example.com:443".
+	Target string
+
+	// TLS enables transport security. The server certificate is verified
+	// against TLSCAFile or, if empty, the system roots.
+	TLS bool
+
+	// TLSCAFile is the path to the PEM encoded CA certificate that the
+	// server certificate is verified against.
+	TLSCAFile string
+
+	// TLSCertFile and TLSKeyFile are the paths to the PEM encoded client
+	// certificate and key for servers that require mutual TLS.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// TLSServerName overrides the server name that the certificate is
+	// verified for, which defaults to the host of the Target.
+	TLSServerName string
+
+	// KeepaliveTime is the interval at which the client pings the server
+	// while there are active calls, so that broken connections are detected
+	// even without traffic. It must not be lower than the server's minimum
+	// ping interval, see [ServerConfig.KeepaliveMinTime]. Zero disables
+	// pings.
+	KeepaliveTime time.Duration
+
+	// KeepaliveTimeout is how long the client waits for a ping
+	// acknowledgement before it closes the connection.
+	KeepaliveTimeout time.Duration
+
+	// MaxRecvMsgSize and MaxSendMsgSize limit the size of messages in bytes.
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+
+	// WaitForReady makes calls wait until the connection is ready, e.g.,
+	// while the server restarts, instead of failing immediately. Calls are
+	// still bounded by their context.
+	WaitForReady bool
+
+	// Telemetry configures the metrics and logging interceptors. If nil, the
+	// [DefaultClientTelemetryConfig] is used.
+	Telemetry *ClientTelemetryConfig
+
+	// DialOptions are appended to the options derived from this config.
+	DialOptions []grpc.DialOption
+}
+
+// DefaultClientConfig returns a [ClientConfig] for an insecure connection to
+// localhost:8080 that waits for the server to become ready and accepts
+// messages of up to 16 MiB.
+func DefaultClientConfig() *ClientConfig {
+	return &ClientConfig{
+		Target:           "localhost:8080",
+		KeepaliveTime:    5 * time.Minute,
+		KeepaliveTimeout: 20 * time.Second,
+		MaxRecvMsgSize:   16 << 20,
+		MaxSendMsgSize:   16 << 20,
+		WaitForReady:     true,
+		Telemetry:        DefaultClientTelemetryConfig(),
+	}
+}
+
+// Validate validates the client configuration.
+func (cfg *ClientConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if cfg.Target == "" {
+		return fmt.Errorf("target must not be empty")
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return fmt.Errorf("tls cert file and tls key file must be set together")
+	}
+
+	if !cfg.TLS && (cfg.TLSCAFile != "" || cfg.TLSCertFile != "" || cfg.TLSServerName != "") {
+		return fmt.Errorf("tls must be enabled to use tls ca, cert, or server name")
+	}
+
+	if cfg.KeepaliveTime < 0 || cfg.KeepaliveTimeout < 0 {
+		return fmt.Errorf("keepalive time and timeout must not be negative")
+	}
+
+	if cfg.MaxRecvMsgSize <= 0 || cfg.MaxSendMsgSize <= 0 {
+		return fmt.Errorf("max message sizes must be positive")
+	}
+
+	return nil
+}
+
+// LogValue implements [slog.LogValuer].
+func (cfg *ClientConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("target", cfg.Target),
+		slog.Bool("tls", cfg.TLS),
+		slog.Bool("mtls", cfg.TLSCertFile != ""),
+		slog.Bool("wait_for_ready", cfg.WaitForReady),
+	)
+}
+
+// NewClient creates a client connection with consistent defaults: the
+// telemetry of [ClientTelemetryDialOptions], keepalive pings, TLS, message
+// size limits, and wait-for-ready calls. Like [grpc.NewClient], it doesn't
+// connect until the first call.
+func NewClient(cfg *ClientConfig) (*grpc.ClientConn, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	creds, err := cfg.transportCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(
+			grpc.WaitForReady(cfg.WaitForReady),
+			grpc.MaxCallRecvMsgSize(cfg.MaxRecvMsgSize),
+			grpc.MaxCallSendMsgSize(cfg.MaxSendMsgSize),
+		),
+	}
+
+	if cfg.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    cfg.KeepaliveTime,
+			Timeout: cfg.KeepaliveTimeout,
+		}))
+	}
+
+	opts = append(opts, ClientTelemetryDialOptions(cfg.Telemetry)...)
+	opts = append(opts, cfg.DialOptions...)
+
+	conn, err := grpc.NewClient(cfg.Target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("new grpc client for %s: %w", cfg.Target, err)
+	}
+
+	return conn, nil
+}
+
+func (cfg *ClientConfig) transportCredentials() (credentials.TransportCredentials, error) {
+	if !cfg.TLS {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsCfg := &tls.Config{
+		ServerName: cfg.TLSServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.TLSCAFile != "" {
+		var err error
+		if tlsCfg.RootCAs, err = loadCertPool(cfg.TLSCAFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load tls client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// MetricsUnaryClientInterceptor records the latency of unary calls per method
+// and status code as well as the number of retries. Retries are only counted
+// if the connection was also configured with the dial options returned by
+// [ClientTelemetryDialOptions].
+func MetricsUnaryClientInterceptor(meter metric.Meter) grpc.UnaryClientInterceptor {
+	return newClientMetrics(meter).unaryInterceptor()
+}
+
+// MetricsStreamClientInterceptor is the streaming counterpart of
+// [MetricsUnaryClientInterceptor]. The latency covers the whole lifetime of
+// the stream.
+func MetricsStreamClientInterceptor(meter metric.Meter) grpc.StreamClientInterceptor {
+	return newClientMetrics(meter).streamInterceptor()
+}
+
+// LoggingUnaryClientInterceptor logs finished unary calls with the same
+// format as the server. Only the given fraction of successful calls is
+// logged, while failed calls are always logged.
+func LoggingUnaryClientInterceptor(sampleRate float64, opts ...logging.Option) grpc.UnaryClientInterceptor {
+	return logging.UnaryClientInterceptor(sampledLogger(sampleRate), clientLoggingOpts(opts)...)
+}
+
+// LoggingStreamClientInterceptor is the streaming counterpart of
+// [LoggingUnaryClientInterceptor].
+func LoggingStreamClientInterceptor(sampleRate float64, opts ...logging.Option) grpc.StreamClientInterceptor {
+	return logging.StreamClientInterceptor(sampledLogger(sampleRate), clientLoggingOpts(opts)...)
+}
+
+func clientLoggingOpts(opts []logging.Option) []logging.Option {
+	return append([]logging.Option{
+		logging.WithLogOnEvents(logging.FinishCall),
+		logging.WithDisableLoggingFields(logging.ServiceFieldKey, logging.ComponentFieldKey, logging.MethodTypeFieldKey),
+	}, opts...)
+}
+
+// sampledLogger forwards warnings and errors to slog and samples all other
+// log statements with the given rate.
+func sampledLogger(sampleRate float64) logging.Logger {
+	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+		if lvl < logging.LevelWarn && rand.Float64() >= sampleRate {
+			return
+		}
+		slog.Log(ctx, slog.Level(lvl), msg, fields...)
+	})
+}
+
+// clientMetrics holds the instruments recorded by the client interceptors.
+type clientMetrics struct {
+	duration metric.Float64Histogram
+	retries  metric.Int64Counter
+}
+
+func newClientMetrics(meter metric.Meter) *clientMetrics {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter("grpc.client")
+	}
+
+	duration, err := meter.Float64Histogram("grpc_client_call_duration",
+		metric.WithDescription("Duration of outgoing gRPC calls by method and status code."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	retries, err := meter.Int64Counter("grpc_client_retries_total",
+		metric.WithDescription("Total number of retried outgoing gRPC call attempts by method."),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return &clientMetrics{duration: duration, retries: retries}
+}
+
+func (m *clientMetrics) record(ctx context.Context, method string, start time.Time, attempts *atomic.Int32, err error) {
+	m.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+		attribute.String("method", method),
+		attribute.String("code", errs.GRPCCode(err).String()),
+	))
+
+	if retries := attempts.Load() - 1; retries > 0 {
+		m.retries.Add(ctx, int64(retries), metric.WithAttributes(attribute.String("method", method)))
+	}
+}
+
+func (m *clientMetrics) unaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, attempts := withAttempts(ctx)
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		m.record(ctx, method, start, attempts, err)
+		return err
+	}
+}
+
+func (m *clientMetrics) streamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, attempts := withAttempts(ctx)
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			m.record(ctx, method, start, attempts, err)
+			return nil, err
+		}
+
+		s := &monitoredClientStream{ClientStream: cs, desc: desc, done: func(err error) {
+			m.record(ctx, method, start, attempts, err)
+		}}
+
+		// Streams whose caller stops reading are only finished by canceling
+		// the call's context. We watch the call's context instead of
+		// cs.Context() because the latter commits the attempt, which disables
+		// retries, and is also canceled when the stream finishes normally.
+		s.stop = context.AfterFunc(ctx, func() { s.finish(ctx.Err()) })
+
+		return s, nil
+	}
+}
+
+// monitoredClientStream calls done exactly once when the stream finishes,
+// i.e., when receiving a message fails with io.EOF or any other error, when
+// the single reply of a call without server streaming was received, or when
+// the context of the call is done before that.
+type monitoredClientStream struct {
+	grpc.ClientStream
+	desc *grpc.StreamDesc
+	done func(err error)
+	stop func() bool
+	once sync.Once
+}
+
+func (s *monitoredClientStream) RecvMsg(msg any) error {
+	err := s.ClientStream.RecvMsg(msg)
+	switch {
+	case errors.Is(err, io.EOF):
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	case !s.desc.ServerStreams:
+		// the caller won't read again after the single reply, e.g., in
+		// CloseAndRecv of a client-streaming call.
+		s.finish(nil)
+	}
+	return err
+}
+
+func (s *monitoredClientStream) finish(err error) {
+	s.once.Do(func() {
+		s.stop()
+		s.done(err)
+	})
+}
+
+// attemptsCtxKey is the context key under which the attempts counter of a
+// call is stored.
+type attemptsCtxKey struct{}
+
+func withAttempts(ctx context.Context) (context.Context, *atomic.Int32) {
+	attempts := &atomic.Int32{}
+	return context.WithValue(ctx, attemptsCtxKey{}, attempts), attempts
+}
+
+// attemptsHandler is a [stats.Handler] that counts the attempts of each call
+// so that the metrics interceptors can derive the number of retries.
+type attemptsHandler struct{}
+
+var _ stats.Handler = attemptsHandler{}
+
+func (attemptsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context   { return ctx }
+func (attemptsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context { return ctx }
+func (attemptsHandler) HandleConn(context.Context, stats.ConnStats)                       {}
+
+func (attemptsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	if _, ok := s.(*stats.Begin); !ok {
+		return
+	}
+
+	if attempts, ok := ctx.Value(attemptsCtxKey{}).(*atomic.Int32); ok {
+		attempts.Add(1)
+	}
+}
@@ -0,0 +1,250 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// uploadServiceDesc describes a client-streaming test service that replies
+// once the client closed its side of the stream.
+var uploadServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Upload",
+	HandlerType: (*any)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Upload",
+		ClientStreams: true,
+		Handler: func(_ any, stream grpc.ServerStream) error {
+			for {
+				err := stream.RecvMsg(&healthgrpc.HealthCheckRequest{})
+				if errors.Is(err, io.EOF) {
+					return stream.SendMsg(&healthgrpc.HealthCheckResponse{})
+				} else if err != nil {
+					return err
+				}
+			}
+		},
+	}},
+}
+
+// newTelemetryTestConn starts a server with the health and upload services
+// and returns a connection to it with the client telemetry options, whose
+// metrics are collected by the returned reader.
+func newTelemetryTestConn(t *testing.T) (*grpc.ClientConn, *sdkmetric.ManualReader) {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() { assert.NoError(t, lis.Close()) })
+
+	s, err := NewServer(&ServerConfig{Listener: lis})
+	require.NoError(t, err)
+
+	s.RegisterService(&uploadServiceDesc, struct{}{})
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- s.ListenAndServe() }()
+	t.Cleanup(func() {
+		s.Shutdown()
+		assert.NoError(t, <-serveErr)
+	})
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	cfg := DefaultClientTelemetryConfig()
+	cfg.Meter = provider.Meter("test")
+
+	opts := append([]grpc.DialOption{
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, ClientTelemetryDialOptions(cfg)...)
+
+	conn, err := grpc.NewClient("passthrough://bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, conn.Close()) })
+
+	return conn, reader
+}
+
+// callDurationCodes returns the number of recorded calls per method and code.
+func callDurationCodes(t *testing.T, reader *sdkmetric.ManualReader) map[string]uint64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	codes := map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "grpc_client_call_duration" {
+				continue
+			}
+
+			for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+				method, _ := dp.Attributes.Value(attribute.Key("method"))
+				code, _ := dp.Attributes.Value(attribute.Key("code"))
+				codes[method.AsString()+" "+code.AsString()] = dp.Count
+			}
+		}
+	}
+
+	return codes
+}
+
+func TestClientTelemetryDialOptions(t *testing.T) {
+	conn, reader := newTelemetryTestConn(t)
+
+	client := healthgrpc.NewHealthClient(conn)
+	_, err := client.Check(context.Background(), &healthgrpc.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	_, err = client.Check(context.Background(), &healthgrpc.HealthCheckRequest{Service: "unknown"})
+	require.Error(t, err)
+
+	assert.Equal(t, map[string]uint64{
+		"/grpc.health.v1.Health/Check OK":       1,
+		"/grpc.health.v1.Health/Check NotFound": 1,
+	}, callDurationCodes(t, reader))
+}
+
+func TestClientTelemetryDialOptions_canceledStream(t *testing.T) {
+	conn, reader := newTelemetryTestConn(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := healthgrpc.NewHealthClient(conn).Watch(ctx, &healthgrpc.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	// the stream is abandoned without reading until it fails
+	cancel()
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, map[string]uint64{"/grpc.health.v1.Health/Watch Canceled": 1}, callDurationCodes(t, reader))
+	}, time.Second, 10*time.Millisecond)
+
+	// reading after the cancellation doesn't record the stream again
+	_, err = stream.Recv()
+	require.Error(t, err)
+	assert.Equal(t, map[string]uint64{"/grpc.health.v1.Health/Watch Canceled": 1}, callDurationCodes(t, reader))
+}
+
+func TestClientTelemetryDialOptions_clientStream(t *testing.T) {
+	conn, reader := newTelemetryTestConn(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	desc := &uploadServiceDesc.Streams[0]
+	stream, err := conn.NewStream(ctx, desc, "/test.Upload/Upload")
+	require.NoError(t, err)
+
+	for range 3 {
+		require.NoError(t, stream.SendMsg(&healthgrpc.HealthCheckRequest{}))
+	}
+	require.NoError(t, stream.CloseSend())
+	require.NoError(t, stream.RecvMsg(&healthgrpc.HealthCheckResponse{}))
+
+	// the call is recorded with the reply and not when ctx is canceled
+	assert.Equal(t, map[string]uint64{"/test.Upload/Upload OK": 1}, callDurationCodes(t, reader))
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, map[string]uint64{"/test.Upload/Upload OK": 1}, callDurationCodes(t, reader))
+}
+
+func TestClientConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultClientConfig().Validate())
+
+	var nilCfg *ClientConfig
+	assert.Error(t, nilCfg.Validate())
+
+	cfg := DefaultClientConfig()
+	cfg.Target = ""
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultClientConfig()
+	cfg.TLS = true
+	cfg.TLSCertFile = "cert.pem"
+	assert.Error(t, cfg.Validate())
+
+	cfg.TLSKeyFile = "key.pem"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.TLS = false
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultClientConfig()
+	cfg.MaxRecvMsgSize = 0
+	assert.Error(t, cfg.Validate())
+}
+
+func TestNewClient(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, 1)
+
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() { assert.NoError(t, lis.Close()) })
+
+	s, err := NewServer(&ServerConfig{Listener: lis, TLSCertFile: certFile, TLSKeyFile: keyFile})
+	require.NoError(t, err)
+	t.Cleanup(s.Shutdown)
+
+	cfg := DefaultClientConfig()
+	cfg.Target = "passthrough://bufnet"
+	cfg.TLS = true
+	cfg.TLSCAFile = certFile
+	cfg.TLSServerName = "localhost"
+	cfg.DialOptions = []grpc.DialOption{
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+	}
+
+	conn, err := NewClient(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, conn.Close()) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the call waits for the server to start serving
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := healthgrpc.NewHealthClient(conn).Check(ctx, &healthgrpc.HealthCheckRequest{})
+		errCh <- err
+	}()
+
+	go func() { _ = s.ListenAndServe() }()
+
+	require.NoError(t, <-errCh)
+}
+
+func TestNewClient_invalidCA(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+
+	cfg := DefaultClientConfig()
+	cfg.TLS = true
+	cfg.TLSCAFile = caFile
+
+	_, err := NewClient(cfg)
+	assert.ErrorContains(t, err, "no certificates found")
+}
@@ -0,0 +1,284 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// GatewayConfig configures the grpc-gateway REST/JSON API of a [Server].
+// The gateway forwards requests to the server through an in-process
+// connection, so they pass the same interceptors as gRPC requests, e.g.,
+// logging, maintenance mode, and rate limits.
+type GatewayConfig struct {
+	// Listener, Host, and Port configure where the gateway serves plain
+	// HTTP, like the fields of [ServerConfig]. If all of them are empty, the
+	// gateway shares the listener of the gRPC server: requests with the
+	// application/grpc content type are served by the gRPC server and all
+	// others by the gateway, over TLS if the server uses TLS and over
+	// HTTP/1.1 or cleartext HTTP/2 otherwise.
+	Listener net.Listener
+	Host     string
+	Port     int
+
+	// MuxOptions customize the gateway, e.g., how messages are marshaled to
+	// JSON or which headers are forwarded as metadata.
+	MuxOptions []runtime.ServeMuxOption
+}
+
+// Validate validates the gateway configuration.
+func (cfg *GatewayConfig) Validate() error {
+	if cfg.Listener != nil {
+		if cfg.Host != "" || cfg.Port != 0 {
+			return fmt.Errorf("gateway listener and host or port cannot both be set")
+		}
+		return nil
+	}
+
+	if cfg.Port < 0 {
+		return fmt.Errorf("gateway port must not be negative")
+	}
+
+	if cfg.Port != 0 && cfg.Host == "" {
+		return fmt.Errorf("gateway host must be set together with the port")
+	}
+
+	return nil
+}
+
+// shared reports whether the gateway shares the listener of the gRPC server.
+func (cfg *GatewayConfig) shared() bool {
+	return cfg.Listener == nil && cfg.Host == "" && cfg.Port == 0
+}
+
+func (cfg *GatewayConfig) listener() (net.Listener, error) {
+	if cfg.Listener != nil {
+		return cfg.Listener, nil
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("tcp listen on %s: %w", addr, err)
+	}
+
+	return lis, nil
+}
+
+// GatewayRegisterFunc registers the REST handlers of a service with the
+// gateway. The Register<Service>Handler functions generated by
+// protoc-gen-grpc-gateway have this signature.
+type GatewayRegisterFunc func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+// RegisterGateway registers the REST handlers of a service with the gateway,
+// e.g., pb.RegisterCrawlsHandler, after the service itself was registered
+// with [Server.RegisterService]. It fails if the server has no gateway.
+func (s *Server) RegisterGateway(ctx context.Context, register GatewayRegisterFunc) error {
+	if s.gateway == nil {
+		return fmt.Errorf("gateway is not configured")
+	}
+
+	return register(ctx, s.gateway.mux, s.gateway.conn)
+}
+
+// gateway holds the grpc-gateway mux of a [Server], the HTTP server that
+// serves it, and the in-process connection to the gRPC server.
+type gateway struct {
+	cfg  *GatewayConfig
+	mux  *runtime.ServeMux
+	http *http.Server
+
+	// lis is the in-process listener that the gRPC server serves conn on.
+	lis  *pipeListener
+	conn *grpc.ClientConn
+}
+
+// newGateway creates the gateway of a server. If the gateway shares the
+// listener of the server, grpcHandler serves the gRPC requests.
+func newGateway(cfg *ServerConfig, grpcHandler http.Handler) (*gateway, error) {
+	lis := newPipeListener()
+
+	conn, err := grpc.NewClient("passthrough:///gateway",
+		grpc.WithContextDialer(lis.DialContext),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			// the server enforces the limits
+			grpc.MaxCallRecvMsgSize(math.MaxInt32),
+			grpc.MaxCallSendMsgSize(math.MaxInt32),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("new gateway client: %w", err)
+	}
+
+	gw := &gateway{
+		cfg:  cfg.Gateway,
+		mux:  runtime.NewServeMux(cfg.Gateway.MuxOptions...),
+		lis:  lis,
+		conn: conn,
+	}
+
+	gw.http = &http.Server{Handler: gw.mux}
+
+	if cfg.Gateway.shared() {
+		gw.http.Handler = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+				grpcHandler.ServeHTTP(rw, r)
+				return
+			}
+			gw.mux.ServeHTTP(rw, r)
+		})
+
+		gw.http.Protocols = new(http.Protocols)
+		gw.http.Protocols.SetHTTP1(true)
+		gw.http.Protocols.SetHTTP2(true)
+		gw.http.Protocols.SetUnencryptedHTTP2(cfg.TLSCertFile == "")
+
+		gw.http.HTTP2 = &http.HTTP2Config{
+			SendPingTimeout: cfg.KeepaliveTime,
+			PingTimeout:     cfg.KeepaliveTimeout,
+		}
+	}
+
+	return gw, nil
+}
+
+// serve serves the gateway on lis until the gateway is shut down. If tlsCfg
+// is not nil, it terminates TLS.
+func (gw *gateway) serve(lis net.Listener, tlsCfg *tls.Config) error {
+	if tlsCfg != nil {
+		tlsCfg = tlsCfg.Clone()
+		tlsCfg.NextProtos = []string{"h2", "http/1.1"}
+		lis = tls.NewListener(lis, tlsCfg)
+	}
+
+	if err := gw.http.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// shutdown stops accepting gateway requests and waits for the in-flight
+// ones.
+func (gw *gateway) shutdown(ctx context.Context) {
+	if err := gw.http.Shutdown(ctx); err != nil {
+		slog.Warn("Failed to shut down gRPC gateway", "err", err)
+	}
+}
+
+// close closes the in-process connection after the gRPC server stopped.
+func (gw *gateway) close() {
+	if err := gw.conn.Close(); err != nil {
+		slog.Warn("Failed to close gRPC gateway connection", "err", err)
+	}
+}
+
+// gatewayCredentials skips the TLS handshake of the server's transport
+// credentials for the in-process connections of the gateway.
+type gatewayCredentials struct {
+	credentials.TransportCredentials
+}
+
+func (c gatewayCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	if _, ok := conn.(*pipeConn); ok {
+		return insecure.NewCredentials().ServerHandshake(conn)
+	}
+	return c.TransportCredentials.ServerHandshake(conn)
+}
+
+func (c gatewayCredentials) Clone() credentials.TransportCredentials {
+	return gatewayCredentials{TransportCredentials: c.TransportCredentials.Clone()}
+}
+
+// gatewayRemoteIP returns the IP address of the client of a gateway request,
+// which the gateway appends to the X-Forwarded-For metadata.
+func gatewayRemoteIP(ctx context.Context) string {
+	xff := metadata.ValueFromIncomingContext(ctx, "x-forwarded-for")
+	if len(xff) == 0 {
+		return ""
+	}
+
+	ips := strings.Split(xff[len(xff)-1], ",")
+	return strings.TrimSpace(ips[len(ips)-1])
+}
+
+// pipeListener is an in-process [net.Listener] whose connections are
+// created with [pipeListener.DialContext].
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+// pipeConn is the server side of a connection of a [pipeListener].
+type pipeConn struct {
+	net.Conn
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return pipeAddr{} }
+func (c *pipeConn) RemoteAddr() net.Addr { return pipeAddr{} }
+
+// pipeAddr is the address of a [pipeListener] and its connections.
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "gateway" }
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+func (l *pipeListener) DialContext(ctx context.Context, _ string) (net.Conn, error) {
+	client, server := net.Pipe()
+
+	select {
+	case l.conns <- &pipeConn{Conn: server}:
+		return client, nil
+	case <-l.done:
+	case <-ctx.Done():
+	}
+
+	_ = client.Close()
+	_ = server.Close()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return nil, net.ErrClosed
+}
@@ -0,0 +1,123 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// registerHealthGateway stands in for a handler generated by
+// protoc-gen-grpc-gateway and serves the health service at GET /health.
+func registerHealthGateway(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+	client := healthgrpc.NewHealthClient(conn)
+	return mux.HandlePath(http.MethodGet, "/health", func(rw http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/grpc.health.v1.Health/Check")
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp, err := client.Check(ctx, &healthgrpc.HealthCheckRequest{})
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, rw, r, err)
+			return
+		}
+
+		_, _ = fmt.Fprint(rw, resp.GetStatus())
+	})
+}
+
+func TestServer_gateway(t *testing.T) {
+	slog.SetLogLoggerLevel(slog.LevelError)
+
+	tests := []struct {
+		name   string
+		shared bool
+	}{
+		{name: "own listener"},
+		{name: "shared listener", shared: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lis := bufconn.Listen(1024 * 1024)
+			t.Cleanup(func() { assert.NoError(t, lis.Close()) })
+
+			gwLis := lis
+			gwCfg := &GatewayConfig{}
+			if !tt.shared {
+				gwLis = bufconn.Listen(1024 * 1024)
+				t.Cleanup(func() { assert.NoError(t, gwLis.Close()) })
+				gwCfg.Listener = gwLis
+			}
+
+			s, err := NewServer(&ServerConfig{Listener: lis, Gateway: gwCfg})
+			require.NoError(t, err)
+			require.NoError(t, s.RegisterGateway(context.Background(), registerHealthGateway))
+
+			done := make(chan error, 1)
+			go func() { done <- s.ListenAndServe() }()
+
+			httpClient := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) { return gwLis.DialContext(ctx) },
+			}}
+
+			resp, err := httpClient.Get("http://gateway/health")
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "SERVING", string(body))
+
+			// gRPC requests are still served on the gRPC listener
+			conn, err := grpc.NewClient("passthrough://bufnet",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			)
+			require.NoError(t, err)
+			t.Cleanup(func() { assert.NoError(t, conn.Close()) })
+
+			hresp, err := healthgrpc.NewHealthClient(conn).Check(context.Background(), &healthgrpc.HealthCheckRequest{})
+			require.NoError(t, err)
+			assert.Equal(t, healthgrpc.HealthCheckResponse_SERVING, hresp.GetStatus())
+
+			s.Shutdown()
+			require.NoError(t, <-done)
+		})
+	}
+}
+
+func TestServer_RegisterGateway_disabled(t *testing.T) {
+	s, err := NewServer(&ServerConfig{Listener: bufconn.Listen(1024)})
+	require.NoError(t, err)
+	assert.Error(t, s.RegisterGateway(context.Background(), registerHealthGateway))
+}
+
+func TestGatewayConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&GatewayConfig{}).Validate())
+	assert.NoError(t, (&GatewayConfig{Host: "localhost", Port: 8081}).Validate())
+	assert.NoError(t, (&GatewayConfig{Listener: &bufconn.Listener{}}).Validate())
+	assert.Error(t, (&GatewayConfig{Listener: &bufconn.Listener{}, Port: 8081}).Validate())
+	assert.Error(t, (&GatewayConfig{Port: 8081}).Validate())
+	assert.Error(t, (&GatewayConfig{Host: "localhost", Port: -1}).Validate())
+}
+
+func TestGatewayRemoteIP(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-forwarded-for", "1.1.1.1, 10.0.0.1"))
+	assert.Equal(t, "10.0.0.1", gatewayRemoteIP(ctx))
+	assert.Equal(t, "", gatewayRemoteIP(context.Background()))
+}
@@ -0,0 +1,208 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimit is a token bucket that allows Rate requests per second on
+// average and bursts of up to Burst requests.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitConfig configures the rate limits of a [Server]. Requests that
+// exceed a limit are rejected with RESOURCE_EXHAUSTED. Health checks are
+// never limited.
+type RateLimitConfig struct {
+	// Default is the limit of all methods without an entry in Methods. A
+	// zero Rate disables it.
+	Default RateLimit
+
+	// Methods overrides the limit of individual methods, keyed by their full
+	// name, e.g., "/api.v1.Crawls/Upload". A zero Rate exempts the method.
+	Methods map[string]RateLimit
+
+	// PerPeer applies the limits to each client IP address separately
+	// instead of to all clients together.
+	PerPeer bool
+}
+
+// Validate validates the rate limit configuration.
+func (cfg *RateLimitConfig) Validate() error {
+	if err := cfg.Default.validate(); err != nil {
+		return fmt.Errorf("default rate limit: %w", err)
+	}
+
+	for method, l := range cfg.Methods {
+		if !strings.HasPrefix(method, "/") {
+			return fmt.Errorf("rate limit method %q must be a full method name like /package.Service/Method", method)
+		}
+
+		if err := l.validate(); err != nil {
+			return fmt.Errorf("rate limit of %s: %w", method, err)
+		}
+	}
+
+	return nil
+}
+
+func (l RateLimit) validate() error {
+	if l.Rate < 0 {
+		return fmt.Errorf("rate must not be negative")
+	}
+
+	if l.Rate > 0 && l.Burst < 1 {
+		return fmt.Errorf("burst must be positive")
+	}
+
+	return nil
+}
+
+// enabled reports whether any limit is configured.
+func (cfg *RateLimitConfig) enabled() bool {
+	if cfg.Default.Rate > 0 {
+		return true
+	}
+
+	for _, l := range cfg.Methods {
+		if l.Rate > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rateLimiterIdleTimeout is how long the bucket of a method and peer is kept
+// after its last request. Recreated buckets start full, so this must be
+// longer than the time it takes to refill a bucket.
+const rateLimiterIdleTimeout = 10 * time.Minute
+
+// rateLimiter holds the token buckets of all methods and, if the limits
+// apply per peer, client addresses.
+type rateLimiter struct {
+	cfg RateLimitConfig
+
+	mu        sync.Mutex
+	buckets   map[rateLimitKey]*rateBucket
+	lastPrune time.Time
+}
+
+type rateLimitKey struct {
+	method string
+	peer   string
+}
+
+type rateBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		cfg:       cfg,
+		buckets:   map[rateLimitKey]*rateBucket{},
+		lastPrune: time.Now(),
+	}
+}
+
+// allow reports whether the call of fullMethod may proceed. It returns a
+// RESOURCE_EXHAUSTED status error otherwise.
+func (r *rateLimiter) allow(ctx context.Context, fullMethod string) error {
+	if r == nil || strings.HasPrefix(fullMethod, "/"+healthgrpc.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+
+	l, ok := r.cfg.Methods[fullMethod]
+	if !ok {
+		l = r.cfg.Default
+	}
+
+	if l.Rate == 0 {
+		return nil
+	}
+
+	key := rateLimitKey{method: fullMethod}
+	if r.cfg.PerPeer {
+		key.peer = peerHost(ctx)
+	}
+
+	now := time.Now()
+
+	r.mu.Lock()
+	if now.Sub(r.lastPrune) > rateLimiterIdleTimeout {
+		for k, b := range r.buckets {
+			if now.Sub(b.lastSeen) > rateLimiterIdleTimeout {
+				delete(r.buckets, k)
+			}
+		}
+		r.lastPrune = now
+	}
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &rateBucket{limiter: rate.NewLimiter(rate.Limit(l.Rate), l.Burst)}
+		r.buckets[key] = b
+	}
+	b.lastSeen = now
+	allowed := b.limiter.AllowN(now, 1)
+	r.mu.Unlock()
+
+	if !allowed {
+		return status.Errorf(codes.ResourceExhausted, "rate limit of %s exceeded", fullMethod)
+	}
+
+	return nil
+}
+
+// peerHost returns the IP address of the client of the call, or an empty
+// string if it is unknown. For requests of the gateway, it is the address
+// of the HTTP client.
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	if _, ok := p.Addr.(pipeAddr); ok {
+		return gatewayRemoteIP(ctx)
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+func (r *rateLimiter) unaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := r.allow(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (r *rateLimiter) streamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := r.allow(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,98 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestRateLimitConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&RateLimitConfig{}).Validate())
+	assert.NoError(t, (&RateLimitConfig{Default: RateLimit{Rate: 1, Burst: 1}}).Validate())
+	assert.Error(t, (&RateLimitConfig{Default: RateLimit{Rate: -1}}).Validate())
+	assert.Error(t, (&RateLimitConfig{Default: RateLimit{Rate: 1}}).Validate())
+	assert.Error(t, (&RateLimitConfig{Methods: map[string]RateLimit{"Upload": {Rate: 1, Burst: 1}}}).Validate())
+	assert.Error(t, (&RateLimitConfig{Methods: map[string]RateLimit{"/api.Crawls/Upload": {Rate: 1}}}).Validate())
+}
+
+func TestRateLimiter_allow(t *testing.T) {
+	peerCtx := func(addr string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(addr), Port: 1234}})
+	}
+
+	t.Run("per method", func(t *testing.T) {
+		r := newRateLimiter(RateLimitConfig{
+			Default: RateLimit{Rate: 0.001, Burst: 2},
+			Methods: map[string]RateLimit{
+				"/api.Crawls/Upload": {Rate: 0.001, Burst: 1},
+				"/api.Crawls/List":   {},
+			},
+		})
+
+		ctx := peerCtx("10.0.0.1")
+		assert.NoError(t, r.allow(ctx, "/api.Crawls/Upload"))
+		err := r.allow(ctx, "/api.Crawls/Upload")
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		assert.NoError(t, r.allow(ctx, "/api.Crawls/Get"))
+		assert.NoError(t, r.allow(ctx, "/api.Crawls/Get"))
+		assert.Error(t, r.allow(ctx, "/api.Crawls/Get"))
+
+		for range 10 {
+			assert.NoError(t, r.allow(ctx, "/api.Crawls/List"))
+			assert.NoError(t, r.allow(ctx, "/grpc.health.v1.Health/Check"))
+		}
+
+		// limits are shared by all peers
+		assert.Error(t, r.allow(peerCtx("10.0.0.2"), "/api.Crawls/Upload"))
+	})
+
+	t.Run("per peer", func(t *testing.T) {
+		r := newRateLimiter(RateLimitConfig{
+			Default: RateLimit{Rate: 0.001, Burst: 1},
+			PerPeer: true,
+		})
+
+		assert.NoError(t, r.allow(peerCtx("10.0.0.1"), "/api.Crawls/Upload"))
+		assert.Error(t, r.allow(peerCtx("10.0.0.1"), "/api.Crawls/Upload"))
+		assert.NoError(t, r.allow(peerCtx("10.0.0.2"), "/api.Crawls/Upload"))
+	})
+}
+
+func TestServer_rateLimit(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() { assert.NoError(t, lis.Close()) })
+
+	s, err := NewServer(&ServerConfig{
+		Listener:  lis,
+		RateLimit: RateLimitConfig{Default: RateLimit{Rate: 0.001, Burst: 1}},
+	})
+	require.NoError(t, err)
+	t.Cleanup(s.Shutdown)
+
+	go func() { _ = s.ListenAndServe() }()
+
+	conn, err := grpc.NewClient("passthrough://bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, conn.Close()) })
+
+	// health checks are exempt from the limit
+	client := healthgrpc.NewHealthClient(conn)
+	for range 3 {
+		_, err := client.Check(context.Background(), &healthgrpc.HealthCheckRequest{}, grpc.WaitForReady(true))
+		require.NoError(t, err)
+	}
+}
@@ -2,11 +2,14 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
-	"runtime/debug"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
@@ -17,10 +20,17 @@ import (
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
 	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+
+	"github.com/probe-lab/go-commons/errs"
+	"github.com/probe-lab/go-commons/log"
+	"github.com/probe-lab/go-commons/tenant"
 )
 
 type ServerConfig struct {
@@ -28,6 +38,147 @@ type ServerConfig struct {
 	Host     string
 	Port     int
 	LogOpts  []logging.Option
+
+	// Maintenance, if set, makes the server reject all but health check
+	// requests with UNAVAILABLE while maintenance mode is enabled. It is
+	// implemented by maintenance.Mode.
+	Maintenance Maintenance
+
+	// TenantLimiter, if set, enforces per-tenant concurrency and rate limits.
+	// The tenant is read from the [ProjectMetadataKey] and
+	// [NetworkMetadataKey] request metadata and stored in the request
+	// context with [tenant.NewContext]. Requests of tenants that exceeded their
+	// limit are rejected with RESOURCE_EXHAUSTED.
+	TenantLimiter *tenant.Limiter
+
+	// Gateway, if set, serves a grpc-gateway REST/JSON API for the services
+	// registered with [Server.RegisterGateway].
+	Gateway *GatewayConfig
+
+	// RateLimit limits the rate of requests per method and optionally per
+	// client to protect public-facing endpoints. Requests that exceed a
+	// limit are rejected with RESOURCE_EXHAUSTED.
+	RateLimit RateLimitConfig
+
+	// Warmup, if set, is run when the server starts listening. The server
+	// reports NOT_SERVING until it returned, so that load balancers only
+	// route traffic to warm instances. Warm-up failures are logged but don't
+	// prevent the server from reporting SERVING. It is implemented by
+	// warmup.Registry.
+	Warmup Warmup
+
+	// TLSCertFile and TLSKeyFile are the paths to the PEM encoded server
+	// certificate and key. If set, the server only accepts TLS connections.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// TLSClientCAFile is the path to the PEM encoded CA certificate that
+	// client certificates are verified against. It requires TLS. Unlike the
+	// server certificate, it is only read once.
+	TLSClientCAFile string
+
+	// TLSClientAuth is the policy for client certificates. It defaults to
+	// [ClientAuthRequire] if TLSClientCAFile is set and to [ClientAuthNone]
+	// otherwise, so that setting a CA is enough to require mutual TLS.
+	TLSClientAuth ClientAuth
+
+	// TLSReloadInterval is the interval at which the certificate files are
+	// checked for changes, so that rotated certificates are picked up
+	// without a restart. Zero disables periodic checks, but the certificate
+	// can still be reloaded with [Server.ReloadTLS].
+	TLSReloadInterval time.Duration
+
+	// MaxRecvMsgSize and MaxSendMsgSize limit the size of messages in bytes.
+	// Zero uses the gRPC defaults of 4 MiB for received messages and no
+	// limit for sent messages.
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+
+	// KeepaliveTime is the interval after which the server pings an idle
+	// connection, so that long-lived streams survive load balancers that
+	// close idle connections, e.g., AWS NLBs after 350 seconds. Zero uses the
+	// gRPC default of two hours.
+	KeepaliveTime time.Duration
+
+	// KeepaliveTimeout is how long the server waits for a ping
+	// acknowledgement before it closes the connection. Zero uses the gRPC
+	// default of 20 seconds.
+	KeepaliveTimeout time.Duration
+
+	// KeepaliveMinTime is the minimum interval at which clients may ping the
+	// server. Connections of clients that ping more often are closed. Zero
+	// uses the gRPC default of five minutes.
+	KeepaliveMinTime time.Duration
+
+	// KeepalivePermitWithoutStream allows clients to ping the server while
+	// there are no active streams.
+	KeepalivePermitWithoutStream bool
+
+	// DrainLogInterval is the interval at which the number of remaining
+	// in-flight requests and streams is logged during graceful shutdown.
+	// Defaults to one second.
+	DrainLogInterval time.Duration
+
+	// Meter is the OTel meter used to record the in-flight and recovered
+	// panic metrics. If nil, the global meter provider is used.
+	Meter metric.Meter
+}
+
+// ClientAuth is the policy of a [Server] for TLS client certificates.
+type ClientAuth string
+
+const (
+	// ClientAuthNone doesn't request client certificates.
+	ClientAuthNone ClientAuth = "none"
+
+	// ClientAuthOptional verifies client certificates if clients send one,
+	// e.g., while clients are migrated to mutual TLS.
+	ClientAuthOptional ClientAuth = "optional"
+
+	// ClientAuthRequire rejects clients without a valid certificate.
+	ClientAuthRequire ClientAuth = "require"
+)
+
+// clientAuth resolves the default of [ServerConfig.TLSClientAuth].
+func (cfg *ServerConfig) clientAuth() ClientAuth {
+	if cfg.TLSClientAuth != "" {
+		return cfg.TLSClientAuth
+	}
+
+	if cfg.TLSClientCAFile != "" {
+		return ClientAuthRequire
+	}
+
+	return ClientAuthNone
+}
+
+// DefaultServerConfig returns a [ServerConfig] that listens on
+// localhost:8080 without TLS, accepts messages of up to 16 MiB, and pings
+// idle connections every minute.
+func DefaultServerConfig() *ServerConfig {
+	return &ServerConfig{
+		Host:              "localhost",
+		Port:              8080,
+		TLSReloadInterval: time.Minute,
+		MaxRecvMsgSize:    16 << 20,
+		MaxSendMsgSize:    16 << 20,
+		KeepaliveTime:     time.Minute,
+		KeepaliveTimeout:  20 * time.Second,
+		KeepaliveMinTime:  30 * time.Second,
+		DrainLogInterval:  time.Second,
+	}
+}
+
+// Warmup prepares the service for traffic before it reports SERVING. Run
+// must return once the context is canceled.
+type Warmup interface {
+	Run(ctx context.Context) error
+}
+
+// Maintenance reports whether the service is in maintenance mode together with
+// the message for clients.
+type Maintenance interface {
+	Enabled() (bool, string)
 }
 
 func (cfg *ServerConfig) Validate() error {
@@ -35,6 +186,50 @@ func (cfg *ServerConfig) Validate() error {
 		return fmt.Errorf("config is nil")
 	}
 
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return fmt.Errorf("tls cert file and tls key file must be set together")
+	}
+
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		return fmt.Errorf("tls client ca file requires tls cert file and tls key file")
+	}
+
+	switch cfg.clientAuth() {
+	case ClientAuthNone:
+	case ClientAuthOptional, ClientAuthRequire:
+		if cfg.TLSClientCAFile == "" {
+			return fmt.Errorf("tls client auth %q requires tls client ca file", cfg.TLSClientAuth)
+		}
+	default:
+		return fmt.Errorf("tls client auth must be one of %q, %q, or %q, got %q", ClientAuthNone, ClientAuthOptional, ClientAuthRequire, cfg.TLSClientAuth)
+	}
+
+	if cfg.Gateway != nil {
+		if err := cfg.Gateway.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := cfg.RateLimit.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.TLSReloadInterval < 0 {
+		return fmt.Errorf("tls reload interval must not be negative")
+	}
+
+	if cfg.MaxRecvMsgSize < 0 || cfg.MaxSendMsgSize < 0 {
+		return fmt.Errorf("max message sizes must not be negative")
+	}
+
+	if cfg.KeepaliveTime < 0 || cfg.KeepaliveTimeout < 0 || cfg.KeepaliveMinTime < 0 {
+		return fmt.Errorf("keepalive durations must not be negative")
+	}
+
+	if cfg.DrainLogInterval < 0 {
+		return fmt.Errorf("drain log interval must not be negative")
+	}
+
 	if cfg.Listener != nil {
 		if cfg.Host != "" {
 			return fmt.Errorf("listener and host cannot both be set")
@@ -58,10 +253,32 @@ func (cfg *ServerConfig) Validate() error {
 	return nil
 }
 
+// LogValue implements [slog.LogValuer].
+func (cfg *ServerConfig) LogValue() slog.Value {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	if cfg.Listener != nil {
+		addr = cfg.Listener.Addr().String()
+	}
+
+	return slog.GroupValue(
+		slog.String("addr", addr),
+		slog.Bool("tls", cfg.TLSCertFile != ""),
+		slog.String("tls_client_auth", string(cfg.clientAuth())),
+		slog.Bool("rate_limit", cfg.RateLimit.enabled()),
+		slog.Bool("gateway", cfg.Gateway != nil),
+	)
+}
+
 type Server struct {
 	cfg    *ServerConfig
 	server *grpc.Server
 	health *health.Server
+	certs  *CertReloader // nil if TLS is disabled
+	tlsCfg *tls.Config   // nil if TLS is disabled
+
+	gateway *gateway // nil if the gateway is disabled
+
+	inflight *inflight // in-flight requests and streams
 }
 
 // NewServer creates and returns a new gRPC Server instance.
@@ -77,31 +294,137 @@ func NewServer(cfg *ServerConfig) (*Server, error) {
 		logging.WithDisableLoggingFields(logging.ServiceFieldKey, logging.ComponentFieldKey, logging.MethodTypeFieldKey),
 	}, cfg.LogOpts...)
 
-	recoverOpt := recoverInterceptor()
+	meter := cfg.Meter
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter("grpc.server")
+	}
+
+	recoverOpt := recoverInterceptor(meter)
+	inflight, err := newInflight(meter)
+	if err != nil {
+		return nil, err
+	}
+
+	var limiter *rateLimiter
+	if cfg.RateLimit.enabled() {
+		limiter = newRateLimiter(cfg.RateLimit)
+	}
+
+	var (
+		opts   []grpc.ServerOption
+		certs  *CertReloader
+		tlsCfg *tls.Config
+	)
+
+	if cfg.TLSCertFile != "" {
+		var err error
+		certs, err = NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsCfg = &tls.Config{
+			GetCertificate: certs.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		}
+
+		switch cfg.clientAuth() {
+		case ClientAuthOptional:
+			tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		case ClientAuthRequire:
+			tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+
+		if cfg.TLSClientCAFile != "" {
+			tlsCfg.ClientCAs, err = loadCertPool(cfg.TLSClientCAFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		creds := credentials.NewTLS(tlsCfg)
+		if cfg.Gateway != nil {
+			creds = gatewayCredentials{TransportCredentials: creds}
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	if cfg.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize))
+	}
+
+	if cfg.MaxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(cfg.MaxSendMsgSize))
+	}
+
+	opts = append(opts,
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    cfg.KeepaliveTime,
+			Timeout: cfg.KeepaliveTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.KeepaliveMinTime,
+			PermitWithoutStream: cfg.KeepalivePermitWithoutStream,
+		}),
+	)
 
 	// Create a new gRPC server
-	server := grpc.NewServer(
+	server := grpc.NewServer(append(opts,
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(
+			inflight.unaryInterceptor(),
 			logging.UnaryServerInterceptor(loggerInterceptor(), loggingOpts...),
+			maintenanceUnaryInterceptor(cfg.Maintenance),
+			limiter.unaryInterceptor(),
+			tenantUnaryInterceptor(cfg.TenantLimiter),
+			errorsUnaryInterceptor(),
 			recovery.UnaryServerInterceptor(recoverOpt),
 		),
 		grpc.ChainStreamInterceptor(
+			inflight.streamInterceptor(),
 			logging.StreamServerInterceptor(loggerInterceptor(), loggingOpts...),
+			maintenanceStreamInterceptor(cfg.Maintenance),
+			limiter.streamInterceptor(),
+			tenantStreamInterceptor(cfg.TenantLimiter),
+			errorsStreamInterceptor(),
 			recovery.StreamServerInterceptor(recoverOpt),
 		),
-	)
+	)...)
 
 	healthcheck := health.NewServer()
 	healthgrpc.RegisterHealthServer(server, healthcheck)
 
+	var gw *gateway
+	if cfg.Gateway != nil {
+		var err error
+		if gw, err = newGateway(cfg, server); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Server{
 		server: server,
 		cfg:    cfg,
 		health: healthcheck,
+		certs:  certs,
+		tlsCfg: tlsCfg,
+
+		gateway: gw,
+
+		inflight: inflight,
 	}, nil
 }
 
+// ReloadTLS re-reads the TLS certificate files if they changed. It returns a
+// description of the change and can be registered as a reload function. It
+// is a no-op if TLS is disabled.
+func (s *Server) ReloadTLS(ctx context.Context) (string, error) {
+	if s.certs == nil {
+		return "", nil
+	}
+	return s.certs.Reload(ctx)
+}
+
 func (s *Server) SetServingStatus(service string, servingStatus healthv1.HealthCheckResponse_ServingStatus) {
 	slog.Debug("Setting health status", "service", service, "status", servingStatus)
 	s.health.SetServingStatus(service, servingStatus)
@@ -113,19 +436,114 @@ func (s *Server) ListenAndServe() error {
 		return fmt.Errorf("new listener: %w", err)
 	}
 
+	var gwLis net.Listener
+	if s.gateway != nil && !s.gateway.cfg.shared() {
+		if gwLis, err = s.gateway.cfg.listener(); err != nil {
+			return fmt.Errorf("new gateway listener: %w", err)
+		}
+	}
+
 	slog.Info("Starting gRPC server", "addr", lis.Addr())
 	defer slog.Info("Stopped gRPC server", "addr", lis.Addr())
 
-	s.health.SetServingStatus("", healthgrpc.HealthCheckResponse_SERVING)
 	defer s.health.SetServingStatus("", healthgrpc.HealthCheckResponse_NOT_SERVING)
 
+	if s.cfg.Warmup == nil {
+		s.health.SetServingStatus("", healthgrpc.HealthCheckResponse_SERVING)
+	} else {
+		s.health.SetServingStatus("", healthgrpc.HealthCheckResponse_NOT_SERVING)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = s.cfg.Warmup.Run(ctx) // failures are logged by the registry
+			if ctx.Err() == nil {
+				s.health.SetServingStatus("", healthgrpc.HealthCheckResponse_SERVING)
+			}
+		}()
+
+		// stop warming up and wait before the deferred status update above,
+		// so that the server can't report SERVING after it stopped.
+		defer func() {
+			cancel()
+			<-done
+		}()
+	}
+
+	if s.certs != nil && s.cfg.TLSReloadInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.certs.Watch(ctx, s.cfg.TLSReloadInterval)
+	}
+
+	if s.gateway != nil {
+		// the gateway forwards requests through the in-process listener,
+		// which is closed when the server stops.
+		go func() { _ = s.server.Serve(s.gateway.lis) }()
+
+		if gwLis == nil {
+			slog.Info("Serving gRPC gateway on the gRPC listener", "addr", lis.Addr())
+			return s.gateway.serve(lis, s.tlsCfg)
+		}
+
+		slog.Info("Starting gRPC gateway", "addr", gwLis.Addr())
+		defer s.gateway.http.Close()
+		go func() {
+			if err := s.gateway.serve(gwLis, nil); err != nil {
+				slog.Warn("gRPC gateway failed", "addr", gwLis.Addr(), "err", err)
+			}
+		}()
+	}
+
 	return s.server.Serve(lis)
 }
 
+// Shutdown gracefully stops the server. It blocks until all in-flight
+// requests and streams have finished and periodically logs how many remain.
 func (s *Server) Shutdown() {
-	slog.Info("Shutting down gRPC server")
+	slog.Info("Shutting down gRPC server", "requests", s.inflight.requests.Load(), "streams", s.inflight.streams.Load())
 	s.health.Shutdown()
-	s.server.GracefulStop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if s.gateway != nil {
+			s.gateway.shutdown(context.Background())
+		}
+
+		s.server.GracefulStop()
+
+		if s.gateway != nil {
+			s.gateway.close()
+		}
+	}()
+
+	interval := s.cfg.DrainLogInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-done:
+			if err := s.inflight.reg.Unregister(); err != nil {
+				slog.Warn("Failed to unregister gRPC in-flight metrics", "err", err)
+			}
+			return
+		case <-ticker.C:
+			slog.Info("Draining gRPC server",
+				"requests", s.inflight.requests.Load(),
+				"streams", s.inflight.streams.Load(),
+				"elapsed", time.Since(start).Round(time.Millisecond),
+			)
+		}
+	}
 }
 
 // BindCtx binds the given context to the server's lifecycle. Cancelling the
@@ -175,8 +593,194 @@ func loggerInterceptor() logging.Logger {
 	})
 }
 
-func recoverInterceptor() recovery.Option {
-	panicsCounter, err := otel.GetMeterProvider().Meter("grpc.server").Int64Counter("grpc_req_panics_recovered_total", metric.WithDescription("Total number of gRPC requests recovered from internal panic."))
+// maintenanceErr returns an UNAVAILABLE status error if maintenance mode is
+// enabled and the method is not part of the health service.
+func maintenanceErr(m Maintenance, fullMethod string) error {
+	if m == nil || strings.HasPrefix(fullMethod, "/"+healthgrpc.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+
+	if enabled, msg := m.Enabled(); enabled {
+		return status.Error(codes.Unavailable, msg)
+	}
+
+	return nil
+}
+
+func maintenanceUnaryInterceptor(m Maintenance) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := maintenanceErr(m, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func maintenanceStreamInterceptor(m Maintenance) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := maintenanceErr(m, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// ProjectMetadataKey and NetworkMetadataKey are the request metadata keys
+// that identify the tenant of a request, see [ServerConfig.TenantLimiter].
+const (
+	ProjectMetadataKey = "x-project"
+	NetworkMetadataKey = "x-network"
+)
+
+// tenantFromMetadata returns the tenant from the incoming request metadata.
+func tenantFromMetadata(ctx context.Context) (project string, network string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vals := md.Get(ProjectMetadataKey); len(vals) > 0 {
+		project = vals[0]
+	}
+	if vals := md.Get(NetworkMetadataKey); len(vals) > 0 {
+		network = vals[0]
+	}
+	return project, network
+}
+
+// acquireTenant stores the tenant of the request in the context and acquires
+// a slot from the limiter. It returns a RESOURCE_EXHAUSTED status error if the
+// tenant exceeded its limit.
+func acquireTenant(ctx context.Context, l *tenant.Limiter) (context.Context, func(), error) {
+	project, network := tenantFromMetadata(ctx)
+	ctx = tenant.NewContext(ctx, project, network)
+
+	release, err := l.Acquire(ctx, project, network)
+	if errors.Is(err, tenant.ErrLimited) {
+		return ctx, nil, status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return ctx, nil, status.FromContextError(err).Err()
+	}
+
+	return ctx, release, nil
+}
+
+func tenantUnaryInterceptor(l *tenant.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if l == nil {
+			return handler(ctx, req)
+		}
+
+		ctx, release, err := acquireTenant(ctx, l)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return handler(ctx, req)
+	}
+}
+
+func tenantStreamInterceptor(l *tenant.Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if l == nil {
+			return handler(srv, ss)
+		}
+
+		ctx, release, err := acquireTenant(ss.Context(), l)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return handler(srv, &tenantServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// tenantServerStream overrides the context of a [grpc.ServerStream] with one
+// that carries the tenant.
+type tenantServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// errorsUnaryInterceptor converts errors returned by handlers into gRPC status
+// errors with the code that corresponds to their [errs] category.
+func errorsUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		return resp, errs.GRPCStatus(err)
+	}
+}
+
+// errorsStreamInterceptor is the streaming counterpart of
+// [errorsUnaryInterceptor].
+func errorsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return errs.GRPCStatus(handler(srv, ss))
+	}
+}
+
+// inflight counts the requests and streams that are currently handled by the
+// server and exports the counts as gauges.
+type inflight struct {
+	requests atomic.Int64
+	streams  atomic.Int64
+
+	// reg is the registration of the gauge callback, which is unregistered
+	// when the server shuts down.
+	reg metric.Registration
+}
+
+// newInflight creates an [inflight] whose gauges are recorded with meter. If
+// meter is nil, the global meter provider is used.
+func newInflight(meter metric.Meter) (*inflight, error) {
+	f := &inflight{}
+
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter("grpc.server")
+	}
+
+	requests, err := meter.Int64ObservableGauge("grpc_server_inflight_requests", metric.WithDescription("Number of unary gRPC requests currently being handled."))
+	if err != nil {
+		return nil, fmt.Errorf("create grpc_server_inflight_requests gauge: %w", err)
+	}
+
+	streams, err := meter.Int64ObservableGauge("grpc_server_inflight_streams", metric.WithDescription("Number of gRPC streams currently open."))
+	if err != nil {
+		return nil, fmt.Errorf("create grpc_server_inflight_streams gauge: %w", err)
+	}
+
+	f.reg, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(requests, f.requests.Load())
+		o.ObserveInt64(streams, f.streams.Load())
+		return nil
+	}, requests, streams)
+	if err != nil {
+		return nil, fmt.Errorf("register in-flight gauges callback: %w", err)
+	}
+
+	return f, nil
+}
+
+func (f *inflight) unaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		f.requests.Add(1)
+		defer f.requests.Add(-1)
+		return handler(ctx, req)
+	}
+}
+
+func (f *inflight) streamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		f.streams.Add(1)
+		defer f.streams.Add(-1)
+		return handler(srv, ss)
+	}
+}
+
+func recoverInterceptor(meter metric.Meter) recovery.Option {
+	panicsCounter, err := meter.Int64Counter("grpc_req_panics_recovered_total", metric.WithDescription("Total number of gRPC requests recovered from internal panic."))
 	if err != nil {
 		panic(err)
 	}
@@ -187,9 +791,9 @@ func recoverInterceptor() recovery.Option {
 	handler := recovery.WithRecoveryHandlerContext(func(ctx context.Context, p any) (err error) {
 		panicsCounter.Add(ctx, 1)
 		if r.Allow() {
-			slog.Error("Recovered from panic", "panic", p, "stack", debug.Stack())
+			log.LogPanic(ctx, p)
 		}
-		return status.Errorf(codes.Internal, "%s", p)
+		return status.Error(codes.Internal, log.PanicString(p))
 	})
 
 	return handler
@@ -4,15 +4,23 @@ import (
 	"context"
 	"log/slog"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/probe-lab/go-commons/tenant"
 )
 
 func TestServer_lifecycle(t *testing.T) {
@@ -93,6 +101,83 @@ func TestServer_healthcheck(t *testing.T) {
 	assert.Equal(t, healthgrpc.HealthCheckResponse_NOT_SERVING, resp.Status)
 }
 
+func TestServer_maxRecvMsgSize(t *testing.T) {
+	slog.SetLogLoggerLevel(slog.LevelError)
+
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() { assert.NoError(t, lis.Close()) })
+
+	s, err := NewServer(&ServerConfig{Listener: lis, MaxRecvMsgSize: 64})
+	require.NoError(t, err)
+	t.Cleanup(s.Shutdown)
+
+	go func() { _ = s.ListenAndServe() }()
+
+	conn, err := grpc.NewClient("passthrough://bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, conn.Close()) })
+
+	client := healthgrpc.NewHealthClient(conn)
+
+	_, err = client.Check(context.Background(), &healthgrpc.HealthCheckRequest{Service: "small"}, grpc.WaitForReady(true))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Check(context.Background(), &healthgrpc.HealthCheckRequest{Service: strings.Repeat("large", 20)})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+// warmupFunc implements [Warmup].
+type warmupFunc func(ctx context.Context) error
+
+func (f warmupFunc) Run(ctx context.Context) error { return f(ctx) }
+
+func TestServer_warmup(t *testing.T) {
+	slog.SetLogLoggerLevel(slog.LevelError)
+
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() { assert.NoError(t, lis.Close()) })
+
+	warm := make(chan struct{})
+	cfg := &ServerConfig{
+		Listener: lis,
+		Warmup: warmupFunc(func(ctx context.Context) error {
+			select {
+			case <-warm:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}),
+	}
+
+	s, err := NewServer(cfg)
+	require.NoError(t, err)
+	t.Cleanup(s.Shutdown)
+
+	conn, err := grpc.NewClient("passthrough://bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, conn.Close()) })
+
+	go func() { require.NoError(t, s.ListenAndServe()) }()
+
+	client := healthgrpc.NewHealthClient(conn)
+
+	resp, err := client.Check(context.Background(), &healthgrpc.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthgrpc.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	close(warm)
+
+	assert.Eventually(t, func() bool {
+		resp, err := client.Check(context.Background(), &healthgrpc.HealthCheckRequest{})
+		return err == nil && resp.Status == healthgrpc.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+}
+
 func TestServerConfig_Validate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -122,6 +207,74 @@ func TestServerConfig_Validate(t *testing.T) {
 			},
 			wantErr: assert.Error,
 		},
+		{
+			name: "tls cert without key",
+			cfg: &ServerConfig{
+				Listener:    &bufconn.Listener{},
+				TLSCertFile: "cert.pem",
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "client ca without tls",
+			cfg: &ServerConfig{
+				Listener:        &bufconn.Listener{},
+				TLSClientCAFile: "ca.pem",
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "client auth without client ca",
+			cfg: &ServerConfig{
+				Listener:      &bufconn.Listener{},
+				TLSCertFile:   "cert.pem",
+				TLSKeyFile:    "key.pem",
+				TLSClientAuth: ClientAuthRequire,
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "unknown client auth",
+			cfg: &ServerConfig{
+				Listener:        &bufconn.Listener{},
+				TLSCertFile:     "cert.pem",
+				TLSKeyFile:      "key.pem",
+				TLSClientCAFile: "ca.pem",
+				TLSClientAuth:   "always",
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "mutual tls",
+			cfg: &ServerConfig{
+				Listener:        &bufconn.Listener{},
+				TLSCertFile:     "cert.pem",
+				TLSKeyFile:      "key.pem",
+				TLSClientCAFile: "ca.pem",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "negative max message size",
+			cfg: &ServerConfig{
+				Listener:       &bufconn.Listener{},
+				MaxRecvMsgSize: -1,
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "negative keepalive time",
+			cfg: &ServerConfig{
+				Listener:      &bufconn.Listener{},
+				KeepaliveTime: -time.Second,
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "defaults",
+			cfg:     DefaultServerConfig(),
+			wantErr: assert.NoError,
+		},
 		{
 			name: "no host",
 			cfg: &ServerConfig{
@@ -155,3 +308,76 @@ func TestServerConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestInflight_unaryInterceptor(t *testing.T) {
+	f, err := newInflight(nil)
+	require.NoError(t, err)
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		assert.Equal(t, int64(1), f.requests.Load())
+		return nil, nil
+	}
+
+	_, err = f.unaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), f.requests.Load())
+}
+
+func TestServer_Shutdown_unregistersInflight(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	inflightGauges := func() int {
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+
+		var n int
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if strings.HasPrefix(m.Name, "grpc_server_inflight_") {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	s, err := NewServer(&ServerConfig{Listener: bufconn.Listen(1024), Meter: provider.Meter("test")})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, inflightGauges())
+
+	s.Shutdown()
+	assert.Equal(t, 0, inflightGauges())
+}
+
+func TestTenantUnaryInterceptor(t *testing.T) {
+	cfg := tenant.DefaultLimitsConfig()
+	cfg.Default = tenant.Limit{Concurrency: 1}
+
+	l, err := tenant.NewLimiter(cfg)
+	require.NoError(t, err)
+
+	interceptor := tenantUnaryInterceptor(l)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(ProjectMetadataKey, "IPFS", NetworkMetadataKey, "amino"))
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		project, network, ok := tenant.FromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "ipfs", project)
+		assert.Equal(t, "amino", network)
+
+		// the tenant's only slot is taken by this request
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) { return nil, nil })
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		return nil, nil
+	}
+
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+
+	// the slot was released
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) { return nil, nil })
+	require.NoError(t, err)
+}
@@ -0,0 +1,132 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader serves a TLS certificate that is re-read from disk on demand
+// or periodically, so that rotated certificates are picked up without a
+// restart. Failed reloads keep the previous certificate. It is safe for
+// concurrent use.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time // latest modification time of both files at the last load
+}
+
+// NewCertReloader creates a new [CertReloader] and loads the initial
+// certificate from the given PEM encoded certificate and key files.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate returns the current certificate. It can be used as
+// [tls.Config.GetCertificate].
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Reload re-reads the certificate and key files if they changed since the
+// last load. It returns a description of the change and can be registered as
+// a reload function.
+func (r *CertReloader) Reload(ctx context.Context) (string, error) {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && modTime.Equal(r.modTime)
+	r.mu.RUnlock()
+
+	if unchanged {
+		return "", nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return "", fmt.Errorf("load tls certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+
+	if cert.Leaf == nil {
+		return "reloaded certificate", nil
+	}
+
+	return fmt.Sprintf("reloaded certificate valid until %s", cert.Leaf.NotAfter.Format(time.RFC3339)), nil
+}
+
+// Watch calls [CertReloader.Reload] every interval until the context is
+// canceled. Failures are logged and the previous certificate is kept.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		change, err := r.Reload(ctx)
+		if err != nil {
+			slog.Warn("Failed to reload TLS certificate", "cert", r.certFile, "err", err)
+		} else if change != "" {
+			slog.Info("Reloaded TLS certificate", "cert", r.certFile, "change", change)
+		}
+	}
+}
+
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("stat tls file: %w", err)
+		}
+
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// loadCertPool returns a pool with the PEM encoded CA certificates in file.
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read tls ca file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in tls ca file %s", file)
+	}
+
+	return pool, nil
+}
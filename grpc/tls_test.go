@@ -0,0 +1,204 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// writeTestCert writes a self-signed certificate for localhost with the
+// given serial number and its key to the given files.
+func writeTestCert(t *testing.T, certFile, keyFile string, serial int64) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, 1)
+
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cert.Leaf.SerialNumber.Int64())
+
+	// unchanged files are not reloaded
+	change, err := r.Reload(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, change)
+
+	// rotate the certificate
+	writeTestCert(t, certFile, keyFile, 2)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+
+	change, err = r.Reload(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, change)
+
+	cert, err = r.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cert.Leaf.SerialNumber.Int64())
+
+	// a broken rotation keeps the previous certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+	future = future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+
+	_, err = r.Reload(context.Background())
+	assert.Error(t, err)
+
+	cert, err = r.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cert.Leaf.SerialNumber.Int64())
+}
+
+func TestServer_tls(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	leaf := writeTestCert(t, certFile, keyFile, 1)
+
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() { assert.NoError(t, lis.Close()) })
+
+	s, err := NewServer(&ServerConfig{Listener: lis, TLSCertFile: certFile, TLSKeyFile: keyFile})
+	require.NoError(t, err)
+	t.Cleanup(s.Shutdown)
+
+	go func() { _ = s.ListenAndServe() }()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	conn, err := grpc.NewClient("passthrough://bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: "localhost"})),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, conn.Close()) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := healthgrpc.NewHealthClient(conn).Check(ctx, &healthgrpc.HealthCheckRequest{}, grpc.WaitForReady(true))
+	require.NoError(t, err)
+	assert.Equal(t, healthgrpc.HealthCheckResponse_SERVING, resp.GetStatus())
+}
+
+func TestServer_mutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, 1)
+
+	clientCertFile := filepath.Join(dir, "client-cert.pem")
+	clientKeyFile := filepath.Join(dir, "client-key.pem")
+	writeTestCert(t, clientCertFile, clientKeyFile, 2)
+
+	tests := []struct {
+		name       string
+		clientAuth ClientAuth
+		clientCert bool
+		wantErr    bool
+	}{
+		{name: "require with cert", clientCert: true},
+		{name: "require without cert", wantErr: true},
+		{name: "optional with cert", clientAuth: ClientAuthOptional, clientCert: true},
+		{name: "optional without cert", clientAuth: ClientAuthOptional},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lis := bufconn.Listen(1024 * 1024)
+			t.Cleanup(func() { assert.NoError(t, lis.Close()) })
+
+			s, err := NewServer(&ServerConfig{
+				Listener:        lis,
+				TLSCertFile:     certFile,
+				TLSKeyFile:      keyFile,
+				TLSClientCAFile: clientCertFile,
+				TLSClientAuth:   tt.clientAuth,
+			})
+			require.NoError(t, err)
+			t.Cleanup(s.Shutdown)
+
+			go func() { _ = s.ListenAndServe() }()
+
+			cfg := DefaultClientConfig()
+			cfg.Target = "passthrough://bufnet"
+			cfg.TLS = true
+			cfg.TLSCAFile = certFile
+			cfg.TLSServerName = "localhost"
+			cfg.WaitForReady = false
+			cfg.DialOptions = []grpc.DialOption{
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			}
+			if tt.clientCert {
+				cfg.TLSCertFile = clientCertFile
+				cfg.TLSKeyFile = clientKeyFile
+			}
+
+			conn, err := NewClient(cfg)
+			require.NoError(t, err)
+			t.Cleanup(func() { assert.NoError(t, conn.Close()) })
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err = healthgrpc.NewHealthClient(conn).Check(ctx, &healthgrpc.HealthCheckRequest{})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// InFlight tracks the number of requests that are currently being handled so
+// that the progress of a graceful shutdown can be observed. The count is
+// exported as the http_server_inflight_requests gauge.
+type InFlight struct {
+	count atomic.Int64
+	reg   metric.Registration
+}
+
+// NewInFlight creates a new [InFlight] tracker that exports its count with
+// the given meter. If meter is nil, the global meter provider is used.
+func NewInFlight(meter metric.Meter) (*InFlight, error) {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter("http.server")
+	}
+
+	f := &InFlight{}
+
+	gauge, err := meter.Int64ObservableGauge("http_server_inflight_requests",
+		metric.WithDescription("Number of HTTP requests currently being handled."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("init http_server_inflight_requests gauge: %w", err)
+	}
+
+	f.reg, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(gauge, f.count.Load())
+		return nil
+	}, gauge)
+	if err != nil {
+		return nil, fmt.Errorf("register in-flight callback: %w", err)
+	}
+
+	return f, nil
+}
+
+// Count returns the number of requests that are currently being handled.
+func (f *InFlight) Count() int64 {
+	return f.count.Load()
+}
+
+// Middleware returns a [Middleware] that counts in-flight requests.
+func (f *InFlight) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			f.count.Add(1)
+			defer f.count.Add(-1)
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// Shutdown gracefully shuts down the server, which must use
+// [InFlight.Middleware], and logs the number of remaining in-flight requests
+// every interval until all requests finished or the context is done. Once the
+// server shut down, the gauge is no longer exported.
+func (f *InFlight) Shutdown(ctx context.Context, srv *http.Server, interval time.Duration) error {
+	slog.Info("Shutting down HTTP server", "addr", srv.Addr, "requests", f.Count())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Shutdown(ctx)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				slog.Warn("HTTP server did not drain in time", "addr", srv.Addr, "requests", f.Count(), "err", err)
+			}
+
+			if err := f.reg.Unregister(); err != nil {
+				slog.Warn("Failed to unregister HTTP in-flight metrics", "err", err)
+			}
+
+			return err
+		case <-ticker.C:
+			slog.Info("Draining HTTP server",
+				"addr", srv.Addr,
+				"requests", f.Count(),
+				"elapsed", time.Since(start).Round(time.Millisecond),
+			)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// inflightGauge returns the value of the in-flight gauge and whether it is
+// exported.
+func inflightGauge(t *testing.T, reader *sdkmetric.ManualReader) (int64, bool) {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "http_server_inflight_requests" {
+				continue
+			}
+
+			dps := m.Data.(metricdata.Gauge[int64]).DataPoints
+			require.Len(t, dps, 1)
+			return dps[0].Value, true
+		}
+	}
+
+	return 0, false
+}
+
+func TestInFlight_Middleware(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	f, err := NewInFlight(provider.Meter("test"))
+	require.NoError(t, err)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	h := f.Middleware()(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+
+		started <- struct{}{}
+		<-release
+	}))
+
+	done := make(chan struct{})
+	for range 2 {
+		go func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			done <- struct{}{}
+		}()
+		<-started
+	}
+
+	assert.EqualValues(t, 2, f.Count())
+	count, found := inflightGauge(t, reader)
+	assert.True(t, found)
+	assert.EqualValues(t, 2, count)
+
+	close(release)
+	<-done
+	<-done
+	assert.Zero(t, f.Count())
+
+	// requests that panic are no longer counted either
+	assert.Panics(t, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+	assert.Zero(t, f.Count())
+}
+
+func TestInFlight_Shutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr error
+	}{
+		{"drained", time.Minute, nil},
+		{"timeout", 50 * time.Millisecond, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			f, err := NewInFlight(provider.Meter("test"))
+			require.NoError(t, err)
+
+			started, release := make(chan struct{}), make(chan struct{})
+			srv := &http.Server{Handler: f.Middleware()(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-release
+			}))}
+
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+
+			serveErr := make(chan error, 1)
+			go func() { serveErr <- srv.Serve(lis) }()
+
+			respErr := make(chan error, 1)
+			go func() {
+				resp, err := http.Get("http://" + lis.Addr().String())
+				if err == nil {
+					err = resp.Body.Close()
+				}
+				respErr <- err
+			}()
+			<-started
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			shutdownErr := make(chan error, 1)
+			go func() { shutdownErr <- f.Shutdown(ctx, srv, 10*time.Millisecond) }()
+
+			// the in-flight request keeps the server from shutting down
+			if tt.wantErr == nil {
+				select {
+				case err := <-shutdownErr:
+					t.Fatalf("shutdown returned before the request finished: %v", err)
+				case <-time.After(50 * time.Millisecond):
+				}
+				close(release)
+			} else {
+				defer close(release)
+			}
+
+			assert.ErrorIs(t, <-shutdownErr, tt.wantErr)
+			assert.ErrorIs(t, <-serveErr, http.ErrServerClosed)
+
+			// the gauge is no longer exported after the shutdown
+			_, found := inflightGauge(t, reader)
+			assert.False(t, found)
+
+			if tt.wantErr == nil {
+				assert.NoError(t, <-respErr)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+
+	"github.com/probe-lab/go-commons/errs"
 )
 
 func Encode[T any](rw http.ResponseWriter, status int, v T) {
@@ -40,6 +42,20 @@ func EncodeErr(rw http.ResponseWriter, status int, errMsg string) {
 	Encode(rw, status, errResp)
 }
 
+// EncodeError writes the given error with the HTTP status that corresponds to
+// its [errs] category. The messages of unclassified server errors are not
+// exposed to the client but logged instead.
+func EncodeError(rw http.ResponseWriter, err error) {
+	status := errs.HTTPStatus(err)
+	if status >= http.StatusInternalServerError && errs.Category(err) == nil {
+		slog.Error("internal server error", "err", err)
+		EncodeErr(rw, status, http.StatusText(status))
+		return
+	}
+
+	EncodeErr(rw, status, err.Error())
+}
+
 func Decode[T any](rw io.Reader) (T, error) {
 	var v T
 	if err := json.NewDecoder(rw).Decode(&v); err != nil {
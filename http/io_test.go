@@ -0,0 +1,47 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/probe-lab/go-commons/errs"
+)
+
+func TestEncodeError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantMsg    string
+	}{
+		{"invalid input", errs.Newf(errs.InvalidInput, "limit must be positive"), http.StatusBadRequest, "limit must be positive"},
+		{"not found", errs.Newf(errs.NotFound, "peer 42"), http.StatusNotFound, "peer 42"},
+		{"conflict", errs.Newf(errs.Conflict, "already exists"), http.StatusConflict, "already exists"},
+		{"unavailable", errs.Wrapf(errs.Unavailable, errors.New("dial tcp"), "query db"), http.StatusServiceUnavailable, "query db: dial tcp"},
+		{"wrapped category", fmt.Errorf("get peer: %w", errs.Newf(errs.NotFound, "peer 42")), http.StatusNotFound, "get peer: peer 42"},
+		{"deadline exceeded", fmt.Errorf("query db: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "Gateway Timeout"},
+		{"unclassified", errors.New("connection to 10.0.0.1 refused"), http.StatusInternalServerError, "Internal Server Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			EncodeError(rec, tt.err)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			resp, err := Decode[Response[any]](rec.Body)
+			require.NoError(t, err)
+			require.NotNil(t, resp.Error)
+			assert.Equal(t, tt.wantMsg, resp.Error.Message)
+		})
+	}
+}
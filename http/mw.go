@@ -3,26 +3,34 @@ package http
 import (
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"path/filepath"
-	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+
+	"github.com/probe-lab/go-commons/log"
+	"github.com/probe-lab/go-commons/sem"
+	"github.com/probe-lab/go-commons/tenant"
 )
 
 // RequestIDHeader is the name of the HTTP Header which contains the request id.
 const (
 	RequestIDHeader = "X-Request-Id"
 	ApiKeyHeader    = "X-API-Key"
+	ProjectHeader   = "X-Project"
+	NetworkHeader   = "X-Network"
 )
 
 type (
@@ -108,7 +116,7 @@ func MiddlewareRecover(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rec := recover(); rec != nil {
-				slog.Error("Recovered panic", "recover", rec, "stack", string(debug.Stack()))
+				log.LogPanic(r.Context(), rec, "method", r.Method, "path", r.URL.Path)
 			}
 		}()
 
@@ -184,12 +192,14 @@ func MiddlewareMetric(provider metric.MeterProvider) Middleware {
 		panic(fmt.Errorf("init in_flight int64 counter: %w", err))
 	}
 
+	var inflightCount atomic.Int64
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
 
-			inflight.Record(ctx, 1)
-			defer inflight.Record(ctx, -1)
+			inflight.Record(ctx, inflightCount.Add(1))
+			defer func() { inflight.Record(ctx, inflightCount.Add(-1)) }()
 
 			wrapped, err := WrapResponseWriter(rw)
 			if err != nil {
@@ -335,3 +345,218 @@ func (g *gzipResponseWriter) Flush() {
 		flusher.Flush()
 	}
 }
+
+// MiddlewareDecompress transparently decompresses gzip or zstd encoded request
+// bodies based on the Content-Encoding header so that handlers can [Decode]
+// them as usual. The decompressed body is limited to maxSize bytes to protect
+// against decompression bombs; reading beyond the limit returns an
+// [http.MaxBytesError]. The memory of the zstd decoder is bounded by maxSize
+// as well. Requests with an unsupported encoding are rejected with 415
+// Unsupported Media Type.
+func MiddlewareDecompress(maxSize int64) (Middleware, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("max size must be positive")
+	}
+
+	zstdOpts := []zstd.DOption{
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxMemory(uint64(maxSize)),
+		zstd.WithDecoderMaxWindow(min(max(uint64(maxSize), zstd.MinWindowSize), zstd.MaxWindowSize)),
+	}
+
+	// create the first decoder eagerly to validate the options
+	zr, err := zstd.NewReader(nil, zstdOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create zstd decoder: %w", err)
+	}
+
+	var zstdPool sync.Pool
+	zstdPool.Put(zr)
+
+	getZstd := func() (*zstd.Decoder, error) {
+		if zr, ok := zstdPool.Get().(*zstd.Decoder); ok {
+			return zr, nil
+		}
+		return zstd.NewReader(nil, zstdOpts...)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			var body io.ReadCloser
+			switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+			case "", "identity":
+				next.ServeHTTP(rw, r)
+				return
+
+			case "gzip", "x-gzip":
+				gzr, err := gzip.NewReader(r.Body)
+				if err != nil {
+					EncodeErr(rw, http.StatusBadRequest, fmt.Sprintf("invalid gzip body: %s", err))
+					return
+				}
+				body = &decompressReader{Reader: gzr, body: r.Body, close: gzr.Close}
+
+			case "zstd":
+				zr, err := getZstd()
+				if err != nil {
+					EncodeErr(rw, http.StatusInternalServerError, fmt.Sprintf("create zstd decoder: %s", err))
+					return
+				}
+
+				if err := zr.Reset(r.Body); err != nil {
+					zstdPool.Put(zr)
+					EncodeErr(rw, http.StatusBadRequest, fmt.Sprintf("invalid zstd body: %s", err))
+					return
+				}
+				body = &decompressReader{Reader: zr, body: r.Body, close: func() error {
+					_ = zr.Reset(nil)
+					zstdPool.Put(zr)
+					return nil
+				}}
+
+			default:
+				EncodeErr(rw, http.StatusUnsupportedMediaType, fmt.Sprintf("unsupported content encoding %q", encoding))
+				return
+			}
+			defer body.Close()
+
+			// the body is no longer encoded and its length is unknown
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			r.Body = http.MaxBytesReader(rw, body, maxSize)
+
+			next.ServeHTTP(rw, r)
+		})
+	}, nil
+}
+
+// decompressReader reads decompressed data and closes both the decompressor
+// and the original request body.
+type decompressReader struct {
+	io.Reader
+	body  io.Closer
+	close func() error
+	once  sync.Once
+}
+
+func (d *decompressReader) Close() error {
+	var err error
+	d.once.Do(func() {
+		err = errors.Join(d.close(), d.body.Close())
+	})
+	return err
+}
+
+// Maintenance reports whether the service is in maintenance mode together with
+// the message for clients. It is implemented by maintenance.Mode.
+type Maintenance interface {
+	Enabled() (bool, string)
+}
+
+// MiddlewareMaintenance rejects requests with 503 Service Unavailable while
+// maintenance mode is enabled. Requests to the exempt paths, e.g., health
+// endpoints, are passed through so that they keep reporting accurate status.
+func MiddlewareMaintenance(m Maintenance, exempt ...string) Middleware {
+	exemptPaths := make(map[string]struct{}, len(exempt))
+	for _, path := range exempt {
+		exemptPaths[path] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if _, found := exemptPaths[r.URL.Path]; found {
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			if enabled, msg := m.Enabled(); enabled {
+				EncodeErr(rw, http.StatusServiceUnavailable, msg)
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// MiddlewareConcurrencyLimit bounds the number of requests that are handled
+// concurrently. Each request acquires a weight of one from the given
+// semaphore. Requests that cannot acquire the semaphore within maxWait are
+// rejected with 503 Service Unavailable. A maxWait of zero rejects requests
+// immediately if the limit is reached.
+func MiddlewareConcurrencyLimit(s *sem.Weighted, maxWait time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if !s.TryAcquire(1) {
+				if maxWait <= 0 {
+					rw.Header().Set("Retry-After", "1")
+					EncodeErr(rw, http.StatusServiceUnavailable, "too many concurrent requests")
+					return
+				}
+
+				ctx, cancel := context.WithTimeout(r.Context(), maxWait)
+				err := s.Acquire(ctx, 1)
+				cancel()
+				if err != nil {
+					rw.Header().Set("Retry-After", "1")
+					EncodeErr(rw, http.StatusServiceUnavailable, "too many concurrent requests")
+					return
+				}
+			}
+			defer s.Release(1)
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// TenantFunc extracts the project and network of a request.
+type TenantFunc func(r *http.Request) (project string, network string)
+
+// TenantFromHeaders is a [TenantFunc] that reads the tenant from the
+// [ProjectHeader] and [NetworkHeader] request headers.
+func TenantFromHeaders(r *http.Request) (string, string) {
+	return r.Header.Get(ProjectHeader), r.Header.Get(NetworkHeader)
+}
+
+// MiddlewareTenantLimit enforces the per-tenant limits of the given
+// [tenant.Limiter]. The tenant is determined by tenantFn, which defaults to
+// [TenantFromHeaders], and stored in the request context with
+// [tenant.NewContext] so that, e.g., a db.ClickHouseRouter further down the
+// chain picks the tenant's connection. Requests of tenants that exceeded their
+// limit are rejected with 429 Too Many Requests.
+func MiddlewareTenantLimit(l *tenant.Limiter, tenantFn TenantFunc) Middleware {
+	if tenantFn == nil {
+		tenantFn = TenantFromHeaders
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			project, network := tenantFn(r)
+			ctx := tenant.NewContext(r.Context(), project, network)
+
+			release, err := l.Acquire(ctx, project, network)
+			if err != nil {
+				var limitErr *tenant.LimitError
+				if !errors.As(err, &limitErr) {
+					// the request context was canceled while waiting
+					EncodeErr(rw, http.StatusServiceUnavailable, err.Error())
+					return
+				}
+
+				retryAfter := int(limitErr.RetryAfter.Seconds())
+				if limitErr.RetryAfter%time.Second != 0 {
+					retryAfter++
+				}
+
+				rw.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+				EncodeErr(rw, http.StatusTooManyRequests, limitErr.Error())
+				return
+			}
+			defer release()
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/probe-lab/go-commons/sem"
+	"github.com/probe-lab/go-commons/tenant"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	_, err := gzw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gzw.Close())
+
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	zw, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer zw.Close()
+
+	return zw.EncodeAll(data, nil)
+}
+
+func TestMiddlewareDecompress(t *testing.T) {
+	const maxSize = 1 << 16
+
+	payload := []byte(`{"hello": "world"}`)
+	oversized := bytes.Repeat([]byte("a"), 4*maxSize)
+
+	tests := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "identity",
+			body:       payload,
+			wantStatus: http.StatusOK,
+			wantBody:   string(payload),
+		},
+		{
+			name:       "gzip",
+			encoding:   "gzip",
+			body:       gzipBytes(t, payload),
+			wantStatus: http.StatusOK,
+			wantBody:   string(payload),
+		},
+		{
+			name:       "zstd",
+			encoding:   " ZSTD ",
+			body:       zstdBytes(t, payload),
+			wantStatus: http.StatusOK,
+			wantBody:   string(payload),
+		},
+		{
+			name:       "oversized gzip",
+			encoding:   "gzip",
+			body:       gzipBytes(t, oversized),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "oversized zstd",
+			encoding:   "zstd",
+			body:       zstdBytes(t, oversized),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "invalid gzip",
+			encoding:   "gzip",
+			body:       payload,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unsupported encoding",
+			encoding:   "br",
+			body:       payload,
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	mw, err := MiddlewareDecompress(maxSize)
+	require.NoError(t, err)
+
+	h := mw(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Content-Encoding"))
+
+		data, err := io.ReadAll(r.Body)
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr), errors.Is(err, zstd.ErrDecoderSizeExceeded), errors.Is(err, zstd.ErrWindowSizeExceeded):
+			rw.WriteHeader(http.StatusRequestEntityTooLarge)
+		case err != nil:
+			rw.WriteHeader(http.StatusBadRequest)
+		default:
+			_, _ = rw.Write(data)
+		}
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMiddlewareDecompress_reuse(t *testing.T) {
+	mw, err := MiddlewareDecompress(1 << 10)
+	require.NoError(t, err)
+
+	h := mw(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(rw, r.Body)
+	}))
+
+	// decoders are returned to the pool and reused
+	for i := range 5 {
+		body := strings.Repeat("x", i+1)
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(zstdBytes(t, []byte(body))))
+		req.Header.Set("Content-Encoding", "zstd")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, body, rec.Body.String())
+	}
+}
+
+func TestMiddlewareDecompress_maxSize(t *testing.T) {
+	_, err := MiddlewareDecompress(0)
+	assert.Error(t, err)
+
+	_, err = MiddlewareDecompress(1)
+	assert.NoError(t, err)
+}
+
+// serveTenant serves a request of the given tenant and decodes the error
+// message of the response, if any.
+func serveTenant(t *testing.T, h http.Handler, project string, network string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(ProjectHeader, project)
+	req.Header.Set(NetworkHeader, network)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code == http.StatusOK {
+		return rec, ""
+	}
+
+	resp, err := Decode[Response[any]](rec.Body)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Error)
+
+	return rec, resp.Error.Message
+}
+
+func TestMiddlewareTenantLimit(t *testing.T) {
+	limitsFile := filepath.Join(t.TempDir(), "limits.json")
+	require.NoError(t, os.WriteFile(limitsFile, []byte(`[
+		{"project": "ipfs", "network": "amino", "rate": 1, "burst": 1},
+		{"project": "ipfs", "network": "celestia", "concurrency": 1}
+	]`), 0o644))
+
+	cfg := tenant.DefaultLimitsConfig()
+	cfg.File = limitsFile
+	cfg.Default = tenant.Limit{Rate: 1, Burst: 2}
+
+	l, err := tenant.NewLimiter(cfg)
+	require.NoError(t, err)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	h := MiddlewareTenantLimit(l, nil)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		project, network, ok := tenant.FromContext(r.Context())
+		assert.True(t, ok)
+
+		if project == "ipfs" && network == "celestia" {
+			started <- struct{}{}
+			<-release
+		}
+	}))
+
+	t.Run("rate", func(t *testing.T) {
+		rec, _ := serveTenant(t, h, "IPFS", "Amino")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec, msg := serveTenant(t, h, "ipfs", "amino")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "rate limit exceeded for ipfs/amino", msg)
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		done := make(chan *httptest.ResponseRecorder)
+		go func() {
+			rec, _ := serveTenant(t, h, "ipfs", "celestia")
+			done <- rec
+		}()
+		<-started
+
+		rec, msg := serveTenant(t, h, "ipfs", "celestia")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+		assert.Equal(t, "concurrency limit exceeded for ipfs/celestia", msg)
+
+		release <- struct{}{}
+		assert.Equal(t, http.StatusOK, (<-done).Code)
+
+		// the slot is released once the handler returned
+		go func() {
+			<-started
+			release <- struct{}{}
+		}()
+		rec, _ = serveTenant(t, h, "ipfs", "celestia")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("unknown tenants share the default budget", func(t *testing.T) {
+		for _, network := range []string{"a", "b"} {
+			rec, _ := serveTenant(t, h, "unknown", network)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		rec, msg := serveTenant(t, h, "other", "c")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "rate limit exceeded for other/c", msg)
+	})
+}
+
+func TestMiddlewareConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxWait    time.Duration
+		freeAfter  time.Duration // zero keeps the slot busy
+		wantStatus int
+	}{
+		{"reject immediately", 0, 0, http.StatusServiceUnavailable},
+		{"wait timeout", 20 * time.Millisecond, 0, http.StatusServiceUnavailable},
+		{"slot freed while waiting", time.Minute, 20 * time.Millisecond, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sem.NewWeighted("test", 1, nil)
+			h := MiddlewareConcurrencyLimit(s, tt.maxWait)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+
+			// occupy the only slot
+			require.True(t, s.TryAcquire(1))
+			if tt.freeAfter > 0 {
+				time.AfterFunc(tt.freeAfter, func() { s.Release(1) })
+			} else {
+				defer s.Release(1)
+			}
+
+			start := time.Now()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusServiceUnavailable {
+				assert.GreaterOrEqual(t, time.Since(start), tt.maxWait)
+				assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+				resp, err := Decode[Response[any]](rec.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "too many concurrent requests", resp.Error.Message)
+			}
+		})
+	}
+}
+
+func TestMiddlewareConcurrencyLimit_release(t *testing.T) {
+	s := sem.NewWeighted("test", 1, nil)
+	h := MiddlewareConcurrencyLimit(s, 0)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+	}))
+
+	for range 3 {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	assert.Panics(t, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+
+	// the slot of the panicking request was released
+	require.True(t, s.TryAcquire(1))
+	s.Release(1)
+}
+
+// staticMaintenance is a [Maintenance] with a fixed state.
+type staticMaintenance struct {
+	enabled bool
+	msg     string
+}
+
+func (m staticMaintenance) Enabled() (bool, string) {
+	return m.enabled, m.msg
+}
+
+func TestMiddlewareMaintenance(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		path       string
+		wantStatus int
+	}{
+		{"disabled", false, "/api", http.StatusOK},
+		{"enabled", true, "/api", http.StatusServiceUnavailable},
+		{"exempt path", true, "/healthz", http.StatusOK},
+		{"exempt path prefix only", true, "/healthz/details", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := staticMaintenance{enabled: tt.enabled, msg: "back soon"}
+			h := MiddlewareMaintenance(m, "/healthz", "/readyz")(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				_, _ = rw.Write([]byte("ok"))
+			}))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, "ok", rec.Body.String())
+				return
+			}
+
+			resp, err := Decode[Response[any]](rec.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "back soon", resp.Error.Message)
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quota defines how many requests a user may issue per UTC day and per UTC
+// month. A zero limit means unlimited. The user "*" defines the default quota
+// for users without an explicit entry.
+type Quota struct {
+	User    string `json:"user"`
+	Daily   int64  `json:"daily"`
+	Monthly int64  `json:"monthly"`
+}
+
+// QuotaSource loads the current set of quota definitions.
+type QuotaSource func(ctx context.Context) ([]Quota, error)
+
+// QuotasFromFile returns a [QuotaSource] that reads a JSON array of [Quota]
+// objects from the given file.
+func QuotasFromFile(path string) QuotaSource {
+	return func(ctx context.Context) ([]Quota, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read quotas file: %w", err)
+		}
+
+		var quotas []Quota
+		if err := json.Unmarshal(data, &quotas); err != nil {
+			return nil, fmt.Errorf("decode quotas file: %w", err)
+		}
+
+		return quotas, nil
+	}
+}
+
+// QuotasFromDB returns a [QuotaSource] that reads the quota definitions from
+// the given database table. The table must have the columns "user", "daily",
+// and "monthly".
+func QuotasFromDB(db *sql.DB, table string) QuotaSource {
+	query := fmt.Sprintf(`SELECT "user", daily, monthly FROM %s`, table)
+	return func(ctx context.Context) ([]Quota, error) {
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("query quotas: %w", err)
+		}
+		defer rows.Close()
+
+		var quotas []Quota
+		for rows.Next() {
+			var q Quota
+			if err := rows.Scan(&q.User, &q.Daily, &q.Monthly); err != nil {
+				return nil, fmt.Errorf("scan quota: %w", err)
+			}
+			quotas = append(quotas, q)
+		}
+
+		return quotas, rows.Err()
+	}
+}
+
+// UsageStore keeps track of the number of requests per user and accounting
+// period. The period is identified by a key like "2006-01-02" for days or
+// "2006-01" for months.
+type UsageStore interface {
+	// Count returns the number of requests the user issued in the period.
+	Count(ctx context.Context, user string, period string) (int64, error)
+
+	// IncrementBelow records a request by the user in all given periods if
+	// the user's count in each of them is below the period's limit. It
+	// returns the counts after the request and whether it was recorded. If
+	// it wasn't, the counts are the current ones. Implementations must check
+	// and increment atomically, so that concurrent requests can't exceed a
+	// quota.
+	IncrementBelow(ctx context.Context, user string, periods []UsagePeriod) (counts []int64, ok bool, err error)
+}
+
+// UsagePeriod is an accounting period and the maximum number of requests a
+// user may issue in it. A zero Limit means unlimited.
+type UsagePeriod struct {
+	Key   string
+	Limit int64
+}
+
+// MemoryUsageStore is an in-memory [UsageStore]. Counts are lost on restart
+// and are not shared between replicas.
+type MemoryUsageStore struct {
+	mu     sync.Mutex
+	counts map[string]map[string]int64 // period -> user -> count
+}
+
+var _ UsageStore = (*MemoryUsageStore)(nil)
+
+// NewMemoryUsageStore creates a new, empty [MemoryUsageStore].
+func NewMemoryUsageStore() *MemoryUsageStore {
+	return &MemoryUsageStore{counts: map[string]map[string]int64{}}
+}
+
+func (s *MemoryUsageStore) Count(ctx context.Context, user string, period string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counts[period][user], nil
+}
+
+func (s *MemoryUsageStore) IncrementBelow(ctx context.Context, user string, periods []UsagePeriod) ([]int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make([]int64, len(periods))
+	ok := true
+	for i, p := range periods {
+		counts[i] = s.counts[p.Key][user]
+		if p.Limit > 0 && counts[i] >= p.Limit {
+			ok = false
+		}
+	}
+
+	if !ok {
+		return counts, false, nil
+	}
+
+	for i, p := range periods {
+		users, found := s.counts[p.Key]
+		if !found {
+			// a new period started - drop periods that can no longer be queried
+			s.prune(p.Key)
+			users = map[string]int64{}
+			s.counts[p.Key] = users
+		}
+
+		users[user]++
+		counts[i] = users[user]
+	}
+
+	return counts, true, nil
+}
+
+// prune removes all periods of the same granularity as the given one that
+// precede it. Must be called with the lock held.
+func (s *MemoryUsageStore) prune(current string) {
+	for period := range s.counts {
+		if len(period) == len(current) && period < current {
+			delete(s.counts, period)
+		}
+	}
+}
+
+// QuotaEnforcer enforces the quotas loaded from a [QuotaSource] on top of the
+// usage recorded in a [UsageStore]. Call [QuotaEnforcer.Reload] to pick up
+// changed quota definitions at runtime, e.g., by registering it with the
+// root command's reload registry.
+type QuotaEnforcer struct {
+	source QuotaSource
+	usage  UsageStore
+	now    func() time.Time
+
+	mu     sync.RWMutex
+	quotas map[string]Quota
+}
+
+// NewQuotaEnforcer creates a new [QuotaEnforcer] and loads the initial quota
+// definitions from the given source.
+func NewQuotaEnforcer(ctx context.Context, source QuotaSource, usage UsageStore) (*QuotaEnforcer, error) {
+	if source == nil {
+		return nil, fmt.Errorf("quota source must not be nil")
+	}
+
+	if usage == nil {
+		return nil, fmt.Errorf("usage store must not be nil")
+	}
+
+	q := &QuotaEnforcer{
+		source: source,
+		usage:  usage,
+		now:    time.Now,
+	}
+
+	if _, err := q.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// Reload re-reads the quota definitions from the source. It returns a
+// description of the change and can be registered as a reload function.
+func (q *QuotaEnforcer) Reload(ctx context.Context) (string, error) {
+	quotas, err := q.source(ctx)
+	if err != nil {
+		return "", fmt.Errorf("load quotas: %w", err)
+	}
+
+	lookup := make(map[string]Quota, len(quotas))
+	for _, quota := range quotas {
+		if quota.Daily < 0 || quota.Monthly < 0 {
+			return "", fmt.Errorf("negative quota for user %q", quota.User)
+		}
+		lookup[quota.User] = quota
+	}
+
+	q.mu.Lock()
+	prev := q.quotas
+	q.quotas = lookup
+	q.mu.Unlock()
+
+	if maps.Equal(prev, lookup) {
+		return "", nil
+	}
+
+	return fmt.Sprintf("%d -> %d quotas", len(prev), len(lookup)), nil
+}
+
+func (q *QuotaEnforcer) quota(user string) (Quota, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if quota, found := q.quotas[user]; found {
+		return quota, true
+	}
+
+	quota, found := q.quotas["*"]
+	return quota, found
+}
+
+// Middleware returns a [Middleware] that rejects requests of users that
+// exceeded their daily or monthly quota with 429 Too Many Requests. It must
+// be chained after [MiddlewareAuthentication] because it reads the
+// authenticated user. The remaining quota is reported in the
+// X-Quota-{Daily,Monthly}-{Limit,Remaining} headers.
+func (q *QuotaEnforcer) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			wrapped, err := WrapResponseWriter(rw)
+			if err != nil {
+				http.Error(rw, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			quota, found := q.quota(wrapped.user)
+			if wrapped.user == "" || !found {
+				next.ServeHTTP(wrapped, req)
+				return
+			}
+
+			ctx := req.Context()
+			now := q.now().UTC()
+
+			periods := []struct {
+				name  string
+				key   string
+				limit int64
+				reset time.Time
+			}{
+				{
+					name:  "Daily",
+					key:   now.Format("2006-01-02"),
+					limit: quota.Daily,
+					reset: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+				},
+				{
+					name:  "Monthly",
+					key:   now.Format("2006-01"),
+					limit: quota.Monthly,
+					reset: time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			}
+
+			usage := make([]UsagePeriod, len(periods))
+			for i, p := range periods {
+				usage[i] = UsagePeriod{Key: p.key, Limit: p.limit}
+			}
+
+			counts, ok, err := q.usage.IncrementBelow(ctx, wrapped.user, usage)
+			if err != nil {
+				// don't reject requests because of accounting failures
+				slog.Warn("Failed to record quota usage", "user", wrapped.user, "err", err)
+				next.ServeHTTP(wrapped, req)
+				return
+			}
+
+			for i, p := range periods {
+				if p.limit == 0 {
+					continue
+				}
+
+				wrapped.Header().Set("X-Quota-"+p.name+"-Limit", strconv.FormatInt(p.limit, 10))
+				wrapped.Header().Set("X-Quota-"+p.name+"-Remaining", strconv.FormatInt(max(p.limit-counts[i], 0), 10))
+			}
+
+			if !ok {
+				// retry once all exceeded quotas have been reset, i.e., a
+				// client that exhausted both quotas waits for the monthly
+				// reset instead of failing again on the next day.
+				exceeded := -1
+				for i, p := range periods {
+					if p.limit == 0 || counts[i] < p.limit {
+						continue
+					}
+
+					if exceeded < 0 || p.reset.After(periods[exceeded].reset) {
+						exceeded = i
+					}
+				}
+
+				if exceeded >= 0 {
+					p := periods[exceeded]
+					wrapped.Header().Set("Retry-After", strconv.Itoa(int(p.reset.Sub(now).Seconds())+1))
+					EncodeErr(wrapped, http.StatusTooManyRequests, fmt.Sprintf("%s quota of %d requests exceeded", strings.ToLower(p.name), p.limit))
+					return
+				}
+			}
+
+			next.ServeHTTP(wrapped, req)
+		})
+	}
+}
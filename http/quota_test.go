@@ -0,0 +1,462 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func staticQuotas(quotas ...Quota) QuotaSource {
+	return func(ctx context.Context) ([]Quota, error) {
+		return quotas, nil
+	}
+}
+
+// newQuotaHandler returns a handler that authenticates the users "alice" and
+// "bob" with their names as API keys and enforces the quotas of q.
+func newQuotaHandler(q *QuotaEnforcer) http.Handler {
+	auth := MiddlewareAuthentication([]string{"alice", "bob"}, []string{"alice", "bob"})
+	return MiddlewareChain(auth, q.Middleware())(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+}
+
+func serveQuota(h http.Handler, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(ApiKeyHeader, user)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestQuotaEnforcer_Middleware(t *testing.T) {
+	// 12h until the end of the day, 16.5 days until the end of the month
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		quotas      []Quota
+		user        string
+		requests    int
+		wantStatus  int
+		wantHeaders map[string]string // an empty value means the header is absent
+	}{
+		{
+			name:       "within quota",
+			quotas:     []Quota{{User: "alice", Daily: 2, Monthly: 10}},
+			user:       "alice",
+			requests:   1,
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"X-Quota-Daily-Limit":       "2",
+				"X-Quota-Daily-Remaining":   "1",
+				"X-Quota-Monthly-Limit":     "10",
+				"X-Quota-Monthly-Remaining": "9",
+				"Retry-After":               "",
+			},
+		},
+		{
+			name:       "last request of quota",
+			quotas:     []Quota{{User: "alice", Daily: 2, Monthly: 10}},
+			user:       "alice",
+			requests:   2,
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"X-Quota-Daily-Remaining":   "0",
+				"X-Quota-Monthly-Remaining": "8",
+			},
+		},
+		{
+			name:       "daily quota exceeded",
+			quotas:     []Quota{{User: "alice", Daily: 2, Monthly: 10}},
+			user:       "alice",
+			requests:   3,
+			wantStatus: http.StatusTooManyRequests,
+			wantHeaders: map[string]string{
+				"X-Quota-Daily-Limit":       "2",
+				"X-Quota-Daily-Remaining":   "0",
+				"X-Quota-Monthly-Remaining": "8",
+				"Retry-After":               "43201",
+			},
+		},
+		{
+			name:       "monthly quota exceeded",
+			quotas:     []Quota{{User: "alice", Monthly: 1}},
+			user:       "alice",
+			requests:   2,
+			wantStatus: http.StatusTooManyRequests,
+			wantHeaders: map[string]string{
+				"X-Quota-Daily-Limit":       "",
+				"X-Quota-Monthly-Limit":     "1",
+				"X-Quota-Monthly-Remaining": "0",
+				"Retry-After":               "1425601",
+			},
+		},
+		{
+			name:       "daily and monthly quota exceeded",
+			quotas:     []Quota{{User: "alice", Daily: 2, Monthly: 2}},
+			user:       "alice",
+			requests:   3,
+			wantStatus: http.StatusTooManyRequests,
+			wantHeaders: map[string]string{
+				"X-Quota-Daily-Remaining":   "0",
+				"X-Quota-Monthly-Remaining": "0",
+				"Retry-After":               "1425601",
+			},
+		},
+		{
+			name:       "default quota",
+			quotas:     []Quota{{User: "alice", Daily: 10}, {User: "*", Daily: 1}},
+			user:       "bob",
+			requests:   2,
+			wantStatus: http.StatusTooManyRequests,
+			wantHeaders: map[string]string{
+				"X-Quota-Daily-Limit":     "1",
+				"X-Quota-Daily-Remaining": "0",
+			},
+		},
+		{
+			name:       "explicit entry overrides default quota",
+			quotas:     []Quota{{User: "alice"}, {User: "*", Daily: 1}},
+			user:       "alice",
+			requests:   5,
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"X-Quota-Daily-Limit": "",
+			},
+		},
+		{
+			name:       "no quota",
+			quotas:     []Quota{{User: "alice", Daily: 1}},
+			user:       "bob",
+			requests:   5,
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"X-Quota-Daily-Limit":   "",
+				"X-Quota-Monthly-Limit": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := NewQuotaEnforcer(context.Background(), staticQuotas(tt.quotas...), NewMemoryUsageStore())
+			require.NoError(t, err)
+			q.now = func() time.Time { return now }
+
+			h := newQuotaHandler(q)
+
+			var rec *httptest.ResponseRecorder
+			for range tt.requests {
+				rec = serveQuota(h, tt.user)
+			}
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			for header, want := range tt.wantHeaders {
+				assert.Equal(t, want, rec.Header().Get(header), header)
+			}
+		})
+	}
+}
+
+func TestQuotaEnforcer_Middleware_rollover(t *testing.T) {
+	type step struct {
+		now        time.Time
+		wantStatus int
+	}
+
+	tests := []struct {
+		name  string
+		quota Quota
+		steps []step
+	}{
+		{
+			name:  "daily",
+			quota: Quota{User: "alice", Daily: 1},
+			steps: []step{
+				{time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), http.StatusOK},
+				{time.Date(2026, 3, 15, 23, 59, 59, 0, time.UTC), http.StatusTooManyRequests},
+				{time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), http.StatusOK},
+				{time.Date(2026, 3, 16, 12, 0, 0, 0, time.UTC), http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:  "monthly",
+			quota: Quota{User: "alice", Daily: 5, Monthly: 2},
+			steps: []step{
+				{time.Date(2026, 12, 30, 0, 0, 0, 0, time.UTC), http.StatusOK},
+				{time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), http.StatusOK},
+				{time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC), http.StatusTooManyRequests},
+				{time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), http.StatusOK},
+			},
+		},
+		{
+			name:  "local time is converted to UTC",
+			quota: Quota{User: "alice", Daily: 1},
+			steps: []step{
+				{time.Date(2026, 3, 15, 23, 0, 0, 0, time.UTC), http.StatusOK},
+				// the same UTC day
+				{time.Date(2026, 3, 16, 0, 30, 0, 0, time.FixedZone("CET", 3600)), http.StatusTooManyRequests},
+				// the next UTC day
+				{time.Date(2026, 3, 16, 1, 0, 0, 0, time.FixedZone("CET", 3600)), http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := NewQuotaEnforcer(context.Background(), staticQuotas(tt.quota), NewMemoryUsageStore())
+			require.NoError(t, err)
+
+			h := newQuotaHandler(q)
+
+			for i, s := range tt.steps {
+				q.now = func() time.Time { return s.now }
+				assert.Equal(t, s.wantStatus, serveQuota(h, "alice").Code, "step %d", i)
+			}
+		})
+	}
+}
+
+func TestQuotaEnforcer_Middleware_concurrent(t *testing.T) {
+	q, err := NewQuotaEnforcer(context.Background(), staticQuotas(Quota{User: "alice", Daily: 10}), NewMemoryUsageStore())
+	require.NoError(t, err)
+
+	h := newQuotaHandler(q)
+
+	var (
+		mu       sync.Mutex
+		accepted int
+		wg       sync.WaitGroup
+	)
+	for range 100 {
+		wg.Go(func() {
+			if serveQuota(h, "alice").Code == http.StatusOK {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, accepted)
+}
+
+func TestMemoryUsageStore_IncrementBelow(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryUsageStore()
+
+	periods := []UsagePeriod{{Key: "2026-03-15", Limit: 2}, {Key: "2026-03"}}
+
+	counts, ok, err := s.IncrementBelow(ctx, "alice", periods)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int64{1, 1}, counts)
+
+	counts, ok, err = s.IncrementBelow(ctx, "alice", periods)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int64{2, 2}, counts)
+
+	// rejected requests aren't recorded in any period
+	counts, ok, err = s.IncrementBelow(ctx, "alice", periods)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, []int64{2, 2}, counts)
+
+	count, err := s.Count(ctx, "alice", "2026-03")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// users are counted separately
+	counts, ok, err = s.IncrementBelow(ctx, "bob", periods)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int64{1, 1}, counts)
+}
+
+func TestMemoryUsageStore_prune(t *testing.T) {
+	tests := []struct {
+		name        string
+		increments  []string
+		wantPeriods []string
+	}{
+		{
+			name:        "same period",
+			increments:  []string{"2026-03-15", "2026-03-15"},
+			wantPeriods: []string{"2026-03-15"},
+		},
+		{
+			name:        "new day drops previous days",
+			increments:  []string{"2026-03-14", "2026-03", "2026-03-15"},
+			wantPeriods: []string{"2026-03", "2026-03-15"},
+		},
+		{
+			name:        "new month drops previous months",
+			increments:  []string{"2026-03-31", "2026-03", "2026-04"},
+			wantPeriods: []string{"2026-03-31", "2026-04"},
+		},
+		{
+			name:        "older period doesn't drop newer ones",
+			increments:  []string{"2026-03-15", "2026-03-14"},
+			wantPeriods: []string{"2026-03-14", "2026-03-15"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryUsageStore()
+			for _, period := range tt.increments {
+				_, ok, err := s.IncrementBelow(context.Background(), "alice", []UsagePeriod{{Key: period}})
+				require.NoError(t, err)
+				require.True(t, ok)
+			}
+
+			var periods []string
+			for period := range s.counts {
+				periods = append(periods, period)
+			}
+			assert.ElementsMatch(t, tt.wantPeriods, periods)
+		})
+	}
+}
+
+func TestQuotasFromFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // the file is missing if empty
+		want    []Quota
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			content: `[{"user": "alice", "daily": 10, "monthly": 100}, {"user": "*", "daily": 1}]`,
+			want:    []Quota{{User: "alice", Daily: 10, Monthly: 100}, {User: "*", Daily: 1}},
+		},
+		{
+			name:    "empty",
+			content: `[]`,
+			want:    []Quota{},
+		},
+		{
+			name:    "invalid json",
+			content: `{"user": "alice"}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "quotas.json")
+			if tt.content != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+			}
+
+			quotas, err := QuotasFromFile(path)(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, quotas)
+		})
+	}
+}
+
+func TestQuotaEnforcer_Reload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "quotas.json")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	write(`[{"user": "alice", "daily": 1}]`)
+
+	q, err := NewQuotaEnforcer(ctx, QuotasFromFile(path), NewMemoryUsageStore())
+	require.NoError(t, err)
+
+	h := newQuotaHandler(q)
+	assert.Equal(t, http.StatusOK, serveQuota(h, "alice").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveQuota(h, "alice").Code)
+
+	tests := []struct {
+		name       string
+		content    string
+		wantMsg    bool
+		wantErr    bool
+		wantStatus int
+	}{
+		{
+			name:       "unchanged",
+			content:    `[{"user": "alice", "daily": 1}]`,
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "raised quota",
+			content:    `[{"user": "alice", "daily": 2}]`,
+			wantMsg:    true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative quota keeps previous quotas",
+			content:    `[{"user": "alice", "daily": -1}]`,
+			wantErr:    true,
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "invalid file keeps previous quotas",
+			content:    `not json`,
+			wantErr:    true,
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "removed quota",
+			content:    `[]`,
+			wantMsg:    true,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write(tt.content)
+
+			msg, err := q.Reload(ctx)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantMsg, msg != "", msg)
+
+			assert.Equal(t, tt.wantStatus, serveQuota(h, "alice").Code)
+		})
+	}
+}
+
+func TestNewQuotaEnforcer(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewQuotaEnforcer(ctx, nil, NewMemoryUsageStore())
+	assert.Error(t, err)
+
+	_, err = NewQuotaEnforcer(ctx, staticQuotas(), nil)
+	assert.Error(t, err)
+
+	_, err = NewQuotaEnforcer(ctx, staticQuotas(Quota{User: "alice", Monthly: -1}), NewMemoryUsageStore())
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+// Package iterutil provides helpers to compose [iter.Seq] and [iter.Seq2]
+// sequences and to adapt them to and from channels. They let streaming
+// producers and consumers, e.g., database cursors and the
+// [github.com/probe-lab/go-commons/db.BatchInserter], be combined without
+// materializing intermediate slices.
+package iterutil
+
+import (
+	"context"
+	"iter"
+)
+
+// Map returns a sequence that yields fn applied to each value of seq.
+func Map[T, U any](seq iter.Seq[T], fn func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for v := range seq {
+			if !yield(fn(v)) {
+				return
+			}
+		}
+	}
+}
+
+// Map2 returns a sequence that yields fn applied to each pair of seq.
+func Map2[K, V, K2, V2 any](seq iter.Seq2[K, V], fn func(K, V) (K2, V2)) iter.Seq2[K2, V2] {
+	return func(yield func(K2, V2) bool) {
+		for k, v := range seq {
+			if !yield(fn(k, v)) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns a sequence that yields the values of seq for which keep
+// returns true.
+func Filter[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Filter2 returns a sequence that yields the pairs of seq for which keep
+// returns true.
+func Filter2[K, V any](seq iter.Seq2[K, V], keep func(K, V) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range seq {
+			if keep(k, v) && !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Chunk returns a sequence that yields the values of seq in slices of size n.
+// The last slice may be shorter. Each yielded slice is newly allocated, so
+// consumers may retain it. Chunk panics if n is less than one.
+func Chunk[T any](seq iter.Seq[T], n int) iter.Seq[[]T] {
+	if n < 1 {
+		panic("iterutil: chunk size must be at least one")
+	}
+
+	return func(yield func([]T) bool) {
+		chunk := make([]T, 0, n)
+		for v := range seq {
+			chunk = append(chunk, v)
+			if len(chunk) < n {
+				continue
+			}
+
+			if !yield(chunk) {
+				return
+			}
+			chunk = make([]T, 0, n)
+		}
+
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// Merge returns a sequence that yields the values of all given sequences,
+// one sequence after another.
+func Merge[T any](seqs ...iter.Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, seq := range seqs {
+			for v := range seq {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Merge2 returns a sequence that yields the pairs of all given sequences,
+// one sequence after another.
+func Merge2[K, V any](seqs ...iter.Seq2[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, seq := range seqs {
+			for k, v := range seq {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// FromChan returns a sequence that yields the values received from ch until
+// it is closed or the context is canceled.
+func FromChan[T any](ctx context.Context, ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-ch:
+				if !ok || !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ToChan sends the values of seq to the returned channel with the given
+// buffer size from a new goroutine. The channel is closed when seq is
+// exhausted or the context is canceled. Cancel the context if the channel is
+// abandoned before it is closed to not leak the goroutine.
+func ToChan[T any](ctx context.Context, seq iter.Seq[T], buffer int) <-chan T {
+	ch := make(chan T, buffer)
+	go func() {
+		defer close(ch)
+		for v := range seq {
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- v:
+			}
+		}
+	}()
+
+	return ch
+}
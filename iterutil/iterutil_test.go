@@ -0,0 +1,95 @@
+package iterutil
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	got := slices.Collect(Map(slices.Values([]int{1, 2, 3}), strconv.Itoa))
+	assert.Equal(t, []string{"1", "2", "3"}, got)
+}
+
+func TestMap2(t *testing.T) {
+	seq := Map2(slices.All([]string{"a", "b"}), func(i int, s string) (string, int) { return s, i })
+	assert.Equal(t, map[string]int{"a": 0, "b": 1}, maps.Collect(seq))
+}
+
+func TestFilter(t *testing.T) {
+	even := func(v int) bool { return v%2 == 0 }
+	got := slices.Collect(Filter(slices.Values([]int{1, 2, 3, 4}), even))
+	assert.Equal(t, []int{2, 4}, got)
+}
+
+func TestFilter2(t *testing.T) {
+	seq := Filter2(slices.All([]string{"a", "b", "c"}), func(i int, _ string) bool { return i != 1 })
+	assert.Equal(t, map[int]string{0: "a", 2: "c"}, maps.Collect(seq))
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []int
+		n    int
+		want [][]int
+	}{
+		{name: "empty", in: nil, n: 2, want: nil},
+		{name: "even", in: []int{1, 2, 3, 4}, n: 2, want: [][]int{{1, 2}, {3, 4}}},
+		{name: "remainder", in: []int{1, 2, 3}, n: 2, want: [][]int{{1, 2}, {3}}},
+		{name: "larger", in: []int{1, 2}, n: 5, want: [][]int{{1, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, slices.Collect(Chunk(slices.Values(tt.in), tt.n)))
+		})
+	}
+
+	assert.Panics(t, func() { Chunk(slices.Values([]int{1}), 0) })
+}
+
+func TestChunk_earlyReturn(t *testing.T) {
+	for chunk := range Chunk(slices.Values([]int{1, 2, 3, 4}), 2) {
+		assert.Equal(t, []int{1, 2}, chunk)
+		break
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := slices.Collect(Merge(slices.Values([]int{1, 2}), slices.Values([]int{}), slices.Values([]int{3})))
+	assert.Equal(t, []int{1, 2, 3}, got)
+}
+
+func TestMerge2(t *testing.T) {
+	seq := Merge2(maps.All(map[string]int{"a": 1}), maps.All(map[string]int{"b": 2}))
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, maps.Collect(seq))
+}
+
+func TestChan(t *testing.T) {
+	ctx := context.Background()
+	ch := ToChan(ctx, slices.Values([]int{1, 2, 3}), 0)
+	assert.Equal(t, []int{1, 2, 3}, slices.Collect(FromChan(ctx, ch)))
+}
+
+func TestToChan_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := ToChan(ctx, slices.Values([]int{1, 2, 3}), 0)
+	<-ch
+	cancel()
+
+	// the channel must be closed eventually without consuming all values
+	for range ch {
+	}
+}
+
+func TestFromChan_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ch := make(chan int) // never closed
+	assert.Empty(t, slices.Collect(FromChan(ctx, ch)))
+}
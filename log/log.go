@@ -2,14 +2,57 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type Config struct {
 	Level  string
 	Format string
 	Source bool
+
+	// Output is where logs are written to: "stderr", "stdout", or the path
+	// of a file. Files are rotated according to Rotation.
+	Output string
+
+	// Rotation configures the rotation of the log file if Output is a path.
+	Rotation RotationConfig
+
+	// Writer receives the log output and takes precedence over Output.
+	Writer io.Writer
+}
+
+// RotationConfig configures the rotation of log files.
+type RotationConfig struct {
+	// MaxSizeMB is the size in megabytes at which the log file is rotated.
+	MaxSizeMB int
+
+	// MaxBackups is the number of rotated files to keep. Zero keeps all.
+	MaxBackups int
+
+	// MaxAgeDays is the number of days to keep rotated files. Zero keeps
+	// them regardless of their age.
+	MaxAgeDays int
+
+	// Compress gzips rotated files.
+	Compress bool
+}
+
+// level is shared by all loggers created with [NewLogger] so that the log
+// level can be changed at runtime with [SetLevel].
+var level = new(slog.LevelVar)
+
+// LogValue implements [slog.LogValuer].
+func (c *Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("level", c.Level),
+		slog.String("format", c.Format),
+		slog.String("output", c.Output),
+	)
 }
 
 func DefaultConfig() *Config {
@@ -17,6 +60,13 @@ func DefaultConfig() *Config {
 		Level:  "info",
 		Format: "text",
 		Source: false,
+		Output: "stderr",
+		Rotation: RotationConfig{
+			MaxSizeMB:  100,
+			MaxBackups: 5,
+			MaxAgeDays: 0,
+			Compress:   false,
+		},
 	}
 }
 
@@ -30,23 +80,33 @@ func NewLogger(cfg *Config) (*slog.Logger, error) {
 		return nil, fmt.Errorf("unknown log level %s: %w", cfg.Level, err)
 	}
 
+	w := cfg.Writer
+	if w == nil {
+		var err error
+		if w, err = cfg.output(); err != nil {
+			return nil, err
+		}
+	}
+
 	// parse log format
 	var h slog.Handler
 	switch cfg.Format {
 	case "text":
-		h = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		h = slog.NewTextHandler(w, &slog.HandlerOptions{
 			AddSource: cfg.Source,
-			Level:     logLevel,
+			Level:     level,
 		})
 	case "json":
-		h = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+		h = slog.NewJSONHandler(w, &slog.HandlerOptions{
 			AddSource: cfg.Source,
-			Level:     logLevel,
+			Level:     level,
 		})
 	default:
 		return nil, fmt.Errorf("unsupported log format: %s", cfg.Format)
 	}
 
+	level.Set(logLevel)
+
 	// wrap the base handler into our custom one so that we can enrich
 	// log information with custom fields extracted from the log context.
 	wrapped := &handler{Handler: h}
@@ -54,6 +114,40 @@ func NewLogger(cfg *Config) (*slog.Logger, error) {
 	return slog.New(wrapped), nil
 }
 
+// output returns the writer for the configured output.
+func (cfg *Config) output() (io.Writer, error) {
+	switch cfg.Output {
+	case "", "stderr":
+		return os.Stderr, nil
+	case "stdout":
+		return os.Stdout, nil
+	}
+
+	if cfg.Rotation.MaxSizeMB <= 0 {
+		return nil, fmt.Errorf("log rotation max size must be positive, got %d", cfg.Rotation.MaxSizeMB)
+	}
+
+	if cfg.Rotation.MaxBackups < 0 {
+		return nil, fmt.Errorf("log rotation max backups must not be negative, got %d", cfg.Rotation.MaxBackups)
+	}
+
+	if cfg.Rotation.MaxAgeDays < 0 {
+		return nil, fmt.Errorf("log rotation max age must not be negative, got %d", cfg.Rotation.MaxAgeDays)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Output), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+
+	return &lumberjack.Logger{
+		Filename:   cfg.Output,
+		MaxSize:    cfg.Rotation.MaxSizeMB,
+		MaxBackups: cfg.Rotation.MaxBackups,
+		MaxAge:     cfg.Rotation.MaxAgeDays,
+		Compress:   cfg.Rotation.Compress,
+	}, nil
+}
+
 // SetGlobaLogger applies the given configuration to the global slog.SetGlobal
 func SetGlobalLogger(cfg *Config) error {
 	logger, err := NewLogger(cfg)
@@ -64,6 +158,20 @@ func SetGlobalLogger(cfg *Config) error {
 	return nil
 }
 
+// SetLevel changes the level of all loggers created with [NewLogger] at
+// runtime. It returns the previous level.
+func SetLevel(lvl string) (slog.Level, error) {
+	prev := level.Level()
+
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(lvl)); err != nil {
+		return prev, fmt.Errorf("unknown log level %s: %w", lvl, err)
+	}
+	level.Set(logLevel)
+
+	return prev, nil
+}
+
 func Defer(fn func() error, errMsg string) {
 	if err := fn(); err != nil {
 		slog.Warn(errMsg, "err", err)
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger_writer(t *testing.T) {
+	var buf bytes.Buffer
+
+	cfg := DefaultConfig()
+	cfg.Format = "json"
+	cfg.Writer = &buf
+
+	logger, err := NewLogger(cfg)
+	require.NoError(t, err)
+
+	logger.Info("hello")
+	assert.Contains(t, buf.String(), `"msg":"hello"`)
+}
+
+func TestNewLogger_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+
+	cfg := DefaultConfig()
+	cfg.Output = path
+
+	logger, err := NewLogger(cfg)
+	require.NoError(t, err)
+
+	logger.Info("hello")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "msg=hello")
+}
+
+func TestNewLogger_invalidRotation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Output = filepath.Join(t.TempDir(), "app.log")
+
+	cfg.Rotation.MaxSizeMB = 0
+	_, err := NewLogger(cfg)
+	assert.ErrorContains(t, err, "max size must be positive")
+
+	cfg.Rotation.MaxSizeMB = 1
+	cfg.Rotation.MaxBackups = -1
+	_, err = NewLogger(cfg)
+	assert.ErrorContains(t, err, "max backups must not be negative")
+
+	cfg = DefaultConfig()
+	cfg.Output = "stdout"
+	cfg.Rotation.MaxSizeMB = 0
+	_, err = NewLogger(cfg)
+	assert.NoError(t, err, "rotation only applies to files")
+}
@@ -0,0 +1,84 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+)
+
+// maxStackFrames limits the number of frames reported for a panic.
+const maxStackFrames = 32
+
+// PanicMessage is the message of all log statements emitted by [LogPanic] so
+// that panics can be found with a single search across services.
+const PanicMessage = "Recovered from panic"
+
+// LogPanic logs a recovered panic value together with the stack of the
+// panicking goroutine at error level. Call it from the deferred function that
+// recovered the panic so that the stack can be trimmed to the relevant frames.
+func LogPanic(ctx context.Context, p any, args ...any) {
+	args = append(args, PanicAttr(p), slog.Any("stack", PanicStack()))
+	slog.ErrorContext(ctx, PanicMessage, args...)
+}
+
+// PanicAttr returns a "panic" group attribute that describes the recovered
+// panic value p consistently, regardless of whether it is an error, a
+// string, or an arbitrary value.
+func PanicAttr(p any) slog.Attr {
+	return slog.Group("panic",
+		slog.String("type", fmt.Sprintf("%T", p)),
+		slog.String("value", PanicString(p)),
+	)
+}
+
+// PanicString formats a recovered panic value as a string.
+func PanicString(p any) string {
+	switch v := p.(type) {
+	case nil:
+		return "nil"
+	case error:
+		return v.Error()
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%+v", v)
+	}
+}
+
+// PanicStack returns the stack of the calling goroutine as "function
+// file:line" entries. If called while panicking, the frames of the recovery
+// path up to and including the panic call are dropped, so that the first
+// entry is the function that panicked. Runtime frames are always dropped.
+func PanicStack() []string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs) // skip runtime.Callers and PanicStack
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []string
+	for {
+		frame, more := frames.Next()
+
+		switch {
+		case frame.Function == "runtime.gopanic":
+			// everything up to here belongs to the recovery path
+			stack = stack[:0]
+		case strings.HasPrefix(frame.Function, "runtime."):
+		default:
+			stack = append(stack, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	if len(stack) > maxStackFrames {
+		stack = stack[:maxStackFrames]
+	}
+
+	return stack
+}
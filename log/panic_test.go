@@ -0,0 +1,54 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type panicStruct struct {
+	Code int
+}
+
+func TestPanicString(t *testing.T) {
+	tests := []struct {
+		name string
+		p    any
+		want string
+	}{
+		{name: "nil", p: nil, want: "nil"},
+		{name: "error", p: errors.New("boom"), want: "boom"},
+		{name: "string", p: "boom", want: "boom"},
+		{name: "struct", p: panicStruct{Code: 42}, want: "{Code:42}"},
+		{name: "int", p: 42, want: "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PanicString(tt.p))
+		})
+	}
+}
+
+func panicking() {
+	panic("boom")
+}
+
+func TestPanicStack(t *testing.T) {
+	var stack []string
+	func() {
+		defer func() {
+			recover()
+			stack = PanicStack()
+		}()
+		panicking()
+	}()
+
+	require.NotEmpty(t, stack)
+	assert.True(t, strings.HasPrefix(stack[0], "github.com/probe-lab/go-commons/log.panicking "), stack[0])
+	for _, frame := range stack {
+		assert.False(t, strings.HasPrefix(frame, "runtime."), frame)
+	}
+}
@@ -0,0 +1,154 @@
+// Package maintenance provides a runtime-togglable maintenance mode. While
+// maintenance mode is enabled, the HTTP middleware and gRPC interceptors that
+// consult a [Mode] reject requests as unavailable, while health endpoints keep
+// reporting the actual status of the service.
+//
+// Maintenance mode can be toggled programmatically, through the admin handler
+// returned by [Mode.Handler], or by creating a sentinel file that is watched
+// with [Mode.WatchFile].
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	phttp "github.com/probe-lab/go-commons/http"
+)
+
+// DefaultMessage is the message returned to clients if maintenance mode was
+// enabled without a specific message.
+const DefaultMessage = "service is under maintenance, please try again later"
+
+// Mode holds the maintenance state. The zero value is a disabled maintenance
+// mode that is ready to use. It is safe for concurrent use.
+type Mode struct {
+	mu      sync.RWMutex
+	manual  bool   // enabled via flag, Enable, or admin endpoint
+	file    bool   // enabled because the sentinel file exists
+	message string // message for clients; DefaultMessage if empty
+}
+
+// New returns a new [Mode] that is enabled if enabled is true.
+func New(enabled bool) *Mode {
+	return &Mode{manual: enabled}
+}
+
+// Enabled reports whether maintenance mode is enabled and returns the message
+// that should be sent to clients.
+func (m *Mode) Enabled() (bool, string) {
+	if m == nil {
+		return false, ""
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.manual && !m.file {
+		return false, ""
+	}
+
+	if m.message == "" {
+		return true, DefaultMessage
+	}
+
+	return true, m.message
+}
+
+// Enable turns on maintenance mode with the given message for clients. An
+// empty message selects [DefaultMessage].
+func (m *Mode) Enable(message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.manual {
+		slog.Warn("Enabled maintenance mode", "message", message)
+	}
+
+	m.manual = true
+	m.message = message
+}
+
+// Disable turns off maintenance mode unless it is enabled by the sentinel
+// file.
+func (m *Mode) Disable() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.manual {
+		slog.Info("Disabled maintenance mode")
+	}
+
+	m.manual = false
+}
+
+func (m *Mode) setFile(exists bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.file != exists {
+		slog.Info("Maintenance sentinel file changed", "exists", exists)
+	}
+
+	m.file = exists
+}
+
+// WatchFile checks every interval whether the sentinel file at path exists
+// and enables maintenance mode for as long as it does. It blocks until the
+// context is canceled.
+func (m *Mode) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := os.Stat(path)
+		switch {
+		case err == nil:
+			m.setFile(true)
+		case errors.Is(err, os.ErrNotExist):
+			m.setFile(false)
+		default:
+			slog.Warn("Failed to check maintenance sentinel file", "path", path, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// status is the JSON representation of the maintenance state.
+type status struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+}
+
+// Handler returns an admin [http.Handler] that reports the maintenance state
+// on GET, enables maintenance mode on POST (with an optional "message" query
+// parameter), and disables it on DELETE. The handler does not perform any
+// authentication itself, so wrap it with e.g.
+// [phttp.MiddlewareAuthentication] before exposing it.
+func (m *Mode) Handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			m.Enable(req.URL.Query().Get("message"))
+		case http.MethodDelete:
+			m.Disable()
+		default:
+			rw.Header().Set("Allow", "GET, POST, DELETE")
+			phttp.EncodeErr(rw, http.StatusMethodNotAllowed, "unsupported method "+req.Method)
+			return
+		}
+
+		enabled, message := m.Enabled()
+		phttp.Encode(rw, http.StatusOK, status{Enabled: enabled, Message: message})
+	})
+}
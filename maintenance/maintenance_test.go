@@ -0,0 +1,82 @@
+package maintenance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMode(t *testing.T) {
+	var nilMode *Mode
+	enabled, _ := nilMode.Enabled()
+	assert.False(t, enabled)
+
+	m := New(false)
+	enabled, _ = m.Enabled()
+	assert.False(t, enabled)
+
+	m.Enable("")
+	enabled, msg := m.Enabled()
+	assert.True(t, enabled)
+	assert.Equal(t, DefaultMessage, msg)
+
+	m.Enable("upgrading database")
+	_, msg = m.Enabled()
+	assert.Equal(t, "upgrading database", msg)
+
+	m.Disable()
+	enabled, _ = m.Enabled()
+	assert.False(t, enabled)
+}
+
+func TestMode_WatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maintenance")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := New(false)
+	go m.WatchFile(ctx, path, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+	assert.Eventually(t, func() bool {
+		enabled, _ := m.Enabled()
+		return enabled
+	}, time.Second, 10*time.Millisecond)
+
+	// disabling manually doesn't override the sentinel file
+	m.Disable()
+	enabled, _ := m.Enabled()
+	assert.True(t, enabled)
+
+	require.NoError(t, os.Remove(path))
+	assert.Eventually(t, func() bool {
+		enabled, _ := m.Enabled()
+		return !enabled
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMode_Handler(t *testing.T) {
+	m := New(false)
+	h := m.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/maintenance?message=down", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":{"enabled":true,"message":"down"}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/maintenance", nil))
+	assert.JSONEq(t, `{"data":{"enabled":false}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/maintenance", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
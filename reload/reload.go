@@ -0,0 +1,137 @@
+// Package reload provides a registry of components whose configuration can be
+// reloaded at runtime. Components such as the log level, API key stores,
+// feature flags, or database mappings register a [Func] with a [Registry].
+// The root command triggers all registered functions when the process
+// receives a reload signal (SIGHUP by default) or when the admin endpoint returned by
+// [Registry.Handler] is called.
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	phttp "github.com/probe-lab/go-commons/http"
+)
+
+// Func reloads the configuration of a single component. It returns a short,
+// human-readable description of what changed, or the empty string if nothing
+// changed.
+type Func func(ctx context.Context) (string, error)
+
+// Result captures the outcome of reloading a single component.
+type Result struct {
+	Name    string `json:"name"`
+	Changes string `json:"changes,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Registry holds the registered reload functions. The zero value is ready to
+// use. It is safe for concurrent use.
+type Registry struct {
+	mu     sync.Mutex // guards names and funcs
+	names  []string   // registration order
+	funcs  map[string]Func
+	reload sync.Mutex // serializes concurrent reloads
+}
+
+// NewRegistry returns an empty [Registry].
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a reload function under the given name. Registering a
+// function under an existing name replaces the previous one.
+func (r *Registry) Register(name string, fn Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.funcs == nil {
+		r.funcs = make(map[string]Func)
+	}
+
+	if _, found := r.funcs[name]; !found {
+		r.names = append(r.names, name)
+	}
+
+	r.funcs[name] = fn
+}
+
+// Names returns the names of all registered components in registration order.
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.names...)
+}
+
+// Reload calls all registered reload functions in registration order and
+// logs what changed. A failing component does not prevent the remaining
+// ones from being reloaded. The returned error joins all failures.
+// Concurrent calls are serialized.
+func (r *Registry) Reload(ctx context.Context) ([]Result, error) {
+	r.reload.Lock()
+	defer r.reload.Unlock()
+
+	r.mu.Lock()
+	names := append([]string(nil), r.names...)
+	funcs := make([]Func, len(names))
+	for i, name := range names {
+		funcs[i] = r.funcs[name]
+	}
+	r.mu.Unlock()
+
+	slog.Info("Reloading configuration", "components", len(names))
+
+	start := time.Now()
+	results := make([]Result, len(names))
+	var errs []error
+	for i, name := range names {
+		results[i].Name = name
+
+		changes, err := funcs[i](ctx)
+		if err != nil {
+			slog.Warn("Failed to reload component", "component", name, "err", err)
+			results[i].Error = err.Error()
+			errs = append(errs, fmt.Errorf("reload %s: %w", name, err))
+			continue
+		}
+
+		results[i].Changes = changes
+		if changes == "" {
+			slog.Debug("Reloaded component without changes", "component", name)
+		} else {
+			slog.Info("Reloaded component", "component", name, "changes", changes)
+		}
+	}
+
+	slog.Info("Reloaded configuration", "components", len(names), "failed", len(errs), "took", time.Since(start))
+
+	return results, errors.Join(errs...)
+}
+
+// Handler returns an [http.Handler] that triggers a reload on POST requests
+// and responds with the per-component results. The handler does not perform
+// any authentication itself, so wrap it with e.g.
+// [phttp.MiddlewareAuthentication] before exposing it.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			rw.Header().Set("Allow", http.MethodPost)
+			phttp.EncodeErr(rw, http.StatusMethodNotAllowed, "reload must be triggered with a POST request")
+			return
+		}
+
+		results, err := r.Reload(req.Context())
+		if err != nil {
+			phttp.Encode(rw, http.StatusInternalServerError, results)
+			return
+		}
+
+		phttp.Encode(rw, http.StatusOK, results)
+	})
+}
@@ -0,0 +1,65 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Reload(t *testing.T) {
+	reg := NewRegistry()
+
+	var calls []string
+	reg.Register("first", func(ctx context.Context) (string, error) {
+		calls = append(calls, "first")
+		return "a -> b", nil
+	})
+	reg.Register("second", func(ctx context.Context) (string, error) {
+		calls = append(calls, "second")
+		return "", fmt.Errorf("boom")
+	})
+	reg.Register("third", func(ctx context.Context) (string, error) {
+		calls = append(calls, "third")
+		return "", nil
+	})
+
+	results, err := reg.Reload(context.Background())
+	assert.ErrorContains(t, err, "reload second: boom")
+	assert.Equal(t, []string{"first", "second", "third"}, calls)
+	require.Len(t, results, 3)
+	assert.Equal(t, Result{Name: "first", Changes: "a -> b"}, results[0])
+	assert.Equal(t, Result{Name: "second", Error: "boom"}, results[1])
+	assert.Equal(t, Result{Name: "third"}, results[2])
+}
+
+func TestRegistry_Register_replaces(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("comp", func(ctx context.Context) (string, error) { return "old", nil })
+	reg.Register("comp", func(ctx context.Context) (string, error) { return "new", nil })
+
+	assert.Equal(t, []string{"comp"}, reg.Names())
+
+	results, err := reg.Reload(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "new", results[0].Changes)
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("comp", func(ctx context.Context) (string, error) { return "", nil })
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/reload", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/reload", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":[{"name":"comp"}]}`, rec.Body.String())
+}
@@ -0,0 +1,205 @@
+// Package sem provides a weighted, context-aware semaphore that bounds the
+// number of concurrently held resources, e.g., in-flight requests or
+// ClickHouse flushes, to limit memory consumption during bursts.
+//
+// Waiters are served in FIFO order. A large request at the head of the queue
+// blocks smaller requests behind it, so large requests cannot starve. The
+// semaphore records how many resources are in use, how many callers are
+// queued, and how long they waited.
+package sem
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// waiter is a caller blocked in Acquire.
+type waiter struct {
+	n     int64
+	ready chan struct{} // closed when the semaphore was acquired
+}
+
+// Weighted is a weighted semaphore. It is safe for concurrent use.
+type Weighted struct {
+	size int64
+	attr metric.MeasurementOption
+
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List // of waiter
+
+	// OTel instruments; always valid (no-op if metrics not configured).
+	mInUse        metric.Int64UpDownCounter // currently acquired weight
+	mQueueLength  metric.Int64UpDownCounter // number of queued callers
+	mWaitDuration metric.Float64Histogram   // time spent waiting in Acquire (seconds)
+}
+
+// NewWeighted creates a new [Weighted] semaphore with the given maximum
+// combined weight. The name distinguishes the semaphore's metrics from those
+// of other semaphores. If meter is nil, the global meter provider is used.
+func NewWeighted(name string, size int64, meter metric.Meter) *Weighted {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter("github.com/probe-lab/go-commons/sem")
+	}
+
+	s := &Weighted{
+		size: size,
+		attr: metric.WithAttributeSet(attribute.NewSet(attribute.String("name", name))),
+	}
+
+	var err error
+	if s.mInUse, err = meter.Int64UpDownCounter("semaphore.in_use",
+		metric.WithDescription("Currently acquired semaphore weight"),
+	); err != nil {
+		panic(err)
+	}
+
+	if s.mQueueLength, err = meter.Int64UpDownCounter("semaphore.queue_length",
+		metric.WithDescription("Number of callers waiting to acquire the semaphore"),
+	); err != nil {
+		panic(err)
+	}
+
+	if s.mWaitDuration, err = meter.Float64Histogram("semaphore.wait_duration",
+		metric.WithDescription("Time spent waiting to acquire the semaphore"),
+		metric.WithUnit("s"),
+	); err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// Size returns the maximum combined weight of the semaphore.
+func (s *Weighted) Size() int64 {
+	return s.size
+}
+
+// Acquire acquires the semaphore with a weight of n, blocking until the
+// resources are available or the context is done. On failure, it returns the
+// context's error and leaves the semaphore unchanged. Requests for more than
+// the semaphore's size fail immediately.
+func (s *Weighted) Acquire(ctx context.Context, n int64) error {
+	if n > s.size {
+		return fmt.Errorf("acquire weight %d exceeds semaphore size %d", n, s.size)
+	}
+
+	s.mu.Lock()
+	if s.cur+n <= s.size && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		s.mInUse.Add(ctx, n, s.attr)
+		return nil
+	}
+
+	// check the context only on the slow path so that callers with a done
+	// context still get resources that are immediately available.
+	if err := ctx.Err(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	w := waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	s.mQueueLength.Add(ctx, 1, s.attr)
+	defer s.mQueueLength.Add(ctx, -1, s.attr)
+
+	start := time.Now()
+	defer func() { s.mWaitDuration.Record(ctx, time.Since(start).Seconds(), s.attr) }()
+
+	select {
+	case <-w.ready:
+		s.mInUse.Add(ctx, n, s.attr)
+		return nil
+
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// acquired the semaphore after being canceled - don't leak it but
+			// pretend the cancellation came first.
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// removing a large waiter at the front may unblock others
+			if isFront && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire acquires the semaphore with a weight of n without blocking. It
+// reports whether it succeeded. To preserve fairness, TryAcquire fails while
+// other callers are queued.
+func (s *Weighted) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	ok := s.cur+n <= s.size && s.waiters.Len() == 0
+	if ok {
+		s.cur += n
+	}
+	s.mu.Unlock()
+
+	if ok {
+		s.mInUse.Add(context.Background(), n, s.attr)
+	}
+
+	return ok
+}
+
+// Release releases the semaphore with a weight of n. It panics if more
+// weight is released than is held.
+func (s *Weighted) Release(n int64) {
+	s.mu.Lock()
+	s.cur -= n
+	if s.cur < 0 {
+		s.mu.Unlock()
+		panic("sem: released more than held")
+	}
+	s.notifyWaiters()
+	s.mu.Unlock()
+
+	s.mInUse.Add(context.Background(), -n, s.attr)
+}
+
+// Len returns the number of callers waiting to acquire the semaphore.
+func (s *Weighted) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.waiters.Len()
+}
+
+// notifyWaiters hands out resources to the waiters in FIFO order. It stops
+// at the first waiter whose request does not fit so that large requests are
+// not starved by smaller ones. Must be called with the lock held.
+func (s *Weighted) notifyWaiters() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+
+		w := next.Value.(waiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
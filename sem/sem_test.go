@@ -0,0 +1,93 @@
+package sem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeighted_TryAcquire(t *testing.T) {
+	s := NewWeighted("test", 3, nil)
+
+	assert.True(t, s.TryAcquire(2))
+	assert.True(t, s.TryAcquire(1))
+	assert.False(t, s.TryAcquire(1))
+
+	s.Release(3)
+	assert.True(t, s.TryAcquire(3))
+}
+
+func TestWeighted_Acquire_exceedsSize(t *testing.T) {
+	s := NewWeighted("test", 3, nil)
+	assert.Error(t, s.Acquire(context.Background(), 4))
+}
+
+func TestWeighted_Acquire_canceled(t *testing.T) {
+	s := NewWeighted("test", 1, nil)
+	require.True(t, s.TryAcquire(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, s.Acquire(ctx, 1), context.DeadlineExceeded)
+	assert.Equal(t, 0, s.Len())
+
+	s.Release(1)
+	assert.True(t, s.TryAcquire(1))
+}
+
+func TestWeighted_Acquire_fifo(t *testing.T) {
+	ctx := context.Background()
+	s := NewWeighted("test", 2, nil)
+	require.NoError(t, s.Acquire(ctx, 2))
+
+	order := make(chan int64, 2)
+	acquire := func(n int64) {
+		require.NoError(t, s.Acquire(ctx, n))
+		order <- n
+	}
+
+	// queue a large waiter first, then a small one
+	go acquire(2)
+	require.Eventually(t, func() bool { return s.Len() == 1 }, time.Second, time.Millisecond)
+	go acquire(1)
+	require.Eventually(t, func() bool { return s.Len() == 2 }, time.Second, time.Millisecond)
+
+	// the small waiter fits but must not overtake the large one
+	assert.False(t, s.TryAcquire(1))
+	s.Release(1)
+	assert.Never(t, func() bool { return len(order) > 0 }, 20*time.Millisecond, time.Millisecond)
+
+	s.Release(1)
+	assert.Equal(t, int64(2), <-order)
+
+	s.Release(2)
+	assert.Equal(t, int64(1), <-order)
+}
+
+func TestWeighted_Acquire_cancelFrontUnblocks(t *testing.T) {
+	ctx := context.Background()
+	s := NewWeighted("test", 2, nil)
+	require.NoError(t, s.Acquire(ctx, 1))
+
+	largeCtx, cancelLarge := context.WithCancel(ctx)
+	largeErr := make(chan error)
+	go func() { largeErr <- s.Acquire(largeCtx, 2) }()
+	require.Eventually(t, func() bool { return s.Len() == 1 }, time.Second, time.Millisecond)
+
+	smallErr := make(chan error)
+	go func() { smallErr <- s.Acquire(ctx, 1) }()
+	require.Eventually(t, func() bool { return s.Len() == 2 }, time.Second, time.Millisecond)
+
+	cancelLarge()
+	assert.ErrorIs(t, <-largeErr, context.Canceled)
+	assert.NoError(t, <-smallErr)
+}
+
+func TestWeighted_Release_panics(t *testing.T) {
+	s := NewWeighted("test", 1, nil)
+	assert.Panics(t, func() { s.Release(1) })
+}
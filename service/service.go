@@ -0,0 +1,236 @@
+// Package service runs the long-running components of a daemon, e.g., gRPC
+// and HTTP servers and background workers, side by side. A [Group] starts
+// all services and, once the context is canceled or any service exits,
+// stops them in reverse order so that, e.g., servers stop accepting requests
+// before the workers and database writers behind them shut down:
+//
+//	group := service.NewGroup()
+//	group.ShutdownTimeout = rootCfg.ShutdownGrace
+//	group.Add(service.Func("inserter", inserter.Run))
+//	group.Add(service.New("grpc",
+//		func(context.Context) error { return srv.ListenAndServe() },
+//		func(context.Context) error { srv.Shutdown(); return nil },
+//	))
+//	group.Add(service.HTTPServer("http", httpSrv))
+//
+//	// ctx is the signal context of the root command's action
+//	return group.Run(ctx)
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultShutdownTimeout is the default upper bound for stopping all
+// services of a [Group].
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Service is a long-running component.
+type Service interface {
+	// Name identifies the service in logs and errors.
+	Name() string
+
+	// Start runs the service and blocks until it stopped or failed. The
+	// context is only canceled if the service didn't stop after Stop was
+	// called.
+	Start(ctx context.Context) error
+
+	// Stop gracefully stops the service and makes Start return. It should
+	// give up once the context is done.
+	Stop(ctx context.Context) error
+}
+
+// Group runs several services. Services must not be added while the group
+// is running.
+type Group struct {
+	// ShutdownTimeout bounds the duration of stopping all services. Zero
+	// means no timeout.
+	ShutdownTimeout time.Duration
+
+	services []Service
+}
+
+// NewGroup returns an empty [Group] with the [DefaultShutdownTimeout].
+func NewGroup() *Group {
+	return &Group{ShutdownTimeout: DefaultShutdownTimeout}
+}
+
+// Add adds services to the group. They are started in the order they were
+// added and stopped in reverse order.
+func (g *Group) Add(services ...Service) {
+	g.services = append(g.services, services...)
+}
+
+// Run starts all services and blocks until the context is canceled or any
+// service exits, whether it failed or not. Then it stops all services in
+// reverse order and waits for them to exit. It returns the joined errors of
+// all services that failed to run or stop. Services that exit with a
+// [context.Canceled] error after their context was canceled didn't fail.
+func (g *Group) Run(ctx context.Context) error {
+	if len(g.services) == 0 {
+		return fmt.Errorf("no services to run")
+	}
+
+	// services are stopped in order with Stop, so their context is only
+	// canceled afterward in case a service didn't stop in time.
+	startCtx, cancelStart := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelStart()
+
+	// canceled when the first service exits
+	exited, exit := context.WithCancel(ctx)
+	defer exit()
+
+	var eg errgroup.Group
+	for _, svc := range g.services {
+		eg.Go(func() error {
+			defer exit()
+
+			slog.Info("Starting service", "service", svc.Name())
+			err := svc.Start(startCtx)
+			if errors.Is(err, context.Canceled) && startCtx.Err() != nil {
+				err = nil
+			}
+
+			if err != nil {
+				slog.Warn("Service failed", "service", svc.Name(), "err", err)
+				return fmt.Errorf("run %s: %w", svc.Name(), err)
+			}
+
+			slog.Info("Service exited", "service", svc.Name())
+			return nil
+		})
+	}
+
+	<-exited.Done()
+
+	stopErr := g.stop(context.WithoutCancel(ctx))
+	cancelStart()
+
+	return errors.Join(eg.Wait(), stopErr)
+}
+
+// stop stops all services in reverse order within the shutdown timeout.
+func (g *Group) stop(ctx context.Context) error {
+	if g.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.ShutdownTimeout)
+		defer cancel()
+	}
+
+	var errs []error
+	for i := len(g.services) - 1; i >= 0; i-- {
+		svc := g.services[i]
+
+		slog.Info("Stopping service", "service", svc.Name())
+		start := time.Now()
+		if err := svc.Stop(ctx); err != nil {
+			slog.Warn("Failed to stop service", "service", svc.Name(), "err", err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", svc.Name(), err))
+			continue
+		}
+		slog.Info("Stopped service", "service", svc.Name(), "took", time.Since(start).Round(time.Millisecond))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run runs the given services in a [Group] with the [DefaultShutdownTimeout].
+func Run(ctx context.Context, services ...Service) error {
+	g := NewGroup()
+	g.Add(services...)
+	return g.Run(ctx)
+}
+
+// funcService is a [Service] defined by its start and stop functions.
+type funcService struct {
+	name  string
+	start func(ctx context.Context) error
+	stop  func(ctx context.Context) error
+}
+
+func (s *funcService) Name() string                    { return s.name }
+func (s *funcService) Start(ctx context.Context) error { return s.start(ctx) }
+func (s *funcService) Stop(ctx context.Context) error  { return s.stop(ctx) }
+
+// New returns a [Service] with the given start and stop functions, e.g.,
+// for a server that blocks in a ListenAndServe method until it is shut
+// down.
+func New(name string, start func(ctx context.Context) error, stop func(ctx context.Context) error) Service {
+	return &funcService{name: name, start: start, stop: stop}
+}
+
+// Func returns a [Service] that runs fn until its context is canceled, e.g.,
+// a worker loop. Stop cancels the context and waits until fn returned.
+func Func(name string, fn func(ctx context.Context) error) Service {
+	var (
+		once sync.Once
+		stop = make(chan struct{})
+		done = make(chan struct{})
+	)
+
+	return New(name,
+		func(ctx context.Context) error {
+			defer close(done)
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			go func() {
+				select {
+				case <-stop:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			err := fn(ctx)
+			select {
+			case <-stop:
+				if errors.Is(err, context.Canceled) {
+					return nil
+				}
+			default:
+			}
+			return err
+		},
+		func(ctx context.Context) error {
+			once.Do(func() { close(stop) })
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	)
+}
+
+// HTTPServer returns a [Service] that serves srv, with TLS if srv.TLSConfig
+// holds certificates, and gracefully shuts it down.
+func HTTPServer(name string, srv *http.Server) Service {
+	return New(name,
+		func(context.Context) error {
+			var err error
+			if srv.TLSConfig != nil && (len(srv.TLSConfig.Certificates) > 0 || srv.TLSConfig.GetCertificate != nil) {
+				err = srv.ListenAndServeTLS("", "")
+			} else {
+				err = srv.ListenAndServe()
+			}
+
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return err
+		},
+		srv.Shutdown,
+	)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the order of service events.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestGroup_Run_stopsInReverseOrder(t *testing.T) {
+	rec := &recorder{}
+	worker := func(name string) Service {
+		return Func(name, func(ctx context.Context) error {
+			<-ctx.Done()
+			rec.add("exit " + name)
+			return ctx.Err()
+		})
+	}
+
+	g := NewGroup()
+	g.Add(worker("db"), worker("grpc"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	require.NoError(t, g.Run(ctx))
+	assert.Equal(t, []string{"exit grpc", "exit db"}, rec.get())
+}
+
+func TestGroup_Run_failingServiceStopsOthers(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	stopped := false
+	g := NewGroup()
+	g.Add(
+		Func("worker", func(ctx context.Context) error {
+			<-ctx.Done()
+			stopped = true
+			return nil
+		}),
+		Func("broken", func(ctx context.Context) error { return errBoom }),
+	)
+
+	err := g.Run(context.Background())
+	assert.ErrorIs(t, err, errBoom)
+	assert.ErrorContains(t, err, "run broken")
+	assert.True(t, stopped)
+}
+
+func TestGroup_Run_shutdownTimeout(t *testing.T) {
+	g := NewGroup()
+	g.ShutdownTimeout = 10 * time.Millisecond
+	g.Add(New("stuck",
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := g.Run(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "stop stuck")
+}
+
+func TestGroup_Run_noServices(t *testing.T) {
+	assert.Error(t, NewGroup().Run(context.Background()))
+}
+
+func TestHTTPServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	srv := &http.Server{Addr: addr, Handler: http.NotFoundHandler()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Run(ctx, HTTPServer("http", srv)) }()
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode == http.StatusNotFound
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
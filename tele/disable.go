@@ -0,0 +1,49 @@
+package tele
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// disabled is set by [Disable] and turns [ServeMetrics] and
+// [InitTraceProvider] into no-ops.
+var disabled atomic.Bool
+
+// Disable installs no-op meter and tracer providers, discards errors that the
+// OpenTelemetry SDK reports through its global error handler, and turns
+// [ServeMetrics] and [InitTraceProvider] into no-ops that neither start
+// servers or exporters nor log failures. This keeps unit tests free of
+// telemetry output. The returned function restores the previous state.
+//
+// Disable modifies global state and must not be used in parallel tests that
+// rely on telemetry.
+func Disable() (restore func()) {
+	prevMeter := otel.GetMeterProvider()
+	prevTracer := otel.GetTracerProvider()
+	prevHandler := otel.GetErrorHandler()
+	prevDisabled := disabled.Swap(true)
+
+	otel.SetMeterProvider(metricnoop.NewMeterProvider())
+	otel.SetTracerProvider(tracenoop.NewTracerProvider())
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+
+	return func() {
+		disabled.Store(prevDisabled)
+		otel.SetErrorHandler(prevHandler)
+		otel.SetTracerProvider(prevTracer)
+		otel.SetMeterProvider(prevMeter)
+	}
+}
+
+// DisableForTest calls [Disable] and restores the previous state when the
+// test finishes. Call it as tele.DisableForTest(t) at the top of a test or
+// from TestMain with a custom cleaner.
+func DisableForTest(tb interface{ Cleanup(func()) }) {
+	tb.Cleanup(Disable())
+}
+
+func noopShutdown(ctx context.Context) error { return nil }
@@ -0,0 +1,32 @@
+package tele
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+)
+
+func TestDisable(t *testing.T) {
+	prev := otel.GetMeterProvider()
+
+	restore := Disable()
+	assert.IsType(t, metricnoop.MeterProvider{}, otel.GetMeterProvider())
+
+	// enabled metrics must neither start a server nor replace the provider
+	shutdown, err := ServeMetrics(&MetricsConfig{Enabled: true, Host: "invalid host", Port: -1, Name: "test"})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	assert.IsType(t, metricnoop.MeterProvider{}, otel.GetMeterProvider())
+
+	shutdown, err = InitTraceProvider(context.Background(), "test", &TraceConfig{Enabled: true})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	restore()
+	assert.Equal(t, prev, otel.GetMeterProvider())
+	assert.False(t, disabled.Load())
+}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/http/pprof"
+	"path/filepath"
+	"strconv"
 
 	"github.com/probe-lab/ecs-exporter/ecscollector"
 	"github.com/probe-lab/ecs-exporter/ecsmetadata"
@@ -25,23 +28,56 @@ type MetricsConfig struct {
 	Port    int
 	Path    string
 	Name    string
+
+	// TextfileDir is a directory to which a snapshot of all metrics is
+	// written on shutdown in the format of node_exporter's textfile
+	// collector. This lets short-lived commands report job results without
+	// a Pushgateway. Metrics are collected even if the metrics server is
+	// disabled as long as this is set.
+	TextfileDir string
+
+	// Handlers are additional handlers, keyed by path, that are served
+	// alongside the metrics endpoint, e.g., authenticated admin endpoints.
+	Handlers map[string]http.Handler
 }
 
 func DefaultMetricsConfig(name string) *MetricsConfig {
 	return &MetricsConfig{
-		Enabled: false,
-		Host:    "localhost",
-		Port:    6060,
-		Path:    "/metrics",
-		Name:    name,
+		Enabled:  false,
+		Host:     "localhost",
+		Port:     6060,
+		Path:     "/metrics",
+		Name:     name,
+		Handlers: map[string]http.Handler{},
+	}
+}
+
+// LogValue implements [slog.LogValuer].
+func (cfg *MetricsConfig) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.Bool("enabled", cfg.Enabled)}
+	if cfg.Enabled {
+		attrs = append(attrs,
+			slog.String("addr", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))),
+			slog.String("path", cfg.Path),
+		)
+	}
+
+	if cfg.TextfileDir != "" {
+		attrs = append(attrs, slog.String("textfile_dir", cfg.TextfileDir))
 	}
+
+	return slog.GroupValue(attrs...)
 }
 
 func ServeMetrics(cfg *MetricsConfig) (func(ctx context.Context) error, error) {
-	if !cfg.Enabled {
+	if disabled.Load() {
+		return noopShutdown, nil
+	}
+
+	if !cfg.Enabled && cfg.TextfileDir == "" {
 		provider := noop.NewMeterProvider()
 		otel.SetMeterProvider(provider)
-		return func(ctx context.Context) error { return nil }, nil
+		return noopShutdown, nil
 	}
 
 	provider, providerShutdownFn, err := initMeterProvider(cfg.Name)
@@ -51,6 +87,22 @@ func ServeMetrics(cfg *MetricsConfig) (func(ctx context.Context) error, error) {
 
 	otel.SetMeterProvider(provider)
 
+	// the snapshot must be written before the exporter shuts down because
+	// shutting down unregisters it from the prometheus registry.
+	if cfg.TextfileDir != "" {
+		exporterShutdownFn := providerShutdownFn
+		providerShutdownFn = func(ctx context.Context) error {
+			if err := WriteTextfile(cfg.TextfileDir, cfg.Name); err != nil {
+				slog.Warn("Failed to write metrics textfile", "dir", cfg.TextfileDir, "err", err)
+			}
+			return exporterShutdownFn(ctx)
+		}
+	}
+
+	if !cfg.Enabled {
+		return providerShutdownFn, nil
+	}
+
 	mux := http.NewServeMux()
 
 	mux.Handle(cfg.Path, promhttp.Handler())
@@ -60,6 +112,10 @@ func ServeMetrics(cfg *MetricsConfig) (func(ctx context.Context) error, error) {
 	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 
+	for path, handler := range cfg.Handlers {
+		mux.Handle(path, handler)
+	}
+
 	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 	srv := &http.Server{
 		Addr:    addr,
@@ -69,7 +125,7 @@ func ServeMetrics(cfg *MetricsConfig) (func(ctx context.Context) error, error) {
 	slogger := slog.With("addr", addr)
 
 	go func() {
-		slogger.Info("Starting metrics server")
+		slogger.Debug("Starting metrics server")
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			slogger.Error("Failed starting metrics server", "err", err)
 		}
@@ -87,6 +143,21 @@ func ServeMetrics(cfg *MetricsConfig) (func(ctx context.Context) error, error) {
 	return shutdownFunc, nil
 }
 
+// WriteTextfile writes a snapshot of all metrics in the default prometheus
+// registry to the file "<name>.prom" in the given directory. The file is
+// written atomically so that node_exporter's textfile collector never reads a
+// partial snapshot.
+func WriteTextfile(dir string, name string) error {
+	path := filepath.Join(dir, name+".prom")
+	if err := prometheus.WriteToTextfile(path, prometheus.DefaultGatherer); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+
+	slog.Debug("Wrote metrics textfile", "path", path)
+
+	return nil
+}
+
 func initMeterProvider(name string) (metric.MeterProvider, func(ctx context.Context) error, error) {
 	// initialize AWS Elastic Container Service collector and register it with
 	// the default prometheus registry. If we are not running in a prometheus
@@ -0,0 +1,51 @@
+package tele
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTextfile(t *testing.T) {
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "tele_test_textfile_total",
+		Help: "Counter for testing the textfile writer",
+	})
+	require.NoError(t, prometheus.Register(counter))
+	t.Cleanup(func() { prometheus.Unregister(counter) })
+
+	counter.Add(42)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "job.prom")
+
+	// a previous snapshot is replaced as a whole
+	require.NoError(t, os.WriteFile(path, []byte("stale\n"), 0o644))
+
+	require.NoError(t, WriteTextfile(dir, "job"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "tele_test_textfile_total 42\n")
+	assert.NotContains(t, string(data), "stale")
+
+	// the snapshot is written to a temporary file that is renamed into
+	// place, so nothing else must be left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "job.prom", entries[0].Name())
+}
+
+func TestWriteTextfile_missingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+
+	assert.Error(t, WriteTextfile(dir, "job"))
+
+	_, err := os.Stat(dir)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
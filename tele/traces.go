@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
@@ -13,19 +15,113 @@ import (
 
 type TraceConfig struct {
 	Enabled bool
+
+	// SampleRatio is the fraction of traces that are sampled, between 0 and
+	// 1. Below 1, spans follow the sampling decision of their parent.
+	SampleRatio float64
+
+	// Endpoint is the host:port or URL of the OTLP gRPC collector. Empty
+	// falls back to the standard OTEL_EXPORTER_OTLP_* environment variables
+	// and then to localhost:4317.
+	Endpoint string
+
+	// Headers are sent with every export, e.g., to authenticate with the
+	// collector.
+	Headers map[string]string
+
+	// Insecure disables TLS for the connection to the collector.
+	Insecure bool
 }
 
 func DefaultTraceConfig() *TraceConfig {
 	return &TraceConfig{
-		Enabled: false,
+		Enabled:     false,
+		SampleRatio: 1,
+		Endpoint:    "",
+		Headers:     map[string]string{},
+		Insecure:    false,
+	}
+}
+
+// Validate validates the trace configuration.
+func (cfg *TraceConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return fmt.Errorf("sample ratio must be between 0 and 1, got %v", cfg.SampleRatio)
+	}
+
+	return nil
+}
+
+// LogValue implements [slog.LogValuer]. Header values are omitted as they
+// usually carry credentials.
+func (cfg *TraceConfig) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.Bool("enabled", cfg.Enabled)}
+	if cfg.Enabled {
+		headers := make([]string, 0, len(cfg.Headers))
+		for key := range cfg.Headers {
+			headers = append(headers, key)
+		}
+		slices.Sort(headers)
+
+		attrs = append(attrs,
+			slog.Float64("sample_ratio", cfg.SampleRatio),
+			slog.String("endpoint", cfg.Endpoint),
+			slog.Any("headers", headers),
+			slog.Bool("insecure", cfg.Insecure),
+		)
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// sampler returns the sampler for the configured sample ratio.
+func (cfg *TraceConfig) sampler() sdktrace.Sampler {
+	if cfg.SampleRatio >= 1 {
+		return sdktrace.AlwaysSample()
 	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))
+}
+
+// exporterOptions returns the options of the OTLP exporter for the
+// configured endpoint, headers, and transport security.
+func (cfg *TraceConfig) exporterOptions() []otlptracegrpc.Option {
+	var opts []otlptracegrpc.Option
+	if strings.Contains(cfg.Endpoint, "://") {
+		opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+	} else if cfg.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+	}
+
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+	}
+
+	// the endpoint URL determines the transport security, so only override
+	// it if explicitly requested.
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	return opts
 }
 
 func InitTraceProvider(ctx context.Context, name string, cfg *TraceConfig) (func(ctx context.Context) error, error) {
+	if disabled.Load() {
+		return noopShutdown, nil
+	}
+
 	if !cfg.Enabled {
 		provider := noop.NewTracerProvider()
 		otel.SetTracerProvider(provider)
-		return func(ctx context.Context) error { return nil }, nil
+		return noopShutdown, nil
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
 	res, err := newResource(name)
@@ -33,14 +129,14 @@ func InitTraceProvider(ctx context.Context, name string, cfg *TraceConfig) (func
 		return nil, fmt.Errorf("failed to create otel trace provider resource: %w", err)
 	}
 
-	exporter, err := otlptracegrpc.New(ctx)
+	exporter, err := otlptracegrpc.New(ctx, cfg.exporterOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
 	}
 
 	// using a batch span processor to aggregate spans before export.
 	provider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSampler(cfg.sampler()),
 		sdktrace.WithResource(res),
 		sdktrace.WithSpanProcessor(sdktrace.NewBatchSpanProcessor(exporter)),
 	)
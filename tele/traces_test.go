@@ -0,0 +1,56 @@
+package tele
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestTraceConfig_Validate(t *testing.T) {
+	var nilCfg *TraceConfig
+	assert.ErrorContains(t, nilCfg.Validate(), "config is nil")
+
+	cfg := DefaultTraceConfig()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SampleRatio = 1.5
+	assert.ErrorContains(t, cfg.Validate(), "sample ratio must be between 0 and 1")
+
+	cfg.SampleRatio = -0.1
+	assert.Error(t, cfg.Validate())
+}
+
+func TestTraceConfig_sampler(t *testing.T) {
+	cfg := DefaultTraceConfig()
+	assert.Equal(t, sdktrace.AlwaysSample().Description(), cfg.sampler().Description())
+
+	cfg.SampleRatio = 0.25
+	assert.Contains(t, cfg.sampler().Description(), "ParentBased{root:TraceIDRatioBased{0.25}")
+}
+
+func TestTraceConfig_exporterOptions(t *testing.T) {
+	cfg := DefaultTraceConfig()
+	assert.Empty(t, cfg.exporterOptions())
+
+	cfg.Endpoint = "collector:4317"
+	cfg.Headers = map[string]string{"authorization": "Bearer s3cr3t"}
+	cfg.Insecure = true
+	assert.Len(t, cfg.exporterOptions(), 3)
+}
+
+func TestTraceConfig_LogValue(t *testing.T) {
+	cfg := DefaultTraceConfig()
+	cfg.Enabled = true
+	cfg.Endpoint = "https://collector"
+	cfg.Headers = map[string]string{"authorization": "Bearer s3cr3t"}
+
+	var sb strings.Builder
+	slog.New(slog.NewTextHandler(&sb, nil)).Info("test", "tracing", cfg)
+
+	assert.Contains(t, sb.String(), "tracing.endpoint=https://collector")
+	assert.Contains(t, sb.String(), "tracing.headers=[authorization]")
+	assert.NotContains(t, sb.String(), "s3cr3t")
+}
@@ -0,0 +1,376 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+
+	"github.com/probe-lab/go-commons/sem"
+)
+
+// ErrLimited is returned by [Limiter.Acquire] if a tenant exceeded its rate
+// or concurrency limit. The concrete error is a [*LimitError].
+var ErrLimited = errors.New("tenant limit exceeded")
+
+// Limit defines how many requests a project/network combination may have in
+// flight and how many it may start per second. A zero value means unlimited.
+// The network "*" matches all networks of the project that have no explicit
+// entry. They share the budget of the wildcard entry.
+type Limit struct {
+	Project     string  `json:"project"`
+	Network     string  `json:"network"`
+	Concurrency int64   `json:"concurrency"`
+	Rate        float64 `json:"rate"`
+	Burst       int     `json:"burst"`
+}
+
+func (l Limit) validate() error {
+	if l.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative")
+	}
+
+	if l.Rate < 0 {
+		return fmt.Errorf("rate must not be negative")
+	}
+
+	if l.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+
+	return nil
+}
+
+// LimitsConfig holds configuration for a [Limiter].
+type LimitsConfig struct {
+	// File is the path to a JSON array of [Limit] objects. If empty, all
+	// tenants use the Default limit.
+	File string
+
+	// Default applies to every tenant without an entry in File. Its Project
+	// and Network fields are ignored. Unless Known reports a tenant as
+	// known, all these tenants share a single default budget.
+	Default Limit
+
+	// Known, if set, reports whether a tenant without an entry in File is
+	// known, e.g., because it is part of a db.Mapping. Known tenants get
+	// their own budget of the Default limit. Lookups pass the lower-cased
+	// project and network.
+	Known func(project string, network string) bool
+
+	// MaxWait is how long a request waits for a free concurrency slot of
+	// its tenant before it is rejected. Zero rejects immediately.
+	MaxWait time.Duration
+
+	// Meter is the OTel meter used to record limiter metrics. If nil, the
+	// global meter provider is used.
+	Meter metric.Meter
+}
+
+// DefaultLimitsConfig returns a [LimitsConfig] that doesn't limit any
+// tenant.
+func DefaultLimitsConfig() *LimitsConfig {
+	return &LimitsConfig{
+		MaxWait: 0,
+	}
+}
+
+// Validate checks the [LimitsConfig] for validity. It doesn't read
+// the limits file.
+func (cfg *LimitsConfig) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if err := cfg.Default.validate(); err != nil {
+		return fmt.Errorf("default tenant limit: %w", err)
+	}
+
+	if cfg.MaxWait < 0 {
+		return fmt.Errorf("max wait must not be negative")
+	}
+
+	return nil
+}
+
+// readLimits reads the tenant limits from the configured file.
+func (cfg *LimitsConfig) readLimits() ([]Limit, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		return nil, fmt.Errorf("read tenant limits file: %w", err)
+	}
+
+	var limits []Limit
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, fmt.Errorf("decode tenant limits file: %w", err)
+	}
+
+	for _, l := range limits {
+		if err := l.validate(); err != nil {
+			return nil, fmt.Errorf("tenant limit for %s/%s: %w", l.Project, l.Network, err)
+		}
+	}
+
+	return limits, nil
+}
+
+// LimitError is returned by [Limiter.Acquire] if a tenant exceeded one of
+// its limits.
+type LimitError struct {
+	Project string
+	Network string
+	// Reason is either "rate" or "concurrency".
+	Reason string
+	// RetryAfter is a hint for when the request may be retried.
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit exceeded for %s/%s", e.Reason, e.Project, e.Network)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimited
+}
+
+// tenantState holds the limiter state of a single tenant, of all networks of
+// a project that match a wildcard entry, or of all unknown tenants.
+type tenantState struct {
+	limit Limit
+	sem   *sem.Weighted // nil if concurrency is unlimited
+	rate  *rate.Limiter // nil if rate is unlimited
+	attrs []attribute.KeyValue
+}
+
+// Limiter enforces per-project/network concurrency and rate limits, so that
+// a single heavy tenant can't starve the database for all others. Each entry
+// in the limits file and each tenant for which [LimitsConfig.Known] returns
+// true gets its own budget, while all other tenants share the default
+// budget. This bounds the limiter state and metric attributes by the
+// configuration instead of by the tenant values that clients send. Call
+// [Limiter.Reload] to pick up changes to the limits file at runtime, e.g., by
+// registering it with the root command's reload registry.
+type Limiter struct {
+	cfg   *LimitsConfig
+	meter metric.Meter
+
+	mu      sync.Mutex
+	limits  []Limit
+	tenants map[tenant]*tenantState
+	shared  *tenantState
+
+	mRejected metric.Int64Counter
+}
+
+// NewLimiter creates a new [Limiter] and reads the initial limits from the
+// configured file.
+func NewLimiter(cfg *LimitsConfig) (*Limiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	meter := cfg.Meter
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter("github.com/probe-lab/go-commons/tenant")
+	}
+
+	l := &Limiter{
+		cfg:     cfg,
+		meter:   meter,
+		tenants: map[tenant]*tenantState{},
+	}
+
+	l.shared = l.newState("tenant:default", cfg.Default)
+
+	var err error
+	if l.mRejected, err = meter.Int64Counter("tenant_limiter.rejected",
+		metric.WithDescription("Total number of requests rejected because a tenant exceeded its limit"),
+	); err != nil {
+		return nil, fmt.Errorf("create tenant_limiter.rejected counter: %w", err)
+	}
+
+	if _, err := l.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Reload re-reads the limits file. Tenants whose limit changed get a fresh
+// budget; requests that are in flight while the limits change still release
+// their slot correctly. It returns a description of the change and can be
+// registered as a reload function.
+func (l *Limiter) Reload(ctx context.Context) (string, error) {
+	limits, err := l.cfg.readLimits()
+	if err != nil {
+		return "", err
+	}
+
+	for i := range limits {
+		limits[i].Project = strings.ToLower(limits[i].Project)
+		limits[i].Network = strings.ToLower(limits[i].Network)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if slices.Equal(l.limits, limits) {
+		return "", nil
+	}
+
+	prev := len(l.limits)
+	l.limits = limits
+
+	// drop the state of tenants whose limit changed or that are no longer
+	// tracked. It is recreated on their next request.
+	for key, tl := range l.tenants {
+		if newKey, limit, tracked := l.lookup(key); !tracked || newKey != key || limit != tl.limit {
+			delete(l.tenants, key)
+		}
+	}
+
+	return fmt.Sprintf("%d -> %d tenant limits", prev, len(limits)), nil
+}
+
+// lookup returns the key of the budget of the given tenant and its limit. The
+// key is the matching entry of the limits file, or the tenant itself if it
+// has no entry but is known. If tracked is false, the tenant uses the shared
+// default budget. Must be called with the lock held.
+func (l *Limiter) lookup(t tenant) (key tenant, limit Limit, tracked bool) {
+	var wildcard *Limit
+	for i, limit := range l.limits {
+		if limit.Project != t.project {
+			continue
+		}
+
+		if limit.Network == t.network {
+			return t, limit, true
+		}
+
+		if limit.Network == "*" && wildcard == nil {
+			wildcard = &l.limits[i]
+		}
+	}
+
+	if wildcard != nil {
+		return tenant{project: wildcard.Project, network: wildcard.Network}, *wildcard, true
+	}
+
+	limit = l.cfg.Default
+	limit.Project, limit.Network = "", ""
+
+	if l.cfg.Known != nil && l.cfg.Known(t.project, t.network) {
+		return t, limit, true
+	}
+
+	return tenant{}, limit, false
+}
+
+// limiter returns the limiter state of the given tenant, creating it if
+// necessary.
+func (l *Limiter) limiter(t tenant) *tenantState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key, limit, tracked := l.lookup(t)
+	if !tracked {
+		return l.shared
+	}
+
+	if tl, found := l.tenants[key]; found {
+		return tl
+	}
+
+	tl := l.newState("tenant:"+key.project+"/"+key.network, limit)
+	tl.attrs = []attribute.KeyValue{
+		attrKeyProject.String(key.project),
+		attrKeyNetwork.String(key.network),
+	}
+
+	l.tenants[key] = tl
+
+	return tl
+}
+
+func (l *Limiter) newState(name string, limit Limit) *tenantState {
+	tl := &tenantState{limit: limit}
+
+	if limit.Concurrency > 0 {
+		tl.sem = sem.NewWeighted(name, limit.Concurrency, l.meter)
+	}
+
+	if limit.Rate > 0 {
+		tl.rate = rate.NewLimiter(rate.Limit(limit.Rate), max(limit.Burst, 1))
+	}
+
+	return tl
+}
+
+// Acquire checks the rate limit of the given tenant and acquires one of its
+// concurrency slots, waiting at most [LimitsConfig.MaxWait]. The returned
+// function must be called once the request is done. If the tenant exceeded a
+// limit, the error is a [*LimitError].
+func (l *Limiter) Acquire(ctx context.Context, project string, network string) (release func(), err error) {
+	t := newTenant(project, network)
+	tl := l.limiter(t)
+
+	if tl.rate != nil {
+		r := tl.rate.Reserve()
+		if delay := r.Delay(); delay > 0 {
+			r.Cancel()
+			return nil, l.reject(ctx, t, tl, "rate", delay)
+		}
+	}
+
+	if tl.sem == nil {
+		return func() {}, nil
+	}
+
+	if !tl.sem.TryAcquire(1) {
+		if l.cfg.MaxWait <= 0 {
+			return nil, l.reject(ctx, t, tl, "concurrency", time.Second)
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, l.cfg.MaxWait)
+		err := tl.sem.Acquire(waitCtx, 1)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, l.reject(ctx, t, tl, "concurrency", time.Second)
+		}
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { tl.sem.Release(1) }) }, nil
+}
+
+// reject records the rejection of a request of the given tenant. The metric
+// carries the project and network of the budget, not those of the request,
+// and none for the shared default budget.
+func (l *Limiter) reject(ctx context.Context, t tenant, tl *tenantState, reason string, retryAfter time.Duration) error {
+	attrs := append(slices.Clip(tl.attrs), attrKeyReason.String(reason))
+	l.mRejected.Add(ctx, 1, metric.WithAttributes(attrs...))
+
+	return &LimitError{
+		Project:    t.project,
+		Network:    t.network,
+		Reason:     reason,
+		RetryAfter: retryAfter,
+	}
+}
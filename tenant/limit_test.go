@@ -0,0 +1,240 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func writeLimits(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestLimitsConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *LimitsConfig
+		wantErr bool
+	}{
+		{"nil", nil, true},
+		{"default", DefaultLimitsConfig(), false},
+		{"negative concurrency", &LimitsConfig{Default: Limit{Concurrency: -1}}, true},
+		{"negative rate", &LimitsConfig{Default: Limit{Rate: -1}}, true},
+		{"negative burst", &LimitsConfig{Default: Limit{Burst: -1}}, true},
+		{"negative wait", &LimitsConfig{MaxWait: -time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLimiter_concurrency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.json")
+	writeLimits(t, path, `[
+		{"project": "ipfs", "network": "amino", "concurrency": 2},
+		{"project": "ipfs", "network": "*", "concurrency": 1}
+	]`)
+
+	cfg := DefaultLimitsConfig()
+	cfg.File = path
+
+	l, err := NewLimiter(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// explicit entry
+	r1, err := l.Acquire(ctx, "IPFS", "amino")
+	require.NoError(t, err)
+	r2, err := l.Acquire(ctx, "ipfs", "amino")
+	require.NoError(t, err)
+
+	_, err = l.Acquire(ctx, "ipfs", "amino")
+	assert.ErrorIs(t, err, ErrLimited)
+
+	var limitErr *LimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "concurrency", limitErr.Reason)
+
+	// other tenants are unaffected
+	r3, err := l.Acquire(ctx, "ipfs", "celestia")
+	require.NoError(t, err)
+	_, err = l.Acquire(ctx, "ipfs", "celestia")
+	assert.ErrorIs(t, err, ErrLimited)
+
+	// no default limit
+	for range 10 {
+		_, err = l.Acquire(ctx, "filecoin", "mainnet")
+		require.NoError(t, err)
+	}
+
+	r1()
+	r1() // releasing twice is a no-op
+	r4, err := l.Acquire(ctx, "ipfs", "amino")
+	require.NoError(t, err)
+
+	r2()
+	r3()
+	r4()
+}
+
+func TestLimiter_rate(t *testing.T) {
+	cfg := DefaultLimitsConfig()
+	cfg.Default = Limit{Rate: 1, Burst: 2}
+
+	l, err := NewLimiter(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	for range 2 {
+		release, err := l.Acquire(ctx, "ipfs", "amino")
+		require.NoError(t, err)
+		release()
+	}
+
+	_, err = l.Acquire(ctx, "ipfs", "amino")
+	var limitErr *LimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "rate", limitErr.Reason)
+	assert.Positive(t, limitErr.RetryAfter)
+
+	// unknown tenants share the default budget
+	_, err = l.Acquire(ctx, "ipfs", "celestia")
+	assert.ErrorIs(t, err, ErrLimited)
+}
+
+func TestLimiter_bounded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.json")
+	writeLimits(t, path, `[{"project": "ipfs", "network": "*", "concurrency": 1}]`)
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	cfg := DefaultLimitsConfig()
+	cfg.File = path
+	cfg.Default = Limit{Concurrency: 1}
+	cfg.Known = func(project string, network string) bool { return project == "filecoin" && network == "mainnet" }
+	cfg.Meter = provider.Meter("test")
+
+	l, err := NewLimiter(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// all networks of the wildcard entry share its budget
+	release, err := l.Acquire(ctx, "ipfs", "amino")
+	require.NoError(t, err)
+	_, err = l.Acquire(ctx, "ipfs", "random-network")
+	assert.ErrorIs(t, err, ErrLimited)
+	release()
+
+	// known tenants get their own budget of the default limit
+	release, err = l.Acquire(ctx, "filecoin", "mainnet")
+	require.NoError(t, err)
+	defer release()
+
+	// all unknown tenants share one budget
+	release, err = l.Acquire(ctx, "unknown-1", "unknown-1")
+	require.NoError(t, err)
+	defer release()
+
+	for i := range 10 {
+		_, err = l.Acquire(ctx, fmt.Sprintf("unknown-%d", i), "unknown")
+		assert.ErrorIs(t, err, ErrLimited)
+	}
+
+	assert.Len(t, l.tenants, 2)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	counts := map[string]int64{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		if m.Name != "tenant_limiter.rejected" {
+			continue
+		}
+
+		for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+			project, _ := dp.Attributes.Value(attrKeyProject)
+			network, _ := dp.Attributes.Value(attrKeyNetwork)
+			counts[project.AsString()+"/"+network.AsString()] = dp.Value
+		}
+	}
+	assert.Equal(t, map[string]int64{"ipfs/*": 1, "/": 10}, counts)
+}
+
+func TestLimiter_wait(t *testing.T) {
+	cfg := DefaultLimitsConfig()
+	cfg.Default = Limit{Concurrency: 1}
+	cfg.MaxWait = time.Second
+
+	l, err := NewLimiter(cfg)
+	require.NoError(t, err)
+
+	release, err := l.Acquire(context.Background(), "ipfs", "amino")
+	require.NoError(t, err)
+
+	time.AfterFunc(10*time.Millisecond, release)
+
+	release, err = l.Acquire(context.Background(), "ipfs", "amino")
+	require.NoError(t, err)
+	release()
+}
+
+func TestLimiter_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.json")
+	writeLimits(t, path, `[{"project": "ipfs", "network": "amino", "concurrency": 1}]`)
+
+	cfg := DefaultLimitsConfig()
+	cfg.File = path
+
+	l, err := NewLimiter(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx, "ipfs", "amino")
+	require.NoError(t, err)
+
+	_, err = l.Acquire(ctx, "ipfs", "amino")
+	require.ErrorIs(t, err, ErrLimited)
+
+	msg, err := l.Reload(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, msg)
+
+	writeLimits(t, path, `[{"project": "ipfs", "network": "amino", "concurrency": 2}]`)
+
+	msg, err = l.Reload(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, msg)
+
+	_, err = l.Acquire(ctx, "ipfs", "amino")
+	require.NoError(t, err)
+
+	// releasing a slot of the previous limiter doesn't panic
+	release()
+
+	writeLimits(t, path, `[{"project": "ipfs", "network": "amino", "concurrency": -1}]`)
+	_, err = l.Reload(ctx)
+	assert.Error(t, err)
+}
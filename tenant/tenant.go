@@ -0,0 +1,51 @@
+// Package tenant identifies the project/network combination, the tenant, that
+// a request belongs to and limits how many requests each tenant may have in
+// flight and how many it may start per second.
+//
+// The HTTP middleware and gRPC interceptors that enforce a [Limiter] store the
+// tenant in the request context with [NewContext], so that handlers and, e.g.,
+// a db.ClickHouseRouter further down the chain can read it with [FromContext].
+// The package has no dependencies on database drivers so that servers can
+// import it without pulling them in.
+package tenant
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	attrKeyProject = attribute.Key("project")
+	attrKeyNetwork = attribute.Key("network")
+	attrKeyReason  = attribute.Key("reason")
+)
+
+type ctxKey struct{}
+
+// tenant is a normalized project/network combination.
+type tenant struct {
+	project string
+	network string
+}
+
+func newTenant(project string, network string) tenant {
+	return tenant{
+		project: strings.ToLower(project),
+		network: strings.ToLower(network),
+	}
+}
+
+// NewContext returns a copy of ctx that carries the given project and
+// network. Both are lower-cased.
+func NewContext(ctx context.Context, project string, network string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, newTenant(project, network))
+}
+
+// FromContext returns the project and network stored in ctx by
+// [NewContext].
+func FromContext(ctx context.Context) (project string, network string, ok bool) {
+	t, ok := ctx.Value(ctxKey{}).(tenant)
+	return t.project, t.network, ok
+}
@@ -0,0 +1,18 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	_, _, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	project, network, ok := FromContext(NewContext(context.Background(), "IPFS", "Amino"))
+	assert.True(t, ok)
+	assert.Equal(t, "ipfs", project)
+	assert.Equal(t, "amino", network)
+}
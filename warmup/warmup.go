@@ -0,0 +1,166 @@
+// Package warmup provides a registry of functions that prepare a service for
+// traffic, e.g., by filling caches, loading database mappings or geoip
+// databases, or preparing statements. The gRPC server runs all registered
+// functions before it reports SERVING, so that the first requests after a
+// deploy don't hit cold components.
+package warmup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Func warms up a single component. It should return once the component is
+// ready or the context is done.
+type Func func(ctx context.Context) error
+
+// DefaultTimeout is the default upper bound for running all warm-up
+// functions.
+const DefaultTimeout = 30 * time.Second
+
+// Registry holds the registered warm-up functions. The zero value is ready to
+// use and has no timeout. It is safe for concurrent use.
+type Registry struct {
+	// Timeout bounds the duration of [Registry.Run]. Zero means no timeout.
+	Timeout time.Duration
+
+	mu    sync.Mutex // guards names and funcs
+	names []string   // registration order
+	funcs map[string]Func
+
+	ran  sync.Once
+	err  error
+	warm atomic.Bool
+}
+
+// NewRegistry returns an empty [Registry] with the [DefaultTimeout].
+func NewRegistry() *Registry {
+	return &Registry{Timeout: DefaultTimeout}
+}
+
+// Register adds a warm-up function under the given name. Registering a
+// function under an existing name replaces the previous one. Functions that
+// are registered after [Registry.Run] was called are not executed.
+func (r *Registry) Register(name string, fn Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.funcs == nil {
+		r.funcs = make(map[string]Func)
+	}
+
+	if _, found := r.funcs[name]; !found {
+		r.names = append(r.names, name)
+	}
+
+	r.funcs[name] = fn
+}
+
+// Names returns the names of all registered components in registration order.
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.names...)
+}
+
+// Run calls all registered warm-up functions concurrently and waits until
+// they returned or the timeout elapsed. Failures are logged and joined into
+// the returned error. Only the first call runs the functions; concurrent
+// calls block until it completed, and all calls return its result.
+func (r *Registry) Run(ctx context.Context) error {
+	r.ran.Do(func() {
+		r.err = r.run(ctx)
+		r.warm.Store(true)
+	})
+
+	return r.err
+}
+
+func (r *Registry) run(ctx context.Context) error {
+	r.mu.Lock()
+	names := append([]string(nil), r.names...)
+	funcs := make([]Func, len(names))
+	for i, name := range names {
+		funcs[i] = r.funcs[name]
+	}
+	r.mu.Unlock()
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	slog.Info("Warming up", "components", len(names), "timeout", r.Timeout)
+
+	start := time.Now()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		errs    []error
+		pending = make(map[string]struct{}, len(names))
+	)
+
+	for _, name := range names {
+		pending[name] = struct{}{}
+	}
+
+	for i, name := range names {
+		wg.Go(func() {
+			componentStart := time.Now()
+			err := funcs[i](ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			delete(pending, name)
+			if err != nil {
+				slog.Warn("Failed to warm up component", "component", name, "err", err)
+				errs = append(errs, fmt.Errorf("warm up %s: %w", name, err))
+				return
+			}
+			slog.Debug("Warmed up component", "component", name, "took", time.Since(componentStart))
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// don't wait for components that ignore the context
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for name := range pending {
+		slog.Warn("Component did not warm up in time", "component", name)
+		errs = append(errs, fmt.Errorf("warm up %s: %w", name, ctx.Err()))
+	}
+
+	slog.Info("Warmed up", "components", len(names), "failed", len(errs), "took", time.Since(start))
+
+	return errors.Join(errs...)
+}
+
+// Warm reports whether [Registry.Run] has completed, regardless of whether
+// all components warmed up successfully.
+func (r *Registry) Warm() bool {
+	return r.warm.Load()
+}
@@ -0,0 +1,81 @@
+package warmup
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Run(t *testing.T) {
+	r := NewRegistry()
+
+	var calls atomic.Int32
+	r.Register("cache", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+	r.Register("geoip", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+
+	assert.Equal(t, []string{"cache", "geoip"}, r.Names())
+	assert.False(t, r.Warm())
+
+	require.NoError(t, r.Run(context.Background()))
+	assert.True(t, r.Warm())
+	assert.Equal(t, int32(2), calls.Load())
+
+	// subsequent calls don't run the functions again
+	require.NoError(t, r.Run(context.Background()))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRegistry_Run_failure(t *testing.T) {
+	r := &Registry{}
+
+	errBoom := errors.New("boom")
+	r.Register("ok", func(ctx context.Context) error { return nil })
+	r.Register("failing", func(ctx context.Context) error { return errBoom })
+
+	err := r.Run(context.Background())
+	assert.ErrorIs(t, err, errBoom)
+	assert.ErrorContains(t, err, "failing")
+	assert.True(t, r.Warm())
+}
+
+func TestRegistry_Run_timeout(t *testing.T) {
+	r := NewRegistry()
+	r.Timeout = 10 * time.Millisecond
+
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+
+	r.Register("stuck", func(ctx context.Context) error {
+		<-block // ignores the context
+		return nil
+	})
+	r.Register("fast", func(ctx context.Context) error { return nil })
+
+	err := r.Run(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "stuck")
+	assert.NotContains(t, err.Error(), "fast")
+}
+
+func TestRegistry_Register_replace(t *testing.T) {
+	r := NewRegistry()
+
+	var called string
+	r.Register("cache", func(ctx context.Context) error { called = "first"; return nil })
+	r.Register("cache", func(ctx context.Context) error { called = "second"; return nil })
+
+	assert.Equal(t, []string{"cache"}, r.Names())
+	require.NoError(t, r.Run(context.Background()))
+	assert.Equal(t, "second", called)
+}